@@ -1,24 +1,39 @@
 ﻿package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
+	_ "time/tzdata" // venue time zones shouldn't depend on the image having zoneinfo
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/hitorii/ticket-booking/internal/booking"
+	"github.com/hitorii/ticket-booking/internal/calendar"
+	"github.com/hitorii/ticket-booking/internal/cancellation"
+	"github.com/hitorii/ticket-booking/internal/catalogue"
 	"github.com/hitorii/ticket-booking/internal/config"
 	"github.com/hitorii/ticket-booking/internal/db"
 	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/hitorii/ticket-booking/internal/fees"
+	"github.com/hitorii/ticket-booking/internal/listings"
+	"github.com/hitorii/ticket-booking/internal/loyalty"
 	"github.com/hitorii/ticket-booking/internal/metrics"
 	"github.com/hitorii/ticket-booking/internal/middleware"
 	"github.com/hitorii/ticket-booking/internal/movie"
 	"github.com/hitorii/ticket-booking/internal/payments"
+	"github.com/hitorii/ticket-booking/internal/pricing"
+	"github.com/hitorii/ticket-booking/internal/promotions"
 	"github.com/hitorii/ticket-booking/internal/queue"
+	"github.com/hitorii/ticket-booking/internal/receipts"
 	"github.com/hitorii/ticket-booking/internal/show"
+	"github.com/hitorii/ticket-booking/internal/tickets"
 	"github.com/hitorii/ticket-booking/internal/user"
+	"github.com/hitorii/ticket-booking/internal/venue"
+	"github.com/hitorii/ticket-booking/internal/wallet"
 	"github.com/hitorii/ticket-booking/internal/notification"
 )
 
@@ -70,60 +85,254 @@ func main() {
 	userCommandHandler := user.NewCommandHandler(userCmdService)
 	userQueryHandler := user.NewQueryHandler(userQueryService)
 
-	movieCmdService := movie.NewCommandServiceWithDispatcher(eventDispatcher)
+	movieRepo := movie.NewRepository(cmdDB)
+	movieCmdService := movie.NewCommandServiceWithDispatcher(movieRepo, eventDispatcher)
+	movieProjection := movie.NewProjection(queryDB, movieRepo)
 	movieQueryService := movie.NewQueryService(queryDB)
 	movieCommandHandler := movie.NewCommandHandler(movieCmdService)
 	movieQueryHandler := movie.NewQueryHandler(movieQueryService)
 
-	showCmdService := show.NewCommandServiceWithDispatcher(eventDispatcher)
+	// Show times are kept as instants and given in their venue's zone; venues without
+	// one of their own are in TIMEZONE
+	timeZone, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		log.Fatalf("❌ Unknown TIMEZONE %q: %v", cfg.TimeZone, err)
+	}
+	venueRepo := venue.NewRepository(cmdDB)
+	venueQueryService := venue.NewQueryService(venueRepo, timeZone)
+	venueCmdService := venue.NewCommandService(venueRepo, eventDispatcher)
+	venueCommandHandler := venue.NewCommandHandler(venueCmdService)
+	venueQueryHandler := venue.NewQueryHandler(venueQueryService)
+
+	showRepo := show.NewRepository(cmdDB)
+	showRepo.Zones = venueQueryService
+	showCmdService := show.NewCommandServiceWithDispatcher(showRepo, eventDispatcher,
+		show.Buffers{Ads: cfg.ShowAdsBuffer, Cleaning: cfg.ShowCleaningBuffer})
+	showCmdService.Venues = venueQueryService
+	showProjection := show.NewProjection(queryDB, showRepo)
+	listingRepo := listings.NewRepository(cmdDB)
+	listingRepo.Zones = venueQueryService
+	listingProjection := listings.NewProjection(queryDB, listingRepo)
+	calendarRepo := calendar.NewRepository(cmdDB)
+	calendarRepo.Zones = venueQueryService
+	calendarProjection := calendar.NewProjection(queryDB, calendarRepo)
+	// Catch the read models up with anything written while events weren't being handled;
+	// shows reference movies, so movies go first
+	go func() {
+		if err := movieProjection.Rebuild(context.Background()); err != nil {
+			log.Printf("❌ Failed to project movies: %v", err)
+			return
+		}
+		if err := showProjection.Rebuild(context.Background()); err != nil {
+			log.Printf("❌ Failed to project shows: %v", err)
+		}
+		if err := listingProjection.Rebuild(context.Background()); err != nil {
+			log.Printf("❌ Failed to project listings: %v", err)
+		}
+		if err := calendarProjection.Rebuild(context.Background()); err != nil {
+			log.Printf("❌ Failed to project calendar entries: %v", err)
+		}
+	}()
 	showQueryService := show.NewQueryService(queryDB)
+	showQueryService.Repo = showRepo
 	showCommandHandler := show.NewCommandHandler(showCmdService)
+	showCommandHandler.Managers = venueCmdService
 	showQueryHandler := show.NewQueryHandler(showQueryService)
+	listingQueryService := listings.NewQueryService(queryDB)
+	listingQueryService.Location = timeZone
+	listingQueryHandler := listings.NewQueryHandler(listingQueryService)
+	calendarQueryHandler := calendar.NewQueryHandler(calendar.NewQueryService(queryDB, timeZone))
+
+	// Imports are queued here and run by the worker
+	catalogueRepo := catalogue.NewRepository(cmdDB)
+	catalogueCommandHandler := catalogue.NewCommandHandler(catalogue.NewCommandService(catalogueRepo, movieCmdService, showCmdService))
+	catalogueQueryHandler := catalogue.NewQueryHandler(catalogue.NewQueryService(catalogueRepo, movieRepo, showRepo))
+
+	promoRepo := promotions.NewRepository(cmdDB)
+	promoCmdService := promotions.NewCommandServiceWithDispatcher(promoRepo, eventDispatcher)
+	promoCommandHandler := promotions.NewCommandHandler(promoCmdService)
+	promoQueryHandler := promotions.NewQueryHandler(promotions.NewQueryService(promoRepo))
+
+	var pricingStrategy pricing.Strategy = pricing.StaticStrategy{}
+	if cfg.PricingStrategy == "demand" {
+		pricingStrategy = pricing.DefaultDemandStrategy()
+	}
+	pricingEngine := pricing.NewEngine(pricing.NewRepository(cmdDB), queryDB,
+		pricing.NewPricer(pricingStrategy, cfg.PricingMinMultiplier, cfg.PricingMaxMultiplier))
+	pricingCmdService := pricing.NewCommandServiceWithEngine(pricingEngine, eventDispatcher, cfg.PriceQuoteTTL)
+	pricingQueryService := pricing.NewQueryServiceWithEngine(pricingEngine)
+	pricingCommandHandler := pricing.NewCommandHandler(pricingCmdService)
+	pricingQueryHandler := pricing.NewQueryHandler(pricingQueryService)
+	bookingCommandService.Pricing = pricingCmdService
+
+	feeRepo := fees.NewRepository(cmdDB)
+	feeQueryService := fees.NewQueryService(feeRepo)
+	feeCommandHandler := fees.NewCommandHandler(fees.NewCommandService(feeRepo))
+	feeQueryHandler := fees.NewQueryHandler(feeQueryService)
+
+	walletRepo := wallet.NewRepository(cmdDB)
+	walletCmdService := wallet.NewCommandService(walletRepo)
+	walletCommandHandler := wallet.NewCommandHandler(walletCmdService)
+	walletQueryHandler := wallet.NewQueryHandler(wallet.NewQueryService(walletRepo))
+
+	loyaltyRepo := loyalty.NewRepository(cmdDB)
+	loyaltyCmdService := loyalty.NewCommandService(loyaltyRepo, loyalty.DefaultProgram())
+	loyaltyQueryHandler := loyalty.NewQueryHandler(loyalty.NewQueryService(loyaltyRepo))
 
 	paymentRepo := payments.NewRepository(cmdDB)
 	paymentCmdService := payments.NewCommandServiceWithDispatcher(paymentRepo, eventDispatcher)
+	paymentCmdService.Currency = cfg.DefaultCurrency
+	paymentCmdService.Promotions = promoCmdService
+	paymentCmdService.Pricing = pricingQueryService
+	paymentCmdService.Fees = feeQueryService
+	paymentCmdService.StoredValue = walletCmdService
+	paymentCmdService.Loyalty = loyaltyCmdService
 	paymentCommandHandler := payments.NewCommandHandler(paymentCmdService)
 
+	// Cancelling a show happens here; its refunds and notices are worked through by the worker
+	cancellationRepo := cancellation.NewRepository(cmdDB)
+	cancellationCmdService := cancellation.NewCommandService(cancellationRepo, showRepo, eventDispatcher)
+	cancellationCmdService.Venues = venueCmdService
+	cancellationCmdService.Payments = paymentCmdService
+	cancellationCmdService.Bookings = bookingCommandService
+	cancellationCommandHandler := cancellation.NewCommandHandler(cancellationCmdService)
+	cancellationQueryHandler := cancellation.NewQueryHandler(cancellation.NewQueryService(cancellationRepo))
+
+	receiptRepo := receipts.NewRepository(cmdDB)
+	receiptRepo.Zones = venueQueryService
+	receiptCmdService := receipts.NewCommandService(receiptRepo, paymentRepo)
+	receiptQueryHandler := receipts.NewQueryHandler(receipts.NewQueryService(receiptRepo))
+
+	// Tickets are signed with the configured keys; without any, a throwaway key is generated
+	var ticketKeys *tickets.Keyring
+	if cfg.TicketSigningKeys != "" {
+		ticketKeys, err = tickets.ParseKeyring(cfg.TicketSigningKeys, cfg.TicketActiveKey)
+	} else {
+		log.Println("⚠️  TICKET_SIGNING_KEYS not set, tickets will not verify after a restart")
+		ticketKeys, err = tickets.NewEphemeralKeyring()
+	}
+	if err != nil {
+		log.Fatalf("❌ Failed to load ticket signing keys: %v", err)
+	}
+	ticketRepo := tickets.NewRepository(cmdDB)
+	ticketRepo.Zones = venueQueryService
+	ticketCmdService := tickets.NewCommandService(ticketRepo, ticketKeys, cfg.TicketGracePeriod, cfg.TicketTTL)
+	ticketCmdService.Dispatcher = eventDispatcher
+	ticketCmdService.Lock = bookingCommandService.Lock
+	ticketCommandHandler := tickets.NewCommandHandler(ticketCmdService)
+	ticketCommandHandler.Managers = venueCmdService
+	ticketQueryHandler := tickets.NewQueryHandler(tickets.NewQueryService(ticketRepo, ticketKeys))
+	ticketQueryHandler.Admins = venueCmdService
+
 	// Pass CommandDB to notification query service for user notifications
 	notificationQueryService := notification.NewQueryService(queryDB, cmdDB)
 	notificationQueryHandler := notification.NewQueryHandler(notificationQueryService)
 	
 	// Initialize payment query handler
-	paymentQueryService := payments.NewQueryService(queryDB)
+	paymentQueryService := payments.NewQueryServiceWithCmdDB(queryDB, cmdDB)
 	paymentQueryHandler := payments.NewQueryHandler(paymentQueryService)
 
 	if eventDispatcher != nil {
 		setupEventSubscribers(eventDispatcher)
+		loyaltyCmdService.Subscribe(eventDispatcher)
+		receiptCmdService.Subscribe(eventDispatcher)
+		ticketCmdService.Subscribe(eventDispatcher)
+		movieProjection.Subscribe(eventDispatcher)
+		showProjection.Subscribe(eventDispatcher)
+		listingProjection.Subscribe(eventDispatcher)
+		calendarProjection.Subscribe(eventDispatcher)
 	}
 
 	r.POST("/cmd/reserve", bookingCommandHandler.ReserveTicket)
 	r.POST("/cmd/cancel", bookingCommandHandler.CancelTicket)
 	r.POST("/cmd/confirm", bookingCommandHandler.ConfirmTicket)
 	r.POST("/cmd/users/register", userCommandHandler.Register)
-	r.POST("/cmd/movies", movieCommandHandler.CreateMovie)
-	r.PUT("/cmd/movies/:id", movieCommandHandler.UpdateMovie)
-	r.DELETE("/cmd/movies/:id", movieCommandHandler.DeleteMovie)
-	r.POST("/cmd/shows", showCommandHandler.CreateShow)
-	r.PUT("/cmd/shows/:id", showCommandHandler.UpdateShow)
-	r.DELETE("/cmd/shows/:id", showCommandHandler.DeleteShow)
+	// The catalogue is managed by admins. Venue data, and the shows and prices of each
+	// venue, are managed by admins and by each venue's own managers
+	authenticate := middleware.Authenticate()
+	requireAdmin, requireManager := venueCommandHandler.RequireAdmin, venueCommandHandler.RequireManager
+	requireShowManager, requireScheduleManager := showCommandHandler.RequireShowManager, showCommandHandler.RequireScheduleManager
+	r.POST("/cmd/movies", authenticate, requireAdmin, movieCommandHandler.CreateMovie)
+	r.PUT("/cmd/movies/:id", authenticate, requireAdmin, movieCommandHandler.UpdateMovie)
+	r.PATCH("/cmd/movies/:id", authenticate, requireAdmin, movieCommandHandler.PatchMovie)
+	r.DELETE("/cmd/movies/:id", authenticate, requireAdmin, movieCommandHandler.DeleteMovie)
+	r.POST("/cmd/shows", authenticate, showCommandHandler.CreateShow)
+	r.POST("/cmd/shows/bulk", authenticate, showCommandHandler.ScheduleShows)
+	r.POST("/cmd/schedules", authenticate, showCommandHandler.CreateSchedule)
+	r.PUT("/cmd/schedules/:id/following", authenticate, requireScheduleManager, showCommandHandler.UpdateFollowing)
+	r.DELETE("/cmd/schedules/:id/following", authenticate, requireScheduleManager, showCommandHandler.CancelFollowing)
+	r.PUT("/cmd/shows/:id", authenticate, requireShowManager, showCommandHandler.UpdateShow)
+	r.DELETE("/cmd/shows/:id", authenticate, requireShowManager, showCommandHandler.DeleteShow)
+	r.PUT("/cmd/shows/:id/prices", authenticate, requireShowManager, pricingCommandHandler.SetShowPrices)
+	r.POST("/cmd/shows/:id/seats", authenticate, requireShowManager, pricingCommandHandler.AddSeats)
+	r.POST("/cmd/cities", authenticate, requireAdmin, venueCommandHandler.CreateCity)
+	r.POST("/cmd/venues", authenticate, requireAdmin, venueCommandHandler.CreateVenue)
+	r.PUT("/cmd/venues/:venue", authenticate, requireManager, venueCommandHandler.UpdateVenue)
+	r.POST("/cmd/venues/:venue/screens", authenticate, requireManager, venueCommandHandler.AddScreen)
+	r.PUT("/cmd/venues/:venue/screens/:id", authenticate, requireManager, venueCommandHandler.UpdateScreen)
+	r.DELETE("/cmd/venues/:venue/screens/:id", authenticate, requireManager, venueCommandHandler.DeleteScreen)
+	r.PUT("/cmd/venues/:venue/managers/:user_id", authenticate, requireAdmin, venueCommandHandler.AddManager)
+	r.DELETE("/cmd/venues/:venue/managers/:user_id", authenticate, requireAdmin, venueCommandHandler.RemoveManager)
+	r.PUT("/cmd/venues/:venue/fees", authenticate, requireManager, feeCommandHandler.SetVenueRules)
+	r.PUT("/cmd/venues/:venue/timezone", authenticate, requireManager, venueCommandHandler.SetTimeZone)
+	r.POST("/cmd/shows/:id/cancel", authenticate, cancellationCommandHandler.CancelShow)
+	r.POST("/cmd/cancellations/:id/retry", authenticate, requireAdmin, cancellationCommandHandler.RetryCancellation)
+	r.POST("/cmd/rebook/:token", cancellationCommandHandler.Rebook)
+	r.POST("/cmd/giftcards", authenticate, requireAdmin, walletCommandHandler.IssueGiftCard)
+	r.POST("/cmd/users/:id/wallet/topup", authenticate, requireAdmin, walletCommandHandler.TopUpWallet)
 	r.POST("/cmd/payments/initiate", paymentCommandHandler.InitiatePayment)
 	r.POST("/cmd/payments/verify", paymentCommandHandler.VerifyPayment)
 	r.POST("/cmd/payments/:id/refund", paymentCommandHandler.RefundPayment)
+	r.POST("/cmd/promotions", authenticate, requireAdmin, promoCommandHandler.CreatePromotion)
+	r.POST("/cmd/checkin", authenticate, ticketCommandHandler.CheckIn)
+	r.POST("/cmd/checkin/sync", authenticate, ticketCommandHandler.SyncOfflineScans)
+	r.POST("/cmd/admin/imports/:kind", authenticate, requireAdmin, catalogueCommandHandler.StartImport)
 
 	r.GET("/query/reservations/:user_id", bookingQueryHandler.GetUserReservations)
+	r.GET("/query/reservations/:user_id/calendar.ics", calendarQueryHandler.GetUserCalendar)
 	r.GET("/query/availability/:seat_id", bookingQueryHandler.CheckAvailability)
 	r.GET("/query/events", bookingQueryHandler.GetEvents)
 	r.GET("/query/users", userQueryHandler.ListUsers)
 	r.POST("/query/users/login", userQueryHandler.Login)
 	r.GET("/query/users/:id", userQueryHandler.GetUser)
+	r.GET("/query/users/:id/wallet", walletQueryHandler.GetWallet)
+	r.GET("/query/users/:id/loyalty", loyaltyQueryHandler.GetSummary)
+	r.GET("/query/users/:id/tickets", authenticate, ticketQueryHandler.GetUserTickets)
 	r.GET("/query/movies", movieQueryHandler.GetMovies)
+	r.GET("/query/movies/search", movieQueryHandler.SearchMovies)
+	r.GET("/query/movies/genre/:genre", movieQueryHandler.GetMoviesByGenre)
 	r.GET("/query/movies/:id", movieQueryHandler.GetMovie)
 	r.GET("/query/shows", showQueryHandler.GetShows)
+	r.GET("/query/shows/search", showQueryHandler.SearchShows)
 	r.GET("/query/shows/:id", showQueryHandler.GetShow)
 	r.GET("/query/shows/movie/:movieID", showQueryHandler.GetShowsByMovie)
+	r.GET("/query/shows/:id/prices", pricingQueryHandler.GetShowPrices)
+	r.GET("/query/shows/:id/checkins", authenticate, requireShowManager, ticketQueryHandler.GetShowCheckIns)
+	r.GET("/query/shows/:id/cancellation", cancellationQueryHandler.GetShowCancellation)
+	r.GET("/query/cancellations/:id", cancellationQueryHandler.GetCancellation)
+	r.GET("/query/schedules/:id", showQueryHandler.GetSchedule)
+	r.GET("/query/listings", listingQueryHandler.GetListings)
+	r.GET("/query/bookings/:bookingID/quote", pricingQueryHandler.QuoteBooking)
+	r.GET("/query/bookings/:bookingID/ticket", authenticate, ticketQueryHandler.GetBookingTicket)
+	r.GET("/query/tickets/keys", ticketQueryHandler.GetPublicKeys)
+	r.GET("/query/tickets/:id", authenticate, ticketQueryHandler.GetTicket)
+	r.GET("/query/cities", venueQueryHandler.ListCities)
+	r.GET("/query/venues", venueQueryHandler.ListVenues)
+	r.GET("/query/venues/:venue", venueQueryHandler.GetVenue)
+	r.GET("/query/venues/:venue/managers", authenticate, requireManager, venueQueryHandler.ListManagers)
+	r.GET("/query/venues/:venue/fees", feeQueryHandler.GetVenueRules)
+	r.GET("/query/venues/:venue/timezone", venueQueryHandler.GetTimeZone)
+	r.GET("/query/venues/:venue/calendar.ics", calendarQueryHandler.GetVenueCalendar)
+	r.GET("/query/giftcards/:code", walletQueryHandler.GetGiftCard)
 	r.GET("/query/payments/:id", paymentQueryHandler.GetPayment)
+	r.GET("/query/payments/:id/receipt", receiptQueryHandler.GetReceipt)
 	r.GET("/query/payments/booking/:bookingID", paymentQueryHandler.GetPaymentByBooking)
 	r.GET("/query/payments/user/:userID", paymentQueryHandler.GetPaymentsByUser)
+	r.GET("/query/admin/reconciliation", authenticate, requireAdmin, paymentQueryHandler.GetReconciliationReports)
+	r.GET("/query/admin/reconciliation/:id", authenticate, requireAdmin, paymentQueryHandler.GetReconciliationReport)
+	r.GET("/query/admin/imports/:id", authenticate, requireAdmin, catalogueQueryHandler.GetImport)
+	r.GET("/query/admin/export/:kind", authenticate, requireAdmin, catalogueQueryHandler.Export)
+	r.GET("/query/promotions/:code", promoQueryHandler.GetPromotion)
 	r.GET("/query/notifications/:user_id", notificationQueryHandler.GetUserNotifications)
 	r.GET("/query/notifications/:user_id/unread", notificationQueryHandler.GetUnreadNotifications)
 	r.GET("/query/notifications/single/:id", notificationQueryHandler.GetNotification)
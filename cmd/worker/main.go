@@ -1,18 +1,31 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/hitorii/ticket-booking/internal/booking"
+	"github.com/hitorii/ticket-booking/internal/calendar"
+	"github.com/hitorii/ticket-booking/internal/cancellation"
+	"github.com/hitorii/ticket-booking/internal/catalogue"
 	"github.com/hitorii/ticket-booking/internal/config"
 	"github.com/hitorii/ticket-booking/internal/db"
 	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/hitorii/ticket-booking/internal/listings"
+	"github.com/hitorii/ticket-booking/internal/loyalty"
+	"github.com/hitorii/ticket-booking/internal/movie"
 	"github.com/hitorii/ticket-booking/internal/notification"
+	"github.com/hitorii/ticket-booking/internal/payments"
+	"github.com/hitorii/ticket-booking/internal/promotions"
 	"github.com/hitorii/ticket-booking/internal/queue"
+	"github.com/hitorii/ticket-booking/internal/show"
+	"github.com/hitorii/ticket-booking/internal/venue"
+	"github.com/hitorii/ticket-booking/internal/wallet"
 )
 
 func main() {
@@ -42,11 +55,69 @@ func main() {
 	notification.StartWorker(notificationRepo)
 	log.Println("🔔 Notification worker started")
 
-	// Start projection worker
-	projection := &booking.ReservationProjection{
-		DB:         queryDB,
-		EventStore: &events.Store{DB: cmdDB},
+	// Start settlement reconciliation job
+	if cfg.SettlementDir != "" {
+		reconciler := payments.NewReconciler(payments.NewRepository(cmdDB), queryDB, cfg.SettlementDir)
+		go reconciler.Run(context.Background(), cfg.ReconciliationInterval)
+		log.Printf("🧾 Reconciliation job watching %s every %s", cfg.SettlementDir, cfg.ReconciliationInterval)
+	}
+
+	// Start pending payment expiry sweeper
+	var dispatcher *events.Dispatcher
+	if queue.RedisClient != nil {
+		dispatcher = events.NewDispatcher(queue.RedisClient, &events.Store{DB: cmdDB})
+	}
+	paymentCmdService := payments.NewCommandServiceWithDispatcher(payments.NewRepository(cmdDB), dispatcher)
+	paymentCmdService.Promotions = promotions.NewCommandServiceWithDispatcher(promotions.NewRepository(cmdDB), dispatcher)
+	paymentCmdService.StoredValue = wallet.NewCommandService(wallet.NewRepository(cmdDB))
+	paymentCmdService.Loyalty = loyalty.NewCommandService(loyalty.NewRepository(cmdDB), loyalty.DefaultProgram())
+	holds := booking.NewCommandServiceWithDispatcher(cmdDB, dispatcher)
+	sweeper := payments.NewExpirySweeper(paymentCmdService, holds, cfg.PaymentExpiry)
+	go sweeper.Run(context.Background(), cfg.PaymentExpirySweepInterval)
+	log.Printf("⌛ Payment expiry sweeper running every %s (expiry %s)", cfg.PaymentExpirySweepInterval, cfg.PaymentExpiry)
+
+	// Start catalogue import worker. Events only reach subscribers in the publishing
+	// process, so the read models of what it imports are kept up to date from here
+	timeZone, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil {
+		log.Fatalf("❌ Unknown TIMEZONE %q: %v", cfg.TimeZone, err)
 	}
+	zones := venue.NewQueryService(venue.NewRepository(cmdDB), timeZone)
+	movieRepo := movie.NewRepository(cmdDB)
+	showRepo := show.NewRepository(cmdDB)
+	showRepo.Zones = zones
+	if dispatcher != nil {
+		listingRepo := listings.NewRepository(cmdDB)
+		listingRepo.Zones = zones
+		calendarRepo := calendar.NewRepository(cmdDB)
+		calendarRepo.Zones = zones
+		movie.NewProjection(queryDB, movieRepo).Subscribe(dispatcher)
+		show.NewProjection(queryDB, showRepo).Subscribe(dispatcher)
+		listings.NewProjection(queryDB, listingRepo).Subscribe(dispatcher)
+		calendar.NewProjection(queryDB, calendarRepo).Subscribe(dispatcher)
+	}
+	showCmdService := show.NewCommandServiceWithDispatcher(showRepo, dispatcher,
+		show.Buffers{Ads: cfg.ShowAdsBuffer, Cleaning: cfg.ShowCleaningBuffer})
+	showCmdService.Venues = zones
+	importer := catalogue.NewCommandService(catalogue.NewRepository(cmdDB),
+		movie.NewCommandServiceWithDispatcher(movieRepo, dispatcher), showCmdService)
+	go catalogue.NewWorker(importer).Run(context.Background(), cfg.ImportPollInterval)
+	log.Printf("📦 Catalogue import worker polling every %s", cfg.ImportPollInterval)
+
+	// Start show cancellation worker, which refunds and notifies the bookings of
+	// cancelled shows
+	cancellations := cancellation.NewCommandService(cancellation.NewRepository(cmdDB), showRepo, dispatcher)
+	cancellations.Payments = paymentCmdService
+	// Its refunds only reach subscribers in this process, so points earned on the refunded
+	// payments are taken back, and points redeemed on them given back, from here
+	if dispatcher != nil {
+		paymentCmdService.Loyalty.Subscribe(dispatcher)
+	}
+	go cancellation.NewWorker(cancellations).Run(context.Background(), cfg.CancellationPollInterval)
+	log.Printf("🚫 Show cancellation worker polling every %s", cfg.CancellationPollInterval)
+
+	// Start projection worker
+	projection := booking.NewReservationProjection(queryDB, cmdDB, &events.Store{DB: cmdDB})
 
 	log.Println("📊 Starting projection worker...")
 
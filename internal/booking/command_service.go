@@ -4,20 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/hitorii/ticket-booking/internal/events"
 	"github.com/hitorii/ticket-booking/internal/notification"
+	"github.com/hitorii/ticket-booking/internal/pricing"
 	"github.com/hitorii/ticket-booking/internal/utils"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrShowCancelled is returned when reserving a seat of a cancelled show
+var ErrShowCancelled = errors.New("show is cancelled")
+
 type CommandService struct {
 	DB         *pgxpool.Pool
 	Dispatcher *events.Dispatcher
 	RedisClient *redis.Client
 	Lock       *utils.DistributedLock
+	Pricing    *pricing.CommandService // locks a price quote for each new hold
 }
 
 func NewCommandService(db *pgxpool.Pool) *CommandService {
@@ -101,13 +108,28 @@ func (s *CommandService) reserveTicketInternal(ctx context.Context, userID, seat
 		}
 	}
 	
+	// Seats of a cancelled show stay in place but can't be reserved. The show is locked so a
+	// cancellation either waits for this hold, and takes it away too, or is seen here.
+	var showStatus string
+	err = tx.QueryRow(ctx,
+		"SELECT sh.status FROM seats s JOIN shows sh ON sh.id = s.show_id WHERE s.id = $1 FOR SHARE OF sh",
+		seatID,
+	).Scan(&showStatus)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to check show: %w", err)
+	}
+	if showStatus == "CANCELLED" {
+		return ErrShowCancelled
+	}
+
 	// Insert reservation with proper UUID
-	_, err = tx.Exec(ctx,
-		"INSERT INTO reservations (id, seat_id, user_id, status) VALUES ($1, $2, $3, 'HELD')",
+	var showID *int
+	err = tx.QueryRow(ctx,
+		"INSERT INTO reservations (id, seat_id, user_id, show_id, status) VALUES ($1, $2, $3, (SELECT show_id FROM seats WHERE id = $2), 'HELD') RETURNING show_id",
 		reservationID,
 		seatID,
 		userID,
-	)
+	).Scan(&showID)
 	if err != nil {
 		return errors.New("seat already reserved")
 	}
@@ -125,12 +147,28 @@ func (s *CommandService) reserveTicketInternal(ctx context.Context, userID, seat
 		println("Warning: Failed to enqueue notification:", err.Error())
 	}
 
+	// Lock the price for as long as the seat is held
+	var quote *pricing.Quote
+	if s.Pricing != nil && showID != nil {
+		quote, err = s.Pricing.LockQuote(ctx, reservationID)
+		if err != nil {
+			println("Warning: Failed to lock price quote:", err.Error())
+		}
+	}
+
 	// Emit event for event-driven flow
 	if s.Dispatcher != nil {
 		payload := events.EventPayload{
-			UserID: userID,
-			SeatID: seatID,
-			Status: "HELD",
+			UserID:    userID,
+			SeatID:    seatID,
+			BookingID: reservationID,
+			Status:    "HELD",
+		}
+		if showID != nil {
+			payload.ShowID = strconv.Itoa(*showID)
+		}
+		if quote != nil {
+			payload.Price = &quote.Total
 		}
 		_ = s.Dispatcher.Publish(ctx, events.EventTicketReserved, seatID, payload)
 	}
@@ -171,20 +209,21 @@ func (s *CommandService) confirmTicketInternal(ctx context.Context, userID, seat
 	}
 	defer tx.Rollback(ctx)
 	
-	res, err := tx.Exec(ctx,
+	var bookingID string
+	err = tx.QueryRow(ctx,
 		`UPDATE reservations 
 		 SET status='BOOKED', updated_at = NOW()
-		 WHERE seat_id=$1 AND user_id=$2 AND status='HELD'`,
+		 WHERE seat_id=$1 AND user_id=$2 AND status='HELD'
+		 RETURNING id`,
 		seatID,
 		userID,
-	)
+	).Scan(&bookingID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("seat not held or already booked")
+	}
 	if err != nil {
 		return errors.New("failed to confirm booking")
 	}
-
-	if res.RowsAffected() == 0 {
-		return errors.New("seat not held or already booked")
-	}
 	
 	// Commit the transaction
 	if err := tx.Commit(ctx); err != nil {
@@ -200,9 +239,10 @@ func (s *CommandService) confirmTicketInternal(ctx context.Context, userID, seat
 	// Emit event for event-driven flow
 	if s.Dispatcher != nil {
 		payload := events.EventPayload{
-			UserID: userID,
-			SeatID: seatID,
-			Status: "BOOKED",
+			UserID:    userID,
+			SeatID:    seatID,
+			BookingID: bookingID,
+			Status:    "BOOKED",
 		}
 		_ = s.Dispatcher.Publish(ctx, events.EventTicketConfirmed, seatID, payload)
 	}
@@ -264,3 +304,38 @@ func (s *CommandService) CancelTicket(ctx context.Context, userID, seatID string
 	return nil
 }
 
+
+// ReleaseHold - Command to release the seat hold behind a booking whose payment can't complete.
+// Releasing a booking that is no longer held is a no-op.
+func (s *CommandService) ReleaseHold(ctx context.Context, bookingID, userID string) error {
+	var seatID string
+	err := s.DB.QueryRow(ctx,
+		"DELETE FROM reservations WHERE id=$1 AND user_id=$2 AND status='HELD' RETURNING seat_id",
+		bookingID,
+		userID,
+	).Scan(&seatID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release hold: %w", err)
+	}
+
+	err = notification.EnqueueBookingNotification(userID, seatID, "Seat hold released: payment expired")
+	if err != nil {
+		println("Warning: Failed to enqueue notification:", err.Error())
+	}
+
+	// Emit event for event-driven flow
+	if s.Dispatcher != nil {
+		payload := events.EventPayload{
+			UserID:    userID,
+			BookingID: bookingID,
+			SeatID:    seatID,
+			Status:    "CANCELLED",
+		}
+		_ = s.Dispatcher.Publish(ctx, events.EventTicketCancelled, seatID, payload)
+	}
+
+	return nil
+}
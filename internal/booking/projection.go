@@ -61,18 +61,20 @@ func (p *ReservationProjection) Run() {
 			var data struct {
 				UserID string `json:"user_id"`
 				SeatID string `json:"seat_id"`
+				ShowID string `json:"show_id"`
 			}
 			json.Unmarshal(payload, &data)
 
 			// Insert or update reservation in projection
 			_, err := p.DB.Exec(context.Background(),
-				`INSERT INTO reservation_projection(seat_id, user_id, status)
-				 VALUES ($1, $2, 'HELD')
+				`INSERT INTO reservation_projection(seat_id, user_id, show_id, status)
+				 VALUES ($1, $2, NULLIF($3, '')::int, 'HELD')
 				 ON CONFLICT (seat_id) DO UPDATE SET
 				 user_id = EXCLUDED.user_id,
+				 show_id = EXCLUDED.show_id,
 				 status = 'HELD',
 				 updated_at = NOW()`,
-				data.SeatID, data.UserID,
+				data.SeatID, data.UserID, data.ShowID,
 			)
 			if err != nil {
 				log.Println("Projection failed for reserve:", err)
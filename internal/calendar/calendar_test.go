@@ -0,0 +1,135 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func testEntry() Entry {
+	stamp := time.Date(2024, 2, 20, 9, 0, 0, 0, time.UTC)
+	return Entry{
+		UID:         showUID(42),
+		ShowID:      42,
+		Venue:       "Screen 1, Mall Road",
+		Summary:     "Dune; Part Two",
+		Description: "Running time: 166 min",
+		StartTime:   time.Date(2024, 3, 1, 18, 30, 0, 0, time.UTC),
+		EndTime:     time.Date(2024, 3, 1, 21, 45, 0, 0, time.UTC),
+		Status:      StatusConfirmed,
+		Sequence:    2,
+		CreatedAt:   stamp,
+		UpdatedAt:   stamp,
+	}
+}
+
+// TestICS tests the feed document, with times given in the venue's zone written in UTC
+func TestICS(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	e := testEntry()
+	e.TimeZone = kolkata.String()
+	e.StartTime = time.Date(2024, 3, 1, 18, 30, 0, 0, kolkata)
+	e.EndTime = time.Date(2024, 3, 1, 21, 45, 0, 0, kolkata)
+	feed := &Feed{Name: "Screen 1", Location: kolkata, Entries: []Entry{e}}
+
+	ics := feed.ICS()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-TIMEZONE:Asia/Kolkata\r\n",
+		"UID:show-42@ticket-booking\r\n",
+		"SEQUENCE:2\r\n",
+		"STATUS:CONFIRMED\r\n",
+		"DTSTART:20240301T130000Z\r\n",
+		"DTEND:20240301T161500Z\r\n",
+		"SUMMARY:Dune\\; Part Two\r\n",
+		"LOCATION:Screen 1\\, Mall Road\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("Expected %q in:\n%s", want, ics)
+		}
+	}
+}
+
+// TestICSCancelled tests that a cancelled entry says so in its status and title
+func TestICSCancelled(t *testing.T) {
+	e := testEntry()
+	e.Status = StatusCancelled
+	ics := (&Feed{Name: "Screen 1", Entries: []Entry{e}}).ICS()
+
+	if !strings.Contains(ics, "STATUS:CANCELLED\r\n") || !strings.Contains(ics, "SUMMARY:Cancelled: Dune") {
+		t.Errorf("Expected a cancelled entry, got:\n%s", ics)
+	}
+	if !strings.Contains(ics, "DTSTART:20240301T183000Z\r\n") || !strings.Contains(ics, "X-WR-TIMEZONE:UTC\r\n") {
+		t.Errorf("Expected UTC without a zone, got:\n%s", ics)
+	}
+}
+
+// TestWriteLineFolding tests that long lines are folded at 75 octets without splitting characters
+func TestWriteLineFolding(t *testing.T) {
+	var b strings.Builder
+	writeLine(&b, "DESCRIPTION:"+strings.Repeat("é", 100))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("Expected the line to be folded, got %q", b.String())
+	}
+	var unfolded string
+	for i, line := range lines {
+		if len(line) > maxLineOctets {
+			t.Errorf("Line %d is %d octets long", i, len(line))
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("Line %d splits a character: %q", i, line)
+		}
+		if i > 0 {
+			if !strings.HasPrefix(line, " ") {
+				t.Errorf("Continuation line %d doesn't start with a space", i)
+			}
+			line = line[1:]
+		}
+		unfolded += line
+	}
+	if unfolded != "DESCRIPTION:"+strings.Repeat("é", 100) {
+		t.Errorf("Unfolding didn't give back the line: %q", unfolded)
+	}
+}
+
+// TestEscapeText tests escaping of TEXT values
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{"plain", "plain"},
+		{"a,b;c", `a\,b\;c`},
+		{`back\slash`, `back\\slash`},
+		{"two\nlines\r\nhere", `two\nlines\nhere`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeText(tt.in); got != tt.expected {
+			t.Errorf("escapeText(%q): expected %q, got %q", tt.in, tt.expected, got)
+		}
+	}
+}
+
+// TestUserEntry tests a booked show as it appears in the user's feed
+func TestUserEntry(t *testing.T) {
+	show := testEntry()
+	e := userEntry(&show, booking{UserID: "u-1", Seats: []string{"A1", "A2"}})
+
+	if e.UID != "show-42-u-1@ticket-booking" || e.UserID != "u-1" {
+		t.Errorf("Unexpected identity: %s for %s", e.UID, e.UserID)
+	}
+	if e.Description != "Seats: A1, A2\nRunning time: 166 min" {
+		t.Errorf("Unexpected description: %q", e.Description)
+	}
+	if show.UID != showUID(42) {
+		t.Errorf("Expected the venue entry to be left alone, got %s", show.UID)
+	}
+}
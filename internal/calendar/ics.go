@@ -0,0 +1,96 @@
+// iCalendar (RFC 5545) rendering of feed entries
+
+package calendar
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Lines longer than maxLineOctets are folded onto continuation lines
+const maxLineOctets = 75
+
+const utcLayout = "20060102T150405Z"
+
+// Feed is a calendar document. Entry times are written in UTC, which every calendar app
+// converts to the viewer's zone; Location is only the zone the feed suggests showing it in.
+type Feed struct {
+	Name     string
+	Location *time.Location
+	Entries  []Entry
+}
+
+// ICS renders the feed as a text/calendar document
+func (f *Feed) ICS() string {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeLine(&b, name+":"+value)
+	}
+	text := func(name, value string) {
+		line(name, escapeText(value))
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//ticket-booking//calendar//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	text("X-WR-CALNAME", f.Name)
+	line("X-WR-TIMEZONE", f.location().String())
+	for _, e := range f.Entries {
+		summary := e.Summary
+		if e.Status == StatusCancelled {
+			summary = "Cancelled: " + summary
+		}
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", e.UpdatedAt.UTC().Format(utcLayout))
+		line("CREATED", e.CreatedAt.UTC().Format(utcLayout))
+		line("LAST-MODIFIED", e.UpdatedAt.UTC().Format(utcLayout))
+		line("SEQUENCE", strconv.Itoa(e.Sequence))
+		line("STATUS", e.Status)
+		line("DTSTART", e.StartTime.UTC().Format(utcLayout))
+		line("DTEND", e.EndTime.UTC().Format(utcLayout))
+		text("SUMMARY", summary)
+		text("LOCATION", e.Venue)
+		if e.Description != "" {
+			text("DESCRIPTION", e.Description)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.String()
+}
+
+func (f *Feed) location() *time.Location {
+	if f.Location == nil {
+		return time.UTC
+	}
+	return f.Location
+}
+
+// escapeText escapes a TEXT value: backslashes, semicolons, commas and newlines
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+// writeLine writes a content line ending in CRLF, folded so that no line is longer than
+// 75 octets without splitting a UTF-8 character
+func writeLine(b *strings.Builder, s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards their length
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
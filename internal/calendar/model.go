@@ -0,0 +1,48 @@
+// Models for the iCalendar feeds of bookings and venue programmes
+
+package calendar
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Entry statuses, as written to STATUS
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// history is how long after a show ends it stays in the feeds
+const history = 30 * 24 * time.Hour
+
+var ErrInvalidUser = errors.New("invalid user ID")
+
+// Entry is one event of a feed: a show of a venue's programme, or a show a user has
+// booked. Start and end are given in TimeZone, the venue's. Sequence goes up each time the
+// show moves or is cancelled, so calendar apps replace their copy.
+type Entry struct {
+	UID         string
+	ShowID      int
+	UserID      string // empty for a venue programme entry
+	Venue       string
+	Summary     string
+	Description string
+	TimeZone    string
+	StartTime   time.Time
+	EndTime     time.Time
+	Status      string
+	Sequence    int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// showUID identifies a show in a venue feed, and userUID the same show in a user's feed
+func showUID(showID int) string {
+	return "show-" + strconv.Itoa(showID) + "@ticket-booking"
+}
+
+func userUID(showID int, userID string) string {
+	return "show-" + strconv.Itoa(showID) + "-" + userID + "@ticket-booking"
+}
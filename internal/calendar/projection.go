@@ -0,0 +1,195 @@
+// Calendar feed read model updater (CQRS projection)
+
+package calendar
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Projection keeps the Query DB calendar_entries table in step with shows and bookings.
+// Entries are never deleted: a show that goes away, or a booking that is cancelled, stays
+// in the feed as cancelled so subscribed calendars drop it too.
+type Projection struct {
+	QueryDB *pgxpool.Pool
+	Repo    *Repository
+}
+
+func NewProjection(queryDB *pgxpool.Pool, repo *Repository) *Projection {
+	return &Projection{QueryDB: queryDB, Repo: repo}
+}
+
+// Subscribe re-projects the show each event touches
+func (p *Projection) Subscribe(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(events.EventShowCreated, p.onShowEvent)
+	dispatcher.Subscribe(events.EventShowUpdated, p.onShowEvent)
+	dispatcher.Subscribe(events.EventShowDeleted, p.onShowEvent)
+	dispatcher.Subscribe(events.EventShowCancelled, p.onShowEvent)
+	dispatcher.Subscribe(events.EventMovieUpdated, p.onMovieEvent)
+	dispatcher.Subscribe(events.EventVenueUpdated, p.onVenueEvent)
+	dispatcher.Subscribe(events.EventTicketConfirmed, p.onSeatEvent)
+	dispatcher.Subscribe(events.EventTicketCancelled, p.onSeatEvent)
+}
+
+func (p *Projection) onShowEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(payload.ShowID)
+	if err != nil {
+		return nil
+	}
+	return p.Project(context.Background(), id)
+}
+
+func (p *Projection) onMovieEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(payload.MovieID)
+	if err != nil {
+		return nil
+	}
+	ctx := context.Background()
+	shows, err := p.Repo.ShowsOfMovie(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, showID := range shows {
+		if err := p.Project(ctx, showID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onVenueEvent re-projects a venue's shows, so its feed picks up the venue's new zone
+func (p *Projection) onVenueEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	shows, err := p.Repo.ShowsAtVenue(ctx, payload.Venue)
+	if err != nil {
+		return err
+	}
+	for _, showID := range shows {
+		if err := p.Project(ctx, showID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Projection) onSeatEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	id, err := p.Repo.ShowOfSeat(ctx, payload.SeatID)
+	if errors.Is(err, ErrShowNotFound) {
+		// The seat went with its show, whose deletion cancelled the entries
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return p.Project(ctx, id)
+}
+
+// Project writes a show's venue entry and the entries of everyone who booked it, and
+// cancels the entries of users whose bookings are gone. Once the show itself is gone
+// every entry of it is cancelled.
+func (p *Projection) Project(ctx context.Context, showID int) error {
+	show, err := p.Repo.ShowEntry(ctx, showID)
+	if errors.Is(err, ErrShowNotFound) {
+		return p.cancel(ctx, "show_id = $1", showID)
+	}
+	if err != nil {
+		return err
+	}
+	bookings, err := p.Repo.Bookings(ctx, showID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.upsert(ctx, show); err != nil {
+		return err
+	}
+	users := make([]string, 0, len(bookings))
+	for _, b := range bookings {
+		e := userEntry(show, b)
+		if err := p.upsert(ctx, &e); err != nil {
+			return err
+		}
+		users = append(users, b.UserID)
+	}
+	return p.cancel(ctx, "show_id = $1 AND user_id IS NOT NULL AND NOT (user_id::text = ANY($2))", showID, users)
+}
+
+// Rebuild projects every show and cancels the entries of shows that no longer exist
+func (p *Projection) Rebuild(ctx context.Context) error {
+	ids, err := p.Repo.ListShowIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := p.Project(ctx, id); err != nil {
+			return err
+		}
+	}
+	if err := p.cancel(ctx, "NOT (show_id = ANY($1))", ids); err != nil {
+		return err
+	}
+	log.Printf("📅 Projected calendar entries of %d shows into the query database", len(ids))
+	return nil
+}
+
+// cancel marks the matching entries that are still confirmed as cancelled
+func (p *Projection) cancel(ctx context.Context, where string, args ...any) error {
+	_, err := p.QueryDB.Exec(ctx, `
+		UPDATE calendar_entries SET status = '`+StatusCancelled+`', sequence = sequence + 1, updated_at = NOW()
+		WHERE status <> '`+StatusCancelled+`' AND `+where, args...)
+	return err
+}
+
+// upsert writes an entry. Moving the show in time or place, or bringing back a cancelled
+// entry, bumps the sequence; any change at all bumps the modification time.
+func (p *Projection) upsert(ctx context.Context, e *Entry) error {
+	var userID *string
+	if e.UserID != "" {
+		userID = &e.UserID
+	}
+	_, err := p.QueryDB.Exec(ctx, `
+		INSERT INTO calendar_entries (uid, show_id, user_id, venue, summary, description, time_zone, start_time, end_time, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (uid) DO UPDATE SET
+			sequence = calendar_entries.sequence + CASE
+				WHEN (calendar_entries.venue, calendar_entries.start_time, calendar_entries.end_time, calendar_entries.status)
+					IS DISTINCT FROM (EXCLUDED.venue, EXCLUDED.start_time, EXCLUDED.end_time, EXCLUDED.status)
+				THEN 1 ELSE 0 END,
+			updated_at = CASE
+				WHEN (calendar_entries.venue, calendar_entries.summary, calendar_entries.description,
+					calendar_entries.start_time, calendar_entries.end_time, calendar_entries.status)
+					IS DISTINCT FROM (EXCLUDED.venue, EXCLUDED.summary, EXCLUDED.description,
+					EXCLUDED.start_time, EXCLUDED.end_time, EXCLUDED.status)
+				THEN NOW() ELSE calendar_entries.updated_at END,
+			venue = EXCLUDED.venue,
+			summary = EXCLUDED.summary,
+			description = EXCLUDED.description,
+			time_zone = EXCLUDED.time_zone,
+			start_time = EXCLUDED.start_time,
+			end_time = EXCLUDED.end_time,
+			status = EXCLUDED.status
+	`, e.UID, e.ShowID, userID, e.Venue, e.Summary, e.Description, e.TimeZone, e.StartTime, e.EndTime, e.Status)
+	return err
+}
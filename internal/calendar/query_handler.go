@@ -0,0 +1,43 @@
+// Query handler for the iCalendar feeds (CQRS)
+
+package calendar
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetUserCalendar - Query handler for a user's bookings as a subscribable calendar
+func (h *QueryHandler) GetUserCalendar(c *gin.Context) {
+	feed, err := h.QueryService.GetUserFeed(c.Request.Context(), c.Param("user_id"))
+	if errors.Is(err, ErrInvalidUser) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	writeFeed(c, feed, err)
+}
+
+// GetVenueCalendar - Query handler for a venue's programme as a subscribable calendar
+func (h *QueryHandler) GetVenueCalendar(c *gin.Context) {
+	feed, err := h.QueryService.GetVenueFeed(c.Request.Context(), c.Param("venue"))
+	writeFeed(c, feed, err)
+}
+
+func writeFeed(c *gin.Context, feed *Feed, err error) {
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build calendar"})
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="calendar.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(feed.ICS()))
+}
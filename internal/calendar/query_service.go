@@ -0,0 +1,75 @@
+// Query service for the iCalendar feeds (CQRS)
+
+package calendar
+
+import (
+	"context"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/utils"
+	"github.com/hitorii/ticket-booking/internal/venue"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type QueryService struct {
+	DB       *pgxpool.Pool
+	Location *time.Location // the zone suggested for feeds that span venues
+}
+
+func NewQueryService(db *pgxpool.Pool, loc *time.Location) *QueryService {
+	return &QueryService{DB: db, Location: loc}
+}
+
+// GetUserFeed - Query to get the shows a user has confirmed seats for, including the
+// ones they or the venue have since cancelled
+func (s *QueryService) GetUserFeed(ctx context.Context, userID string) (*Feed, error) {
+	if !utils.IsValidUUID(userID) {
+		return nil, ErrInvalidUser
+	}
+	entries, err := s.entries(ctx, "user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	return &Feed{Name: "My bookings", Location: s.Location, Entries: entries}, nil
+}
+
+// GetVenueFeed - Query to get a venue's programme, suggested to be shown in its zone
+func (s *QueryService) GetVenueFeed(ctx context.Context, name string) (*Feed, error) {
+	entries, err := s.entries(ctx, "user_id IS NULL AND venue = $1", name)
+	if err != nil {
+		return nil, err
+	}
+	loc := s.Location
+	if len(entries) > 0 {
+		loc = entries[0].StartTime.Location()
+	}
+	return &Feed{Name: name, Location: loc, Entries: entries}, nil
+}
+
+// entries returns the matching entries that haven't long finished, earliest first
+func (s *QueryService) entries(ctx context.Context, where string, arg any) ([]Entry, error) {
+	rows, err := s.DB.Query(ctx, `
+		SELECT uid, show_id, COALESCE(user_id::text, ''), venue, summary, description, time_zone, start_time, end_time,
+			status, sequence, created_at, updated_at
+		FROM calendar_entries
+		WHERE `+where+` AND end_time > $2
+		ORDER BY start_time, uid
+	`, arg, time.Now().Add(-history))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		err := rows.Scan(&e.UID, &e.ShowID, &e.UserID, &e.Venue, &e.Summary, &e.Description, &e.TimeZone,
+			&e.StartTime, &e.EndTime, &e.Status, &e.Sequence, &e.CreatedAt, &e.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.StartTime, e.EndTime = venue.In(e.StartTime, e.TimeZone), venue.In(e.EndTime, e.TimeZone)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
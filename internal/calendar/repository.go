@@ -0,0 +1,140 @@
+// Repository that reads shows and bookings for the feeds from the Command DB
+
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hitorii/ticket-booking/internal/venue"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrShowNotFound is returned when the show behind an entry no longer exists
+var ErrShowNotFound = errors.New("show not found")
+
+// Repository reads from the Command DB, giving show times in the venue's zone from Zones
+type Repository struct {
+	DB    *pgxpool.Pool
+	Zones *venue.QueryService
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+// ShowEntry returns the venue programme entry of a show, cancelled if the show was
+func (r *Repository) ShowEntry(ctx context.Context, showID int) (*Entry, error) {
+	var e Entry
+	var duration int
+	var status string
+	err := r.DB.QueryRow(ctx, `
+		SELECT sh.id, sh.theater, m.name, m.duration, sh.start_time, sh.end_time, sh.status
+		FROM shows sh
+		JOIN movies m ON m.id = sh.movie_id
+		WHERE sh.id = $1
+	`, showID).Scan(&e.ShowID, &e.Venue, &e.Summary, &duration, &e.StartTime, &e.EndTime, &status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrShowNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	loc, err := r.Zones.Location(ctx, e.Venue)
+	if err != nil {
+		return nil, err
+	}
+	e.TimeZone = loc.String()
+	e.StartTime, e.EndTime = e.StartTime.In(loc), e.EndTime.In(loc)
+	e.UID = showUID(showID)
+	e.Description = fmt.Sprintf("Running time: %d min", duration)
+	e.Status = StatusConfirmed
+	if status == StatusCancelled {
+		e.Status = StatusCancelled
+	}
+	return &e, nil
+}
+
+// booking is the seats one user has confirmed for a show
+type booking struct {
+	UserID string
+	Seats  []string
+}
+
+// Bookings returns who has confirmed seats for a show
+func (r *Repository) Bookings(ctx context.Context, showID int) ([]booking, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT r.user_id::text, array_agg(s.label ORDER BY s.label)
+		FROM reservations r
+		JOIN seats s ON s.id = r.seat_id
+		WHERE r.show_id = $1 AND r.status = 'BOOKED'
+		GROUP BY r.user_id
+	`, showID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bookings []booking
+	for rows.Next() {
+		var b booking
+		if err := rows.Scan(&b.UserID, &b.Seats); err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+	return bookings, rows.Err()
+}
+
+// userEntry is a booked show as it appears in the user's own feed
+func userEntry(show *Entry, b booking) Entry {
+	e := *show
+	e.UID = userUID(show.ShowID, b.UserID)
+	e.UserID = b.UserID
+	label := "Seat"
+	if len(b.Seats) > 1 {
+		label = "Seats"
+	}
+	e.Description = label + ": " + strings.Join(b.Seats, ", ") + "\n" + show.Description
+	return e
+}
+
+// ShowOfSeat returns the show a seat belongs to
+func (r *Repository) ShowOfSeat(ctx context.Context, seatID string) (int, error) {
+	var showID int
+	err := r.DB.QueryRow(ctx, "SELECT show_id FROM seats WHERE id = $1", seatID).Scan(&showID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrShowNotFound
+	}
+	return showID, err
+}
+
+// ShowsOfMovie returns the IDs of a movie's shows
+func (r *Repository) ShowsOfMovie(ctx context.Context, movieID int) ([]int, error) {
+	rows, err := r.DB.Query(ctx, "SELECT id FROM shows WHERE movie_id = $1 ORDER BY id", movieID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// ShowsAtVenue returns the IDs of a venue's shows
+func (r *Repository) ShowsAtVenue(ctx context.Context, venue string) ([]int, error) {
+	rows, err := r.DB.Query(ctx, "SELECT id FROM shows WHERE theater = $1 ORDER BY id", venue)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// ListShowIDs returns the IDs of every show
+func (r *Repository) ListShowIDs(ctx context.Context) ([]int, error) {
+	rows, err := r.DB.Query(ctx, "SELECT id FROM shows ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
@@ -0,0 +1,225 @@
+package cancellation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/hitorii/ticket-booking/internal/payments"
+	"github.com/hitorii/ticket-booking/internal/show"
+)
+
+func intPtr(n int) *int { return &n }
+
+// TestValidate tests checking a cancel request against its show
+func TestValidate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	upcoming := show.Show{ID: 1, MovieID: 7, Status: show.StatusScheduled, StartTime: now.Add(2 * time.Hour), EndTime: now.Add(5 * time.Hour)}
+	running := upcoming
+	running.StartTime, running.EndTime = now.Add(-time.Hour), now.Add(time.Hour)
+	ended := upcoming
+	ended.StartTime, ended.EndTime = now.Add(-3*time.Hour), now.Add(-time.Minute)
+	cancelled := upcoming
+	cancelled.Status = show.StatusCancelled
+
+	tests := []struct {
+		name       string
+		show       show.Show
+		req        CancelShowRequest
+		wantReason string
+		wantErr    error
+	}{
+		{name: "valid", show: upcoming, req: CancelShowRequest{Reason: "  Projector fault "}, wantReason: "Projector fault"},
+		{name: "running show", show: running, req: CancelShowRequest{Reason: "Fire alarm"}, wantReason: "Fire alarm"},
+		{name: "with alternative", show: upcoming, req: CancelShowRequest{Reason: "Fault", AlternativeShowID: intPtr(2)}, wantReason: "Fault"},
+		{name: "missing reason", show: upcoming, req: CancelShowRequest{Reason: "   "}, wantErr: ErrInvalidCancellation},
+		{name: "reason too long", show: upcoming, req: CancelShowRequest{Reason: strings.Repeat("x", maxReasonLength+1)}, wantErr: ErrInvalidCancellation},
+		{name: "already cancelled", show: cancelled, req: CancelShowRequest{Reason: "Fault"}, wantErr: show.ErrShowCancelled},
+		{name: "already ended", show: ended, req: CancelShowRequest{Reason: "Fault"}, wantErr: ErrInvalidCancellation},
+		{name: "own alternative", show: upcoming, req: CancelShowRequest{Reason: "Fault", AlternativeShowID: intPtr(1)}, wantErr: ErrInvalidCancellation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, err := validate(&tt.show, tt.req, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("Expected reason %q, got %q", tt.wantReason, reason)
+			}
+		})
+	}
+}
+
+// TestCheckAlternative tests which shows can be offered instead of a cancelled one
+func TestCheckAlternative(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sh := &show.Show{ID: 1, MovieID: 7}
+
+	tests := []struct {
+		name    string
+		alt     show.Show
+		wantErr error
+	}{
+		{name: "later show of the same movie", alt: show.Show{ID: 2, MovieID: 7, Status: show.StatusScheduled, StartTime: now.Add(24 * time.Hour)}},
+		{name: "other movie", alt: show.Show{ID: 2, MovieID: 8, Status: show.StatusScheduled, StartTime: now.Add(24 * time.Hour)}, wantErr: ErrInvalidCancellation},
+		{name: "cancelled", alt: show.Show{ID: 2, MovieID: 7, Status: show.StatusCancelled, StartTime: now.Add(24 * time.Hour)}, wantErr: ErrInvalidCancellation},
+		{name: "already started", alt: show.Show{ID: 2, MovieID: 7, Status: show.StatusScheduled, StartTime: now}, wantErr: ErrInvalidCancellation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkAlternative(sh, &tt.alt, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestRefundOutcome tests summing up what refunding a booking did to its payments
+func TestRefundOutcome(t *testing.T) {
+	inr := func(amount int64) money.Money { return money.Money{Amount: amount, Currency: "INR"} }
+
+	tests := []struct {
+		name          string
+		changed       []payments.Payment
+		wantStatus    string
+		wantPaymentID string
+		wantRefunded  *money.Money
+	}{
+		{name: "nothing to give back"},
+		{
+			name:          "checkout called off",
+			changed:       []payments.Payment{{ID: "p1", Status: payments.StatusCancelled, Amount: inr(50000)}},
+			wantStatus:    payments.StatusCancelled,
+			wantPaymentID: "p1",
+		},
+		{
+			name:          "refunded",
+			changed:       []payments.Payment{{ID: "p1", Status: payments.StatusRefunded, Amount: inr(50000), RefundedAmount: inr(50000)}},
+			wantStatus:    payments.StatusRefunded,
+			wantPaymentID: "p1",
+			wantRefunded:  &money.Money{Amount: 50000, Currency: "INR"},
+		},
+		{
+			name: "refund wins over a void and amounts add up",
+			changed: []payments.Payment{
+				{ID: "p1", Status: payments.StatusCancelled, Amount: inr(20000)},
+				{ID: "p2", Status: payments.StatusRefunded, Amount: inr(50000), RefundedAmount: inr(50000)},
+				{ID: "p3", Status: payments.StatusRefunded, Amount: inr(30000), RefundedAmount: inr(30000)},
+			},
+			wantStatus:    payments.StatusRefunded,
+			wantPaymentID: "p2",
+			wantRefunded:  &money.Money{Amount: 80000, Currency: "INR"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, paymentID, refunded := refundOutcome(tt.changed)
+			if status != tt.wantStatus || paymentID != tt.wantPaymentID {
+				t.Errorf("Expected %s %q, got %s %q", tt.wantStatus, tt.wantPaymentID, status, paymentID)
+			}
+			if (refunded == nil) != (tt.wantRefunded == nil) || (refunded != nil && *refunded != *tt.wantRefunded) {
+				t.Errorf("Expected refunded %v, got %v", tt.wantRefunded, refunded)
+			}
+		})
+	}
+}
+
+// TestNotice tests the email telling a user their show was cancelled
+func TestNotice(t *testing.T) {
+	c := &Cancellation{
+		MovieName: "Dune",
+		Theater:   "Screen 1",
+		StartTime: time.Date(2024, 3, 1, 13, 30, 0, 0, time.UTC),
+		TimeZone:  "Asia/Kolkata",
+		Reason:    "Projector fault",
+	}
+	alt := time.Date(2024, 3, 2, 13, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		booking     Booking
+		refunded    *money.Money
+		alternative *time.Time
+		want        []string
+		notWant     []string
+	}{
+		{
+			name:     "refunded",
+			booking:  Booking{SeatLabel: "A1", PaymentStatus: payments.StatusRefunded},
+			refunded: &money.Money{Amount: 50000, Currency: "INR"},
+			want:     []string{"Dune at Screen 1 on Fri 1 Mar 2024, 19:00", "(Projector fault)", "seat A1", "has been refunded"},
+			notWant:  []string{"rebook"},
+		},
+		{
+			name:    "checkout called off",
+			booking: Booking{PaymentStatus: payments.StatusCancelled},
+			want:    []string{"not been charged"},
+			notWant: []string{"refunded"},
+		},
+		{
+			name:        "rebooking offer",
+			booking:     Booking{RebookToken: "tok"},
+			alternative: &alt,
+			want:        []string{"Sat 2 Mar 2024, 19:00 showing", "/cmd/rebook/tok"},
+		},
+		{
+			name:        "no offer without a token",
+			booking:     Booking{},
+			alternative: &alt,
+			notWant:     []string{"/cmd/rebook/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subject, body := notice(c, &tt.booking, tt.refunded, tt.alternative)
+			if subject != "Cancelled: Dune at Screen 1, Fri 1 Mar 2024, 19:00" {
+				t.Errorf("Unexpected subject %q", subject)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("Expected %q in %q", want, body)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(body, notWant) {
+					t.Errorf("Did not expect %q in %q", notWant, body)
+				}
+			}
+		})
+	}
+}
+
+// TestCancelShow_Validation tests what CancelShow rejects before touching the database
+func TestCancelShow_Validation(t *testing.T) {
+	s := NewCommandService(nil, nil, nil)
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{name: "non-numeric ID", id: "abc", wantErr: show.ErrShowNotFound},
+		{name: "zero ID", id: "0", wantErr: show.ErrShowNotFound},
+		{name: "not configured", id: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.CancelShow(context.Background(), "user-1", tt.id, CancelShowRequest{Reason: "Fault"})
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
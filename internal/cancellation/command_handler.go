@@ -0,0 +1,85 @@
+// Command handler for show cancellations and rebooking (CQRS)
+
+package cancellation
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/show"
+	"github.com/hitorii/ticket-booking/internal/venue"
+)
+
+type CommandHandler struct {
+	CommandService *CommandService
+}
+
+func NewCommandHandler(cs *CommandService) *CommandHandler {
+	return &CommandHandler{CommandService: cs}
+}
+
+// CancelShow - Command handler for cancelling a show. It follows middleware.Authenticate;
+// admins and the managers of the show's venue may cancel it.
+func (h *CommandHandler) CancelShow(c *gin.Context) {
+	var req CancelShowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	cancellation, err := h.CommandService.CancelShow(c.Request.Context(), c.GetString("user_id"), c.Param("id"), req)
+	if err != nil {
+		writeError(c, "Failed to cancel show: ", err)
+		return
+	}
+	c.JSON(http.StatusAccepted, cancellation)
+}
+
+// RetryCancellation - Command handler for trying a cancellation's failed refunds again
+func (h *CommandHandler) RetryCancellation(c *gin.Context) {
+	cancellation, err := h.CommandService.Retry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, "Failed to retry cancellation: ", err)
+		return
+	}
+	c.JSON(http.StatusAccepted, cancellation)
+}
+
+// Rebook - Command handler for the one-click link of a rebooking offer
+func (h *CommandHandler) Rebook(c *gin.Context) {
+	rebooking, err := h.CommandService.Rebook(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		writeError(c, "Failed to rebook: ", err)
+		return
+	}
+	c.JSON(http.StatusCreated, rebooking)
+}
+
+// writeError maps cancellation errors to status codes
+func writeError(c *gin.Context, prefix string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidCancellation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, venue.ErrUnauthenticated):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, venue.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, show.ErrShowNotFound), errors.Is(err, ErrCancellationNotFound), errors.Is(err, ErrOfferNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, show.ErrShowCancelled), errors.Is(err, ErrAlreadyRebooked), errors.Is(err, ErrNoSeatsAvailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrOfferClosed):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": prefix + err.Error()})
+	}
+}
+
+// CancelShowRequest - Request model for cancelling a show. The reason is passed on to
+// everyone who had booked; an alternative show of the same movie is offered to them for
+// one-click rebooking.
+type CancelShowRequest struct {
+	Reason            string `json:"reason"`
+	AlternativeShowID *int   `json:"alternative_show_id"`
+}
@@ -0,0 +1,335 @@
+// Command service for show cancellations (CQRS)
+
+package cancellation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/booking"
+	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/hitorii/ticket-booking/internal/notification"
+	"github.com/hitorii/ticket-booking/internal/payments"
+	"github.com/hitorii/ticket-booking/internal/show"
+	"github.com/hitorii/ticket-booking/internal/venue"
+)
+
+// batchSize is how many bookings the worker loads at a time
+const batchSize = 100
+
+// seatAttempts is how many free seats a rebooking tries before giving up, in case others
+// take them first
+const seatAttempts = 5
+
+// Refunder gives back what was paid for a booking that was taken away
+type Refunder interface {
+	RefundBooking(ctx context.Context, bookingID, reason string) ([]payments.Payment, error)
+}
+
+// Reserver holds a seat for a user
+type Reserver interface {
+	ReserveTicket(ctx context.Context, userID, seatID string) error
+}
+
+// CommandService cancels shows and works through the refunds, notifications and rebooking
+// offers that follow. Cancelling is done by the API; the rest by the worker.
+type CommandService struct {
+	Repo       *Repository
+	Shows      *show.Repository
+	Venues     *venue.CommandService // only admins and the show venue's managers may cancel
+	Payments   Refunder
+	Bookings   Reserver
+	Dispatcher *events.Dispatcher
+}
+
+func NewCommandService(repo *Repository, shows *show.Repository, dispatcher *events.Dispatcher) *CommandService {
+	return &CommandService{Repo: repo, Shows: shows, Dispatcher: dispatcher}
+}
+
+// parseShowID turns a path ID into a show ID
+func parseShowID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, show.ErrShowNotFound
+	}
+	return n, nil
+}
+
+// validate checks a cancel request against the show it cancels
+func validate(sh *show.Show, req CancelShowRequest, now time.Time) (string, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return "", fmt.Errorf("%w: a reason is required", ErrInvalidCancellation)
+	}
+	if len(reason) > maxReasonLength {
+		return "", fmt.Errorf("%w: the reason is longer than %d characters", ErrInvalidCancellation, maxReasonLength)
+	}
+	if sh.Status == show.StatusCancelled {
+		return "", show.ErrShowCancelled
+	}
+	if !sh.EndTime.After(now) {
+		return "", fmt.Errorf("%w: the show has already ended", ErrInvalidCancellation)
+	}
+	if req.AlternativeShowID != nil && *req.AlternativeShowID == sh.ID {
+		return "", fmt.Errorf("%w: a show can't be its own alternative", ErrInvalidCancellation)
+	}
+	return reason, nil
+}
+
+// checkAlternative checks that a show can be offered instead of the cancelled one
+func checkAlternative(sh, alt *show.Show, now time.Time) error {
+	switch {
+	case alt.Status == show.StatusCancelled:
+		return fmt.Errorf("%w: alternative show %d is cancelled too", ErrInvalidCancellation, alt.ID)
+	case alt.MovieID != sh.MovieID:
+		return fmt.Errorf("%w: alternative show %d is of another movie", ErrInvalidCancellation, alt.ID)
+	case !alt.StartTime.After(now):
+		return fmt.Errorf("%w: alternative show %d has already started", ErrInvalidCancellation, alt.ID)
+	}
+	return nil
+}
+
+// CancelShow - Command to cancel a show on behalf of userID. The show and its reservations
+// are marked cancelled straight away; refunds, notifications and rebooking offers are
+// queued for the worker, and the returned cancellation tracks them.
+func (s *CommandService) CancelShow(ctx context.Context, userID, id string, req CancelShowRequest) (*Cancellation, error) {
+	showID, err := parseShowID(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Repo == nil || s.Shows == nil || s.Venues == nil {
+		return nil, errors.New("show cancellations are not configured")
+	}
+
+	sh, err := s.Shows.GetByID(ctx, showID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Venues.Authorize(ctx, userID, sh.Theater); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	reason, err := validate(sh, req, now)
+	if err != nil {
+		return nil, err
+	}
+	if req.AlternativeShowID != nil {
+		alt, err := s.Shows.GetByID(ctx, *req.AlternativeShowID)
+		if errors.Is(err, show.ErrShowNotFound) {
+			return nil, fmt.Errorf("%w: alternative show %d not found", ErrInvalidCancellation, *req.AlternativeShowID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := checkAlternative(sh, alt, now); err != nil {
+			return nil, err
+		}
+	}
+	c := &Cancellation{
+		ShowID:            sh.ID,
+		MovieID:           sh.MovieID,
+		Theater:           sh.Theater,
+		StartTime:         sh.StartTime,
+		TimeZone:          sh.TimeZone,
+		Reason:            reason,
+		AlternativeShowID: req.AlternativeShowID,
+		CancelledBy:       userID,
+	}
+	if err := s.Repo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+
+	// Emit event for event-driven flow
+	if s.Dispatcher != nil {
+		payload := events.EventPayload{
+			UserID:    userID,
+			ShowID:    id,
+			MovieID:   strconv.Itoa(sh.MovieID),
+			Venue:     sh.Theater,
+			StartTime: sh.StartTime.Format(time.RFC3339),
+			EndTime:   sh.EndTime.Format(time.RFC3339),
+			Reason:    reason,
+			Status:    show.StatusCancelled,
+		}
+		_ = s.Dispatcher.Publish(ctx, events.EventShowCancelled, id, payload)
+	}
+	log.Printf("🚫 Show %d cancelled (%s); %d bookings queued for refunds", sh.ID, reason, c.Total)
+	return c, nil
+}
+
+// Retry - Command to queue a cancellation's failed refunds to be tried again. It returns
+// the cancellation with its progress.
+func (s *CommandService) Retry(ctx context.Context, id string) (*Cancellation, error) {
+	if _, err := s.Repo.Retry(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.Repo.Get(ctx, id)
+}
+
+// Run deals with each pending booking of a cancellation: it refunds the booking, offers
+// the alternative show while that can still be booked, tells the user and publishes the
+// ticket's cancellation. A booking whose refund fails is recorded and the rest carry on.
+func (s *CommandService) Run(ctx context.Context, c *Cancellation) error {
+	alternative := s.alternative(ctx, c)
+	for {
+		batch, err := s.Repo.PendingBookings(ctx, c.ID, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for i := range batch {
+			b := &batch[i]
+			s.settle(ctx, c, b, alternative)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := s.Repo.SaveBooking(ctx, b); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.Status = StatusCompleted
+	return s.Repo.Finish(ctx, c)
+}
+
+// alternative returns when the alternative show starts, or nil if there is none or it
+// can't be booked any more
+func (s *CommandService) alternative(ctx context.Context, c *Cancellation) *time.Time {
+	if c.AlternativeShowID == nil {
+		return nil
+	}
+	alt, err := s.Shows.GetByID(ctx, *c.AlternativeShowID)
+	if err != nil {
+		log.Printf("⚠️ Not offering show %d instead of show %d: %v", *c.AlternativeShowID, c.ShowID, err)
+		return nil
+	}
+	if alt.Status == show.StatusCancelled || !alt.StartTime.After(time.Now()) {
+		return nil
+	}
+	return &alt.StartTime
+}
+
+// settle refunds, notifies and publishes one booking of a cancellation
+func (s *CommandService) settle(ctx context.Context, c *Cancellation, b *Booking, alternative *time.Time) {
+	changed, err := s.Payments.RefundBooking(ctx, b.BookingID, "show cancelled: "+c.Reason)
+	if err != nil {
+		// Left for a retry, which tells the user once the money is back
+		b.Status, b.Error = BookingFailed, "refund failed: "+err.Error()
+		log.Printf("⚠️ Failed to refund booking %s of cancelled show %d: %v", b.BookingID, c.ShowID, err)
+		return
+	}
+	status, paymentID, refunded := refundOutcome(changed)
+	if status != "" {
+		b.PaymentStatus, b.PaymentID = status, paymentID
+	}
+	if alternative != nil && b.RebookToken == "" {
+		b.RebookToken = uuid.New().String()
+	}
+	b.Status, b.Error = BookingDone, ""
+
+	subject, body := notice(c, b, refunded, alternative)
+	url := ""
+	if alternative != nil {
+		url = rebookURL(b.RebookToken)
+	}
+	if err := notification.EnqueueShowCancelled(b.UserID, b.BookingID, subject, body, url); err != nil {
+		log.Printf("⚠️ Failed to enqueue cancellation notice for booking %s: %v", b.BookingID, err)
+	}
+
+	// Emit event for event-driven flow
+	if s.Dispatcher != nil {
+		payload := events.EventPayload{
+			UserID:    b.UserID,
+			BookingID: b.BookingID,
+			SeatID:    b.SeatID,
+			ShowID:    strconv.Itoa(c.ShowID),
+			Reason:    c.Reason,
+			Status:    "CANCELLED",
+		}
+		_ = s.Dispatcher.Publish(ctx, events.EventTicketCancelled, b.SeatID, payload)
+	}
+}
+
+// Rebook - Command to take up a rebooking offer: a seat in the alternative show, of the
+// same tier as the cancelled one where there is one left, is held for the user. The hold
+// is paid for like any other; an offer can be taken up once.
+func (s *CommandService) Rebook(ctx context.Context, token string) (*Rebooking, error) {
+	if s.Bookings == nil {
+		return nil, errors.New("rebooking is not configured")
+	}
+	b, c, err := s.Repo.ClaimOffer(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.rebook(ctx, b, c)
+	if err != nil {
+		if releaseErr := s.Repo.ReleaseOffer(ctx, token); releaseErr != nil {
+			log.Printf("⚠️ Failed to reopen rebooking offer for booking %s: %v", b.BookingID, releaseErr)
+		}
+		return nil, err
+	}
+	if err := s.Repo.CompleteOffer(ctx, token, r.SeatID); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *CommandService) rebook(ctx context.Context, b *Booking, c *Cancellation) (*Rebooking, error) {
+	if c.AlternativeShowID == nil {
+		return nil, ErrOfferClosed
+	}
+	alt, err := s.Shows.GetByID(ctx, *c.AlternativeShowID)
+	if errors.Is(err, show.ErrShowNotFound) {
+		return nil, ErrOfferClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	if alt.Status == show.StatusCancelled || !alt.StartTime.After(time.Now()) {
+		return nil, ErrOfferClosed
+	}
+
+	seats, err := s.Repo.FreeSeats(ctx, alt.ID, b.Tier, seatAttempts)
+	if err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for _, seat := range seats {
+		err := s.Bookings.ReserveTicket(ctx, b.UserID, seat.ID)
+		if errors.Is(err, booking.ErrShowCancelled) {
+			return nil, ErrOfferClosed
+		}
+		if err != nil {
+			// Most likely someone else took the seat first
+			lastErr = err
+			continue
+		}
+		bookingID, err := s.Repo.HeldBooking(ctx, b.UserID, seat.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &Rebooking{
+			BookingID: bookingID,
+			ShowID:    alt.ID,
+			SeatID:    seat.ID,
+			SeatLabel: seat.Label,
+			Tier:      seat.Tier,
+			StartTime: alt.StartTime,
+			Status:    "HELD",
+		}, nil
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoSeatsAvailable, lastErr)
+	}
+	return nil, ErrNoSeatsAvailable
+}
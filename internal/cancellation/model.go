@@ -0,0 +1,177 @@
+// Models for show cancellations, their refunds and rebooking offers
+
+package cancellation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/hitorii/ticket-booking/internal/payments"
+	"github.com/hitorii/ticket-booking/internal/venue"
+)
+
+// Cancellation statuses. A COMPLETED cancellation may still have bookings whose refund
+// failed; FAILED means the job itself couldn't run to the end.
+const (
+	StatusPending   = "PENDING"
+	StatusRunning   = "RUNNING"
+	StatusCompleted = "COMPLETED"
+	StatusFailed    = "FAILED"
+)
+
+// What became of each booking of a cancelled show
+const (
+	BookingPending = "PENDING"
+	BookingDone    = "DONE"
+	BookingFailed  = "FAILED"
+)
+
+// maxReasonLength caps the reason given to everyone who had booked
+const maxReasonLength = 500
+
+var (
+	ErrInvalidCancellation  = errors.New("invalid cancellation")
+	ErrCancellationNotFound = errors.New("cancellation not found")
+
+	ErrOfferNotFound    = errors.New("rebooking offer not found")
+	ErrOfferClosed      = errors.New("rebooking offer is no longer open")
+	ErrAlreadyRebooked  = errors.New("booking was already rebooked")
+	ErrNoSeatsAvailable = errors.New("no seats left in the alternative show")
+)
+
+// Cancellation is a cancelled show and how far refunding and notifying the people who had
+// booked it has got. The show's details are copied, so the record outlives the show.
+type Cancellation struct {
+	ID                string    `json:"id"`
+	ShowID            int       `json:"show_id"`
+	MovieID           int       `json:"movie_id"`
+	MovieName         string    `json:"movie_name"`
+	Theater           string    `json:"theater"`
+	StartTime         time.Time `json:"start_time"`
+	TimeZone          string    `json:"time_zone"`
+	Reason            string    `json:"reason"`
+	AlternativeShowID *int      `json:"alternative_show_id,omitempty"`
+	CancelledBy       string    `json:"cancelled_by,omitempty"`
+	Status            string    `json:"status"`
+	Progress
+	Errors     []BookingError `json:"errors"`
+	Error      string         `json:"error,omitempty"` // why a FAILED cancellation stopped
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+}
+
+// Progress counts the bookings of a cancellation. Refunded and Voided count bookings whose
+// payment was refunded, or whose checkout was called off before anything was charged.
+type Progress struct {
+	Total     int `json:"total_bookings"`
+	Processed int `json:"processed"`
+	Refunded  int `json:"refunded"`
+	Voided    int `json:"voided"`
+	Failed    int `json:"failed"`
+	Rebooked  int `json:"rebooked"`
+}
+
+// Booking is one reservation a cancellation took away. Its details are copied from the
+// reservation, which goes if the show is deleted later on.
+type Booking struct {
+	CancellationID string
+	BookingID      string
+	UserID         string
+	SeatID         string
+	SeatLabel      string
+	Tier           string
+	BookingStatus  string // HELD or BOOKED, before the show was cancelled
+	Status         string
+	PaymentID      string
+	PaymentStatus  string // what refunding left the booking's payment in; empty without one
+	Error          string
+	RebookToken    string // set once the user has been offered the alternative show
+}
+
+// BookingError is a booking whose refund failed
+type BookingError struct {
+	BookingID string `json:"booking_id"`
+	UserID    string `json:"user_id"`
+	Error     string `json:"error"`
+}
+
+// Rebooking is the seat held for a user in the alternative show. It is paid for like any
+// other hold.
+type Rebooking struct {
+	BookingID string    `json:"booking_id"`
+	ShowID    int       `json:"show_id"`
+	SeatID    string    `json:"seat_id"`
+	SeatLabel string    `json:"seat_label"`
+	Tier      string    `json:"tier"`
+	StartTime time.Time `json:"start_time"`
+	Status    string    `json:"status"`
+}
+
+// Seat is a free seat of a show
+type Seat struct {
+	ID    string
+	Label string
+	Tier  string
+}
+
+// refundOutcome sums up what refunding a booking did to its payments: REFUNDED if any
+// money went back, CANCELLED if only a checkout was called off, and empty when there was
+// nothing to give back. It returns the payment that was refunded, or else voided, and how
+// much has been refunded in all.
+func refundOutcome(changed []payments.Payment) (string, string, *money.Money) {
+	status, paymentID := "", ""
+	var refunded *money.Money
+	for _, p := range changed {
+		if payments.IsSettled(p.Status) {
+			if refunded == nil {
+				refunded = &money.Money{Currency: p.RefundedAmount.Currency}
+				status, paymentID = p.Status, p.ID
+			}
+			if p.RefundedAmount.Currency == refunded.Currency {
+				refunded.Amount += p.RefundedAmount.Amount
+			}
+		} else if status == "" {
+			status, paymentID = p.Status, p.ID
+		}
+	}
+	return status, paymentID, refunded
+}
+
+// notice is the email telling a user their show was cancelled. alternative is when the show
+// offered instead starts, if the user can still rebook into it.
+func notice(c *Cancellation, b *Booking, refunded *money.Money, alternative *time.Time) (string, string) {
+	start := venue.In(c.StartTime, c.TimeZone)
+	when := start.Format("Mon 2 Jan 2006, 15:04")
+	subject := fmt.Sprintf("Cancelled: %s at %s, %s", c.MovieName, c.Theater, when)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "We're sorry: %s at %s on %s has been cancelled", c.MovieName, c.Theater, when)
+	if c.Reason != "" {
+		fmt.Fprintf(&body, " (%s)", c.Reason)
+	}
+	body.WriteString(".")
+	if b.SeatLabel != "" {
+		fmt.Fprintf(&body, " Your booking for seat %s no longer stands.", b.SeatLabel)
+	}
+	switch {
+	case refunded != nil:
+		fmt.Fprintf(&body, "\n\nThe %s you paid has been refunded.", refunded)
+	case b.PaymentStatus != "":
+		body.WriteString("\n\nYour checkout was called off and you have not been charged.")
+	}
+	if alternative != nil && b.RebookToken != "" {
+		alt := venue.In(*alternative, c.TimeZone)
+		fmt.Fprintf(&body, "\n\nYou can take a seat at the %s showing instead with one click: %s",
+			alt.Format("Mon 2 Jan 2006, 15:04"), rebookURL(b.RebookToken))
+	}
+	return subject, body.String()
+}
+
+// rebookURL is the one-click link of a rebooking offer
+func rebookURL(token string) string {
+	return "/cmd/rebook/" + token
+}
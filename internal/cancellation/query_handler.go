@@ -0,0 +1,42 @@
+// Query handler for show cancellations (CQRS)
+
+package cancellation
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetCancellation - Query handler for a cancellation's progress and failed refunds
+func (h *QueryHandler) GetCancellation(c *gin.Context) {
+	h.respond(c, h.QueryService.GetCancellation, c.Param("id"))
+}
+
+// GetShowCancellation - Query handler for the cancellation of a show
+func (h *QueryHandler) GetShowCancellation(c *gin.Context) {
+	h.respond(c, h.QueryService.GetShowCancellation, c.Param("id"))
+}
+
+func (h *QueryHandler) respond(c *gin.Context, get func(context.Context, string) (*Cancellation, error), id string) {
+	cancellation, err := get(c.Request.Context(), id)
+	if errors.Is(err, ErrCancellationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cancellation not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cancellation"})
+		return
+	}
+	c.JSON(http.StatusOK, cancellation)
+}
@@ -0,0 +1,31 @@
+// Query service for show cancellations (CQRS)
+
+package cancellation
+
+import (
+	"context"
+	"strconv"
+)
+
+// QueryService reads cancellations from the Command DB, where the worker records progress
+type QueryService struct {
+	Repo *Repository
+}
+
+func NewQueryService(repo *Repository) *QueryService {
+	return &QueryService{Repo: repo}
+}
+
+// GetCancellation - Query to get a cancellation and how far its refunds have got
+func (s *QueryService) GetCancellation(ctx context.Context, id string) (*Cancellation, error) {
+	return s.Repo.Get(ctx, id)
+}
+
+// GetShowCancellation - Query to get the cancellation of a show
+func (s *QueryService) GetShowCancellation(ctx context.Context, showID string) (*Cancellation, error) {
+	id, err := strconv.Atoi(showID)
+	if err != nil || id <= 0 {
+		return nil, ErrCancellationNotFound
+	}
+	return s.Repo.GetByShow(ctx, id)
+}
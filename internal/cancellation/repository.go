@@ -0,0 +1,353 @@
+// Repository for show cancellations and the bookings they took away
+
+package cancellation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/show"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+const cancellationColumns = `c.id, c.show_id, c.movie_id, c.movie_name, c.theater, c.start_time, c.time_zone, c.reason,
+	c.alternative_show_id, COALESCE(c.cancelled_by::text, ''), c.status, c.total_bookings, COALESCE(c.error, ''),
+	c.created_at, c.started_at, c.finished_at`
+
+func scanCancellation(row pgx.Row, extra ...any) (*Cancellation, error) {
+	var c Cancellation
+	dest := []any{&c.ID, &c.ShowID, &c.MovieID, &c.MovieName, &c.Theater, &c.StartTime, &c.TimeZone, &c.Reason,
+		&c.AlternativeShowID, &c.CancelledBy, &c.Status, &c.Total, &c.Error, &c.CreatedAt, &c.StartedAt,
+		&c.FinishedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCancellationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Create cancels a show and takes away its held and booked seats in one go, queueing the
+// cancellation for the worker to refund and notify everyone who had them
+func (r *Repository) Create(ctx context.Context, c *Cancellation) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Reservations lock the show while they check it, so none slips in once it's cancelled
+	var status string
+	err = tx.QueryRow(ctx, "SELECT status FROM shows WHERE id = $1 FOR UPDATE", c.ShowID).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return show.ErrShowNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status == show.StatusCancelled {
+		return show.ErrShowCancelled
+	}
+	if _, err := tx.Exec(ctx, "UPDATE shows SET status = $2 WHERE id = $1", c.ShowID, show.StatusCancelled); err != nil {
+		return err
+	}
+
+	c.ID = uuid.New().String()
+	c.Status = StatusPending
+	var cancelledBy *string
+	if c.CancelledBy != "" {
+		cancelledBy = &c.CancelledBy
+	}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO show_cancellations (id, show_id, movie_id, movie_name, theater, start_time, time_zone, reason,
+			alternative_show_id, cancelled_by, status)
+		VALUES ($1, $2, $3, COALESCE((SELECT name FROM movies WHERE id = $3), ''), $4, $5, $6, $7, $8, $9, $10)
+		RETURNING movie_name, created_at
+	`, c.ID, c.ShowID, c.MovieID, c.Theater, c.StartTime, c.TimeZone, c.Reason,
+		c.AlternativeShowID, cancelledBy, c.Status).Scan(&c.MovieName, &c.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(ctx, `
+		WITH taken AS (
+			SELECT r.id, r.user_id, r.seat_id, r.status, s.label, s.tier FROM reservations r
+			JOIN seats s ON s.id = r.seat_id
+			WHERE s.show_id = $2 AND r.status IN ('HELD', 'BOOKED')
+			FOR UPDATE OF r
+		), cancelled AS (
+			UPDATE reservations r SET status = 'CANCELLED', updated_at = NOW() FROM taken WHERE r.id = taken.id
+		)
+		INSERT INTO show_cancellation_bookings (cancellation_id, booking_id, user_id, seat_id, seat_label, tier, booking_status)
+		SELECT $1, id, user_id, seat_id, label, tier, status FROM taken
+	`, c.ID, c.ShowID)
+	if err != nil {
+		return err
+	}
+	c.Total = int(res.RowsAffected())
+	c.Errors = []BookingError{}
+	if _, err := tx.Exec(ctx, "UPDATE show_cancellations SET total_bookings = $2 WHERE id = $1", c.ID, c.Total); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// progress fills in how many of a cancellation's bookings have been dealt with, and which
+// ones failed
+func (r *Repository) progress(ctx context.Context, c *Cancellation) error {
+	err := r.DB.QueryRow(ctx, `
+		SELECT
+			COUNT(*) FILTER (WHERE status <> $2),
+			COUNT(*) FILTER (WHERE payment_status IN ('REFUNDED', 'PARTIALLY_REFUNDED')),
+			COUNT(*) FILTER (WHERE payment_status = 'CANCELLED'),
+			COUNT(*) FILTER (WHERE status = $3),
+			COUNT(*) FILTER (WHERE rebooked_seat_id IS NOT NULL)
+		FROM show_cancellation_bookings
+		WHERE cancellation_id = $1
+	`, c.ID, BookingPending, BookingFailed).Scan(&c.Processed, &c.Refunded, &c.Voided, &c.Failed, &c.Rebooked)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT booking_id::text, user_id::text, COALESCE(error, '') FROM show_cancellation_bookings
+		WHERE cancellation_id = $1 AND status = $2
+		ORDER BY booking_id
+	`, c.ID, BookingFailed)
+	if err != nil {
+		return err
+	}
+	c.Errors, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (BookingError, error) {
+		var e BookingError
+		err := row.Scan(&e.BookingID, &e.UserID, &e.Error)
+		return e, err
+	})
+	if err == nil && c.Errors == nil {
+		c.Errors = []BookingError{}
+	}
+	return err
+}
+
+// Get returns a cancellation and its progress
+func (r *Repository) Get(ctx context.Context, id string) (*Cancellation, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrCancellationNotFound
+	}
+	c, err := scanCancellation(r.DB.QueryRow(ctx, "SELECT "+cancellationColumns+" FROM show_cancellations c WHERE c.id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	return c, r.progress(ctx, c)
+}
+
+// GetByShow returns the cancellation of a show and its progress
+func (r *Repository) GetByShow(ctx context.Context, showID int) (*Cancellation, error) {
+	c, err := scanCancellation(r.DB.QueryRow(ctx, "SELECT "+cancellationColumns+" FROM show_cancellations c WHERE c.show_id = $1", showID))
+	if err != nil {
+		return nil, err
+	}
+	return c, r.progress(ctx, c)
+}
+
+// ClaimNext marks the oldest pending cancellation as running and returns it, or nil when
+// there is nothing to do. A running one that hasn't made progress since stale is claimed
+// again and carries on with the bookings still pending.
+func (r *Repository) ClaimNext(ctx context.Context, stale time.Time) (*Cancellation, error) {
+	c, err := scanCancellation(r.DB.QueryRow(ctx, `
+		UPDATE show_cancellations c SET status = $1, started_at = COALESCE(started_at, NOW()), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM show_cancellations
+			WHERE status = $2 OR status = $1 AND updated_at < $3
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+cancellationColumns,
+		StatusRunning, StatusPending, stale))
+	if errors.Is(err, ErrCancellationNotFound) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// PendingBookings returns up to limit bookings of a cancellation still to be dealt with
+func (r *Repository) PendingBookings(ctx context.Context, cancellationID string, limit int) ([]Booking, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT cancellation_id::text, booking_id::text, user_id::text, seat_id::text, seat_label, tier, booking_status,
+			status, COALESCE(payment_id::text, ''), payment_status, COALESCE(error, ''), COALESCE(rebook_token::text, '')
+		FROM show_cancellation_bookings
+		WHERE cancellation_id = $1 AND status = $2
+		ORDER BY booking_id
+		LIMIT $3
+	`, cancellationID, BookingPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Booking, error) {
+		var b Booking
+		err := row.Scan(&b.CancellationID, &b.BookingID, &b.UserID, &b.SeatID, &b.SeatLabel, &b.Tier, &b.BookingStatus,
+			&b.Status, &b.PaymentID, &b.PaymentStatus, &b.Error, &b.RebookToken)
+		return b, err
+	})
+}
+
+// SaveBooking records what became of a booking, and that its cancellation is still making
+// progress
+func (r *Repository) SaveBooking(ctx context.Context, b *Booking) error {
+	_, err := r.DB.Exec(ctx, `
+		WITH touched AS (
+			UPDATE show_cancellations SET updated_at = NOW() WHERE id = $1
+		)
+		UPDATE show_cancellation_bookings SET status = $3, payment_id = NULLIF($4, '')::uuid, payment_status = $5,
+			error = NULLIF($6, ''), rebook_token = NULLIF($7, '')::uuid, processed_at = NOW()
+		WHERE cancellation_id = $1 AND booking_id = $2
+	`, b.CancellationID, b.BookingID, b.Status, b.PaymentID, b.PaymentStatus, b.Error, b.RebookToken)
+	return err
+}
+
+// Finish records that a cancellation ran to the end, or why it couldn't
+func (r *Repository) Finish(ctx context.Context, c *Cancellation) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE show_cancellations SET status = $2, error = NULLIF($3, ''), finished_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, c.ID, c.Status, c.Error)
+	return err
+}
+
+// Retry queues a cancellation's failed bookings to be dealt with again
+func (r *Repository) Retry(ctx context.Context, id string) (int, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return 0, ErrCancellationNotFound
+	}
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, "SELECT status FROM show_cancellations WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrCancellationNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec(ctx, `
+		UPDATE show_cancellation_bookings SET status = $2, error = NULL
+		WHERE cancellation_id = $1 AND status = $3
+	`, id, BookingPending, BookingFailed)
+	if err != nil {
+		return 0, err
+	}
+	// A cancellation that stopped part way through is picked up again even with no failed bookings
+	if res.RowsAffected() > 0 || status == StatusFailed {
+		_, err = tx.Exec(ctx, `
+			UPDATE show_cancellations SET status = $2, error = NULL, finished_at = NULL, updated_at = NOW()
+			WHERE id = $1 AND status <> $3
+		`, id, StatusPending, StatusRunning)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return int(res.RowsAffected()), tx.Commit(ctx)
+}
+
+// ClaimOffer takes up a rebooking offer so it can't be used twice at once, returning the
+// booking it was made for and its cancellation. ReleaseOffer gives it back if no seat
+// could be held.
+func (r *Repository) ClaimOffer(ctx context.Context, token string) (*Booking, *Cancellation, error) {
+	if _, err := uuid.Parse(token); err != nil {
+		return nil, nil, ErrOfferNotFound
+	}
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var b Booking
+	var rebooked *time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT cancellation_id::text, booking_id::text, user_id::text, seat_id::text, seat_label, tier, rebooked_at
+		FROM show_cancellation_bookings
+		WHERE rebook_token = $1
+		FOR UPDATE
+	`, token).Scan(&b.CancellationID, &b.BookingID, &b.UserID, &b.SeatID, &b.SeatLabel, &b.Tier, &rebooked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrOfferNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if rebooked != nil {
+		return nil, nil, ErrAlreadyRebooked
+	}
+	b.RebookToken = token
+
+	c, err := scanCancellation(tx.QueryRow(ctx, "SELECT "+cancellationColumns+" FROM show_cancellations c WHERE c.id = $1", b.CancellationID))
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = tx.Exec(ctx, "UPDATE show_cancellation_bookings SET rebooked_at = NOW() WHERE rebook_token = $1", token)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &b, c, tx.Commit(ctx)
+}
+
+// ReleaseOffer opens a claimed offer up again
+func (r *Repository) ReleaseOffer(ctx context.Context, token string) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE show_cancellation_bookings SET rebooked_at = NULL
+		WHERE rebook_token = $1 AND rebooked_seat_id IS NULL
+	`, token)
+	return err
+}
+
+// CompleteOffer records the seat a claimed offer was used for
+func (r *Repository) CompleteOffer(ctx context.Context, token, seatID string) error {
+	_, err := r.DB.Exec(ctx, "UPDATE show_cancellation_bookings SET rebooked_seat_id = $2 WHERE rebook_token = $1", token, seatID)
+	return err
+}
+
+// FreeSeats returns up to limit seats of a show without a reservation, those of the given
+// tier first
+func (r *Repository) FreeSeats(ctx context.Context, showID int, tier string, limit int) ([]Seat, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT s.id::text, s.label, s.tier FROM seats s
+		WHERE s.show_id = $1 AND NOT EXISTS (SELECT 1 FROM reservations r WHERE r.seat_id = s.id)
+		ORDER BY s.tier <> $2, s.label
+		LIMIT $3
+	`, showID, tier, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Seat, error) {
+		var s Seat
+		err := row.Scan(&s.ID, &s.Label, &s.Tier)
+		return s, err
+	})
+}
+
+// HeldBooking returns the ID of the reservation holding a user's seat
+func (r *Repository) HeldBooking(ctx context.Context, userID, seatID string) (string, error) {
+	var id string
+	err := r.DB.QueryRow(ctx,
+		"SELECT id::text FROM reservations WHERE seat_id = $1 AND user_id = $2 AND status = 'HELD'",
+		seatID, userID).Scan(&id)
+	return id, err
+}
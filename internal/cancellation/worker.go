@@ -0,0 +1,70 @@
+// Background worker that refunds and notifies the bookings of cancelled shows
+
+package cancellation
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// staleAfter is how long a running cancellation may go without progress before another
+// worker takes it over
+const staleAfter = 10 * time.Minute
+
+// Worker works through queued cancellations one at a time
+type Worker struct {
+	Service *CommandService
+}
+
+func NewWorker(service *CommandService) *Worker {
+	return &Worker{Service: service}
+}
+
+// Run looks for queued cancellations every interval until the context is cancelled
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			ran, err := w.RunOnce(ctx)
+			if err != nil {
+				log.Printf("❌ Show cancellation failed: %v", err)
+			}
+			if !ran || ctx.Err() != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce works through the next queued cancellation, if there is one, and reports
+// whether there was
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	c, err := w.Service.Repo.ClaimNext(ctx, time.Now().Add(-staleAfter))
+	if err != nil || c == nil {
+		return false, err
+	}
+
+	log.Printf("💸 Refunding %d bookings of cancelled show %d (cancellation %s)", c.Total, c.ShowID, c.ID)
+	if err := w.Service.Run(ctx, c); err != nil {
+		if ctx.Err() != nil {
+			// Shutting down: leave it running so it's picked up again once stale
+			return true, err
+		}
+		c.Status = StatusFailed
+		c.Error = err.Error()
+		if saveErr := w.Service.Repo.Finish(ctx, c); saveErr != nil {
+			log.Printf("⚠️ Failed to record cancellation %s as failed: %v", c.ID, saveErr)
+		}
+		return true, err
+	}
+	log.Printf("✅ Cancellation %s of show %d done", c.ID, c.ShowID)
+	return true, nil
+}
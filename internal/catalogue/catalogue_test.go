@@ -0,0 +1,186 @@
+package catalogue
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/movie"
+)
+
+// TestParseMoviesCSV tests reading movies with list cells and credits from CSV
+func TestParseMoviesCSV(t *testing.T) {
+	file := "external_ref,name,genres,duration,languages,cast,formats\n" +
+		"m-1,Dune,Sci-Fi|Drama,155,English|Hindi,Timothée Chalamet:Paul|Zendaya,2d|IMAX\n" +
+		"m-2,Heat,Crime,170,,,\n"
+
+	batch, err := Parse(KindMovies, FormatCSV, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch.Len() != 2 {
+		t.Fatalf("Expected 2 rows, got %d", batch.Len())
+	}
+	dune := batch.Movies[0]
+	if dune.ExternalRef != "m-1" || dune.Name != "Dune" || dune.Duration != 155 {
+		t.Errorf("Unexpected first row: %+v", dune)
+	}
+	if !reflect.DeepEqual(dune.Genres, []string{"Sci-Fi", "Drama"}) {
+		t.Errorf("Expected two genres, got %v", dune.Genres)
+	}
+	expectedCast := []movie.CastMember{{Name: "Timothée Chalamet", Character: "Paul"}, {Name: "Zendaya"}}
+	if !reflect.DeepEqual(dune.Cast, expectedCast) {
+		t.Errorf("Expected cast %v, got %v", expectedCast, dune.Cast)
+	}
+}
+
+// TestParseShowsJSON tests reading shows from a JSON array
+func TestParseShowsJSON(t *testing.T) {
+	file := `[
+		{"external_ref": "s-1", "movie_ref": "m-1", "theater": "Screen 1", "start_time": "2024-03-01T18:00:00Z"},
+		{"external_ref": "s-2", "movie_id": 7, "theater": " Screen 2 ", "start_time": "2024-03-01T21:00:00Z"}
+	]`
+
+	batch, err := Parse(KindShows, FormatJSON, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch.Len() != 2 || batch.Shows[0].MovieRef != "m-1" || batch.Shows[1].MovieID != 7 {
+		t.Fatalf("Unexpected rows: %+v", batch.Shows)
+	}
+	if batch.Shows[1].Theater != "Screen 2" {
+		t.Errorf("Expected the theater to be trimmed, got %q", batch.Shows[1].Theater)
+	}
+}
+
+// TestParseRowErrors tests that every bad row is reported, not just the first
+func TestParseRowErrors(t *testing.T) {
+	file := "external_ref,movie_ref,theater,start_time\n" +
+		"s-1,m-1,Screen 1,2024-03-01T18:00:00Z\n" +
+		",m-1,Screen 1,2024-03-01T21:00:00Z\n" +
+		"s-1,m-1,Screen 2,2024-03-01T18:00:00Z\n" +
+		"s-3,,Screen 1,2024-03-02T18:00:00Z\n" +
+		"s-4,m-1,Screen 1,tomorrow\n"
+
+	_, err := Parse(KindShows, FormatCSV, strings.NewReader(file))
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || !errors.Is(err, ErrInvalidImport) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+	var rows []int
+	for _, r := range invalid.Rows {
+		rows = append(rows, r.Row)
+	}
+	if !reflect.DeepEqual(rows, []int{2, 3, 4, 5}) {
+		t.Errorf("Expected rows 2 to 5 to fail, got %v", invalid.Rows)
+	}
+}
+
+// TestParseFileErrors tests problems with the file as a whole
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   string
+		format string
+		file   string
+	}{
+		{"Unknown column", KindMovies, FormatCSV, "external_ref,title\nm-1,Dune\n"},
+		{"No rows", KindMovies, FormatCSV, "external_ref,name,duration\n"},
+		{"Empty file", KindShows, FormatCSV, ""},
+		{"Not an array", KindMovies, FormatJSON, `{"external_ref": "m-1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.kind, tt.format, strings.NewReader(tt.file))
+			if !errors.Is(err, ErrInvalidImport) {
+				t.Errorf("Expected ErrInvalidImport, got %v", err)
+			}
+		})
+	}
+}
+
+// TestParseMovieValidation tests that movie rows are checked like created movies
+func TestParseMovieValidation(t *testing.T) {
+	file := `[{"external_ref": "m-1", "name": "Dune", "duration": 0},
+		{"external_ref": "m-2", "name": "Heat", "duration": 170, "formats": ["VHS"]}]`
+
+	_, err := Parse(KindMovies, FormatJSON, strings.NewReader(file))
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) || len(invalid.Rows) != 2 {
+		t.Fatalf("Expected both rows to fail, got %v", err)
+	}
+	if invalid.Rows[0].Error != movie.ErrInvalidDuration.Error() || invalid.Rows[1].ExternalRef != "m-2" {
+		t.Errorf("Unexpected row errors: %+v", invalid.Rows)
+	}
+}
+
+// TestWriteRoundTrip tests that an exported CSV file imports as the same rows
+func TestWriteRoundTrip(t *testing.T) {
+	movies := &Batch{Kind: KindMovies, Movies: []MovieRow{{
+		ExternalRef: "movies:3",
+		ID:          3,
+		CreateMovieRequest: movie.CreateMovieRequest{
+			Name:     "Dune",
+			Duration: 155,
+			Details: movie.Details{
+				Genres: []string{"Sci-Fi"},
+				Cast:   []movie.CastMember{{Name: "Zendaya", Character: "Chani"}},
+				Crew:   []movie.CrewMember{{Name: "Denis Villeneuve", Job: "Director"}},
+			},
+		},
+	}}}
+	shows := &Batch{Kind: KindShows, Shows: []ShowRow{{
+		ExternalRef: "s-1", ID: 9, MovieRef: "movies:3", MovieID: 3, Theater: "Screen 1",
+		StartTime: time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC),
+	}}}
+
+	for _, batch := range []*Batch{movies, shows} {
+		var buf bytes.Buffer
+		if err := Write(&buf, FormatCSV, batch); err != nil {
+			t.Fatalf("Unexpected error writing %s: %v", batch.Kind, err)
+		}
+		got, err := Parse(batch.Kind, FormatCSV, &buf)
+		if err != nil {
+			t.Fatalf("Unexpected error reading %s back: %v", batch.Kind, err)
+		}
+		if batch.Kind == KindMovies {
+			m := got.Movies[0]
+			if m.ID != 3 || m.Name != "Dune" || m.Cast[0].Character != "Chani" || m.Crew[0].Job != "Director" {
+				t.Errorf("Movie didn't survive the round trip: %+v", m)
+			}
+		} else if !reflect.DeepEqual(got.Shows, shows.Shows) {
+			t.Errorf("Expected %+v, got %+v", shows.Shows, got.Shows)
+		}
+	}
+}
+
+// TestParseKindAndFormat tests the kind and format parameters
+func TestParseKindAndFormat(t *testing.T) {
+	if kind, err := ParseKind(" Movies "); err != nil || kind != KindMovies {
+		t.Errorf("Expected movies, got %q, %v", kind, err)
+	}
+	if _, err := ParseKind("theaters"); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("Expected ErrInvalidImport, got %v", err)
+	}
+	if format, err := ParseFormat(""); err != nil || format != FormatJSON {
+		t.Errorf("Expected JSON by default, got %q, %v", format, err)
+	}
+	if _, err := ParseFormat("xml"); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("Expected ErrInvalidImport, got %v", err)
+	}
+}
+
+// TestExportRef tests that movies and shows that were never imported get a stable reference
+func TestExportRef(t *testing.T) {
+	refs := map[int]string{1: "m-1"}
+	if ref := exportRef(KindMovies, refs, 1); ref != "m-1" {
+		t.Errorf("Expected the attached reference, got %q", ref)
+	}
+	if ref := exportRef(KindMovies, refs, 2); ref != "movies:2" {
+		t.Errorf("Expected movies:2, got %q", ref)
+	}
+}
@@ -0,0 +1,335 @@
+// Reading and writing catalogue files as CSV or JSON
+
+package catalogue
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/movie"
+	"github.com/hitorii/ticket-booking/internal/show"
+)
+
+// listSeparator joins list values inside one CSV cell, and creditSeparator splits a cast or
+// crew entry into the name and the character or job
+const (
+	listSeparator   = "|"
+	creditSeparator = ":"
+)
+
+// CSV columns, in the order exports write them
+var (
+	movieColumns = []string{"external_ref", "id", "name", "genres", "duration", "release_date", "languages",
+		"subtitles", "certification", "synopsis", "cast", "crew", "poster_url", "formats"}
+	showColumns = []string{"external_ref", "id", "movie_ref", "movie_id", "theater", "screen_id", "start_time"}
+)
+
+// Parse reads a file of the given kind and format and validates every row. When any row
+// is wrong, a ValidationError lists them all.
+func Parse(kind, format string, r io.Reader) (*Batch, error) {
+	var records []record
+	var err error
+	if format == FormatCSV {
+		columns := movieColumns
+		if kind == KindShows {
+			columns = showColumns
+		}
+		records, err = readCSV(r, columns)
+	} else {
+		records, err = readJSON(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: the file has no rows", ErrInvalidImport)
+	}
+	if len(records) > MaxImportRows {
+		return nil, fmt.Errorf("%w: at most %d rows per file", ErrInvalidImport, MaxImportRows)
+	}
+
+	batch := &Batch{Kind: kind}
+	var rows []RowError
+	seen := map[string]int{}
+	for i, rec := range records {
+		n := i + 1
+		var ref string
+		var err error
+		if kind == KindMovies {
+			var row MovieRow
+			row, err = rec.movie()
+			if err == nil {
+				err = row.validate()
+			}
+			ref = row.ExternalRef
+			batch.Movies = append(batch.Movies, row)
+		} else {
+			var row ShowRow
+			row, err = rec.show()
+			if err == nil {
+				err = row.validate()
+			}
+			ref = row.ExternalRef
+			batch.Shows = append(batch.Shows, row)
+		}
+		if err == nil && seen[ref] > 0 {
+			err = fmt.Errorf("external_ref %q repeats row %d", ref, seen[ref])
+		}
+		if err != nil {
+			rows = append(rows, RowError{Row: n, ExternalRef: ref, Error: err.Error()})
+			continue
+		}
+		seen[ref] = n
+	}
+	if len(rows) > 0 {
+		return nil, &ValidationError{Rows: rows}
+	}
+	return batch, nil
+}
+
+func checkRef(ref string) error {
+	if ref == "" {
+		return errors.New("external_ref is required")
+	}
+	if len(ref) > maxRefLength {
+		return fmt.Errorf("external_ref is longer than %d characters", maxRefLength)
+	}
+	return nil
+}
+
+// validate checks a movie row the way creating the movie would
+func (row *MovieRow) validate() error {
+	row.ExternalRef = strings.TrimSpace(row.ExternalRef)
+	if err := checkRef(row.ExternalRef); err != nil {
+		return err
+	}
+	_, err := row.Movie()
+	return err
+}
+
+// validate checks a show row. Whether its movie exists is only known once the rows before
+// it have been imported.
+func (row *ShowRow) validate() error {
+	row.ExternalRef = strings.TrimSpace(row.ExternalRef)
+	row.MovieRef = strings.TrimSpace(row.MovieRef)
+	row.Theater = strings.TrimSpace(row.Theater)
+	if err := checkRef(row.ExternalRef); err != nil {
+		return err
+	}
+	if row.MovieRef == "" && row.MovieID <= 0 {
+		return errors.New("movie_ref or movie_id is required")
+	}
+	if row.Theater == "" && row.ScreenID == nil {
+		return show.ErrTheaterRequired
+	}
+	if row.StartTime.IsZero() {
+		return show.ErrStartTimeMissing
+	}
+	return nil
+}
+
+// record is one row as read from a file, before it is turned into a movie or a show
+type record struct {
+	csv  map[string]string
+	json json.RawMessage
+}
+
+// readCSV reads a file with a header row naming some of the allowed columns
+func readCSV(r io.Reader, allowed []string) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read the header: %v", ErrInvalidImport, err)
+	}
+	known := map[string]bool{}
+	for _, col := range allowed {
+		known[col] = true
+	}
+	for i, name := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+		if !known[header[i]] {
+			return nil, fmt.Errorf("%w: unknown column %q; expected %s", ErrInvalidImport, name, strings.Join(allowed, ", "))
+		}
+	}
+
+	var records []record
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImport, line, err)
+		}
+		if len(records) == MaxImportRows {
+			return nil, fmt.Errorf("%w: at most %d rows per file", ErrInvalidImport, MaxImportRows)
+		}
+		values := make(map[string]string, len(header))
+		for i, col := range header {
+			values[col] = strings.TrimSpace(row[i])
+		}
+		records = append(records, record{csv: values})
+	}
+}
+
+// readJSON reads a file holding an array of row objects
+func readJSON(r io.Reader) ([]record, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: expected a JSON array of rows: %v", ErrInvalidImport, err)
+	}
+	records := make([]record, len(raw))
+	for i, msg := range raw {
+		records[i] = record{json: msg}
+	}
+	return records, nil
+}
+
+func (rec record) movie() (MovieRow, error) {
+	var row MovieRow
+	if rec.json != nil {
+		err := json.Unmarshal(rec.json, &row)
+		return row, err
+	}
+	var err error
+	row.ExternalRef = rec.csv["external_ref"]
+	if row.ID, err = optionalInt(rec.csv, "id"); err != nil {
+		return row, err
+	}
+	row.Name = rec.csv["name"]
+	row.Genres = splitList(rec.csv["genres"])
+	if row.Duration, err = optionalInt(rec.csv, "duration"); err != nil {
+		return row, err
+	}
+	row.ReleaseDate = rec.csv["release_date"]
+	row.Languages = splitList(rec.csv["languages"])
+	row.Subtitles = splitList(rec.csv["subtitles"])
+	row.Certification = rec.csv["certification"]
+	row.Synopsis = rec.csv["synopsis"]
+	for _, entry := range splitList(rec.csv["cast"]) {
+		name, character, _ := strings.Cut(entry, creditSeparator)
+		row.Cast = append(row.Cast, movie.CastMember{Name: name, Character: character})
+	}
+	for _, entry := range splitList(rec.csv["crew"]) {
+		name, job, _ := strings.Cut(entry, creditSeparator)
+		row.Crew = append(row.Crew, movie.CrewMember{Name: name, Job: job})
+	}
+	row.PosterURL = rec.csv["poster_url"]
+	row.Formats = splitList(rec.csv["formats"])
+	return row, nil
+}
+
+func (rec record) show() (ShowRow, error) {
+	var row ShowRow
+	if rec.json != nil {
+		err := json.Unmarshal(rec.json, &row)
+		return row, err
+	}
+	var err error
+	row.ExternalRef = rec.csv["external_ref"]
+	if row.ID, err = optionalInt(rec.csv, "id"); err != nil {
+		return row, err
+	}
+	row.MovieRef = rec.csv["movie_ref"]
+	if row.MovieID, err = optionalInt(rec.csv, "movie_id"); err != nil {
+		return row, err
+	}
+	row.Theater = rec.csv["theater"]
+	screenID, err := optionalInt(rec.csv, "screen_id")
+	if err != nil {
+		return row, err
+	}
+	if screenID > 0 {
+		row.ScreenID = &screenID
+	}
+	if start := rec.csv["start_time"]; start != "" {
+		if row.StartTime, err = time.Parse(time.RFC3339, start); err != nil {
+			return row, errors.New("start_time must be an RFC 3339 time")
+		}
+	}
+	return row, nil
+}
+
+func optionalInt(values map[string]string, col string) (int, error) {
+	if values[col] == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(values[col])
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a whole number", col)
+	}
+	return n, nil
+}
+
+func splitList(cell string) []string {
+	if cell == "" {
+		return nil
+	}
+	parts := strings.Split(cell, listSeparator)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// Write writes a batch in the given format. CSV files have a header row and can be
+// imported again as they are.
+func Write(w io.Writer, format string, batch *Batch) error {
+	if format == FormatJSON {
+		var rows any = batch.Shows
+		if batch.Kind == KindMovies {
+			rows = batch.Movies
+		}
+		return json.NewEncoder(w).Encode(rows)
+	}
+
+	out := csv.NewWriter(w)
+	if batch.Kind == KindMovies {
+		out.Write(movieColumns)
+		for _, m := range batch.Movies {
+			cast := make([]string, len(m.Cast))
+			for i, c := range m.Cast {
+				cast[i] = joinCredit(c.Name, c.Character)
+			}
+			crew := make([]string, len(m.Crew))
+			for i, c := range m.Crew {
+				crew[i] = joinCredit(c.Name, c.Job)
+			}
+			out.Write([]string{m.ExternalRef, strconv.Itoa(m.ID), m.Name, strings.Join(m.Genres, listSeparator),
+				strconv.Itoa(m.Duration), m.ReleaseDate, strings.Join(m.Languages, listSeparator),
+				strings.Join(m.Subtitles, listSeparator), m.Certification, m.Synopsis,
+				strings.Join(cast, listSeparator), strings.Join(crew, listSeparator), m.PosterURL,
+				strings.Join(m.Formats, listSeparator)})
+		}
+	} else {
+		out.Write(showColumns)
+		for _, s := range batch.Shows {
+			screenID := ""
+			if s.ScreenID != nil {
+				screenID = strconv.Itoa(*s.ScreenID)
+			}
+			out.Write([]string{s.ExternalRef, strconv.Itoa(s.ID), s.MovieRef, strconv.Itoa(s.MovieID), s.Theater,
+				screenID, s.StartTime.Format(time.RFC3339)})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+func joinCredit(name, role string) string {
+	if role == "" {
+		return name
+	}
+	return name + creditSeparator + role
+}
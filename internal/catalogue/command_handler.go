@@ -0,0 +1,48 @@
+// Command handler for catalogue imports (CQRS)
+
+package catalogue
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportBytes caps the size of an uploaded file
+const maxImportBytes = 10 << 20
+
+type CommandHandler struct {
+	CommandService *CommandService
+}
+
+func NewCommandHandler(cs *CommandService) *CommandHandler {
+	return &CommandHandler{CommandService: cs}
+}
+
+// StartImport - Command handler for uploading a CSV or JSON file of movies or shows. The
+// format comes from ?format=, or else the Content-Type.
+func (h *CommandHandler) StartImport(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" && strings.Contains(c.ContentType(), "csv") {
+		format = FormatCSV
+	}
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	imp, err := h.CommandService.StartImport(c.Request.Context(), c.Param("kind"), format, body)
+	var invalid *ValidationError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &invalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidImport.Error(), "rows": invalid.Rows})
+	case errors.As(err, &tooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Import file is too large"})
+	case errors.Is(err, ErrInvalidImport):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue import: " + err.Error()})
+	default:
+		c.JSON(http.StatusAccepted, imp)
+	}
+}
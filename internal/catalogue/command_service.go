@@ -0,0 +1,208 @@
+// Command service for catalogue imports (CQRS)
+
+package catalogue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/movie"
+	"github.com/hitorii/ticket-booking/internal/show"
+)
+
+// progressEvery is how many rows an import processes between progress updates
+const progressEvery = 25
+
+// What importing a row did
+const (
+	rowCreated = iota
+	rowUpdated
+	rowUnchanged
+)
+
+// CommandService queues imports and applies their rows through the movie and show
+// commands, so imported rows are validated, checked for clashes and published like any
+// other change
+type CommandService struct {
+	Repo   *Repository
+	Movies *movie.CommandService
+	Shows  *show.CommandService
+}
+
+func NewCommandService(repo *Repository, movies *movie.CommandService, shows *show.CommandService) *CommandService {
+	return &CommandService{Repo: repo, Movies: movies, Shows: shows}
+}
+
+// StartImport - Command to validate a file and queue it for the worker. Nothing is queued
+// unless every row is valid.
+func (s *CommandService) StartImport(ctx context.Context, kind, format string, file io.Reader) (*Import, error) {
+	kind, err := ParseKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	format, err = ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	batch, err := Parse(kind, format, file)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &Import{Kind: kind, Format: format}
+	if err := s.Repo.CreateImport(ctx, imp, batch); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// Run applies an import's rows in order. A row that fails is recorded and the rest carry
+// on; running the same rows again leaves the catalogue as it is.
+func (s *CommandService) Run(ctx context.Context, imp *Import, batch *Batch) error {
+	for i := range batch.Len() {
+		var ref string
+		var outcome int
+		var err error
+		if batch.Kind == KindMovies {
+			ref = batch.Movies[i].ExternalRef
+			outcome, err = s.importMovie(ctx, batch.Movies[i])
+		} else {
+			ref = batch.Shows[i].ExternalRef
+			outcome, err = s.importShow(ctx, batch.Shows[i])
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		imp.Processed++
+		switch {
+		case err != nil:
+			imp.Failed++
+			imp.Errors = append(imp.Errors, RowError{Row: i + 1, ExternalRef: ref, Error: err.Error()})
+		case outcome == rowCreated:
+			imp.Created++
+		case outcome == rowUpdated:
+			imp.Updated++
+		default:
+			imp.Unchanged++
+		}
+		if imp.Processed%progressEvery == 0 {
+			if err := s.Repo.SaveProgress(ctx, imp); err != nil {
+				return err
+			}
+		}
+	}
+
+	now := time.Now()
+	imp.Status = StatusCompleted
+	imp.FinishedAt = &now
+	return s.Repo.SaveProgress(ctx, imp)
+}
+
+// existing finds what a row refers to: the entity its reference is attached to, otherwise
+// the ID the row gives
+func (s *CommandService) existing(ctx context.Context, kind, ref string, id int) (int, error) {
+	attached, ok, err := s.Repo.LookupRef(ctx, kind, ref)
+	if err != nil || ok {
+		return attached, err
+	}
+	return id, nil
+}
+
+func (s *CommandService) importMovie(ctx context.Context, row MovieRow) (int, error) {
+	want, err := row.Movie()
+	if err != nil {
+		return 0, err
+	}
+	id, err := s.existing(ctx, KindMovies, row.ExternalRef, row.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	outcome := rowCreated
+	var current *movie.Movie
+	if id > 0 {
+		current, err = s.Movies.Repo.GetByID(ctx, id)
+		if err != nil && !errors.Is(err, movie.ErrMovieNotFound) {
+			return 0, err
+		}
+	}
+	switch {
+	case current == nil:
+		if _, err := s.Movies.CreateMovie(ctx, row.CreateMovieRequest, attachRef(KindMovies, row.ExternalRef)); err != nil {
+			return 0, err
+		}
+		return rowCreated, nil
+	case sameMovie(current, want):
+		outcome = rowUnchanged
+	default:
+		if _, err := s.Movies.UpdateMovie(ctx, strconv.Itoa(id), movie.UpdateMovieRequest(row.CreateMovieRequest)); err != nil {
+			return 0, err
+		}
+		outcome = rowUpdated
+	}
+	return outcome, s.Repo.SaveRef(ctx, KindMovies, row.ExternalRef, id)
+}
+
+func sameMovie(current, want *movie.Movie) bool {
+	want.ID = current.ID
+	return reflect.DeepEqual(current, want)
+}
+
+func sameScreen(current, want *int) bool {
+	if current == nil || want == nil {
+		return current == want
+	}
+	return *current == *want
+}
+
+func (s *CommandService) importShow(ctx context.Context, row ShowRow) (int, error) {
+	movieID := row.MovieID
+	if row.MovieRef != "" {
+		attached, ok, err := s.Repo.LookupRef(ctx, KindMovies, row.MovieRef)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			movieID = attached
+		} else if movieID <= 0 {
+			return 0, fmt.Errorf("unknown movie_ref %q", row.MovieRef)
+		}
+	}
+	id, err := s.existing(ctx, KindShows, row.ExternalRef, row.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	outcome := rowCreated
+	var current *show.Show
+	if id > 0 {
+		current, err = s.Shows.Repo.GetByID(ctx, id)
+		if err != nil && !errors.Is(err, show.ErrShowNotFound) {
+			return 0, err
+		}
+	}
+	switch {
+	case current == nil:
+		req := show.CreateShowRequest{MovieID: movieID, Theater: row.Theater, ScreenID: row.ScreenID, StartTime: row.StartTime}
+		if _, err := s.Shows.CreateShow(ctx, req, attachRef(KindShows, row.ExternalRef)); err != nil {
+			return 0, err
+		}
+		return rowCreated, nil
+	case current.MovieID == movieID && (row.Theater == "" || current.Theater == row.Theater) &&
+		sameScreen(current.ScreenID, row.ScreenID) && current.StartTime.Equal(row.StartTime):
+		outcome = rowUnchanged
+	default:
+		req := show.UpdateShowRequest{MovieID: movieID, Theater: row.Theater, ScreenID: row.ScreenID, StartTime: row.StartTime}
+		if _, err := s.Shows.UpdateShow(ctx, strconv.Itoa(id), req); err != nil {
+			return 0, err
+		}
+		outcome = rowUpdated
+	}
+	return outcome, s.Repo.SaveRef(ctx, KindShows, row.ExternalRef, id)
+}
@@ -0,0 +1,146 @@
+// Models for bulk catalogue imports and exports
+
+package catalogue
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/movie"
+)
+
+// What a file holds
+const (
+	KindMovies = "movies"
+	KindShows  = "shows"
+)
+
+// File formats
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Import statuses. A COMPLETED import may still have failed rows; FAILED means the job
+// itself couldn't run to the end.
+const (
+	StatusPending   = "PENDING"
+	StatusRunning   = "RUNNING"
+	StatusCompleted = "COMPLETED"
+	StatusFailed    = "FAILED"
+)
+
+// MaxImportRows caps how many rows one file may hold
+const MaxImportRows = 5000
+
+// maxRefLength matches the external_ref column
+const maxRefLength = 100
+
+var (
+	ErrInvalidImport  = errors.New("invalid import")
+	ErrImportNotFound = errors.New("import not found")
+)
+
+// MovieRow is one movie of an import or export. ExternalRef is the caller's own key for
+// the movie: importing a row with a known reference updates that movie instead of adding
+// another. ID, when set, adopts an existing movie the reference isn't attached to yet.
+type MovieRow struct {
+	ExternalRef string `json:"external_ref"`
+	ID          int    `json:"id,omitempty"`
+	movie.CreateMovieRequest
+}
+
+// ShowRow is one show of an import or export. The movie is found by MovieRef, a movie
+// row's external reference, falling back to MovieID. A screen is needed in theaters that
+// have them, and is enough to name the theater.
+type ShowRow struct {
+	ExternalRef string    `json:"external_ref"`
+	ID          int       `json:"id,omitempty"`
+	MovieRef    string    `json:"movie_ref,omitempty"`
+	MovieID     int       `json:"movie_id,omitempty"`
+	Theater     string    `json:"theater"`
+	ScreenID    *int      `json:"screen_id,omitempty"`
+	StartTime   time.Time `json:"start_time"`
+}
+
+// Batch is the parsed rows of one file; only the list matching Kind is filled in
+type Batch struct {
+	Kind   string     `json:"kind"`
+	Movies []MovieRow `json:"movies,omitempty"`
+	Shows  []ShowRow  `json:"shows,omitempty"`
+}
+
+// Len is the number of rows in the batch
+func (b *Batch) Len() int {
+	if b.Kind == KindMovies {
+		return len(b.Movies)
+	}
+	return len(b.Shows)
+}
+
+// RowError is what's wrong with one row. Rows are numbered from 1 in file order, not
+// counting a CSV header.
+type RowError struct {
+	Row         int    `json:"row"`
+	ExternalRef string `json:"external_ref,omitempty"`
+	Error       string `json:"error"`
+}
+
+// ValidationError lists every row that kept a file from being imported
+type ValidationError struct {
+	Rows []RowError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Rows))
+	for i, row := range e.Rows {
+		msgs[i] = fmt.Sprintf("row %d: %s", row.Row, row.Error)
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidImport, strings.Join(msgs, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidImport
+}
+
+// Import is a queued or finished import job and how far it got
+type Import struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Format     string     `json:"format"`
+	Status     string     `json:"status"`
+	TotalRows  int        `json:"total_rows"`
+	Processed  int        `json:"processed_rows"`
+	Created    int        `json:"created"`
+	Updated    int        `json:"updated"`
+	Unchanged  int        `json:"unchanged"`
+	Failed     int        `json:"failed"`
+	Errors     []RowError `json:"errors"`
+	Error      string     `json:"error,omitempty"` // why a FAILED import stopped
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ParseKind checks an import or export kind
+func ParseKind(kind string) (string, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind != KindMovies && kind != KindShows {
+		return "", fmt.Errorf("%w: kind must be %s or %s", ErrInvalidImport, KindMovies, KindShows)
+	}
+	return kind, nil
+}
+
+// ParseFormat checks a file format; empty means JSON
+func ParseFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "":
+		return FormatJSON, nil
+	case FormatCSV, FormatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("%w: format must be %s or %s", ErrInvalidImport, FormatCSV, FormatJSON)
+}
@@ -0,0 +1,64 @@
+// Query handler for import progress and catalogue exports (CQRS)
+
+package catalogue
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetImport - Query handler for an import's progress and row errors
+func (h *QueryHandler) GetImport(c *gin.Context) {
+	imp, err := h.QueryService.GetImport(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, ErrImportNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get import"})
+		return
+	}
+	c.JSON(http.StatusOK, imp)
+}
+
+// Export - Query handler for downloading every movie or show as a file that can be
+// imported again
+func (h *QueryHandler) Export(c *gin.Context) {
+	format, err := ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	batch, err := h.QueryService.Export(c.Request.Context(), c.Param("kind"))
+	if errors.Is(err, ErrInvalidImport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export " + c.Param("kind")})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, format, batch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write export"})
+		return
+	}
+	contentType := "application/json"
+	if format == FormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+batch.Kind+"."+format+`"`)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,92 @@
+// Query service for import progress and catalogue exports (CQRS)
+
+package catalogue
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hitorii/ticket-booking/internal/movie"
+	"github.com/hitorii/ticket-booking/internal/show"
+)
+
+// QueryService reads the Command DB so exports match what an import would compare against
+type QueryService struct {
+	Repo   *Repository
+	Movies *movie.Repository
+	Shows  *show.Repository
+}
+
+func NewQueryService(repo *Repository, movies *movie.Repository, shows *show.Repository) *QueryService {
+	return &QueryService{Repo: repo, Movies: movies, Shows: shows}
+}
+
+// GetImport - Query to get an import's status, progress and row errors
+func (s *QueryService) GetImport(ctx context.Context, id string) (*Import, error) {
+	return s.Repo.GetImport(ctx, id)
+}
+
+// exportRef is the reference an export gives a movie or show. One that was never imported
+// gets "kind:id", which the row's ID ties to it when the export is imported again.
+func exportRef(kind string, refs map[int]string, id int) string {
+	if ref, ok := refs[id]; ok {
+		return ref
+	}
+	return kind + ":" + strconv.Itoa(id)
+}
+
+// Export - Query to get every movie or show as import rows
+func (s *QueryService) Export(ctx context.Context, kind string) (*Batch, error) {
+	kind, err := ParseKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := s.Repo.Refs(ctx, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &Batch{Kind: kind}
+	if kind == KindMovies {
+		movies, err := s.Movies.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		batch.Movies = make([]MovieRow, len(movies))
+		for i, m := range movies {
+			batch.Movies[i] = MovieRow{
+				ExternalRef: exportRef(KindMovies, refs, m.ID),
+				ID:          m.ID,
+				CreateMovieRequest: movie.CreateMovieRequest{
+					Name:     m.Name,
+					Genre:    m.Genre,
+					Duration: m.Duration,
+					Details:  m.Details,
+				},
+			}
+		}
+		return batch, nil
+	}
+
+	movieRefs, err := s.Repo.Refs(ctx, KindMovies)
+	if err != nil {
+		return nil, err
+	}
+	shows, err := s.Shows.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch.Shows = make([]ShowRow, len(shows))
+	for i, sh := range shows {
+		batch.Shows[i] = ShowRow{
+			ExternalRef: exportRef(KindShows, refs, sh.ID),
+			ID:          sh.ID,
+			MovieRef:    exportRef(KindMovies, movieRefs, sh.MovieID),
+			MovieID:     sh.MovieID,
+			Theater:     sh.Theater,
+			ScreenID:    sh.ScreenID,
+			StartTime:   sh.StartTime,
+		}
+	}
+	return batch, nil
+}
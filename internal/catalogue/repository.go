@@ -0,0 +1,172 @@
+// Repository for import jobs and the external references of movies and shows
+
+package catalogue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+const importColumns = `id, kind, format, status, total_rows, processed_rows, created_count, updated_count,
+	unchanged_count, failed_count, errors, COALESCE(error, ''), created_at, started_at, finished_at`
+
+func scanImport(row pgx.Row, extra ...any) (*Import, error) {
+	var imp Import
+	dest := []any{&imp.ID, &imp.Kind, &imp.Format, &imp.Status, &imp.TotalRows, &imp.Processed, &imp.Created,
+		&imp.Updated, &imp.Unchanged, &imp.Failed, &imp.Errors, &imp.Error, &imp.CreatedAt, &imp.StartedAt,
+		&imp.FinishedAt}
+	err := row.Scan(append(dest, extra...)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrImportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// CreateImport queues an import with its parsed rows
+func (r *Repository) CreateImport(ctx context.Context, imp *Import, batch *Batch) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	imp.ID = uuid.New().String()
+	imp.Status = StatusPending
+	imp.TotalRows = batch.Len()
+	imp.Errors = []RowError{}
+	return r.DB.QueryRow(ctx, `
+		INSERT INTO catalogue_imports (id, kind, format, status, batch, total_rows)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`, imp.ID, imp.Kind, imp.Format, imp.Status, data, imp.TotalRows).Scan(&imp.CreatedAt)
+}
+
+// GetImport returns an import and its progress
+func (r *Repository) GetImport(ctx context.Context, id string) (*Import, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrImportNotFound
+	}
+	return scanImport(r.DB.QueryRow(ctx, "SELECT "+importColumns+" FROM catalogue_imports WHERE id = $1", id))
+}
+
+// ClaimNext marks the oldest pending import as running and returns it with its rows, or
+// nil when there is nothing to do. A running import that hasn't reported progress since
+// stale is claimed again; rows are idempotent, so starting it over is safe.
+func (r *Repository) ClaimNext(ctx context.Context, stale time.Time) (*Import, *Batch, error) {
+	var data []byte
+	imp, err := scanImport(r.DB.QueryRow(ctx, `
+		UPDATE catalogue_imports SET status = $1, started_at = NOW(), updated_at = NOW(),
+			processed_rows = 0, created_count = 0, updated_count = 0, unchanged_count = 0, failed_count = 0,
+			errors = '[]'
+		WHERE id = (
+			SELECT id FROM catalogue_imports
+			WHERE status = $2 OR status = $1 AND updated_at < $3
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+importColumns+`, batch
+	`, StatusRunning, StatusPending, stale), &data)
+	if errors.Is(err, ErrImportNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var batch Batch
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return imp, nil, err
+	}
+	return imp, &batch, nil
+}
+
+// SaveProgress records an import's counts, errors and status
+func (r *Repository) SaveProgress(ctx context.Context, imp *Import) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE catalogue_imports SET status = $2, processed_rows = $3, created_count = $4, updated_count = $5,
+			unchanged_count = $6, failed_count = $7, errors = $8, error = NULLIF($9, ''), finished_at = $10,
+			updated_at = NOW()
+		WHERE id = $1
+	`, imp.ID, imp.Status, imp.Processed, imp.Created, imp.Updated, imp.Unchanged, imp.Failed, imp.Errors,
+		imp.Error, imp.FinishedAt)
+	return err
+}
+
+// LookupRef returns the movie or show an external reference is attached to
+func (r *Repository) LookupRef(ctx context.Context, kind, ref string) (int, bool, error) {
+	var id int
+	err := r.DB.QueryRow(ctx,
+		"SELECT entity_id FROM catalogue_refs WHERE kind = $1 AND external_ref = $2", kind, ref).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	return id, err == nil, err
+}
+
+// execer is satisfied by both the pool and a transaction
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// SaveRef attaches an external reference to a movie or show, moving it if it was attached
+// to one that has since been deleted
+func (r *Repository) SaveRef(ctx context.Context, kind, ref string, id int) error {
+	return saveRef(ctx, r.DB, kind, ref, id)
+}
+
+// attachRef saves the reference of a movie or show in the transaction that creates it, so
+// a row retried after a failure finds what it created instead of creating it again
+func attachRef(kind, ref string) func(ctx context.Context, tx pgx.Tx, id int) error {
+	return func(ctx context.Context, tx pgx.Tx, id int) error {
+		return saveRef(ctx, tx, kind, ref, id)
+	}
+}
+
+func saveRef(ctx context.Context, db execer, kind, ref string, id int) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO catalogue_refs (kind, external_ref, entity_id) VALUES ($1, $2, $3)
+		ON CONFLICT (kind, external_ref) DO UPDATE SET entity_id = EXCLUDED.entity_id
+	`, kind, ref, id)
+	return err
+}
+
+// Refs returns the external reference of every movie or show that has one. Where several
+// references point at the same one, the first in sort order wins.
+func (r *Repository) Refs(ctx context.Context, kind string) (map[int]string, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT DISTINCT ON (entity_id) entity_id, external_ref FROM catalogue_refs
+		WHERE kind = $1
+		ORDER BY entity_id, external_ref
+	`, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := map[int]string{}
+	for rows.Next() {
+		var id int
+		var ref string
+		if err := rows.Scan(&id, &ref); err != nil {
+			return nil, err
+		}
+		refs[id] = ref
+	}
+	return refs, rows.Err()
+}
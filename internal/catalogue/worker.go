@@ -0,0 +1,78 @@
+// Background worker that runs queued imports
+
+package catalogue
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// staleAfter is how long a running import may go without progress before another worker
+// takes it over
+const staleAfter = 10 * time.Minute
+
+// Worker runs queued imports one at a time
+type Worker struct {
+	Service *CommandService
+}
+
+func NewWorker(service *CommandService) *Worker {
+	return &Worker{Service: service}
+}
+
+// Run looks for queued imports every interval until the context is cancelled
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting again
+		for {
+			ran, err := w.RunOnce(ctx)
+			if err != nil {
+				log.Printf("❌ Catalogue import failed: %v", err)
+			}
+			if !ran || ctx.Err() != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce runs the next queued import, if there is one, and reports whether there was
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	imp, batch, err := w.Service.Repo.ClaimNext(ctx, time.Now().Add(-staleAfter))
+	if err != nil && imp == nil {
+		return false, err
+	}
+	if imp == nil {
+		return false, nil
+	}
+	if err == nil {
+		log.Printf("📦 Importing %d %s (import %s)", imp.TotalRows, imp.Kind, imp.ID)
+		err = w.Service.Run(ctx, imp, batch)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutting down: leave it running so it's picked up again once stale
+			return true, err
+		}
+		now := time.Now()
+		imp.Status = StatusFailed
+		imp.Error = err.Error()
+		imp.FinishedAt = &now
+		if saveErr := w.Service.Repo.SaveProgress(ctx, imp); saveErr != nil {
+			log.Printf("⚠️ Failed to record import %s as failed: %v", imp.ID, saveErr)
+		}
+		return true, err
+	}
+	log.Printf("✅ Import %s done: %d created, %d updated, %d unchanged, %d failed",
+		imp.ID, imp.Created, imp.Updated, imp.Unchanged, imp.Failed)
+	return true, nil
+}
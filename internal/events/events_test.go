@@ -1,8 +1,11 @@
 package events
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
 )
 
 // TestBaseEvent tests the BaseEvent struct
@@ -103,3 +106,32 @@ func TestEventPayloadWithBooking(t *testing.T) {
 		t.Errorf("Expected Status BOOKED, got %s", payload.Status)
 	}
 }
+
+// TestDecodePayload tests decoding struct and stream (JSON map) payloads
+func TestDecodePayload(t *testing.T) {
+	amount := money.Money{Amount: 50000, Currency: "INR"}
+	payload := EventPayload{UserID: "user_123", PaymentID: "pay_123", Amount: &amount, Status: "CAPTURED"}
+
+	data, err := json.Marshal(BaseEvent{Type: EventPaymentVerified, Payload: payload})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var fromStream BaseEvent
+	if err := json.Unmarshal(data, &fromStream); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for name, event := range map[string]BaseEvent{
+		"struct":  {Payload: payload},
+		"pointer": {Payload: &payload},
+		"stream":  fromStream,
+	} {
+		got, err := DecodePayload(event)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if got.UserID != "user_123" || got.Status != "CAPTURED" || got.Amount == nil || *got.Amount != amount {
+			t.Errorf("%s: unexpected payload %+v", name, got)
+		}
+	}
+}
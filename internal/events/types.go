@@ -1,7 +1,10 @@
 package events
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
 )
 
 // Event types for the event-driven system
@@ -10,11 +13,22 @@ const (
 	EventTicketReserved   = "TicketReserved"
 	EventTicketConfirmed  = "TicketConfirmed"
 	EventTicketCancelled  = "TicketCancelled"
+	EventTicketCheckedIn  = "TicketCheckedIn"
 	
 	// Payment events
 	EventPaymentInitiated = "PaymentInitiated"
 	EventPaymentVerified  = "PaymentVerified"
 	EventPaymentRefunded  = "PaymentRefunded"
+	EventPaymentExpired   = "PaymentExpired"
+	EventPaymentCancelled = "PaymentCancelled"
+	
+	// Promotion events
+	EventPromoRedeemed    = "PromoRedeemed"
+	EventPromoReleased    = "PromoReleased"
+	
+	// Pricing events
+	EventPriceChanged     = "PriceChanged"
+	EventSeatsAdded       = "SeatsAdded"
 	
 	// User events
 	EventUserRegistered   = "UserRegistered"
@@ -25,11 +39,15 @@ const (
 	EventShowCreated      = "ShowCreated"
 	EventShowUpdated      = "ShowUpdated"
 	EventShowDeleted      = "ShowDeleted"
+	EventShowCancelled    = "ShowCancelled"
 	
 	// Movie events
 	EventMovieCreated     = "MovieCreated"
 	EventMovieUpdated     = "MovieUpdated"
 	EventMovieDeleted     = "MovieDeleted"
+
+	// Venue events
+	EventVenueUpdated     = "VenueUpdated"
 )
 
 // BaseEvent represents a base event structure
@@ -52,23 +70,58 @@ type EventPayload struct {
 	Email     string `json:"email,omitempty"`
 	
 	// Payment specific
-	PaymentID string `json:"payment_id,omitempty"`
-	Amount    int    `json:"amount,omitempty"`
-	Status    string `json:"status,omitempty"`
-	Mode      string `json:"mode,omitempty"`
+	PaymentID string       `json:"payment_id,omitempty"`
+	Amount    *money.Money `json:"amount,omitempty"`
+	Status    string       `json:"status,omitempty"`
+	Mode      string       `json:"mode,omitempty"`
+	PromoCode string       `json:"promo_code,omitempty"`
+	LineItems []money.LineItem `json:"line_items,omitempty"`
 	
 	// Show/Movie specific
-	ShowID    string `json:"show_id,omitempty"`
-	MovieID   string `json:"movie_id,omitempty"`
-	Name      string `json:"name,omitempty"`
-	StartTime string `json:"start_time,omitempty"`
-	EndTime   string `json:"end_time,omitempty"`
-	Price     int    `json:"price,omitempty"`
+	ShowID     string       `json:"show_id,omitempty"`
+	ScheduleID string       `json:"schedule_id,omitempty"`
+	MovieID    string       `json:"movie_id,omitempty"`
+	Name       string       `json:"name,omitempty"`
+	StartTime  string       `json:"start_time,omitempty"`
+	EndTime    string       `json:"end_time,omitempty"`
+	Price      *money.Money `json:"price,omitempty"`
+	
+	// Venue specific
+	Venue    string `json:"venue,omitempty"`
+	TimeZone string `json:"time_zone,omitempty"`
+	
+	// Ticket specific
+	TicketID string `json:"ticket_id,omitempty"`
+	Gate     string `json:"gate,omitempty"`
+	
+	// Pricing specific
+	Tier          string       `json:"tier,omitempty"`
+	PreviousPrice *money.Money `json:"previous_price,omitempty"`
+	Reason        string       `json:"reason,omitempty"`
 	
 	// Admin specific
 	IsAdmin bool `json:"is_admin,omitempty"`
 }
 
+// DecodePayload returns an event's payload as an EventPayload. Events published in-process
+// carry the struct itself; events read back from the Redis stream carry decoded JSON.
+func DecodePayload(event BaseEvent) (EventPayload, error) {
+	switch p := event.Payload.(type) {
+	case EventPayload:
+		return p, nil
+	case *EventPayload:
+		return *p, nil
+	}
+
+	var payload EventPayload
+	data, err := json.Marshal(event.Payload)
+	if err != nil {
+		return payload, err
+	}
+	err = json.Unmarshal(data, &payload)
+	return payload, err
+}
+
 // EventHandler is a function type for handling events
 type EventHandler func(event BaseEvent) error
 
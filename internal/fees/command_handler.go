@@ -0,0 +1,45 @@
+// Command handler for venue fee and tax write operations (CQRS)
+
+package fees
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommandHandler struct {
+	CommandService *CommandService
+}
+
+func NewCommandHandler(cs *CommandService) *CommandHandler {
+	return &CommandHandler{CommandService: cs}
+}
+
+// SetVenueRules - Command handler for replacing a venue's fees and taxes
+func (h *CommandHandler) SetVenueRules(c *gin.Context) {
+	var req SetRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	rules, err := h.CommandService.SetVenueRules(c.Request.Context(), c.Param("venue"), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRules) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set fee rules: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// SetRulesRequest - Request model for a venue's fees and taxes
+type SetRulesRequest struct {
+	Fees  []FeeRule `json:"fees"`
+	Taxes []TaxRate `json:"taxes"`
+}
@@ -0,0 +1,58 @@
+// Command service for venue fee and tax write operations (CQRS)
+
+package fees
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+type CommandService struct {
+	Repo *Repository
+}
+
+func NewCommandService(repo *Repository) *CommandService {
+	return &CommandService{Repo: repo}
+}
+
+// SetVenueRules - Command to replace the fee rules and tax rates of a venue.
+// Use DefaultVenue ("*") for the rules of venues without their own.
+func (s *CommandService) SetVenueRules(ctx context.Context, venue string, req SetRulesRequest) (*Rules, error) {
+	venue = strings.TrimSpace(venue)
+	if venue == "" {
+		return nil, fmt.Errorf("%w: venue is required", ErrInvalidRules)
+	}
+
+	rules := Rules{Venue: venue, Fees: req.Fees, Taxes: req.Taxes}
+	if rules.Fees == nil {
+		rules.Fees = []FeeRule{}
+	}
+	if rules.Taxes == nil {
+		rules.Taxes = []TaxRate{}
+	}
+	for i := range rules.Fees {
+		rules.Fees[i].Kind = strings.ToUpper(rules.Fees[i].Kind)
+		if rules.Fees[i].Kind == FeePerTicket {
+			rules.Fees[i].Currency, _ = money.NormalizeCurrency(rules.Fees[i].Currency)
+		} else {
+			rules.Fees[i].Currency = ""
+		}
+	}
+	for i := range rules.Taxes {
+		rules.Taxes[i].AppliesTo = strings.ToUpper(rules.Taxes[i].AppliesTo)
+		if rules.Taxes[i].AppliesTo == "" {
+			rules.Taxes[i].AppliesTo = TaxOnAll
+		}
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.Repo.ReplaceRules(ctx, rules); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
@@ -0,0 +1,106 @@
+package fees
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// TestCalculate tests the fare, discount, fee and tax line items of a booking
+func TestCalculate(t *testing.T) {
+	fare := money.Money{Amount: 50000, Currency: "INR"}
+	discounts := []money.LineItem{{Label: "SAVE10", Amount: money.Money{Amount: 5000, Currency: "INR"}}}
+	rules := Rules{
+		Venue: "Theater A",
+		Fees: []FeeRule{
+			{Label: "Convenience fee", Kind: FeePerTicket, Value: 3000, Currency: "INR"},
+			{Label: "Platform fee", Kind: FeePercentage, Value: 200},
+		},
+		Taxes: []TaxRate{
+			{Label: "GST", RateBps: 1800, AppliesTo: TaxOnAll},
+			{Label: "Service tax", RateBps: 500, AppliesTo: TaxOnFees},
+		},
+	}
+
+	b, err := Calculate(fare, discounts, 2, rules)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []struct {
+		kind   string
+		amount int64
+	}{
+		{money.LineBaseFare, 50000},
+		{money.LineDiscount, -5000},
+		{money.LineBookingFee, 6000},
+		{money.LineBookingFee, 900},
+		{money.LineTax, 9342},
+		{money.LineTax, 345},
+	}
+	if len(b.Items) != len(expected) {
+		t.Fatalf("Expected %d line items, got %d", len(expected), len(b.Items))
+	}
+	for i, e := range expected {
+		if b.Items[i].Kind != e.kind || b.Items[i].Amount.Amount != e.amount {
+			t.Errorf("Item %d: expected %s %d, got %s %d", i, e.kind, e.amount, b.Items[i].Kind, b.Items[i].Amount.Amount)
+		}
+	}
+	if b.Total.Amount != 61587 || b.Total.Currency != "INR" {
+		t.Errorf("Expected total 61587 INR, got %s", b.Total)
+	}
+}
+
+// TestCalculateNoRules tests that a booking without rules is charged its net fare
+func TestCalculateNoRules(t *testing.T) {
+	fare := money.Money{Amount: 25000, Currency: "INR"}
+
+	b, err := Calculate(fare, nil, 1, Rules{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(b.Items) != 1 || b.Total != fare {
+		t.Errorf("Expected a single base fare line of %s, got %d items totalling %s", fare, len(b.Items), b.Total)
+	}
+}
+
+// TestCalculateCurrencyMismatch tests that a fee in another currency is rejected
+func TestCalculateCurrencyMismatch(t *testing.T) {
+	rules := Rules{Fees: []FeeRule{{Label: "Fee", Kind: FeePerTicket, Value: 100, Currency: "USD"}}}
+
+	_, err := Calculate(money.Money{Amount: 25000, Currency: "INR"}, nil, 1, rules)
+	if !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Expected currency mismatch, got %v", err)
+	}
+}
+
+// TestValidate tests fee and tax rule validation
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		valid bool
+	}{
+		{"empty", Rules{}, true},
+		{"per ticket", Rules{Fees: []FeeRule{{Label: "Fee", Kind: FeePerTicket, Value: 100, Currency: "INR"}}}, true},
+		{"per ticket without currency", Rules{Fees: []FeeRule{{Label: "Fee", Kind: FeePerTicket, Value: 100}}}, false},
+		{"unknown kind", Rules{Fees: []FeeRule{{Label: "Fee", Kind: "FLAT", Value: 100}}}, false},
+		{"negative fee", Rules{Fees: []FeeRule{{Label: "Fee", Kind: FeePercentage, Value: -1}}}, false},
+		{"missing label", Rules{Taxes: []TaxRate{{RateBps: 1800, AppliesTo: TaxOnAll}}}, false},
+		{"rate above 100%", Rules{Taxes: []TaxRate{{Label: "GST", RateBps: 10001, AppliesTo: TaxOnAll}}}, false},
+		{"unknown base", Rules{Taxes: []TaxRate{{Label: "GST", RateBps: 1800, AppliesTo: "TOTAL"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Expected valid, got %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidRules) {
+				t.Errorf("Expected ErrInvalidRules, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Models for venue fee rules and tax rates
+
+package fees
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// Fee rule kinds
+const (
+	FeePerTicket  = "PER_TICKET" // Value is minor units per ticket
+	FeePercentage = "PERCENTAGE" // Value is basis points of the fare after discounts
+)
+
+// What a tax rate is charged on
+const (
+	TaxOnFare = "FARE"
+	TaxOnFees = "FEES"
+	TaxOnAll  = "ALL"
+)
+
+// DefaultVenue holds the rules used by venues without their own
+const DefaultVenue = "*"
+
+// ErrInvalidRules is returned when fee rules or tax rates are rejected
+var ErrInvalidRules = errors.New("invalid fee rules")
+
+// FeeRule is a booking fee charged by a venue
+type FeeRule struct {
+	Label    string `json:"label"`
+	Kind     string `json:"kind"`
+	Value    int64  `json:"value"`
+	Currency string `json:"currency,omitempty"` // required for PER_TICKET
+}
+
+// TaxRate is a tax charged by a venue, in basis points (1800 = 18%)
+type TaxRate struct {
+	Label     string `json:"label"`
+	RateBps   int64  `json:"rate_bps"`
+	AppliesTo string `json:"applies_to"` // FARE, FEES or ALL
+}
+
+// Rules are the fees and taxes of a venue
+type Rules struct {
+	Venue string    `json:"venue"`
+	Fees  []FeeRule `json:"fees"`
+	Taxes []TaxRate `json:"taxes"`
+}
+
+// Breakdown is a priced booking split into line items
+type Breakdown struct {
+	Items []money.LineItem `json:"items"`
+	Total money.Money      `json:"total"`
+}
+
+// Validate checks that every fee and tax is well formed
+func (r Rules) Validate() error {
+	for _, f := range r.Fees {
+		if f.Label == "" {
+			return fmt.Errorf("%w: fee label is required", ErrInvalidRules)
+		}
+		switch f.Kind {
+		case FeePerTicket:
+			if _, err := money.NormalizeCurrency(f.Currency); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidRules, f.Label, err)
+			}
+		case FeePercentage:
+		default:
+			return fmt.Errorf("%w: %s: kind must be PER_TICKET or PERCENTAGE", ErrInvalidRules, f.Label)
+		}
+		if f.Value < 0 {
+			return fmt.Errorf("%w: %s: value must not be negative", ErrInvalidRules, f.Label)
+		}
+	}
+	for _, t := range r.Taxes {
+		if t.Label == "" {
+			return fmt.Errorf("%w: tax label is required", ErrInvalidRules)
+		}
+		if t.RateBps < 0 || t.RateBps > 10000 {
+			return fmt.Errorf("%w: %s: rate must be between 0 and 10000 bps", ErrInvalidRules, t.Label)
+		}
+		switch t.AppliesTo {
+		case TaxOnFare, TaxOnFees, TaxOnAll:
+		default:
+			return fmt.Errorf("%w: %s: applies_to must be FARE, FEES or ALL", ErrInvalidRules, t.Label)
+		}
+	}
+	return nil
+}
+
+// Calculate breaks a booking into fare, discounts, fees and taxes. Discounts are given as
+// positive amounts and become negative lines. Percentage fees and taxes on the fare are
+// charged on the fare after discounts.
+func Calculate(fare money.Money, discounts []money.LineItem, tickets int, rules Rules) (*Breakdown, error) {
+	items := []money.LineItem{{Kind: money.LineBaseFare, Label: "Tickets", Amount: fare}}
+
+	netFare := fare
+	for _, d := range discounts {
+		if !d.Amount.IsPositive() {
+			continue
+		}
+		var err error
+		if netFare, err = netFare.Sub(d.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, money.LineItem{
+			Kind:   money.LineDiscount,
+			Label:  d.Label,
+			Amount: money.Money{Amount: -d.Amount.Amount, Currency: d.Amount.Currency},
+		})
+	}
+
+	feeTotal := money.Zero(fare.Currency)
+	for _, f := range rules.Fees {
+		var fee money.Money
+		switch f.Kind {
+		case FeePerTicket:
+			fee = money.Money{Amount: f.Value, Currency: f.Currency}.Mul(int64(tickets))
+		case FeePercentage:
+			fee = money.PercentOf(netFare, f.Value)
+		}
+		var err error
+		if feeTotal, err = feeTotal.Add(fee); err != nil {
+			return nil, fmt.Errorf("fee %s: %w", f.Label, err)
+		}
+		items = append(items, money.LineItem{Kind: money.LineBookingFee, Label: f.Label, Amount: fee})
+	}
+
+	for _, t := range rules.Taxes {
+		base := netFare
+		switch t.AppliesTo {
+		case TaxOnFees:
+			base = feeTotal
+		case TaxOnAll:
+			base = money.Money{Amount: netFare.Amount + feeTotal.Amount, Currency: fare.Currency}
+		}
+		items = append(items, money.LineItem{Kind: money.LineTax, Label: t.Label, Amount: money.PercentOf(base, t.RateBps)})
+	}
+
+	total, err := money.SumLineItems(items)
+	if err != nil {
+		return nil, err
+	}
+	return &Breakdown{Items: items, Total: total}, nil
+}
@@ -0,0 +1,28 @@
+// Query handler for venue fee and tax read operations (CQRS)
+
+package fees
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetVenueRules - Query handler for the fees and taxes charged at a venue
+func (h *QueryHandler) GetVenueRules(c *gin.Context) {
+	rules, err := h.QueryService.GetVenueRules(c.Request.Context(), c.Param("venue"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get fee rules: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
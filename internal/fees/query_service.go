@@ -0,0 +1,50 @@
+// Query service for venue fee and tax read operations (CQRS)
+
+package fees
+
+import (
+	"context"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// QueryService reads rules from the Command DB so checkout always uses the current rates
+type QueryService struct {
+	Repo *Repository
+}
+
+func NewQueryService(repo *Repository) *QueryService {
+	return &QueryService{Repo: repo}
+}
+
+// GetVenueRules - Query to get the rules that apply at a venue, falling back to the defaults
+func (s *QueryService) GetVenueRules(ctx context.Context, venue string) (*Rules, error) {
+	if venue != "" && venue != DefaultVenue {
+		own, err := s.Repo.HasRules(ctx, venue)
+		if err != nil {
+			return nil, err
+		}
+		if own {
+			return s.Repo.GetRules(ctx, venue)
+		}
+	}
+	return s.Repo.GetRules(ctx, DefaultVenue)
+}
+
+// BreakdownForShow - Query to price a booking's fees and taxes at the show's venue.
+// showID 0 uses the default rules.
+func (s *QueryService) BreakdownForShow(ctx context.Context, showID int, fare money.Money, discounts []money.LineItem, tickets int) (*Breakdown, error) {
+	venue := ""
+	if showID > 0 {
+		var err error
+		if venue, err = s.Repo.GetVenueForShow(ctx, showID); err != nil {
+			return nil, err
+		}
+	}
+
+	rules, err := s.GetVenueRules(ctx, venue)
+	if err != nil {
+		return nil, err
+	}
+	return Calculate(fare, discounts, tickets, *rules)
+}
@@ -0,0 +1,116 @@
+package fees
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+// ReplaceRules replaces all fee rules and tax rates of a venue
+func (r *Repository) ReplaceRules(ctx context.Context, rules Rules) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM venue_fee_rules WHERE venue = $1", rules.Venue); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM venue_tax_rates WHERE venue = $1", rules.Venue); err != nil {
+		return err
+	}
+
+	for i, f := range rules.Fees {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO venue_fee_rules (venue, position, label, kind, value, currency)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		`, rules.Venue, i, f.Label, f.Kind, f.Value, f.Currency)
+		if err != nil {
+			return err
+		}
+	}
+	for i, t := range rules.Taxes {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO venue_tax_rates (venue, position, label, rate_bps, applies_to)
+			VALUES ($1, $2, $3, $4, $5)
+		`, rules.Venue, i, t.Label, t.RateBps, t.AppliesTo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetRules returns the rules configured for exactly this venue
+func (r *Repository) GetRules(ctx context.Context, venue string) (*Rules, error) {
+	rules := &Rules{Venue: venue, Fees: []FeeRule{}, Taxes: []TaxRate{}}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT label, kind, value, COALESCE(currency, '')
+		FROM venue_fee_rules WHERE venue = $1 ORDER BY position
+	`, venue)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var f FeeRule
+		if err := rows.Scan(&f.Label, &f.Kind, &f.Value, &f.Currency); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rules.Fees = append(rules.Fees, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.DB.Query(ctx, `
+		SELECT label, rate_bps, applies_to
+		FROM venue_tax_rates WHERE venue = $1 ORDER BY position
+	`, venue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t TaxRate
+		if err := rows.Scan(&t.Label, &t.RateBps, &t.AppliesTo); err != nil {
+			return nil, err
+		}
+		rules.Taxes = append(rules.Taxes, t)
+	}
+	return rules, rows.Err()
+}
+
+// HasRules reports whether a venue has its own rules
+func (r *Repository) HasRules(ctx context.Context, venue string) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM venue_fee_rules WHERE venue = $1)
+		    OR EXISTS (SELECT 1 FROM venue_tax_rates WHERE venue = $1)
+	`, venue).Scan(&exists)
+	return exists, err
+}
+
+// GetVenueForShow returns the venue a show plays at
+func (r *Repository) GetVenueForShow(ctx context.Context, showID int) (string, error) {
+	var venue string
+	err := r.DB.QueryRow(ctx, "SELECT theater FROM shows WHERE id = $1", showID).Scan(&venue)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return venue, err
+}
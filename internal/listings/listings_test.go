@@ -0,0 +1,79 @@
+package listings
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// TestGroup tests that listings are grouped by movie, then venue, in showtime order
+func TestGroup(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2024, 3, 1, hour, 0, 0, 0, time.UTC) }
+	price := func(amount int64) *money.Money { return &money.Money{Amount: amount, Currency: "INR"} }
+	rows := []Listing{
+		{ShowID: 4, MovieID: 2, MovieName: "Zodiac", Venue: "Screen 1", StartTime: at(21), TotalSeats: 10, RemainingSeats: 0},
+		{ShowID: 3, MovieID: 1, MovieName: "Alien", Venue: "Screen 2", StartTime: at(18), TotalSeats: 10, RemainingSeats: 4, LowestPrice: price(30000)},
+		{ShowID: 2, MovieID: 1, MovieName: "Alien", Venue: "Screen 1", StartTime: at(20), TotalSeats: 10, RemainingSeats: 9, LowestPrice: price(25000)},
+		{ShowID: 1, MovieID: 1, MovieName: "Alien", Venue: "Screen 1", StartTime: at(14), TotalSeats: 10, RemainingSeats: 2, LowestPrice: price(20000)},
+	}
+
+	movies := group(rows)
+	if len(movies) != 2 || movies[0].Name != "Alien" || movies[1].Name != "Zodiac" {
+		t.Fatalf("Expected Alien then Zodiac, got %+v", movies)
+	}
+
+	alien := movies[0]
+	if len(alien.Venues) != 2 || alien.Venues[0].Venue != "Screen 1" || alien.Venues[1].Venue != "Screen 2" {
+		t.Fatalf("Expected Screen 1 then Screen 2, got %+v", alien.Venues)
+	}
+	times := alien.Venues[0].Showtimes
+	if len(times) != 2 || times[0].ShowID != 1 || times[1].ShowID != 2 {
+		t.Errorf("Expected shows 1 then 2 at Screen 1, got %+v", times)
+	}
+	if alien.LowestPrice == nil || alien.LowestPrice.Amount != 20000 {
+		t.Errorf("Expected Alien from 20000, got %v", alien.LowestPrice)
+	}
+
+	zodiac := movies[1].Venues[0].Showtimes[0]
+	if !zodiac.SoldOut || movies[1].LowestPrice != nil {
+		t.Errorf("Expected Zodiac sold out without a price, got %+v", zodiac)
+	}
+}
+
+// TestGroupEmpty tests that a day without shows lists no movies rather than null
+func TestGroupEmpty(t *testing.T) {
+	movies := group(nil)
+	if movies == nil || len(movies) != 0 {
+		t.Errorf("Expected an empty list, got %v", movies)
+	}
+}
+
+// TestParseDate tests the listings day parameter
+func TestParseDate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		date     string
+		expected string
+		err      error
+	}{
+		{"Default today", "", "2024-03-01", nil},
+		{"Given day", "2024-03-05", "2024-03-05", nil},
+		{"Not a date", "tomorrow", "", ErrInvalidDate},
+		{"Wrong layout", "05/03/2024", "", ErrInvalidDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDate(tt.date, now)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Models for the "what's on" listings read model
+
+package listings
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// dateLayout is how listing days are written
+const dateLayout = "2006-01-02"
+
+// ErrInvalidDate is returned when the listings day isn't a YYYY-MM-DD date
+var ErrInvalidDate = errors.New("date must be YYYY-MM-DD")
+
+// Listing is the denormalized read row of one show
+type Listing struct {
+	ShowID         int
+	MovieID        int
+	MovieName      string
+	Duration       int
+	Certification  string
+	PosterURL      string
+	Languages      []string
+	Formats        []string
+	Venue          string
+	VenueID        *int // nil for a theater that isn't a managed venue
+	City           string
+	Screen         string
+	TimeZone       string // the venue's; start and end are given in it
+	StartTime      time.Time
+	EndTime        time.Time
+	TotalSeats     int
+	RemainingSeats int
+	LowestPrice    *money.Money // nil when no seat with a price is left
+}
+
+// Day is everything on in a city on one day, grouped by movie and then by venue
+type Day struct {
+	Date   string         `json:"date"`
+	City   string         `json:"city,omitempty"`
+	Movies []MovieListing `json:"movies"`
+}
+
+// MovieListing is a movie and the venues showing it that day
+type MovieListing struct {
+	MovieID       int            `json:"movie_id"`
+	Name          string         `json:"name"`
+	Duration      int            `json:"duration"`
+	Certification string         `json:"certification,omitempty"`
+	PosterURL     string         `json:"poster_url,omitempty"`
+	Languages     []string       `json:"languages"`
+	Formats       []string       `json:"formats"`
+	LowestPrice   *money.Money   `json:"lowest_price,omitempty"`
+	Venues        []VenueListing `json:"venues"`
+}
+
+// VenueListing is a venue's showtimes of one movie
+type VenueListing struct {
+	Venue     string     `json:"venue"`
+	VenueID   *int       `json:"venue_id,omitempty"`
+	City      string     `json:"city,omitempty"`
+	TimeZone  string     `json:"time_zone"`
+	Showtimes []Showtime `json:"showtimes"`
+}
+
+// Showtime is one show with how many seats are left and the cheapest of them
+type Showtime struct {
+	ShowID         int          `json:"show_id"`
+	Screen         string       `json:"screen,omitempty"`
+	StartTime      time.Time    `json:"start_time"`
+	EndTime        time.Time    `json:"end_time"`
+	TotalSeats     int          `json:"total_seats"`
+	RemainingSeats int          `json:"remaining_seats"`
+	SoldOut        bool         `json:"sold_out"`
+	LowestPrice    *money.Money `json:"lowest_price,omitempty"`
+}
+
+// group arranges listings into movies by name, venues by name and showtimes by start.
+// A movie's lowest price is the cheapest of its showtimes in the same currency as the
+// first priced one.
+func group(rows []Listing) []MovieListing {
+	sorted := append([]Listing(nil), rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.MovieName != b.MovieName {
+			return a.MovieName < b.MovieName
+		}
+		if a.MovieID != b.MovieID {
+			return a.MovieID < b.MovieID
+		}
+		if a.Venue != b.Venue {
+			return a.Venue < b.Venue
+		}
+		if !a.StartTime.Equal(b.StartTime) {
+			return a.StartTime.Before(b.StartTime)
+		}
+		return a.ShowID < b.ShowID
+	})
+
+	movies := []MovieListing{}
+	for _, l := range sorted {
+		if len(movies) == 0 || movies[len(movies)-1].MovieID != l.MovieID {
+			movies = append(movies, MovieListing{
+				MovieID:       l.MovieID,
+				Name:          l.MovieName,
+				Duration:      l.Duration,
+				Certification: l.Certification,
+				PosterURL:     l.PosterURL,
+				Languages:     l.Languages,
+				Formats:       l.Formats,
+				Venues:        []VenueListing{},
+			})
+		}
+		m := &movies[len(movies)-1]
+		if len(m.Venues) == 0 || m.Venues[len(m.Venues)-1].Venue != l.Venue {
+			m.Venues = append(m.Venues, VenueListing{Venue: l.Venue, VenueID: l.VenueID, City: l.City, TimeZone: l.TimeZone, Showtimes: []Showtime{}})
+		}
+		v := &m.Venues[len(m.Venues)-1]
+		v.Showtimes = append(v.Showtimes, Showtime{
+			ShowID:         l.ShowID,
+			Screen:         l.Screen,
+			StartTime:      l.StartTime,
+			EndTime:        l.EndTime,
+			TotalSeats:     l.TotalSeats,
+			RemainingSeats: l.RemainingSeats,
+			SoldOut:        l.TotalSeats > 0 && l.RemainingSeats == 0,
+			LowestPrice:    l.LowestPrice,
+		})
+		if p := l.LowestPrice; p != nil {
+			if m.LowestPrice == nil || p.Currency == m.LowestPrice.Currency && p.Amount < m.LowestPrice.Amount {
+				m.LowestPrice = p
+			}
+		}
+	}
+	return movies
+}
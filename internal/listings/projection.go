@@ -0,0 +1,180 @@
+// Listings read model updater (CQRS projection)
+
+package listings
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Projection keeps the Query DB listings table in step with shows, movies, bookings and
+// price lists
+type Projection struct {
+	QueryDB *pgxpool.Pool
+	Repo    *Repository
+}
+
+func NewProjection(queryDB *pgxpool.Pool, repo *Repository) *Projection {
+	return &Projection{QueryDB: queryDB, Repo: repo}
+}
+
+// Subscribe re-projects the shows each event touches
+func (p *Projection) Subscribe(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(events.EventShowCreated, p.onShowEvent)
+	dispatcher.Subscribe(events.EventShowUpdated, p.onShowEvent)
+	dispatcher.Subscribe(events.EventShowDeleted, p.onShowEvent)
+	dispatcher.Subscribe(events.EventShowCancelled, p.onShowEvent)
+	dispatcher.Subscribe(events.EventPriceChanged, p.onShowEvent)
+	dispatcher.Subscribe(events.EventSeatsAdded, p.onShowEvent)
+	dispatcher.Subscribe(events.EventMovieUpdated, p.onMovieEvent)
+	dispatcher.Subscribe(events.EventVenueUpdated, p.onVenueEvent)
+	dispatcher.Subscribe(events.EventTicketReserved, p.onSeatEvent)
+	dispatcher.Subscribe(events.EventTicketConfirmed, p.onSeatEvent)
+	dispatcher.Subscribe(events.EventTicketCancelled, p.onSeatEvent)
+}
+
+func (p *Projection) onShowEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(payload.ShowID)
+	if err != nil {
+		return nil
+	}
+	return p.Project(context.Background(), id)
+}
+
+func (p *Projection) onMovieEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(payload.MovieID)
+	if err != nil {
+		return nil
+	}
+	ctx := context.Background()
+	shows, err := p.Repo.ShowsOfMovie(ctx, id)
+	if err != nil {
+		return err
+	}
+	return p.projectAll(ctx, shows)
+}
+
+// onVenueEvent re-projects a venue's shows, whose days and times depend on its zone
+func (p *Projection) onVenueEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	shows, err := p.Repo.ShowsAtVenue(ctx, payload.Venue)
+	if err != nil {
+		return err
+	}
+	return p.projectAll(ctx, shows)
+}
+
+func (p *Projection) projectAll(ctx context.Context, shows []int) error {
+	for _, showID := range shows {
+		if err := p.Project(ctx, showID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onSeatEvent handles booking events, which only some publishers tag with the show, so
+// the show is otherwise found through the seat
+func (p *Projection) onSeatEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	id, err := strconv.Atoi(payload.ShowID)
+	if err != nil {
+		id, err = p.Repo.ShowOfSeat(ctx, payload.SeatID)
+		if errors.Is(err, ErrShowNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return p.Project(ctx, id)
+}
+
+// Project writes the show's current listing to the Query DB, or removes it once the show
+// is gone or cancelled. It is listed on the day it starts at the venue.
+func (p *Projection) Project(ctx context.Context, showID int) error {
+	l, err := p.Repo.Get(ctx, showID)
+	if errors.Is(err, ErrShowNotFound) {
+		_, err = p.QueryDB.Exec(ctx, "DELETE FROM listings WHERE show_id = $1", showID)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return p.upsert(ctx, l)
+}
+
+// Rebuild projects every show and drops listings whose show no longer exists
+func (p *Projection) Rebuild(ctx context.Context) error {
+	ids, err := p.Repo.ListShowIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if err := p.projectAll(ctx, ids); err != nil {
+		return err
+	}
+	if _, err := p.QueryDB.Exec(ctx, "DELETE FROM listings WHERE NOT (show_id = ANY($1))", ids); err != nil {
+		return err
+	}
+	log.Printf("🗓️ Projected %d listings into the query database", len(ids))
+	return nil
+}
+
+func (p *Projection) upsert(ctx context.Context, l *Listing) error {
+	var amount *int64
+	var currency *string
+	if l.LowestPrice != nil {
+		amount, currency = &l.LowestPrice.Amount, &l.LowestPrice.Currency
+	}
+	_, err := p.QueryDB.Exec(ctx, `
+		INSERT INTO listings (show_id, movie_id, movie_name, duration, certification, poster_url,
+			languages, formats, venue, venue_id, city, screen, time_zone, show_date, start_time, end_time,
+			total_seats, remaining_seats, lowest_price, currency, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14::date, $15, $16, $17, $18, $19, $20, NOW())
+		ON CONFLICT (show_id) DO UPDATE SET
+			movie_id = EXCLUDED.movie_id,
+			movie_name = EXCLUDED.movie_name,
+			duration = EXCLUDED.duration,
+			certification = EXCLUDED.certification,
+			poster_url = EXCLUDED.poster_url,
+			languages = EXCLUDED.languages,
+			formats = EXCLUDED.formats,
+			venue = EXCLUDED.venue,
+			venue_id = EXCLUDED.venue_id,
+			city = EXCLUDED.city,
+			screen = EXCLUDED.screen,
+			time_zone = EXCLUDED.time_zone,
+			show_date = EXCLUDED.show_date,
+			start_time = EXCLUDED.start_time,
+			end_time = EXCLUDED.end_time,
+			total_seats = EXCLUDED.total_seats,
+			remaining_seats = EXCLUDED.remaining_seats,
+			lowest_price = EXCLUDED.lowest_price,
+			currency = EXCLUDED.currency,
+			updated_at = EXCLUDED.updated_at
+	`, l.ShowID, l.MovieID, l.MovieName, l.Duration, l.Certification, l.PosterURL,
+		l.Languages, l.Formats, l.Venue, l.VenueID, l.City, l.Screen, l.TimeZone, l.StartTime.Format(dateLayout), l.StartTime, l.EndTime,
+		l.TotalSeats, l.RemainingSeats, amount, currency)
+	return err
+}
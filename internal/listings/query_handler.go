@@ -0,0 +1,41 @@
+// Query handler for "what's on" listings (CQRS)
+
+package listings
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetListings - Query handler for what's on in a city, or at one venue (?venue_id=), on a day
+func (h *QueryHandler) GetListings(c *gin.Context) {
+	venueID := 0
+	if v := c.Query("venue_id"); v != "" {
+		var err error
+		if venueID, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue_id"})
+			return
+		}
+	}
+	day, err := h.QueryService.GetDay(c.Request.Context(), c.Query("date"), c.Query("city"), venueID)
+	if errors.Is(err, ErrInvalidDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch listings"})
+		return
+	}
+	c.JSON(http.StatusOK, day)
+}
@@ -0,0 +1,83 @@
+// Query service for "what's on" listings (CQRS)
+
+package listings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/hitorii/ticket-booking/internal/venue"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type QueryService struct {
+	DB       *pgxpool.Pool
+	Location *time.Location // the zone "today" is in when no date is given
+}
+
+func NewQueryService(db *pgxpool.Pool) *QueryService {
+	return &QueryService{DB: db}
+}
+
+// parseDate reads a YYYY-MM-DD day, defaulting to today
+func parseDate(date string, now time.Time) (string, error) {
+	if date == "" {
+		return now.Format(dateLayout), nil
+	}
+	d, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDate, date)
+	}
+	return d.Format(dateLayout), nil
+}
+
+// GetDay - Query to list the movies on a day with their showtimes per venue. Shows are
+// on the day they start at their venue, and showtimes are given in its zone. An empty
+// city and a zero venueID match every venue.
+func (s *QueryService) GetDay(ctx context.Context, date, city string, venueID int) (*Day, error) {
+	now := time.Now()
+	if s.Location != nil {
+		now = now.In(s.Location)
+	}
+	date, err := parseDate(date, now)
+	if err != nil {
+		return nil, err
+	}
+	city = strings.TrimSpace(city)
+
+	rows, err := s.DB.Query(ctx, `
+		SELECT show_id, movie_id, movie_name, duration, certification, poster_url, languages, formats,
+			venue, venue_id, city, screen, time_zone, start_time, end_time, total_seats, remaining_seats, lowest_price, currency
+		FROM listings
+		WHERE show_date = $1::date AND ($2 = '' OR lower(city) = lower($2)) AND ($3 = 0 OR venue_id = $3)
+	`, date, city, venueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var listings []Listing
+	for rows.Next() {
+		var l Listing
+		var amount *int64
+		var currency *string
+		err := rows.Scan(&l.ShowID, &l.MovieID, &l.MovieName, &l.Duration, &l.Certification, &l.PosterURL,
+			&l.Languages, &l.Formats, &l.Venue, &l.VenueID, &l.City, &l.Screen, &l.TimeZone, &l.StartTime, &l.EndTime,
+			&l.TotalSeats, &l.RemainingSeats, &amount, &currency)
+		if err != nil {
+			return nil, err
+		}
+		l.StartTime, l.EndTime = venue.In(l.StartTime, l.TimeZone), venue.In(l.EndTime, l.TimeZone)
+		if amount != nil && currency != nil {
+			l.LowestPrice = &money.Money{Amount: *amount, Currency: *currency}
+		}
+		listings = append(listings, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &Day{Date: date, City: city, Movies: group(listings)}, nil
+}
@@ -0,0 +1,115 @@
+// Repository that reads listings from the Command DB
+
+package listings
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/hitorii/ticket-booking/internal/venue"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrShowNotFound is returned when the show behind a listing no longer exists
+var ErrShowNotFound = errors.New("show not found")
+
+// Repository works out listings from the shows, movies, venues, seats, reservations and
+// price lists in the Command DB. Showtimes are given in the venue's zone, from Zones.
+type Repository struct {
+	DB    *pgxpool.Pool
+	Zones *venue.QueryService
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+// listingQuery computes a show's listing. A seat is left while it has no held or booked
+// reservation, and the lowest price is the cheapest tier that still has a seat left.
+// Cancelled shows have no listing.
+const listingQuery = `
+	WITH free AS (
+		SELECT s.tier FROM seats s
+		WHERE s.show_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM reservations r WHERE r.seat_id = s.id AND r.status IN ('HELD', 'BOOKED'))
+	)
+	SELECT sh.id, sh.movie_id, m.name, m.duration, COALESCE(m.certification, ''), COALESCE(m.poster_url, ''),
+		m.languages, m.formats, sh.theater, v.id, COALESCE(c.name, ''), COALESCE(sc.name, ''), sh.start_time, sh.end_time,
+		(SELECT COUNT(*) FROM seats WHERE show_id = sh.id),
+		(SELECT COUNT(*) FROM free),
+		lp.amount, lp.currency
+	FROM shows sh
+	JOIN movies m ON m.id = sh.movie_id
+	LEFT JOIN venues v ON v.name = sh.theater
+	LEFT JOIN cities c ON c.id = v.city_id
+	LEFT JOIN screens sc ON sc.id = sh.screen_id
+	LEFT JOIN LATERAL (
+		SELECT p.amount, p.currency FROM show_prices p
+		WHERE p.show_id = sh.id AND p.tier IN (SELECT tier FROM free)
+		ORDER BY p.amount
+		LIMIT 1
+	) lp ON true
+	WHERE sh.id = $1 AND sh.status <> 'CANCELLED'`
+
+// Get returns the current listing of a show
+func (r *Repository) Get(ctx context.Context, showID int) (*Listing, error) {
+	var l Listing
+	var amount *int64
+	var currency *string
+	err := r.DB.QueryRow(ctx, listingQuery, showID).Scan(
+		&l.ShowID, &l.MovieID, &l.MovieName, &l.Duration, &l.Certification, &l.PosterURL,
+		&l.Languages, &l.Formats, &l.Venue, &l.VenueID, &l.City, &l.Screen, &l.StartTime, &l.EndTime,
+		&l.TotalSeats, &l.RemainingSeats, &amount, &currency,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrShowNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if amount != nil && currency != nil {
+		l.LowestPrice = &money.Money{Amount: *amount, Currency: *currency}
+	}
+	loc, err := r.Zones.Location(ctx, l.Venue)
+	if err != nil {
+		return nil, err
+	}
+	l.TimeZone = loc.String()
+	l.StartTime, l.EndTime = l.StartTime.In(loc), l.EndTime.In(loc)
+	return &l, nil
+}
+
+// ShowOfSeat returns the show a seat belongs to
+func (r *Repository) ShowOfSeat(ctx context.Context, seatID string) (int, error) {
+	var showID int
+	err := r.DB.QueryRow(ctx, "SELECT show_id FROM seats WHERE id = $1", seatID).Scan(&showID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrShowNotFound
+	}
+	return showID, err
+}
+
+// ShowsOfMovie returns the IDs of a movie's shows
+func (r *Repository) ShowsOfMovie(ctx context.Context, movieID int) ([]int, error) {
+	return r.showIDs(ctx, "SELECT id FROM shows WHERE movie_id = $1 ORDER BY id", movieID)
+}
+
+// ShowsAtVenue returns the IDs of a venue's shows
+func (r *Repository) ShowsAtVenue(ctx context.Context, venue string) ([]int, error) {
+	return r.showIDs(ctx, "SELECT id FROM shows WHERE theater = $1 ORDER BY id", venue)
+}
+
+// ListShowIDs returns the IDs of every show
+func (r *Repository) ListShowIDs(ctx context.Context) ([]int, error) {
+	return r.showIDs(ctx, "SELECT id FROM shows ORDER BY id")
+}
+
+func (r *Repository) showIDs(ctx context.Context, query string, args ...any) ([]int, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
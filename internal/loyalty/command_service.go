@@ -0,0 +1,80 @@
+// Command service for loyalty point write operations (CQRS)
+
+package loyalty
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+type CommandService struct {
+	Repo    *Repository
+	Program Program
+}
+
+func NewCommandService(repo *Repository, program Program) *CommandService {
+	return &CommandService{Repo: repo, Program: program}
+}
+
+// Earn - Command to credit the points for a captured payment. Repeated calls for the
+// same payment are ignored and return nil.
+func (s *CommandService) Earn(ctx context.Context, userID, paymentID, bookingID string, charged money.Money) (*Entry, error) {
+	points := s.Program.Earned(charged)
+	if points <= 0 {
+		return nil, nil
+	}
+
+	e := &Entry{
+		Kind:        EntryEarn,
+		Points:      points,
+		PaymentID:   paymentID,
+		BookingID:   bookingID,
+		Amount:      &charged,
+		Description: "Earned on payment " + paymentID,
+	}
+	inserted, err := s.Repo.Earn(ctx, userID, e)
+	if err != nil || !inserted {
+		return nil, err
+	}
+	return e, nil
+}
+
+// ReverseEarned - Command to take back the points earned on a payment after a refund
+func (s *CommandService) ReverseEarned(ctx context.Context, paymentID string, refunded money.Money) (*Entry, error) {
+	return s.Repo.Reverse(ctx, paymentID, refunded, fmt.Sprintf("Refund of %s on payment %s", refunded, paymentID))
+}
+
+// Redeem - Command to spend points on the fare of a booking's payment. It returns the
+// discount they buy.
+func (s *CommandService) Redeem(ctx context.Context, userID, paymentID, bookingID string, points int64, fare money.Money) (money.Money, error) {
+	if points <= 0 {
+		return money.Money{}, ErrInvalidPoints
+	}
+	if max := s.Program.MaxRedeemable(fare); points > max {
+		return money.Money{}, fmt.Errorf("%w: at most %d points on this booking", ErrRedeemLimit, max)
+	}
+
+	discount := s.Program.Value(points, fare.Currency)
+	e := &Entry{
+		Kind:        EntryRedeem,
+		Points:      -points,
+		PaymentID:   paymentID,
+		BookingID:   bookingID,
+		Amount:      &discount,
+		Description: "Redeemed on booking " + bookingID,
+	}
+	redemption, _, err := s.Repo.Redeem(ctx, userID, e)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return *redemption.Amount, nil
+}
+
+// Restore - Command to give back the points redeemed on a payment that won't be captured
+// or was refunded
+func (s *CommandService) Restore(ctx context.Context, paymentID, reason string) error {
+	_, err := s.Repo.Restore(ctx, paymentID, reason)
+	return err
+}
@@ -0,0 +1,75 @@
+package loyalty
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+func inr(amount int64) money.Money {
+	return money.Money{Amount: amount, Currency: "INR"}
+}
+
+// TestProgram tests earning, point value and redemption limits
+func TestProgram(t *testing.T) {
+	p := DefaultProgram()
+
+	if got := p.Earned(inr(61587)); got != 615 {
+		t.Errorf("Expected 615 points, got %d", got)
+	}
+	if got := p.Earned(inr(0)); got != 0 {
+		t.Errorf("Expected no points for nothing charged, got %d", got)
+	}
+	if got := p.Value(200, "INR"); got != inr(5000) {
+		t.Errorf("Expected 200 points to be worth 5000, got %s", got)
+	}
+	// Half of 50000 is 25000, which is 1000 points
+	if got := p.MaxRedeemable(inr(50000)); got != 1000 {
+		t.Errorf("Expected 1000 redeemable points, got %d", got)
+	}
+}
+
+// TestReversal tests proportional reversal of earned points on refunds
+func TestReversal(t *testing.T) {
+	tests := []struct {
+		name     string
+		earned   int64
+		reversed int64
+		charged  int64
+		refunded int64
+		expected int64
+	}{
+		{"full refund", 500, 0, 50000, 50000, 500},
+		{"half refund", 500, 0, 50000, 25000, 250},
+		{"rounded", 615, 0, 61587, 10000, 100},
+		{"capped at remaining", 500, 400, 50000, 25000, 100},
+		{"nothing left", 500, 500, 50000, 10000, 0},
+		{"nothing charged", 0, 0, 0, 10000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Reversal(tt.earned, tt.reversed, inr(tt.charged), inr(tt.refunded))
+			if got != tt.expected {
+				t.Errorf("Expected %d points, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestRedeemValidation tests redemptions rejected before touching the ledger
+func TestRedeemValidation(t *testing.T) {
+	s := NewCommandService(nil, DefaultProgram())
+
+	if _, err := s.Redeem(context.Background(), "user", "payment", "booking", 0, inr(50000)); !errors.Is(err, ErrInvalidPoints) {
+		t.Errorf("Expected ErrInvalidPoints, got %v", err)
+	}
+	if _, err := s.Redeem(context.Background(), "user", "payment", "booking", 1001, inr(50000)); !errors.Is(err, ErrRedeemLimit) {
+		t.Errorf("Expected ErrRedeemLimit, got %v", err)
+	}
+	if !errors.Is(ErrInsufficientPoints, ErrLoyalty) {
+		t.Error("Expected ErrInsufficientPoints to wrap ErrLoyalty")
+	}
+}
@@ -0,0 +1,96 @@
+// Models for loyalty points
+
+package loyalty
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// Ledger entry kinds. EARN and RESTORE add points, REVERSE and REDEEM take them away.
+const (
+	EntryEarn    = "EARN"    // points for a captured payment
+	EntryReverse = "REVERSE" // earned points taken back after a refund
+	EntryRedeem  = "REDEEM"  // points spent as a checkout discount
+	EntryRestore = "RESTORE" // redeemed points given back when the booking isn't paid for or is refunded
+)
+
+// ErrLoyalty is the base error for rejected redemptions; wrap it with %w
+var ErrLoyalty = errors.New("loyalty points rejected")
+
+var (
+	ErrInsufficientPoints = fmt.Errorf("%w: insufficient points", ErrLoyalty)
+	ErrRedeemLimit        = fmt.Errorf("%w: redemption exceeds the allowed share of the fare", ErrLoyalty)
+	ErrInvalidPoints      = fmt.Errorf("%w: points must be positive", ErrLoyalty)
+)
+
+// Program sets how points are earned and what they are worth
+type Program struct {
+	EarnPer100   int64 // points earned per 100 minor units charged
+	PointValue   int64 // minor units one point is worth at checkout
+	MaxRedeemBps int64 // largest share of the fare payable with points, in basis points
+}
+
+// DefaultProgram earns 1 point per 100 minor units and lets points pay for half a fare
+func DefaultProgram() Program {
+	return Program{EarnPer100: 1, PointValue: 25, MaxRedeemBps: 5000}
+}
+
+// Earned returns the points for a charged amount, rounded down
+func (p Program) Earned(charged money.Money) int64 {
+	if charged.Amount <= 0 {
+		return 0
+	}
+	return charged.Amount * p.EarnPer100 / 100
+}
+
+// Value returns what points are worth in a currency
+func (p Program) Value(points int64, currency string) money.Money {
+	return money.Money{Amount: points * p.PointValue, Currency: currency}
+}
+
+// MaxRedeemable returns the most points that may be spent on a fare
+func (p Program) MaxRedeemable(fare money.Money) int64 {
+	if p.PointValue <= 0 || fare.Amount <= 0 {
+		return 0
+	}
+	return money.PercentOf(fare, p.MaxRedeemBps).Amount / p.PointValue
+}
+
+// Reversal returns the earned points to take back for a refund of refunded out of charged,
+// never more than the points not already reversed
+func Reversal(earned, alreadyReversed int64, charged, refunded money.Money) int64 {
+	if charged.Amount <= 0 || refunded.Amount <= 0 {
+		return 0
+	}
+	points := (earned*refunded.Amount + charged.Amount/2) / charged.Amount
+	if remaining := earned - alreadyReversed; points > remaining {
+		points = remaining
+	}
+	if points < 0 {
+		return 0
+	}
+	return points
+}
+
+// Entry is one movement of a user's points; spent and reversed points are negative
+type Entry struct {
+	ID          string       `json:"id"`
+	Kind        string       `json:"kind"`
+	Points      int64        `json:"points"`
+	PaymentID   string       `json:"payment_id,omitempty"`
+	BookingID   string       `json:"booking_id,omitempty"`
+	Amount      *money.Money `json:"amount,omitempty"` // charged amount for EARN, discount for REDEEM
+	Description string       `json:"description,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// Summary is a user's points balance and ledger
+type Summary struct {
+	UserID  string  `json:"user_id"`
+	Balance int64   `json:"balance"`
+	History []Entry `json:"history"`
+}
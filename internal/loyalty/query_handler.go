@@ -0,0 +1,35 @@
+// Query handler for loyalty point read operations (CQRS)
+
+package loyalty
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/utils"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetSummary - Query handler for a user's points balance and history
+func (h *QueryHandler) GetSummary(c *gin.Context) {
+	userID := c.Param("id")
+	if err := utils.ValidateUUID("user_id", userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, err := h.QueryService.GetSummary(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get loyalty points: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
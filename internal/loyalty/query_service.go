@@ -0,0 +1,21 @@
+// Query service for loyalty point read operations (CQRS)
+
+package loyalty
+
+import (
+	"context"
+)
+
+// QueryService reads the ledger from the Command DB so balances match what checkout sees
+type QueryService struct {
+	Repo *Repository
+}
+
+func NewQueryService(repo *Repository) *QueryService {
+	return &QueryService{Repo: repo}
+}
+
+// GetSummary - Query to get a user's points balance and history
+func (s *QueryService) GetSummary(ctx context.Context, userID string) (*Summary, error) {
+	return s.Repo.GetSummary(ctx, userID)
+}
@@ -0,0 +1,259 @@
+package loyalty
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+// lockAccount opens the user's points account if needed and locks it for the transaction,
+// so balance checks and ledger writes for one user never interleave
+func lockAccount(ctx context.Context, tx pgx.Tx, userID string) (int64, error) {
+	_, err := tx.Exec(ctx, "INSERT INTO loyalty_accounts (user_id, balance) VALUES ($1, 0) ON CONFLICT (user_id) DO NOTHING", userID)
+	if err != nil {
+		return 0, err
+	}
+	var balance int64
+	err = tx.QueryRow(ctx, "SELECT balance FROM loyalty_accounts WHERE user_id = $1 FOR UPDATE", userID).Scan(&balance)
+	return balance, err
+}
+
+// insertEntry writes a ledger entry and moves the balance by its points. It reports false
+// when the entry was already recorded.
+func insertEntry(ctx context.Context, tx pgx.Tx, userID string, e *Entry) (bool, error) {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	var amount *int64
+	var currency *string
+	if e.Amount != nil {
+		amount, currency = &e.Amount.Amount, &e.Amount.Currency
+	}
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO loyalty_ledger (id, user_id, kind, points, payment_id, booking_id, amount, currency, description, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid, NULLIF($6, '')::uuid, $7, $8, $9, $10)
+		ON CONFLICT DO NOTHING
+	`, e.ID, userID, e.Kind, e.Points, e.PaymentID, e.BookingID, amount, currency, e.Description, e.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE loyalty_accounts SET balance = balance + $2, updated_at = NOW() WHERE user_id = $1", userID, e.Points)
+	return err == nil, err
+}
+
+// Earn records the points of a captured payment once, however often the event is delivered
+func (r *Repository) Earn(ctx context.Context, userID string, e *Entry) (bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockAccount(ctx, tx, userID); err != nil {
+		return false, err
+	}
+	inserted, err := insertEntry(ctx, tx, userID, e)
+	if err != nil || !inserted {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// Reverse takes back the points earned on a payment in proportion to refunded.
+// It returns the entry written, or nil when nothing was earned or everything was reversed.
+func (r *Repository) Reverse(ctx context.Context, paymentID string, refunded money.Money, description string) (*Entry, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID string
+	var earned int64
+	var charged money.Money
+	err = tx.QueryRow(ctx, `
+		SELECT user_id::text, points, amount, currency FROM loyalty_ledger
+		WHERE payment_id = $1 AND kind = 'EARN'
+	`, paymentID).Scan(&userID, &earned, &charged.Amount, &charged.Currency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := lockAccount(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	var reversed int64
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(-SUM(points), 0) FROM loyalty_ledger
+		WHERE payment_id = $1 AND kind = 'REVERSE'
+	`, paymentID).Scan(&reversed)
+	if err != nil {
+		return nil, err
+	}
+
+	points := Reversal(earned, reversed, charged, refunded)
+	if points == 0 {
+		return nil, nil
+	}
+	e := &Entry{Kind: EntryReverse, Points: -points, PaymentID: paymentID, Amount: &refunded, Description: description}
+	if _, err := insertEntry(ctx, tx, userID, e); err != nil {
+		return nil, err
+	}
+	return e, tx.Commit(ctx)
+}
+
+// activeRedemption returns the points a payment currently has redeemed
+func activeRedemption(ctx context.Context, q interface {
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
+}, paymentID string) (userID string, points int64, err error) {
+	err = q.QueryRow(ctx, `
+		SELECT user_id::text, -SUM(points) FROM loyalty_ledger
+		WHERE payment_id = $1 AND kind IN ('REDEEM', 'RESTORE')
+		GROUP BY user_id
+	`, paymentID).Scan(&userID, &points)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", 0, nil
+	}
+	return userID, points, err
+}
+
+// Redeem spends points on a payment. A payment that already has points redeemed returns
+// that redemption with replayed set instead of spending again.
+func (r *Repository) Redeem(ctx context.Context, userID string, e *Entry) (redemption *Entry, replayed bool, err error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	balance, err := lockAccount(ctx, tx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, active, err := activeRedemption(ctx, tx, e.PaymentID)
+	if err != nil {
+		return nil, false, err
+	}
+	if active > 0 {
+		existing := Entry{Kind: EntryRedeem, PaymentID: e.PaymentID, BookingID: e.BookingID}
+		var amount money.Money
+		err = tx.QueryRow(ctx, `
+			SELECT id, points, amount, currency, created_at FROM loyalty_ledger
+			WHERE payment_id = $1 AND kind = 'REDEEM'
+			ORDER BY created_at DESC LIMIT 1
+		`, e.PaymentID).Scan(&existing.ID, &existing.Points, &amount.Amount, &amount.Currency, &existing.CreatedAt)
+		if err != nil {
+			return nil, false, err
+		}
+		existing.Amount = &amount
+		return &existing, true, nil
+	}
+
+	if balance+e.Points < 0 {
+		return nil, false, ErrInsufficientPoints
+	}
+	if _, err := insertEntry(ctx, tx, userID, e); err != nil {
+		return nil, false, err
+	}
+	return e, false, tx.Commit(ctx)
+}
+
+// Restore gives back the points a payment has redeemed. It returns the points restored.
+func (r *Repository) Restore(ctx context.Context, paymentID, description string) (int64, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	userID, _, err := activeRedemption(ctx, tx, paymentID)
+	if err != nil || userID == "" {
+		return 0, err
+	}
+	if _, err := lockAccount(ctx, tx, userID); err != nil {
+		return 0, err
+	}
+	// Re-read under the account lock
+	_, active, err := activeRedemption(ctx, tx, paymentID)
+	if err != nil || active <= 0 {
+		return 0, err
+	}
+
+	var bookingID string
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(booking_id::text, '') FROM loyalty_ledger
+		WHERE payment_id = $1 AND kind = 'REDEEM'
+		ORDER BY created_at DESC LIMIT 1
+	`, paymentID).Scan(&bookingID)
+	if err != nil {
+		return 0, err
+	}
+	e := &Entry{Kind: EntryRestore, Points: active, PaymentID: paymentID, BookingID: bookingID, Description: description}
+	if _, err := insertEntry(ctx, tx, userID, e); err != nil {
+		return 0, err
+	}
+	return active, tx.Commit(ctx)
+}
+
+// GetSummary returns a user's balance and ledger, newest first
+func (r *Repository) GetSummary(ctx context.Context, userID string) (*Summary, error) {
+	summary := &Summary{UserID: userID, History: []Entry{}}
+	err := r.DB.QueryRow(ctx, "SELECT balance FROM loyalty_accounts WHERE user_id = $1", userID).Scan(&summary.Balance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return summary, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, kind, points, COALESCE(payment_id::text, ''), COALESCE(booking_id::text, ''),
+		       amount, COALESCE(currency, ''), COALESCE(description, ''), created_at
+		FROM loyalty_ledger
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Entry
+		var amount *int64
+		var currency string
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Points, &e.PaymentID, &e.BookingID, &amount, &currency, &e.Description, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if amount != nil {
+			e.Amount = &money.Money{Amount: *amount, Currency: currency}
+		}
+		summary.History = append(summary.History, e)
+	}
+	return summary, rows.Err()
+}
@@ -0,0 +1,61 @@
+// Event subscriber that accrues and reverses loyalty points
+
+package loyalty
+
+import (
+	"context"
+	"log"
+
+	"github.com/hitorii/ticket-booking/internal/events"
+)
+
+// Payment statuses the subscriber reacts to (see payments/state.go)
+const (
+	statusCaptured = "CAPTURED"
+	statusRefunded = "REFUNDED"
+)
+
+// Subscribe registers the loyalty handlers on the dispatcher
+func (s *CommandService) Subscribe(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(events.EventPaymentVerified, s.onPaymentVerified)
+	dispatcher.Subscribe(events.EventPaymentRefunded, s.onPaymentRefunded)
+}
+
+// onPaymentVerified earns points when a payment is captured
+func (s *CommandService) onPaymentVerified(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	if payload.Status != statusCaptured || payload.Amount == nil {
+		return nil
+	}
+
+	entry, err := s.Earn(context.Background(), payload.UserID, payload.PaymentID, payload.BookingID, *payload.Amount)
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		log.Printf("⭐ User %s earned %d points on payment %s", payload.UserID, entry.Points, payload.PaymentID)
+	}
+	return nil
+}
+
+// onPaymentRefunded takes back earned points and, on a full refund, restores redeemed ones
+func (s *CommandService) onPaymentRefunded(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	if payload.Amount != nil {
+		if _, err := s.ReverseEarned(ctx, payload.PaymentID, *payload.Amount); err != nil {
+			return err
+		}
+	}
+	if payload.Status == statusRefunded && payload.PaymentID != "" {
+		return s.Restore(ctx, payload.PaymentID, "Booking refunded")
+	}
+	return nil
+}
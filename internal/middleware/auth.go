@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/hitorii/ticket-booking/internal/user"
 	"gorm.io/gorm"
@@ -14,6 +15,33 @@ import (
 
 var jwtKey = []byte("supersecretkey")
 
+// Authenticate is the gin middleware for routes that need a signed-in user. It checks the
+// bearer token from /query/users/login and sets "user_id" on the context.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenStr, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || tokenStr == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing token"})
+			return
+		}
+		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+			return jwtKey, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+		if err != nil || !token.Valid {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+		claims, ok := token.Claims.(jwt.MapClaims)
+		userID, _ := claims["user_id"].(string)
+		if !ok || userID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			return
+		}
+		c.Set("user_id", userID)
+		c.Next()
+	}
+}
+
 // AuthMiddleware verifies JWT token and adds user to context
 func AuthMiddleware(db *gorm.DB) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -1,8 +1,13 @@
 package middleware
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // TestRateLimiter tests the RateLimiter struct
@@ -83,3 +88,55 @@ func TestAdminMiddleware(t *testing.T) {
 	// Just verify the function exists
 	_ = AdminMiddleware
 }
+
+// TestAuthenticate tests which bearer tokens let a request through
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sign := func(claims jwt.MapClaims) string {
+		s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	userID := "2f1c6f3e-8f0a-4f0e-9a55-3c1d2b7e9a10"
+	valid := sign(jwt.MapClaims{"user_id": userID, "exp": time.Now().Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"valid", "Bearer " + valid, http.StatusOK},
+		{"missing", "", http.StatusUnauthorized},
+		{"not bearer", valid, http.StatusUnauthorized},
+		{"expired", "Bearer " + sign(jwt.MapClaims{"user_id": userID, "exp": time.Now().Add(-time.Hour).Unix()}), http.StatusUnauthorized},
+		{"no expiry", "Bearer " + sign(jwt.MapClaims{"user_id": userID}), http.StatusUnauthorized},
+		{"no user", "Bearer " + sign(jwt.MapClaims{"exp": time.Now().Add(time.Hour).Unix()}), http.StatusUnauthorized},
+		{"other key", "Bearer " + func() string {
+			s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"user_id": userID, "exp": time.Now().Add(time.Hour).Unix()}).SignedString([]byte("other"))
+			return s
+		}(), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/", Authenticate(), func(c *gin.Context) {
+				if got := c.GetString("user_id"); got != userID {
+					t.Errorf("Expected user %s, got %q", userID, got)
+				}
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Line items that make up a charged amount
+
+package money
+
+// Line item kinds
+const (
+	LineBaseFare   = "BASE_FARE"
+	LineDiscount   = "DISCOUNT"
+	LineBookingFee = "BOOKING_FEE"
+	LineTax        = "TAX"
+)
+
+// LineItem is one row of a price breakdown; discounts are negative
+type LineItem struct {
+	Kind   string `json:"kind"`
+	Label  string `json:"label"`
+	Amount Money  `json:"amount"`
+}
+
+// SumLineItems totals a breakdown, failing if the items mix currencies
+func SumLineItems(items []LineItem) (Money, error) {
+	if len(items) == 0 {
+		return Money{}, nil
+	}
+	total := Zero(items[0].Amount.Currency)
+	for _, item := range items {
+		var err error
+		if total, err = total.Add(item.Amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// PercentOf returns bps basis points of amount, rounded half away from zero
+func PercentOf(amount Money, bps int64) Money {
+	v := amount.Amount * bps
+	if v >= 0 {
+		v = (v + 5000) / 10000
+	} else {
+		v = (v - 5000) / 10000
+	}
+	return Money{Amount: v, Currency: amount.Currency}
+}
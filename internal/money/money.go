@@ -0,0 +1,206 @@
+// Money type for amounts held in minor units with an ISO 4217 currency
+
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when a request does not specify a currency
+const DefaultCurrency = "INR"
+
+// ErrCurrencyMismatch is returned when amounts in different currencies are combined
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// ErrUnknownCurrency is returned for currency codes we don't support
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// currency describes how a currency is stored and displayed
+type currency struct {
+	Exponent int    // number of minor-unit digits (2 for INR paise, 0 for JPY)
+	Symbol   string // display symbol, empty to fall back to the ISO code
+}
+
+var currencies = map[string]currency{
+	"INR": {Exponent: 2, Symbol: "₹"},
+	"USD": {Exponent: 2, Symbol: "$"},
+	"EUR": {Exponent: 2, Symbol: "€"},
+	"GBP": {Exponent: 2, Symbol: "£"},
+	"AED": {Exponent: 2},
+	"SGD": {Exponent: 2},
+	"JPY": {Exponent: 0, Symbol: "¥"},
+	"KRW": {Exponent: 0, Symbol: "₩"},
+	"KWD": {Exponent: 3},
+	"BHD": {Exponent: 3},
+}
+
+// Money is an amount in minor units (e.g. paise, cents) of a currency
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NormalizeCurrency upper-cases a currency code and checks that it is supported
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := currencies[code]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCurrency, code)
+	}
+	return code, nil
+}
+
+// New creates a Money value after validating the currency code
+func New(amount int64, code string) (Money, error) {
+	code, err := NormalizeCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: code}, nil
+}
+
+// ParseMajor parses a decimal amount in major units (e.g. "1,250.50") into minor units
+func ParseMajor(value, code string) (Money, error) {
+	code, err := NormalizeCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	exp := currencies[code].Exponent
+
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	whole, frac, _ := strings.Cut(value, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places for %s", value, exp, code)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: code}, nil
+}
+
+// Zero returns a zero amount in the given currency
+func Zero(code string) Money {
+	return Money{Currency: code}
+}
+
+// Exponent returns the number of minor-unit digits for the currency
+func (m Money) Exponent() int {
+	return currencies[m.Currency].Exponent
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsPositive reports whether the amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// SameCurrency reports whether both values share a currency
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == o.Currency
+}
+
+// Add returns m + o, failing if the currencies differ
+func (m Money) Add(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o, failing if the currencies differ
+func (m Money) Sub(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns the amount multiplied by n
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// String formats the amount for display, e.g. "₹1,250.00", "¥1,250" or "KWD 1.250"
+func (m Money) String() string {
+	if symbol := currencies[m.Currency].Symbol; symbol != "" {
+		return m.format(symbol)
+	}
+	return m.format(m.Currency + " ")
+}
+
+// ISOString formats the amount with its currency code, e.g. "INR 1,250.00", for outputs
+// limited to ASCII such as PDF standard fonts
+func (m Money) ISOString() string {
+	return m.format(m.Currency + " ")
+}
+
+// format writes the amount in major units after prefix
+func (m Money) format(prefix string) string {
+	cur := currencies[m.Currency]
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	var scale int64 = 1
+	for i := 0; i < cur.Exponent; i++ {
+		scale *= 10
+	}
+
+	major := groupThousands(strconv.FormatInt(amount/scale, 10))
+	if cur.Exponent > 0 {
+		major += "." + fmt.Sprintf("%0*d", cur.Exponent, amount%scale)
+	}
+
+	return sign + prefix + major
+}
+
+// MarshalJSON includes a pre-formatted amount so clients don't need currency tables
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount    int64  `json:"amount"`
+		Currency  string `json:"currency"`
+		Formatted string `json:"formatted"`
+	}{m.Amount, m.Currency, m.String()})
+}
+
+// groupThousands inserts comma separators into a string of digits
+func groupThousands(digits string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
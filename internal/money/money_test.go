@@ -0,0 +1,147 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestNew tests currency validation on construction
+func TestNew(t *testing.T) {
+	m, err := New(1250, "inr")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if m.Currency != "INR" {
+		t.Errorf("Expected currency INR, got %s", m.Currency)
+	}
+
+	if _, err := New(100, "XYZ"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("Expected ErrUnknownCurrency, got %v", err)
+	}
+}
+
+// TestAddSub tests arithmetic and currency mismatch detection
+func TestAddSub(t *testing.T) {
+	a := Money{Amount: 1000, Currency: "INR"}
+	b := Money{Amount: 250, Currency: "INR"}
+
+	sum, err := a.Add(b)
+	if err != nil || sum.Amount != 1250 {
+		t.Errorf("Expected 1250, got %d (err %v)", sum.Amount, err)
+	}
+
+	diff, err := a.Sub(b)
+	if err != nil || diff.Amount != 750 {
+		t.Errorf("Expected 750, got %d (err %v)", diff.Amount, err)
+	}
+
+	_, err = a.Add(Money{Amount: 1, Currency: "USD"})
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+}
+
+// TestString tests per-currency formatting
+func TestString(t *testing.T) {
+	tests := []struct {
+		money    Money
+		expected string
+	}{
+		{Money{Amount: 125000, Currency: "INR"}, "₹1,250.00"},
+		{Money{Amount: 5, Currency: "USD"}, "$0.05"},
+		{Money{Amount: 1250, Currency: "JPY"}, "¥1,250"},
+		{Money{Amount: 1250, Currency: "KWD"}, "KWD 1.250"},
+		{Money{Amount: -199, Currency: "EUR"}, "-€1.99"},
+		{Money{Amount: 123456789, Currency: "AED"}, "AED 1,234,567.89"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, got)
+		}
+	}
+
+	if got := (Money{Amount: -125000, Currency: "INR"}).ISOString(); got != "-INR 1,250.00" {
+		t.Errorf("Expected -INR 1,250.00, got %s", got)
+	}
+}
+
+// TestJSONRoundTrip tests that formatted output doesn't break decoding
+func TestJSONRoundTrip(t *testing.T) {
+	data, err := json.Marshal(Money{Amount: 50000, Currency: "INR"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(data) != `{"amount":50000,"currency":"INR","formatted":"₹500.00"}` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	var m Money
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if m.Amount != 50000 || m.Currency != "INR" {
+		t.Errorf("Round trip mismatch: %+v", m)
+	}
+}
+
+// TestParseMajor tests parsing decimal amounts from provider files
+func TestParseMajor(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		expected int64
+		wantErr  bool
+	}{
+		{"1250.50", "INR", 125050, false},
+		{"1,250.5", "INR", 125050, false},
+		{"12", "USD", 1200, false},
+		{"1250", "JPY", 1250, false},
+		{"1.5", "JPY", 0, true},
+		{"-3.125", "KWD", -3125, false},
+		{"abc", "INR", 0, true},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMajor(tt.value, tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for %s %s", tt.value, tt.currency)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", tt.value, err)
+			continue
+		}
+		if m.Amount != tt.expected {
+			t.Errorf("Expected %d for %s, got %d", tt.expected, tt.value, m.Amount)
+		}
+	}
+}
+
+// TestLineItems tests summing a breakdown and basis-point rounding
+func TestLineItems(t *testing.T) {
+	items := []LineItem{
+		{Kind: LineBaseFare, Label: "Tickets", Amount: Money{Amount: 50000, Currency: "INR"}},
+		{Kind: LineDiscount, Label: "SAVE10", Amount: Money{Amount: -5000, Currency: "INR"}},
+		{Kind: LineBookingFee, Label: "Convenience fee", Amount: Money{Amount: 3000, Currency: "INR"}},
+	}
+	total, err := SumLineItems(items)
+	if err != nil || total.Amount != 48000 {
+		t.Errorf("Expected 48000, got %d (err %v)", total.Amount, err)
+	}
+
+	items = append(items, LineItem{Kind: LineTax, Amount: Money{Amount: 1, Currency: "USD"}})
+	if _, err := SumLineItems(items); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+
+	if got := PercentOf(Money{Amount: 3000, Currency: "INR"}, 1800); got.Amount != 540 {
+		t.Errorf("Expected 540, got %d", got.Amount)
+	}
+	if got := PercentOf(Money{Amount: 333, Currency: "INR"}, 1850); got.Amount != 62 {
+		t.Errorf("Expected 62, got %d", got.Amount)
+	}
+}
@@ -3,6 +3,7 @@
 package movie
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -26,7 +27,7 @@ func (h *CommandHandler) CreateMovie(c *gin.Context) {
 
 	movie, err := h.CommandService.CreateMovie(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create movie: " + err.Error()})
+		writeError(c, "Failed to create movie: ", err)
 		return
 	}
 
@@ -43,13 +44,30 @@ func (h *CommandHandler) UpdateMovie(c *gin.Context) {
 		return
 	}
 
-	err := h.CommandService.UpdateMovie(c.Request.Context(), id, req)
+	movie, err := h.CommandService.UpdateMovie(c.Request.Context(), id, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update movie: " + err.Error()})
+		writeError(c, "Failed to update movie: ", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Movie updated successfully"})
+	c.JSON(http.StatusOK, movie)
+}
+
+// PatchMovie - Command handler for a partial update of a movie's catalogue entry
+func (h *CommandHandler) PatchMovie(c *gin.Context) {
+	var req PatchMovieRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	movie, err := h.CommandService.PatchMovie(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeError(c, "Failed to update movie: ", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, movie)
 }
 
 // DeleteMovie - Command handler for deleting a movie
@@ -58,9 +76,25 @@ func (h *CommandHandler) DeleteMovie(c *gin.Context) {
 
 	err := h.CommandService.DeleteMovie(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete movie: " + err.Error()})
+		writeError(c, "Failed to delete movie: ", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Movie deleted successfully"})
 }
+
+// writeError maps movie errors to status codes
+func writeError(c *gin.Context, prefix string, err error) {
+	switch {
+	case errors.Is(err, ErrNameRequired), errors.Is(err, ErrInvalidDuration),
+		errors.Is(err, ErrInvalidReleaseDate), errors.Is(err, ErrInvalidFormat), errors.Is(err, ErrInvalidPosterURL),
+		errors.Is(err, ErrInvalidCertification), errors.Is(err, ErrInvalidCredit):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrMovieNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
+	case errors.Is(err, ErrMovieScheduled), errors.Is(err, ErrShowsOverlap):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": prefix + err.Error()})
+	}
+}
@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hitorii/ticket-booking/internal/events"
 )
 
 type CommandService struct {
+	Repo       *Repository
 	Dispatcher *events.Dispatcher
 }
 
@@ -16,8 +22,8 @@ func NewCommandService() *CommandService {
 	return &CommandService{}
 }
 
-func NewCommandServiceWithDispatcher(dispatcher *events.Dispatcher) *CommandService {
-	return &CommandService{Dispatcher: dispatcher}
+func NewCommandServiceWithDispatcher(repo *Repository, dispatcher *events.Dispatcher) *CommandService {
+	return &CommandService{Repo: repo, Dispatcher: dispatcher}
 }
 
 // CreateMovieRequest - Request model for creating a movie
@@ -25,40 +31,216 @@ type CreateMovieRequest struct {
 	Name     string `json:"name"`
 	Genre    string `json:"genre"`
 	Duration int    `json:"duration"`
+	Details
 }
 
-// UpdateMovieRequest - Request model for updating a movie
+// UpdateMovieRequest - Request model for replacing a movie, metadata included
 type UpdateMovieRequest struct {
 	Name     string `json:"name"`
 	Genre    string `json:"genre"`
 	Duration int    `json:"duration"`
+	Details
 }
 
-// CreateMovie - Command to create a new movie
-func (s *CommandService) CreateMovie(ctx context.Context, req CreateMovieRequest) (*Movie, error) {
-	// Validate input
-	if req.Name == "" {
-		return nil, errors.New("movie name is required")
+// PatchMovieRequest - Request model for a partial update. Only the fields present are
+// changed; send an empty string or list to clear one.
+type PatchMovieRequest struct {
+	Name          *string       `json:"name"`
+	Genre         *string       `json:"genre"`
+	Duration      *int          `json:"duration"`
+	Genres        *[]string     `json:"genres"`
+	ReleaseDate   *string       `json:"release_date"`
+	Languages     *[]string     `json:"languages"`
+	Subtitles     *[]string     `json:"subtitles"`
+	Certification *string       `json:"certification"`
+	Synopsis      *string       `json:"synopsis"`
+	Cast          *[]CastMember `json:"cast"`
+	Crew          *[]CrewMember `json:"crew"`
+	PosterURL     *string       `json:"poster_url"`
+	Formats       *[]string     `json:"formats"`
+}
+
+// apply copies the fields present in the patch onto the movie. A new genre list without a
+// genre makes its first entry primary; a new genre alone replaces the old primary one.
+func (p PatchMovieRequest) apply(m *Movie) {
+	if p.Name != nil {
+		m.Name = *p.Name
+	}
+	if p.Duration != nil {
+		m.Duration = *p.Duration
+	}
+	switch {
+	case p.Genres != nil:
+		m.Genres = *p.Genres
+		m.Genre = ""
+		if p.Genre != nil {
+			m.Genre = *p.Genre
+		}
+	case p.Genre != nil:
+		kept := []string{}
+		for _, g := range m.Genres {
+			if !strings.EqualFold(g, m.Genre) {
+				kept = append(kept, g)
+			}
+		}
+		m.Genre, m.Genres = *p.Genre, kept
+	}
+	if p.ReleaseDate != nil {
+		m.ReleaseDate = *p.ReleaseDate
+	}
+	if p.Languages != nil {
+		m.Languages = *p.Languages
+	}
+	if p.Subtitles != nil {
+		m.Subtitles = *p.Subtitles
+	}
+	if p.Certification != nil {
+		m.Certification = *p.Certification
+	}
+	if p.Synopsis != nil {
+		m.Synopsis = *p.Synopsis
 	}
-	if req.Duration <= 0 {
-		return nil, errors.New("movie duration must be positive")
+	if p.Cast != nil {
+		m.Cast = *p.Cast
+	}
+	if p.Crew != nil {
+		m.Crew = *p.Crew
+	}
+	if p.PosterURL != nil {
+		m.PosterURL = *p.PosterURL
+	}
+	if p.Formats != nil {
+		m.Formats = *p.Formats
+	}
+}
+
+// validateMovie tidies a movie's fields and checks the ones every stored movie must have.
+// The primary genre is kept at the front of the genre list.
+func validateMovie(m *Movie) error {
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Name == "" {
+		return ErrNameRequired
+	}
+	if m.Duration <= 0 {
+		return ErrInvalidDuration
 	}
 
-	// The actual database operation would be handled by a repository
-	// Here we just return a movie object with the request data
-	// The repository would handle the actual INSERT operation
+	m.Genre = strings.TrimSpace(m.Genre)
+	m.Genres = cleanList(append([]string{m.Genre}, m.Genres...))
+	if len(m.Genres) > 0 {
+		m.Genre = m.Genres[0]
+	}
+	m.Languages = cleanList(m.Languages)
+	m.Subtitles = cleanList(m.Subtitles)
+	m.Synopsis = strings.TrimSpace(m.Synopsis)
+
+	m.ReleaseDate = strings.TrimSpace(m.ReleaseDate)
+	if m.ReleaseDate != "" {
+		if _, err := time.Parse(releaseDateLayout, m.ReleaseDate); err != nil {
+			return ErrInvalidReleaseDate
+		}
+	}
+	m.Certification = strings.TrimSpace(m.Certification)
+	if len(m.Certification) > maxCertificationLength {
+		return ErrInvalidCertification
+	}
+	m.PosterURL = strings.TrimSpace(m.PosterURL)
+	if m.PosterURL != "" {
+		u, err := url.Parse(m.PosterURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidPosterURL
+		}
+	}
+
+	formats := make([]string, len(m.Formats))
+	for i, f := range m.Formats {
+		formats[i] = strings.ToUpper(strings.TrimSpace(f))
+		if !slices.Contains(Formats, formats[i]) {
+			return fmt.Errorf("%w %q: expected one of %s", ErrInvalidFormat, f, strings.Join(Formats, ", "))
+		}
+	}
+	m.Formats = cleanList(formats)
+
+	cast := make([]CastMember, 0, len(m.Cast))
+	for _, c := range m.Cast {
+		c.Name, c.Character = strings.TrimSpace(c.Name), strings.TrimSpace(c.Character)
+		if c.Name == "" {
+			return ErrInvalidCredit
+		}
+		cast = append(cast, c)
+	}
+	crew := make([]CrewMember, 0, len(m.Crew))
+	for _, c := range m.Crew {
+		c.Name, c.Job = strings.TrimSpace(c.Name), strings.TrimSpace(c.Job)
+		if c.Name == "" {
+			return ErrInvalidCredit
+		}
+		crew = append(crew, c)
+	}
+	m.Cast, m.Crew = cast, crew
+	return nil
+}
+
+// cleanList trims the entries of a list and drops blanks and repeats, ignoring case
+func cleanList(list []string) []string {
+	cleaned := []string{}
+	seen := map[string]bool{}
+	for _, v := range list {
+		v = strings.TrimSpace(v)
+		key := strings.ToLower(v)
+		if v == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		cleaned = append(cleaned, v)
+	}
+	return cleaned
+}
+
+// parseMovieID turns a path ID into a movie ID; anything else can't name a movie
+func parseMovieID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, ErrMovieNotFound
+	}
+	return n, nil
+}
+
+// Movie builds the movie a create request describes, tidied and validated as it would be
+// stored
+func (req CreateMovieRequest) Movie() (*Movie, error) {
 	movie := &Movie{
 		Name:     req.Name,
 		Genre:    req.Genre,
 		Duration: req.Duration,
+		Details:  req.Details,
+	}
+	if err := validateMovie(movie); err != nil {
+		return nil, err
+	}
+	return movie, nil
+}
+
+// CreateMovie - Command to create a new movie. Anything attached is saved with it.
+func (s *CommandService) CreateMovie(ctx context.Context, req CreateMovieRequest, attach ...Attach) (*Movie, error) {
+	movie, err := req.Movie()
+	if err != nil {
+		return nil, err
+	}
+	if s.Repo == nil {
+		return nil, errors.New("movie repository is not configured")
+	}
+
+	if err := s.Repo.Create(ctx, movie, attach...); err != nil {
+		return nil, fmt.Errorf("failed to create movie: %w", err)
 	}
 
 	// Emit event for event-driven flow
 	if s.Dispatcher != nil {
-		movieIDStr := fmt.Sprintf("%d", movie.ID)
+		movieIDStr := strconv.Itoa(movie.ID)
 		payload := events.EventPayload{
 			MovieID: movieIDStr,
-			Name:    req.Name,
+			Name:    movie.Name,
 		}
 		_ = s.Dispatcher.Publish(ctx, events.EventMovieCreated, movieIDStr, payload)
 	}
@@ -67,29 +249,87 @@ func (s *CommandService) CreateMovie(ctx context.Context, req CreateMovieRequest
 }
 
 // UpdateMovie - Command to update an existing movie
-func (s *CommandService) UpdateMovie(ctx context.Context, id string, req UpdateMovieRequest) error {
-	// Validate input
-	if req.Name == "" {
-		return errors.New("movie name is required")
+func (s *CommandService) UpdateMovie(ctx context.Context, id string, req UpdateMovieRequest) (*Movie, error) {
+	movieID, err := parseMovieID(id)
+	if err != nil {
+		return nil, err
 	}
-	if req.Duration <= 0 {
-		return errors.New("movie duration must be positive")
+	movie := &Movie{
+		ID:       movieID,
+		Name:     req.Name,
+		Genre:    req.Genre,
+		Duration: req.Duration,
+		Details:  req.Details,
+	}
+	if err := validateMovie(movie); err != nil {
+		return nil, err
+	}
+	if s.Repo == nil {
+		return nil, errors.New("movie repository is not configured")
 	}
 
-	// Emit event for event-driven flow
-	if s.Dispatcher != nil {
-		payload := events.EventPayload{
-			MovieID: id,
-			Name:    req.Name,
-		}
-		_ = s.Dispatcher.Publish(ctx, events.EventMovieUpdated, id, payload)
+	shows, err := s.Repo.Update(ctx, movie)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	s.publishUpdate(ctx, movie, shows)
+	return movie, nil
+}
+
+// publishUpdate announces a changed movie and the shows it moved, so their read models
+// pick up the new end times
+func (s *CommandService) publishUpdate(ctx context.Context, movie *Movie, shows []int) {
+	if s.Dispatcher == nil {
+		return
+	}
+	id := strconv.Itoa(movie.ID)
+	_ = s.Dispatcher.Publish(ctx, events.EventMovieUpdated, id, events.EventPayload{
+		MovieID: id,
+		Name:    movie.Name,
+	})
+	for _, showID := range shows {
+		show := strconv.Itoa(showID)
+		_ = s.Dispatcher.Publish(ctx, events.EventShowUpdated, show, events.EventPayload{ShowID: show, MovieID: id})
+	}
+}
+
+// PatchMovie - Command to change some of a movie's fields, leaving the rest as they are
+func (s *CommandService) PatchMovie(ctx context.Context, id string, req PatchMovieRequest) (*Movie, error) {
+	movieID, err := parseMovieID(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Repo == nil {
+		return nil, errors.New("movie repository is not configured")
+	}
+
+	movie, shows, err := s.Repo.Modify(ctx, movieID, func(m *Movie) error {
+		req.apply(m)
+		return validateMovie(m)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishUpdate(ctx, movie, shows)
+	return movie, nil
 }
 
-// DeleteMovie - Command to delete a movie
+// DeleteMovie - Command to delete a movie that has no shows
 func (s *CommandService) DeleteMovie(ctx context.Context, id string) error {
+	movieID, err := parseMovieID(id)
+	if err != nil {
+		return err
+	}
+	if s.Repo == nil {
+		return errors.New("movie repository is not configured")
+	}
+
+	if err := s.Repo.Delete(ctx, movieID); err != nil {
+		return err
+	}
+
 	// Emit event for event-driven flow
 	if s.Dispatcher != nil {
 		payload := events.EventPayload{
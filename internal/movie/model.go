@@ -2,9 +2,93 @@
 
 package movie
 
+import "errors"
+
+var (
+	ErrMovieNotFound   = errors.New("movie not found")
+	ErrMovieScheduled  = errors.New("movie has scheduled shows")
+	ErrShowsOverlap    = errors.New("new duration would make a show run into the next one on its screen")
+	ErrNameRequired    = errors.New("movie name is required")
+	ErrInvalidDuration = errors.New("movie duration must be positive")
+
+	ErrInvalidReleaseDate   = errors.New("release date must be YYYY-MM-DD")
+	ErrInvalidFormat        = errors.New("unknown screening format")
+	ErrInvalidPosterURL     = errors.New("poster URL must be an absolute http(s) URL")
+	ErrInvalidCertification = errors.New("certification is too long")
+	ErrInvalidCredit        = errors.New("cast and crew entries need a name")
+)
+
+var ErrInvalidSearch = errors.New("invalid search")
+
+// Sort keys for movie search; a leading "-" sorts descending
+const (
+	SortTitle           = "title"
+	SortTitleDesc       = "-title"
+	SortReleaseDate     = "release_date"
+	SortReleaseDateDesc = "-release_date"
+	SortRelevance       = "relevance" // best match first; needs a search text
+)
+
+// Formats a movie can be screened in
+var Formats = []string{"2D", "3D", "IMAX", "IMAX 3D", "4DX", "SCREENX"}
+
+// releaseDateLayout is how release dates are written
+const releaseDateLayout = "2006-01-02"
+
+// maxCertificationLength bounds ratings such as "U", "UA13+" or "PG-13"
+const maxCertificationLength = 16
+
 type Movie struct {
 	ID       int    `json:"id"`
 	Name     string `json:"name"`
-	Genre    string `json:"genre"`
+	Genre    string `json:"genre"`    // primary genre, always the first of Genres
 	Duration int    `json:"duration"` // in minutes
+	Details
+}
+
+// Details is the catalogue metadata shown on a movie's page
+type Details struct {
+	Genres        []string     `json:"genres"`
+	ReleaseDate   string       `json:"release_date,omitempty"` // YYYY-MM-DD
+	Languages     []string     `json:"languages"`
+	Subtitles     []string     `json:"subtitles"`
+	Certification string       `json:"certification,omitempty"`
+	Synopsis      string       `json:"synopsis,omitempty"`
+	Cast          []CastMember `json:"cast"`
+	Crew          []CrewMember `json:"crew"`
+	PosterURL     string       `json:"poster_url,omitempty"`
+	Formats       []string     `json:"formats"`
+}
+
+// CastMember is an actor and the part they play
+type CastMember struct {
+	Name      string `json:"name"`
+	Character string `json:"character,omitempty"`
+}
+
+// CrewMember is someone who worked on the movie and what they did
+type CrewMember struct {
+	Name string `json:"name"`
+	Job  string `json:"job,omitempty"`
+}
+
+// MovieSearch filters and orders the catalogue. Empty fields don't filter; the release
+// dates are YYYY-MM-DD and inclusive.
+type MovieSearch struct {
+	Query         string
+	Genre         string
+	Language      string
+	Certification string
+	Format        string
+	ReleasedFrom  string
+	ReleasedTo    string
+	Sort          string
+	Limit         int
+	Cursor        string
+}
+
+// MoviePage is one page of search results. NextCursor is empty on the last page.
+type MoviePage struct {
+	Movies     []Movie `json:"movies"`
+	NextCursor string  `json:"next_cursor,omitempty"`
 }
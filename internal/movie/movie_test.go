@@ -1,8 +1,16 @@
 package movie
 
 import (
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/utils"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // TestMovieModel tests the Movie struct
@@ -211,3 +219,268 @@ func BenchmarkCreateMovieValidation(b *testing.B) {
 		validateCreateMovieRequest(req)
 	}
 }
+
+// TestCommandService_Validation tests that commands reject bad input before touching storage
+func TestCommandService_Validation(t *testing.T) {
+	svc := NewCommandService()
+	ctx := context.Background()
+
+	if _, err := svc.CreateMovie(ctx, CreateMovieRequest{Name: "  ", Duration: 120}); !errors.Is(err, ErrNameRequired) {
+		t.Errorf("Expected ErrNameRequired, got %v", err)
+	}
+	if _, err := svc.CreateMovie(ctx, CreateMovieRequest{Name: "Test Movie"}); !errors.Is(err, ErrInvalidDuration) {
+		t.Errorf("Expected ErrInvalidDuration, got %v", err)
+	}
+	if _, err := svc.UpdateMovie(ctx, "abc", UpdateMovieRequest{Name: "Test Movie", Duration: 90}); !errors.Is(err, ErrMovieNotFound) {
+		t.Errorf("Expected ErrMovieNotFound, got %v", err)
+	}
+	if err := svc.DeleteMovie(ctx, "-1"); !errors.Is(err, ErrMovieNotFound) {
+		t.Errorf("Expected ErrMovieNotFound, got %v", err)
+	}
+}
+
+// TestValidateMovie_Metadata tests how catalogue metadata is tidied and checked
+func TestValidateMovie_Metadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		details Details
+		genre   string
+		wantErr error
+		check   func(t *testing.T, m *Movie)
+	}{
+		{
+			name:    "primary genre leads the list",
+			genre:   " Thriller ",
+			details: Details{Genres: []string{"Sci-Fi", "thriller", " ", "Sci-Fi"}},
+			check: func(t *testing.T, m *Movie) {
+				if m.Genre != "Thriller" || strings.Join(m.Genres, ",") != "Thriller,Sci-Fi" {
+					t.Errorf("Expected Thriller first in [Thriller Sci-Fi], got %q %v", m.Genre, m.Genres)
+				}
+			},
+		},
+		{
+			name:    "genre taken from the list",
+			details: Details{Genres: []string{"Drama", "Romance"}},
+			check: func(t *testing.T, m *Movie) {
+				if m.Genre != "Drama" {
+					t.Errorf("Expected primary genre Drama, got %q", m.Genre)
+				}
+			},
+		},
+		{
+			name: "lists are never nil",
+			check: func(t *testing.T, m *Movie) {
+				if m.Genres == nil || m.Languages == nil || m.Subtitles == nil || m.Formats == nil || m.Cast == nil || m.Crew == nil {
+					t.Errorf("Expected empty lists, got %+v", m.Details)
+				}
+			},
+		},
+		{
+			name:    "formats are normalised",
+			details: Details{Formats: []string{"imax 3d", "2d", "2D"}},
+			check: func(t *testing.T, m *Movie) {
+				if strings.Join(m.Formats, ",") != "IMAX 3D,2D" {
+					t.Errorf("Expected [IMAX 3D 2D], got %v", m.Formats)
+				}
+			},
+		},
+		{name: "unknown format", details: Details{Formats: []string{"VR"}}, wantErr: ErrInvalidFormat},
+		{name: "release date", details: Details{ReleaseDate: "2010-07-16"}},
+		{name: "bad release date", details: Details{ReleaseDate: "16/07/2010"}, wantErr: ErrInvalidReleaseDate},
+		{name: "poster", details: Details{PosterURL: "https://cdn.example.com/inception.jpg"}},
+		{name: "relative poster", details: Details{PosterURL: "/posters/inception.jpg"}, wantErr: ErrInvalidPosterURL},
+		{name: "non-http poster", details: Details{PosterURL: "ftp://example.com/p.jpg"}, wantErr: ErrInvalidPosterURL},
+		{name: "long certification", details: Details{Certification: "NOT SUITABLE FOR ANYONE"}, wantErr: ErrInvalidCertification},
+		{name: "nameless cast", details: Details{Cast: []CastMember{{Character: "Cobb"}}}, wantErr: ErrInvalidCredit},
+		{name: "nameless crew", details: Details{Crew: []CrewMember{{Name: " ", Job: "Director"}}}, wantErr: ErrInvalidCredit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Movie{Name: "Inception", Genre: tt.genre, Duration: 148, Details: tt.details}
+			err := validateMovie(m)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && tt.check != nil {
+				tt.check(t, m)
+			}
+		})
+	}
+}
+
+// TestPatchMovieRequest_Apply tests that a patch only changes the fields it carries
+func TestPatchMovieRequest_Apply(t *testing.T) {
+	str := func(s string) *string { return &s }
+	list := func(s ...string) *[]string { return &s }
+	base := func() *Movie {
+		return &Movie{ID: 1, Name: "Inception", Genre: "Sci-Fi", Duration: 148, Details: Details{
+			Genres:    []string{"Sci-Fi", "Thriller"},
+			Languages: []string{"English"},
+			Synopsis:  "Dreams within dreams",
+			Formats:   []string{"2D"},
+		}}
+	}
+
+	tests := []struct {
+		name  string
+		patch PatchMovieRequest
+		check func(t *testing.T, m *Movie)
+	}{
+		{"synopsis only", PatchMovieRequest{Synopsis: str("A heist in dreams")}, func(t *testing.T, m *Movie) {
+			if m.Synopsis != "A heist in dreams" || m.Name != "Inception" || m.Duration != 148 || m.Formats[0] != "2D" {
+				t.Errorf("Expected only the synopsis to change, got %+v", m)
+			}
+		}},
+		{"clear languages", PatchMovieRequest{Languages: list()}, func(t *testing.T, m *Movie) {
+			if len(m.Languages) != 0 {
+				t.Errorf("Expected no languages, got %v", m.Languages)
+			}
+		}},
+		{"new genre list", PatchMovieRequest{Genres: list("Action", "Sci-Fi")}, func(t *testing.T, m *Movie) {
+			if m.Genre != "Action" || strings.Join(m.Genres, ",") != "Action,Sci-Fi" {
+				t.Errorf("Expected Action primary in [Action Sci-Fi], got %q %v", m.Genre, m.Genres)
+			}
+		}},
+		{"new primary genre", PatchMovieRequest{Genre: str("Action")}, func(t *testing.T, m *Movie) {
+			if m.Genre != "Action" || strings.Join(m.Genres, ",") != "Action,Thriller" {
+				t.Errorf("Expected Action to replace Sci-Fi in [Action Thriller], got %q %v", m.Genre, m.Genres)
+			}
+		}},
+		{"genre and list", PatchMovieRequest{Genre: str("Thriller"), Genres: list("Sci-Fi", "Thriller")}, func(t *testing.T, m *Movie) {
+			if m.Genre != "Thriller" || strings.Join(m.Genres, ",") != "Thriller,Sci-Fi" {
+				t.Errorf("Expected Thriller primary in [Thriller Sci-Fi], got %q %v", m.Genre, m.Genres)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := base()
+			tt.patch.apply(m)
+			if err := validateMovie(m); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			tt.check(t, m)
+		})
+	}
+
+	t.Run("invalid result", func(t *testing.T) {
+		m := base()
+		PatchMovieRequest{Name: str(" ")}.apply(m)
+		if err := validateMovie(m); !errors.Is(err, ErrNameRequired) {
+			t.Errorf("Expected ErrNameRequired, got %v", err)
+		}
+	})
+	t.Run("bad id", func(t *testing.T) {
+		if _, err := NewCommandService().PatchMovie(context.Background(), "x", PatchMovieRequest{}); !errors.Is(err, ErrMovieNotFound) {
+			t.Errorf("Expected ErrMovieNotFound, got %v", err)
+		}
+	})
+}
+
+// TestMovieSearch_SQL tests how search parameters become a query
+func TestMovieSearch_SQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		search    MovieSearch
+		wantSort  string
+		wantLimit int
+		wantSQL   []string
+		wantArgs  int
+		wantErr   error
+	}{
+		{
+			name:      "defaults",
+			wantSort:  SortTitle,
+			wantLimit: utils.DefaultPageSize,
+			wantSQL:   []string{"ORDER BY lower(name) ASC, id ASC LIMIT 21"},
+		},
+		{
+			name:      "text search sorts by relevance",
+			search:    MovieSearch{Query: "dark kni", Limit: 5},
+			wantSort:  SortRelevance,
+			wantLimit: 5,
+			wantSQL:   []string{"search_vector @@ to_tsquery('simple', $1)", "ORDER BY ts_rank(search_vector, to_tsquery('simple', $1)) DESC, id DESC LIMIT 6"},
+			wantArgs:  1,
+		},
+		{
+			name:      "filters",
+			search:    MovieSearch{Genre: "Drama", Language: "Hindi", Format: "imax", Certification: "UA", ReleasedFrom: "2020-01-01", ReleasedTo: "2020-12-31", Sort: SortReleaseDateDesc, Limit: 500},
+			wantSort:  SortReleaseDateDesc,
+			wantLimit: utils.MaxPageSize,
+			wantSQL:   []string{"genres @> ARRAY[$1]", "languages @> ARRAY[$2]", "formats @> ARRAY[$3]", "certification = $4", "release_date >= $5::date", "release_date <= $6::date", "DESC, id DESC"},
+			wantArgs:  6,
+		},
+		{
+			name:      "cursor",
+			search:    MovieSearch{Sort: SortTitle, Cursor: utils.Cursor{Sort: SortTitle, Value: "inception", ID: 3}.Encode()},
+			wantSort:  SortTitle,
+			wantLimit: utils.DefaultPageSize,
+			wantSQL:   []string{"(lower(name), id) > ($1::text, $2)"},
+			wantArgs:  2,
+		},
+		{name: "unknown sort", search: MovieSearch{Sort: "rating"}, wantErr: ErrInvalidSearch},
+		{name: "relevance without text", search: MovieSearch{Sort: SortRelevance, Query: "!!"}, wantErr: ErrInvalidSearch},
+		{name: "bad date", search: MovieSearch{ReleasedFrom: "2020"}, wantErr: ErrInvalidSearch},
+		{name: "cursor for another sort", search: MovieSearch{Sort: SortTitleDesc, Cursor: utils.Cursor{Sort: SortTitle}.Encode()}, wantErr: utils.ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.search
+			query, args, err := q.sql()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if q.Sort != tt.wantSort || q.Limit != tt.wantLimit {
+				t.Errorf("Expected sort %q limit %d, got %q %d", tt.wantSort, tt.wantLimit, q.Sort, q.Limit)
+			}
+			for _, want := range tt.wantSQL {
+				if !strings.Contains(query, want) {
+					t.Errorf("Expected %q in %s", want, query)
+				}
+			}
+			if len(args) != tt.wantArgs {
+				t.Errorf("Expected %d args, got %d", tt.wantArgs, len(args))
+			}
+		})
+	}
+}
+
+// TestOverlapError tests that a duration change pushing a show into the next one is refused
+// with a conflict, and that other database errors are left alone
+func TestOverlapError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		err      error
+		wantErr  error
+		wantCode int
+	}{
+		{"exclusion violation", &pgconn.PgError{Code: exclusionViolation, Detail: "conflicting key value"}, ErrShowsOverlap, http.StatusConflict},
+		{"other error", &pgconn.PgError{Code: "40001"}, nil, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := overlapError(tt.err)
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && err != tt.err {
+				t.Errorf("Expected the error unchanged, got %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			writeError(c, "Failed to update movie: ", err)
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected %d, got %d", tt.wantCode, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Movie read model updater (CQRS projection)
+
+package movie
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Projection copies movies from the Command DB into the Query DB movies table
+type Projection struct {
+	QueryDB *pgxpool.Pool
+	Repo    *Repository
+}
+
+func NewProjection(queryDB *pgxpool.Pool, repo *Repository) *Projection {
+	return &Projection{QueryDB: queryDB, Repo: repo}
+}
+
+// Subscribe keeps the read model in step with movie events
+func (p *Projection) Subscribe(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(events.EventMovieCreated, p.onMovieEvent)
+	dispatcher.Subscribe(events.EventMovieUpdated, p.onMovieEvent)
+	dispatcher.Subscribe(events.EventMovieDeleted, p.onMovieEvent)
+}
+
+func (p *Projection) onMovieEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(payload.MovieID)
+	if err != nil {
+		return nil
+	}
+	return p.Project(context.Background(), id)
+}
+
+// Project writes the movie's current state to the Query DB. The Command DB is read rather
+// than the event so that events handled out of order still leave the latest state behind.
+func (p *Projection) Project(ctx context.Context, id int) error {
+	m, err := p.Repo.GetByID(ctx, id)
+	if errors.Is(err, ErrMovieNotFound) {
+		_, err = p.QueryDB.Exec(ctx, "DELETE FROM movies WHERE id = $1", id)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return p.upsert(ctx, m)
+}
+
+// Rebuild projects every movie and drops read rows whose movie no longer exists
+func (p *Projection) Rebuild(ctx context.Context) error {
+	movies, err := p.Repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(movies))
+	for i := range movies {
+		if err := p.upsert(ctx, &movies[i]); err != nil {
+			return err
+		}
+		ids = append(ids, movies[i].ID)
+	}
+	if _, err := p.QueryDB.Exec(ctx, "DELETE FROM movies WHERE NOT (id = ANY($1))", ids); err != nil {
+		return err
+	}
+	log.Printf("🎬 Projected %d movies into the query database", len(movies))
+	return nil
+}
+
+func (p *Projection) upsert(ctx context.Context, m *Movie) error {
+	_, err := p.QueryDB.Exec(ctx, `
+		INSERT INTO movies (id, name, genre, duration, genres, release_date, languages, subtitles,
+		                    certification, synopsis, cast_members, crew, poster_url, formats)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::date, $7, $8, NULLIF($9, ''), NULLIF($10, ''), $11, $12, NULLIF($13, ''), $14)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			genre = EXCLUDED.genre,
+			duration = EXCLUDED.duration,
+			genres = EXCLUDED.genres,
+			release_date = EXCLUDED.release_date,
+			languages = EXCLUDED.languages,
+			subtitles = EXCLUDED.subtitles,
+			certification = EXCLUDED.certification,
+			synopsis = EXCLUDED.synopsis,
+			cast_members = EXCLUDED.cast_members,
+			crew = EXCLUDED.crew,
+			poster_url = EXCLUDED.poster_url,
+			formats = EXCLUDED.formats
+	`, m.ID, m.Name, m.Genre, m.Duration, m.Genres, m.ReleaseDate, m.Languages, m.Subtitles,
+		m.Certification, m.Synopsis, m.Cast, m.Crew, m.PosterURL, m.Formats)
+	return err
+}
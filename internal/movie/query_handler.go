@@ -3,9 +3,11 @@
 package movie
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/utils"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -32,13 +34,58 @@ func (h *QueryHandler) GetMovie(c *gin.Context) {
 	id := c.Param("id")
 	
 	movie, err := h.QueryService.GetMovieByID(c.Request.Context(), id)
-	if err != nil {
+	if errors.Is(err, ErrMovieNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movie"})
+		return
+	}
 	c.JSON(http.StatusOK, movie)
 }
 
+// GetMoviesByGenre - Query handler for listing the movies in a genre
+func (h *QueryHandler) GetMoviesByGenre(c *gin.Context) {
+	movies, err := h.QueryService.GetMoviesByGenre(c.Request.Context(), c.Param("genre"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch movies"})
+		return
+	}
+	c.JSON(http.StatusOK, movies)
+}
+
+// SearchMovies - Query handler for searching movies with filters, sorting and pagination
+func (h *QueryHandler) SearchMovies(c *gin.Context) {
+	limit, err := utils.ParseLimit(c.Query("limit"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.QueryService.SearchMovies(c.Request.Context(), MovieSearch{
+		Query:         c.Query("q"),
+		Genre:         c.Query("genre"),
+		Language:      c.Query("language"),
+		Certification: c.Query("certification"),
+		Format:        c.Query("format"),
+		ReleasedFrom:  c.Query("released_from"),
+		ReleasedTo:    c.Query("released_to"),
+		Sort:          c.Query("sort"),
+		Limit:         limit,
+		Cursor:        c.Query("cursor"),
+	})
+	if errors.Is(err, ErrInvalidSearch) || errors.Is(err, utils.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search movies"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
 // Initialize QueryHandler with database pool (for compatibility with existing setup)
 func NewQueryHandlerWithDB(db *pgxpool.Pool) *QueryHandler {
 	qs := NewQueryService(db)
@@ -4,6 +4,12 @@ package movie
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/utils"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -18,50 +24,143 @@ func NewQueryService(db *pgxpool.Pool) *QueryService {
 
 // GetAllMovies - Query to get all movies
 func (s *QueryService) GetAllMovies(ctx context.Context) ([]Movie, error) {
-	rows, err := s.DB.Query(ctx, "SELECT id, name, genre, duration FROM movies")
+	return listMovies(ctx, s.DB, "SELECT "+movieColumns+" FROM movies ORDER BY id")
+}
+
+// GetMovieByID - Query to get a single movie by ID with its full catalogue record
+func (s *QueryService) GetMovieByID(ctx context.Context, id string) (*Movie, error) {
+	return scanMovie(s.DB.QueryRow(ctx, "SELECT "+movieColumns+" FROM movies WHERE id=$1", id))
+}
+
+// GetMoviesByGenre - Query to get movies listed under a genre, primary or not
+func (s *QueryService) GetMoviesByGenre(ctx context.Context, genre string) ([]Movie, error) {
+	return listMovies(ctx, s.DB, "SELECT "+movieColumns+" FROM movies WHERE genre=$1 OR $1 = ANY(genres) ORDER BY id", genre)
+}
+
+// sortKey is an ORDER BY expression, the type its text form is cast back to when it comes
+// round in a cursor, and its direction. Rows tied on it are ordered by ID the same way.
+type sortKey struct {
+	expr string
+	cast string
+	desc bool
+}
+
+// Movies without a release date sort after the dated ones either way round
+var movieSorts = map[string]sortKey{
+	SortTitle:           {"lower(name)", "text", false},
+	SortTitleDesc:       {"lower(name)", "text", true},
+	SortReleaseDate:     {"COALESCE(release_date, DATE '9999-12-31')", "date", false},
+	SortReleaseDateDesc: {"COALESCE(release_date, DATE '0001-01-01')", "date", true},
+}
+
+// SearchMovies - Query to search the catalogue by title with filters, a sort order and
+// cursor pagination. Title matching uses the full-text index on the movies table.
+func (s *QueryService) SearchMovies(ctx context.Context, q MovieSearch) (*MoviePage, error) {
+	query, args, err := q.sql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var movies []Movie
+	page := &MoviePage{Movies: []Movie{}}
+	var last string
 	for rows.Next() {
-		var m Movie
-		if err := rows.Scan(&m.ID, &m.Name, &m.Genre, &m.Duration); err != nil {
+		var sortValue string
+		m, err := scanMovie(rows, &sortValue)
+		if err != nil {
 			return nil, err
 		}
-		movies = append(movies, m)
+		if len(page.Movies) == q.Limit {
+			page.NextCursor = utils.Cursor{Sort: q.Sort, Value: last, ID: page.Movies[q.Limit-1].ID}.Encode()
+			break
+		}
+		page.Movies = append(page.Movies, *m)
+		last = sortValue
 	}
-
-	return movies, nil
+	return page, rows.Err()
 }
 
-// GetMovieByID - Query to get a single movie by ID
-func (s *QueryService) GetMovieByID(ctx context.Context, id string) (*Movie, error) {
-	var m Movie
-	err := s.DB.QueryRow(ctx, "SELECT id, name, genre, duration FROM movies WHERE id=$1", id).Scan(&m.ID, &m.Name, &m.Genre, &m.Duration)
-	if err != nil {
-		return nil, err
+// sql builds the search query, filling in the default sort and page size. It fetches one
+// row more than the page so the caller can tell whether there is a next page. Each row ends
+// with its sort key as text, for the cursor.
+func (q *MovieSearch) sql() (string, []any, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
 	}
-	return &m, nil
-}
 
-// GetMoviesByGenre - Query to get movies by genre
-func (s *QueryService) GetMoviesByGenre(ctx context.Context, genre string) ([]Movie, error) {
-	rows, err := s.DB.Query(ctx, "SELECT id, name, genre, duration FROM movies WHERE genre=$1", genre)
-	if err != nil {
-		return nil, err
+	sorts := movieSorts
+	if tsq := utils.PrefixQuery(q.Query); tsq != "" {
+		p := arg(tsq)
+		conds = append(conds, "search_vector @@ to_tsquery('simple', "+p+")")
+		sorts = map[string]sortKey{SortRelevance: {"ts_rank(search_vector, to_tsquery('simple', " + p + "))", "real", true}}
+		for name, key := range movieSorts {
+			sorts[name] = key
+		}
+		if q.Sort == "" {
+			q.Sort = SortRelevance
+		}
+	}
+	if q.Sort == "" {
+		q.Sort = SortTitle
+	}
+	key, ok := sorts[q.Sort]
+	if !ok && q.Sort == SortRelevance {
+		return "", nil, fmt.Errorf("%w: sorting by relevance needs a search text", ErrInvalidSearch)
+	}
+	if !ok {
+		return "", nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidSearch, q.Sort)
 	}
-	defer rows.Close()
 
-	var movies []Movie
-	for rows.Next() {
-		var m Movie
-		if err := rows.Scan(&m.ID, &m.Name, &m.Genre, &m.Duration); err != nil {
-			return nil, err
+	if q.Genre != "" {
+		conds = append(conds, "genres @> ARRAY["+arg(q.Genre)+"]::text[]")
+	}
+	if q.Language != "" {
+		conds = append(conds, "languages @> ARRAY["+arg(q.Language)+"]::text[]")
+	}
+	if q.Format != "" {
+		conds = append(conds, "formats @> ARRAY["+arg(strings.ToUpper(q.Format))+"]::text[]")
+	}
+	if q.Certification != "" {
+		conds = append(conds, "certification = "+arg(q.Certification))
+	}
+	for _, bound := range []struct{ value, op string }{{q.ReleasedFrom, ">="}, {q.ReleasedTo, "<="}} {
+		if bound.value == "" {
+			continue
+		}
+		if _, err := time.Parse(releaseDateLayout, bound.value); err != nil {
+			return "", nil, fmt.Errorf("%w: release dates must be YYYY-MM-DD", ErrInvalidSearch)
 		}
-		movies = append(movies, m)
+		conds = append(conds, "release_date "+bound.op+" "+arg(bound.value)+"::date")
 	}
 
-	return movies, nil
+	dir, cmp := "ASC", ">"
+	if key.desc {
+		dir, cmp = "DESC", "<"
+	}
+	if q.Cursor != "" {
+		cursor, err := utils.DecodeCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return "", nil, err
+		}
+		conds = append(conds, fmt.Sprintf("(%s, id) %s (%s::%s, %s)", key.expr, cmp, arg(cursor.Value), key.cast, arg(cursor.ID)))
+	}
+	if q.Limit <= 0 {
+		q.Limit = utils.DefaultPageSize
+	}
+	q.Limit = min(q.Limit, utils.MaxPageSize)
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	query := fmt.Sprintf("SELECT %s, (%s)::text FROM movies %s ORDER BY %s %s, id %s LIMIT %d",
+		movieColumns, key.expr, where, key.expr, dir, dir, q.Limit+1)
+	return query, args, nil
 }
@@ -0,0 +1,233 @@
+package movie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository stores movies in the Command DB
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+const movieColumns = `
+	id, name, genre, duration, genres, release_date, languages, subtitles, COALESCE(certification, ''),
+	COALESCE(synopsis, ''), cast_members, crew, COALESCE(poster_url, ''), formats`
+
+// scanMovie reads a row of movieColumns, followed by any extra columns into extra. The
+// Query DB's movies table has the same columns.
+func scanMovie(row pgx.Row, extra ...any) (*Movie, error) {
+	var m Movie
+	var released *time.Time
+	dest := []any{&m.ID, &m.Name, &m.Genre, &m.Duration, &m.Genres, &released, &m.Languages, &m.Subtitles,
+		&m.Certification, &m.Synopsis, &m.Cast, &m.Crew, &m.PosterURL, &m.Formats}
+	err := row.Scan(append(dest, extra...)...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMovieNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if released != nil {
+		m.ReleaseDate = released.Format(releaseDateLayout)
+	}
+	return &m, nil
+}
+
+// Attach records something about a movie being created in the transaction that creates
+// it, so the movie is never saved without it
+type Attach func(ctx context.Context, tx pgx.Tx, id int) error
+
+// Create inserts a movie and sets its ID, running attach in the same transaction
+func (r *Repository) Create(ctx context.Context, m *Movie, attach ...Attach) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO movies (name, genre, duration, genres, release_date, languages, subtitles,
+		                    certification, synopsis, cast_members, crew, poster_url, formats)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::date, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, NULLIF($12, ''), $13)
+		RETURNING id
+	`, m.Name, m.Genre, m.Duration, m.Genres, m.ReleaseDate, m.Languages, m.Subtitles,
+		m.Certification, m.Synopsis, m.Cast, m.Crew, m.PosterURL, m.Formats,
+	).Scan(&m.ID)
+	if err != nil {
+		return err
+	}
+	for _, fn := range attach {
+		if err := fn(ctx, tx, m.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// execer is satisfied by both the pool and a transaction
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+func update(ctx context.Context, db execer, m *Movie) error {
+	res, err := db.Exec(ctx, `
+		UPDATE movies SET name = $2, genre = $3, duration = $4, genres = $5, release_date = NULLIF($6, '')::date,
+			languages = $7, subtitles = $8, certification = NULLIF($9, ''), synopsis = NULLIF($10, ''),
+			cast_members = $11, crew = $12, poster_url = NULLIF($13, ''), formats = $14
+		WHERE id = $1
+	`, m.ID, m.Name, m.Genre, m.Duration, m.Genres, m.ReleaseDate, m.Languages, m.Subtitles,
+		m.Certification, m.Synopsis, m.Cast, m.Crew, m.PosterURL, m.Formats,
+	)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrMovieNotFound
+	}
+	return nil
+}
+
+// exclusionViolation is the SQLSTATE of a show pushed into the next one on its screen
+const exclusionViolation = "23P01"
+
+// reschedule moves the end of the movie's upcoming shows by the change in its duration,
+// keeping their ads and cleaning time. It returns the IDs of the shows moved, or
+// ErrShowsOverlap when one would then run into the next show on its screen.
+func reschedule(ctx context.Context, tx pgx.Tx, movieID, minutes int) ([]int, error) {
+	if minutes == 0 {
+		return nil, nil
+	}
+	rows, err := tx.Query(ctx, `
+		UPDATE shows SET end_time = end_time + make_interval(mins => $2)
+		WHERE movie_id = $1 AND status = 'SCHEDULED' AND start_time > NOW()
+		RETURNING id
+	`, movieID, minutes)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	return ids, overlapError(err)
+}
+
+// overlapError turns a reschedule rejected by the shows' exclusion constraint into ErrShowsOverlap
+func overlapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+		return fmt.Errorf("%w: %s", ErrShowsOverlap, pgErr.Detail)
+	}
+	return err
+}
+
+// Update overwrites a movie's fields. Upcoming shows of the movie end when its new
+// duration says; the IDs of the shows moved are returned.
+func (r *Repository) Update(ctx context.Context, m *Movie) ([]int, error) {
+	_, shows, err := r.Modify(ctx, m.ID, func(stored *Movie) error {
+		*stored = *m
+		return nil
+	})
+	return shows, err
+}
+
+// Modify applies fn to the stored movie and saves the result. The row stays locked in
+// between, so two editors patching different fields don't undo each other's changes.
+// Upcoming shows are moved along with a change of duration in the same transaction, and
+// their IDs returned.
+func (r *Repository) Modify(ctx context.Context, id int, fn func(*Movie) error) (*Movie, []int, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	m, err := scanMovie(tx.QueryRow(ctx, "SELECT "+movieColumns+" FROM movies WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		return nil, nil, err
+	}
+	duration := m.Duration
+	if err := fn(m); err != nil {
+		return nil, nil, err
+	}
+	if err := update(ctx, tx, m); err != nil {
+		return nil, nil, err
+	}
+	shows, err := reschedule(ctx, tx, m.ID, m.Duration-duration)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, err
+	}
+	return m, shows, nil
+}
+
+// Delete removes a movie that no show refers to. The movie row is locked first so a
+// show can't be scheduled for it in between.
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var locked int
+	err = tx.QueryRow(ctx, "SELECT id FROM movies WHERE id = $1 FOR UPDATE", id).Scan(&locked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrMovieNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var scheduled bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM shows WHERE movie_id = $1)", id).Scan(&scheduled); err != nil {
+		return err
+	}
+	if scheduled {
+		return ErrMovieScheduled
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM movies WHERE id = $1", id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetByID returns a movie from the Command DB
+func (r *Repository) GetByID(ctx context.Context, id int) (*Movie, error) {
+	return scanMovie(r.DB.QueryRow(ctx, "SELECT "+movieColumns+" FROM movies WHERE id = $1", id))
+}
+
+// List returns every movie in the Command DB
+func (r *Repository) List(ctx context.Context) ([]Movie, error) {
+	return listMovies(ctx, r.DB, "SELECT "+movieColumns+" FROM movies ORDER BY id")
+}
+
+// listMovies runs a query over movieColumns against either database
+func listMovies(ctx context.Context, db *pgxpool.Pool, query string, args ...any) ([]Movie, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := []Movie{}
+	for rows.Next() {
+		m, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, *m)
+	}
+	return movies, rows.Err()
+}
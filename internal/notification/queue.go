@@ -71,6 +71,35 @@ func EnqueueBookingNotification(userID, seatID, eventType string) error {
 	})
 }
 
+// EnqueueShowCancelled creates and enqueues the email telling a user the show they booked
+// was cancelled, and an in-app notification of it. rebookURL, when set, is the one-click
+// link for moving the booking to the show offered instead.
+func EnqueueShowCancelled(userID, bookingID, subject, body, rebookURL string) error {
+	data := map[string]interface{}{
+		"body":       body,
+		"booking_id": bookingID,
+		"event":      "ShowCancelled",
+	}
+	if rebookURL != "" {
+		data["rebook_url"] = rebookURL
+	}
+	err := Enqueue(Job{
+		Type:    JobTypeEmail,
+		UserID:  userID,
+		Message: subject,
+		Data:    data,
+	})
+	if err != nil {
+		return err
+	}
+	return Enqueue(Job{
+		Type:    JobTypeBooking,
+		UserID:  userID,
+		Message: subject,
+		Data:    data,
+	})
+}
+
 // EnqueuePaymentNotification creates and enqueues a payment-related notification
 func EnqueuePaymentNotification(userID, paymentID, status string) error {
 	return Enqueue(Job{
@@ -83,3 +112,22 @@ func EnqueuePaymentNotification(userID, paymentID, status string) error {
 		},
 	})
 }
+
+// EnqueueReceipt creates and enqueues the email carrying a payment receipt, and an
+// in-app notification that it is ready
+func EnqueueReceipt(userID, paymentID, number, html string) error {
+	err := Enqueue(Job{
+		Type:    JobTypeEmail,
+		UserID:  userID,
+		Message: "Your receipt " + number,
+		Data: map[string]interface{}{
+			"body":        html,
+			"payment_id":  paymentID,
+			"receipt_url": "/query/payments/" + paymentID + "/receipt?format=pdf",
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return EnqueuePaymentNotification(userID, paymentID, "Receipt "+number+" is ready")
+}
@@ -3,9 +3,14 @@
 package payments
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/loyalty"
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/hitorii/ticket-booking/internal/pricing"
+	"github.com/hitorii/ticket-booking/internal/promotions"
 )
 
 type CommandHandler struct {
@@ -28,6 +33,42 @@ func (h *CommandHandler) InitiatePayment(c *gin.Context) {
 
 	payment, err := h.CommandService.InitiatePayment(c.Request.Context(), req)
 	if err != nil {
+		if errors.Is(err, money.ErrCurrencyMismatch) || errors.Is(err, money.ErrUnknownCurrency) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, ErrPriceMismatch) || errors.Is(err, ErrPaymentInProgress) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, pricing.ErrBookingNotFound) || errors.Is(err, pricing.ErrPriceNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, promotions.ErrPromoLimitReached) || errors.Is(err, promotions.ErrPromoInUse) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, promotions.ErrInvalidPromo) || errors.Is(err, ErrInvalidTender) || errors.Is(err, loyalty.ErrLoyalty) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, ErrTenderDeclined) {
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to initiate payment: " + err.Error(),
 		})
@@ -49,6 +90,12 @@ func (h *CommandHandler) VerifyPayment(c *gin.Context) {
 
 	err := h.CommandService.VerifyPayment(c.Request.Context(), req)
 	if err != nil {
+		if errors.Is(err, ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to verify payment: " + err.Error(),
 		})
@@ -64,8 +111,31 @@ func (h *CommandHandler) VerifyPayment(c *gin.Context) {
 func (h *CommandHandler) RefundPayment(c *gin.Context) {
 	paymentID := c.Param("id")
 
-	err := h.CommandService.RefundPayment(c.Request.Context(), paymentID)
+	// Body is optional; without an amount the remaining balance is refunded
+	var req RefundPaymentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid request body",
+			})
+			return
+		}
+	}
+
+	err := h.CommandService.RefundPartial(c.Request.Context(), paymentID, req.Amount)
 	if err != nil {
+		if errors.Is(err, ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, ErrRefundExceedsBalance) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to refund payment: " + err.Error(),
 		})
@@ -81,7 +151,15 @@ func (h *CommandHandler) RefundPayment(c *gin.Context) {
 type InitiatePaymentRequest struct {
 	BookingID string `json:"booking_id"`
 	UserID    string `json:"user_id"`
-	Amount    int    `json:"amount"`
+	Amount    int64  `json:"amount"`   // in minor units of Currency, before any discount; must match the booking price
+	Currency  string `json:"currency"` // ISO 4217, defaults to the service currency
+	PromoCode string `json:"promo_code,omitempty"`
+
+	// RedeemPoints spends loyalty points as a discount on the fare
+	RedeemPoints int64 `json:"redeem_points,omitempty"`
+
+	// Tenders charge part of the total to a wallet or gift cards; the card pays the rest
+	Tenders []TenderRequest `json:"tenders,omitempty"`
 }
 
 // VerifyPaymentRequest - Request model for verifying payment
@@ -89,3 +167,8 @@ type VerifyPaymentRequest struct {
 	PaymentID string `json:"payment_id"`
 	Mode      string `json:"mode"` // success/fail/random
 }
+
+// RefundPaymentRequest - Request model for refunding a payment
+type RefundPaymentRequest struct {
+	Amount int64 `json:"amount"` // minor units; 0 refunds the remaining balance
+}
@@ -6,21 +6,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/hitorii/ticket-booking/internal/fees"
+	"github.com/hitorii/ticket-booking/internal/loyalty"
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/hitorii/ticket-booking/internal/pricing"
+	"github.com/hitorii/ticket-booking/internal/promotions"
 	"github.com/hitorii/ticket-booking/internal/utils"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type CommandService struct {
-	Repo       *Repository
-	Dispatcher *events.Dispatcher
-	DB         *pgxpool.Pool
+	Repo        *Repository
+	Dispatcher  *events.Dispatcher
+	DB          *pgxpool.Pool
+	Currency    string   // default currency when a request doesn't specify one
+	Provider    Provider // payment gateway, MockProvider when nil
+	Promotions  *promotions.CommandService
+	Pricing     *pricing.QueryService // computes booking prices; client amounts are trusted when nil
+	Fees        *fees.QueryService    // adds venue fees and taxes; none are charged when nil
+	StoredValue TenderProvider        // wallets and gift cards; split payments are rejected when nil
+	Loyalty     *loyalty.CommandService
 }
 
+// ErrPriceMismatch is returned when the client amount differs from the computed booking price
+var ErrPriceMismatch = errors.New("amount does not match booking price")
+
+// ErrPaymentInProgress is returned when a booking already has a payment at checkout or paid
+var ErrPaymentInProgress = errors.New("booking already has an open payment")
+
 func NewCommandService(repo *Repository) *CommandService {
 	return &CommandService{Repo: repo}
 }
@@ -35,6 +55,22 @@ func NewCommandServiceWithDB(db *pgxpool.Pool) *CommandService {
 	return &CommandService{Repo: repo, DB: db}
 }
 
+// defaultCurrency returns the configured currency or the package default
+func (s *CommandService) defaultCurrency() string {
+	if s.Currency != "" {
+		return s.Currency
+	}
+	return money.DefaultCurrency
+}
+
+// provider returns the configured gateway or the mock one
+func (s *CommandService) provider() Provider {
+	if s.Provider != nil {
+		return s.Provider
+	}
+	return MockProvider{}
+}
+
 // InitiatePayment - Command to initiate a new payment
 func (s *CommandService) InitiatePayment(ctx context.Context, req InitiatePaymentRequest) (*Payment, error) {
 	// Validate input
@@ -56,18 +92,125 @@ func (s *CommandService) InitiatePayment(ctx context.Context, req InitiatePaymen
 		return nil, fmt.Errorf("invalid user_id: %w", err)
 	}
 
+	// A booking is paid for once; a new payment may only follow ones that failed or expired.
+	// The unique index on open payments settles races; this read only gives a clearer error.
+	open, err := s.Repo.GetOpenPaymentsByBookingID(ctx, req.BookingID)
+	if err != nil {
+		return nil, err
+	}
+	if len(open) > 0 {
+		return nil, fmt.Errorf("%w: payment %s is %s", ErrPaymentInProgress, open[0].ID, open[0].Status)
+	}
+
+	// Price the booking server-side; the client only confirms the amount it was shown
+	var quote *pricing.Quote
+	var showID, movieID string
+	if s.Pricing != nil {
+		quote, err = s.Pricing.QuoteBooking(ctx, req.BookingID)
+		if err != nil {
+			return nil, err
+		}
+		if quote.UserID != req.UserID {
+			return nil, fmt.Errorf("%w: booking belongs to another user", pricing.ErrBookingNotFound)
+		}
+		showID, movieID = strconv.Itoa(quote.ShowID), strconv.Itoa(quote.MovieID)
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = s.defaultCurrency()
+		if quote != nil {
+			currency = quote.Total.Currency
+		}
+	}
+	amount, err := money.New(req.Amount, currency)
+	if err != nil {
+		return nil, err
+	}
+	if quote != nil && amount != quote.Total {
+		return nil, fmt.Errorf("%w: booking costs %s, got %s", ErrPriceMismatch, quote.Total, amount)
+	}
+
+	// A booking is priced in a single currency
+	if existing, err := s.Repo.GetPaymentByBookingID(req.BookingID); err == nil && !existing.Amount.SameCurrency(amount) {
+		return nil, fmt.Errorf("%w: booking is priced in %s, got %s", money.ErrCurrencyMismatch, existing.Amount.Currency, amount.Currency)
+	}
+
 	// Generate proper UUID for payment
 	payment := &Payment{
 		ID:        uuid.New().String(),
 		BookingID: req.BookingID,
 		UserID:    req.UserID,
-		Amount:    req.Amount,
-		Status:    "PENDING",
+		Amount:    amount,
+		Discount:  money.Zero(amount.Currency),
+		Status:    StatusPending,
 		CreatedAt: time.Now(),
 	}
 
-	err := s.Repo.CreatePayment(payment)
+	var discounts []money.LineItem
+	if req.PromoCode != "" {
+		if s.Promotions == nil {
+			return nil, fmt.Errorf("%w: promotions are not enabled", promotions.ErrPromoNotApplicable)
+		}
+		redemption, err := s.Promotions.Redeem(ctx, promotions.RedeemRequest{
+			Code:      req.PromoCode,
+			UserID:    req.UserID,
+			BookingID: req.BookingID,
+			PaymentID: payment.ID,
+			ShowID:    showID,
+			MovieID:   movieID,
+			Total:     amount,
+		})
+		if err != nil {
+			return nil, err
+		}
+		payment.Discount = redemption.Discount
+		payment.PromoCode = redemption.Code
+		discounts = append(discounts, money.LineItem{Kind: money.LineDiscount, Label: "Promo " + redemption.Code, Amount: redemption.Discount})
+	}
+
+	// Points pay for part of what's left of the fare after the promo
+	if req.RedeemPoints > 0 {
+		var pointsDiscount money.Money
+		if s.Loyalty == nil {
+			err = fmt.Errorf("%w: loyalty points are not enabled", loyalty.ErrLoyalty)
+		} else {
+			remaining := money.Money{Amount: amount.Amount - payment.Discount.Amount, Currency: amount.Currency}
+			pointsDiscount, err = s.Loyalty.Redeem(ctx, req.UserID, payment.ID, req.BookingID, req.RedeemPoints, remaining)
+		}
+		if err != nil {
+			s.releaseDiscounts(ctx, payment)
+			return nil, err
+		}
+		payment.Discount.Amount += pointsDiscount.Amount
+		discounts = append(discounts, money.LineItem{Kind: money.LineDiscount, Label: "Loyalty points", Amount: pointsDiscount})
+	}
+
+	// The client confirms the fare; fees and taxes are added on top of it
+	breakdown, err := s.breakdown(ctx, quote, amount, discounts)
+	if err != nil {
+		s.releaseDiscounts(ctx, payment)
+		return nil, err
+	}
+	payment.Amount = breakdown.Total
+	payment.LineItems = breakdown.Items
+
+	payment.Tenders, err = splitTenders(payment.Amount, req.Tenders)
+	if err == nil && len(req.Tenders) > 0 && s.StoredValue == nil {
+		err = fmt.Errorf("%w: wallet and gift card payments are not enabled", ErrInvalidTender)
+	}
+	if err == nil {
+		err = s.chargeTenders(ctx, payment)
+	}
 	if err != nil {
+		s.releaseDiscounts(ctx, payment)
+		return nil, err
+	}
+
+	err = s.Repo.CreatePayment(payment)
+	if err != nil {
+		s.voidTenders(ctx, payment)
+		s.releaseDiscounts(ctx, payment)
 		return nil, err
 	}
 
@@ -77,15 +220,142 @@ func (s *CommandService) InitiatePayment(ctx context.Context, req InitiatePaymen
 			UserID:    req.UserID,
 			BookingID: req.BookingID,
 			PaymentID: payment.ID,
-			Amount:    req.Amount,
-			Status:    "PENDING",
+			Amount:    &payment.Amount,
+			Status:    StatusPending,
+			PromoCode: payment.PromoCode,
+			ShowID:    showID,
+			LineItems: payment.LineItems,
+		}
+		if quote != nil {
+			payload.Price = &quote.Total
 		}
 		_ = s.Dispatcher.Publish(ctx, events.EventPaymentInitiated, payment.ID, payload)
 	}
 
+	// Nothing left for the gateway: stored value was debited up front, so capture now
+	if len(payment.Tenders) > 0 && !hasCardTender(payment.Tenders) {
+		if err := s.captureStoredValue(ctx, payment); err != nil {
+			return nil, err
+		}
+	}
+
 	return payment, nil
 }
 
+// captureStoredValue captures a payment paid entirely from wallets and gift cards
+func (s *CommandService) captureStoredValue(ctx context.Context, payment *Payment) error {
+	txnID := "stored_value_" + payment.ID
+	if err := s.transition(ctx, payment, StatusAuthorized, txnID, 0, "paid with stored value"); err != nil {
+		return err
+	}
+	if err := s.transition(ctx, payment, StatusCaptured, txnID, 0, "paid with stored value"); err != nil {
+		return err
+	}
+
+	if s.Dispatcher != nil {
+		payload := events.EventPayload{
+			UserID:    payment.UserID,
+			BookingID: payment.BookingID,
+			PaymentID: payment.ID,
+			Amount:    &payment.Amount,
+			Status:    StatusCaptured,
+			Mode:      "stored_value",
+		}
+		_ = s.Dispatcher.Publish(ctx, events.EventPaymentVerified, payment.ID, payload)
+	}
+	return nil
+}
+
+// chargeTenders debits the stored-value tenders of a new payment, undoing them all if one fails
+func (s *CommandService) chargeTenders(ctx context.Context, payment *Payment) error {
+	for i, t := range payment.Tenders {
+		if !t.IsStoredValue() {
+			continue
+		}
+		if err := s.StoredValue.Charge(ctx, t.Method, t.Reference, payment.UserID, payment.ID, t.Amount); err != nil {
+			for _, charged := range payment.Tenders[:i] {
+				if charged.IsStoredValue() {
+					s.reverseTender(ctx, payment, charged, charged.Amount)
+				}
+			}
+			return fmt.Errorf("%w: %s: %w", ErrTenderDeclined, t.Method, err)
+		}
+	}
+	return nil
+}
+
+// voidTenders gives back every stored-value tender of a payment that will never be captured
+func (s *CommandService) voidTenders(ctx context.Context, payment *Payment) {
+	tenders := payment.Tenders
+	if tenders == nil {
+		var err error
+		if tenders, err = s.Repo.GetTenders(ctx, payment.ID); err != nil {
+			log.Printf("⚠️ Failed to load tenders of payment %s: %v", payment.ID, err)
+			return
+		}
+	}
+
+	for i, t := range tenders {
+		remaining := money.Money{Amount: t.Amount.Amount - t.RefundedAmount.Amount, Currency: t.Amount.Currency}
+		if !t.IsStoredValue() || !remaining.IsPositive() {
+			continue
+		}
+		if s.reverseTender(ctx, payment, t, remaining) {
+			s.recordTenderRefund(ctx, payment, i, remaining.Amount)
+		}
+	}
+}
+
+// refundTenders returns a refund to the tenders it was paid with; card shares go back through the gateway
+func (s *CommandService) refundTenders(ctx context.Context, payment *Payment, amount int64) {
+	tenders, err := s.Repo.GetTenders(ctx, payment.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load tenders of payment %s: %v", payment.ID, err)
+		return
+	}
+
+	for i, share := range allocateRefund(tenders, amount) {
+		if share == 0 {
+			continue
+		}
+		t := tenders[i]
+		if t.IsStoredValue() && !s.reverseTender(ctx, payment, t, money.Money{Amount: share, Currency: t.Amount.Currency}) {
+			continue
+		}
+		s.recordTenderRefund(ctx, payment, i, share)
+	}
+}
+
+// reverseTender credits amount back to a wallet or gift card, logging failures for manual follow-up
+func (s *CommandService) reverseTender(ctx context.Context, payment *Payment, t Tender, amount money.Money) bool {
+	if s.StoredValue == nil {
+		log.Printf("⚠️ Cannot reverse %s tender of payment %s: stored value is not enabled", t.Method, payment.ID)
+		return false
+	}
+	if err := s.StoredValue.Reverse(ctx, t.Method, t.Reference, payment.UserID, payment.ID, amount); err != nil {
+		log.Printf("⚠️ Failed to reverse %s of %s tender for payment %s: %v", amount, t.Method, payment.ID, err)
+		return false
+	}
+	return true
+}
+
+func (s *CommandService) recordTenderRefund(ctx context.Context, payment *Payment, position int, amount int64) {
+	if err := s.Repo.AddTenderRefund(ctx, payment.ID, position, amount); err != nil {
+		log.Printf("⚠️ Failed to record tender refund for payment %s: %v", payment.ID, err)
+	}
+}
+
+// breakdown splits a booking's charge into fare, discounts, venue fees and taxes
+func (s *CommandService) breakdown(ctx context.Context, quote *pricing.Quote, fare money.Money, discounts []money.LineItem) (*fees.Breakdown, error) {
+	if s.Fees == nil {
+		return fees.Calculate(fare, discounts, 1, fees.Rules{})
+	}
+	if quote == nil {
+		return s.Fees.BreakdownForShow(ctx, 0, fare, discounts, 1)
+	}
+	return s.Fees.BreakdownForShow(ctx, quote.ShowID, fare, discounts, len(quote.Items))
+}
+
 // VerifyPayment - Command to verify a payment
 func (s *CommandService) VerifyPayment(ctx context.Context, req VerifyPaymentRequest) error {
 	// Validate input
@@ -103,34 +373,36 @@ func (s *CommandService) VerifyPayment(ctx context.Context, req VerifyPaymentReq
 		return err
 	}
 
-	if payment.Status != "PENDING" {
-		return errors.New("payment already processed")
-	}
-
-	var finalStatus string
+	var success bool
 
 	switch req.Mode {
 	case "success":
-		finalStatus = "SUCCESS"
+		success = true
 	case "fail":
-		finalStatus = "FAILED"
+		success = false
 	case "random":
-		rand.Seed(time.Now().UnixNano())
-		if rand.Intn(2) == 0 {
-			finalStatus = "SUCCESS"
-		} else {
-			finalStatus = "FAILED"
-		}
+		success = rand.Intn(2) == 0
 	default:
 		return errors.New("invalid mode (use success/fail/random)")
 	}
 
 	txnID := fmt.Sprintf("mock_txn_%d", time.Now().Unix())
 
-	err = s.Repo.UpdateStatus(req.PaymentID, finalStatus, txnID)
-	if err != nil {
+	finalStatus := StatusFailed
+	if success {
+		// The mock gateway authorizes and captures in one step
+		if err := s.transition(ctx, payment, StatusAuthorized, txnID, 0, "authorized by provider"); err != nil {
+			return err
+		}
+		finalStatus = StatusCaptured
+	}
+	if err := s.transition(ctx, payment, finalStatus, txnID, 0, "verification mode "+req.Mode); err != nil {
 		return err
 	}
+	if finalStatus == StatusFailed {
+		s.voidTenders(ctx, payment)
+		s.releaseDiscounts(ctx, payment)
+	}
 
 	// Emit event for event-driven flow
 	if s.Dispatcher != nil {
@@ -138,7 +410,7 @@ func (s *CommandService) VerifyPayment(ctx context.Context, req VerifyPaymentReq
 			UserID:    payment.UserID,
 			BookingID: payment.BookingID,
 			PaymentID: req.PaymentID,
-			Amount:    payment.Amount,
+			Amount:    &payment.Amount,
 			Status:    finalStatus,
 		}
 		_ = s.Dispatcher.Publish(ctx, events.EventPaymentVerified, req.PaymentID, payload)
@@ -147,12 +419,20 @@ func (s *CommandService) VerifyPayment(ctx context.Context, req VerifyPaymentReq
 	return nil
 }
 
-// RefundPayment - Command to refund a payment
+// RefundPayment - Command to refund the remaining captured amount of a payment
 func (s *CommandService) RefundPayment(ctx context.Context, paymentID string) error {
+	return s.RefundPartial(ctx, paymentID, 0)
+}
+
+// RefundPartial - Command to refund part of a captured payment; amount 0 refunds the remainder
+func (s *CommandService) RefundPartial(ctx context.Context, paymentID string, amount int64) error {
 	// Validate input
 	if paymentID == "" {
 		return errors.New("payment ID is required")
 	}
+	if amount < 0 {
+		return errors.New("refund amount must be positive")
+	}
 
 	// Validate UUID
 	if err := utils.ValidateUUID("payment_id", paymentID); err != nil {
@@ -164,26 +444,148 @@ func (s *CommandService) RefundPayment(ctx context.Context, paymentID string) er
 		return err
 	}
 
-	if payment.Status != "SUCCESS" {
-		return errors.New("can only refund successful payments")
+	remaining := payment.Amount.Amount - payment.RefundedAmount.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount > remaining {
+		return fmt.Errorf("%w: %s requested, %s refundable", ErrRefundExceedsBalance,
+			money.Money{Amount: amount, Currency: payment.Amount.Currency},
+			money.Money{Amount: remaining, Currency: payment.Amount.Currency})
+	}
+
+	return s.refund(ctx, payment, amount, "refund requested")
+}
+
+// refund gives back amount of a captured payment, all of what's left of it or part
+func (s *CommandService) refund(ctx context.Context, payment *Payment, amount int64, reason string) error {
+	status := StatusPartiallyRefunded
+	if amount == payment.Amount.Amount-payment.RefundedAmount.Amount {
+		status = StatusRefunded
+	}
+
+	if err := s.transition(ctx, payment, status, "", amount, reason); err != nil {
+		return err
+	}
+	s.refundTenders(ctx, payment, amount)
+
+	// Emit event for event-driven flow
+	if s.Dispatcher != nil {
+		refunded := money.Money{Amount: amount, Currency: payment.Amount.Currency}
+		payload := events.EventPayload{
+			UserID:    payment.UserID,
+			BookingID: payment.BookingID,
+			PaymentID: payment.ID,
+			Amount:    &refunded,
+			Status:    status,
+		}
+		_ = s.Dispatcher.Publish(ctx, events.EventPaymentRefunded, payment.ID, payload)
 	}
 
-	err = s.Repo.UpdateStatus(paymentID, "REFUNDED", "")
+	return nil
+}
+
+// RefundBooking - Command to give back what was paid for a booking that was taken away
+// from its user, as when its show is cancelled. Captured payments are refunded in full and
+// ones still at checkout are cancelled; payments already settled either way are left alone,
+// so running it again is safe. It returns the payments it changed, in their new status.
+func (s *CommandService) RefundBooking(ctx context.Context, bookingID, reason string) ([]Payment, error) {
+	open, err := s.Repo.GetOpenPaymentsByBookingID(ctx, bookingID)
 	if err != nil {
+		return nil, err
+	}
+
+	for i := range open {
+		p := &open[i]
+		if IsSettled(p.Status) {
+			err = s.refund(ctx, p, p.Amount.Amount-p.RefundedAmount.Amount, reason)
+		} else {
+			err = s.void(ctx, p, StatusCancelled, reason, events.EventPaymentCancelled)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return open, nil
+}
+
+// ExpirePayment - Command to expire a payment abandoned at checkout and void its intent
+func (s *CommandService) ExpirePayment(ctx context.Context, payment *Payment) error {
+	return s.void(ctx, payment, StatusExpired, "pending payment expired", events.EventPaymentExpired)
+}
+
+// void ends a payment that was never captured, expired or cancelled, and gives back the
+// tenders and discounts it was holding
+func (s *CommandService) void(ctx context.Context, payment *Payment, status, reason, eventType string) error {
+	// End it first so a concurrent verification can't capture a cancelled intent
+	if err := s.transition(ctx, payment, status, "", 0, reason); err != nil {
 		return err
 	}
 
+	if err := s.provider().CancelIntent(ctx, payment.ID, payment.TransactionID); err != nil {
+		log.Printf("⚠️ Failed to cancel provider intent for payment %s: %v", payment.ID, err)
+	}
+	s.voidTenders(ctx, payment)
+	s.releaseDiscounts(ctx, payment)
+
 	// Emit event for event-driven flow
 	if s.Dispatcher != nil {
 		payload := events.EventPayload{
 			UserID:    payment.UserID,
 			BookingID: payment.BookingID,
-			PaymentID: paymentID,
-			Amount:    payment.Amount,
-			Status:    "REFUNDED",
+			PaymentID: payment.ID,
+			Amount:    &payment.Amount,
+			Status:    status,
 		}
-		_ = s.Dispatcher.Publish(ctx, events.EventPaymentRefunded, paymentID, payload)
+		_ = s.Dispatcher.Publish(ctx, eventType, payment.ID, payload)
+	}
+
+	return nil
+}
+
+// releaseDiscounts gives back the promo code and loyalty points of a payment that will never be captured
+func (s *CommandService) releaseDiscounts(ctx context.Context, payment *Payment) {
+	s.releasePromo(ctx, payment)
+	if s.Loyalty == nil {
+		return
+	}
+	if err := s.Loyalty.Restore(ctx, payment.ID, "Payment not completed"); err != nil {
+		log.Printf("⚠️ Failed to restore loyalty points for payment %s: %v", payment.ID, err)
+	}
+}
+
+// releasePromo gives back the promo redemption of a payment that will never be captured
+func (s *CommandService) releasePromo(ctx context.Context, payment *Payment) {
+	if s.Promotions == nil || payment.PromoCode == "" {
+		return
+	}
+	if err := s.Promotions.Release(ctx, payment.ID); err != nil {
+		log.Printf("⚠️ Failed to release promo %s for payment %s: %v", payment.PromoCode, payment.ID, err)
+	}
+}
+
+// transition applies a guarded status change and updates the in-memory payment
+func (s *CommandService) transition(ctx context.Context, p *Payment, to, txnID string, refundDelta int64, reason string) error {
+	if err := checkTransition(p, to); err != nil {
+		return err
 	}
 
+	err := s.Repo.TransitionStatus(ctx, StatusChange{
+		PaymentID:     p.ID,
+		From:          p.Status,
+		To:            to,
+		TransactionID: txnID,
+		RefundDelta:   refundDelta,
+		Reason:        reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	p.Status = to
+	p.RefundedAmount.Amount += refundDelta
+	if txnID != "" {
+		p.TransactionID = txnID
+	}
 	return nil
 }
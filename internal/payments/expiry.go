@@ -0,0 +1,88 @@
+// Expiry of payments abandoned at checkout
+
+package payments
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// expiryBatchSize caps how many payments a single sweep expires
+const expiryBatchSize = 100
+
+// HoldReleaser frees the seat hold behind a booking once its payment can no longer complete
+type HoldReleaser interface {
+	ReleaseHold(ctx context.Context, bookingID, userID string) error
+}
+
+// ExpirySweeper moves payments that stayed PENDING longer than TTL to EXPIRED
+type ExpirySweeper struct {
+	Service *CommandService
+	Holds   HoldReleaser
+	TTL     time.Duration
+}
+
+func NewExpirySweeper(service *CommandService, holds HoldReleaser, ttl time.Duration) *ExpirySweeper {
+	return &ExpirySweeper{Service: service, Holds: holds, TTL: ttl}
+}
+
+// Run sweeps every interval until the context is cancelled
+func (s *ExpirySweeper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := s.RunOnce(ctx); err != nil {
+			log.Printf("❌ Payment expiry sweep failed: %v", err)
+		} else if n > 0 {
+			log.Printf("⌛ Expired %d stale pending payments", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce expires one batch of stale payments and returns how many were expired
+func (s *ExpirySweeper) RunOnce(ctx context.Context) (int, error) {
+	stale, err := s.Service.Repo.GetStalePendingPayments(ctx, time.Now().Add(-s.TTL), expiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range stale {
+		p := &stale[i]
+		if err := s.Service.ExpirePayment(ctx, p); err != nil {
+			// Most likely verified concurrently; the guarded transition lost the race
+			log.Printf("⚠️ Failed to expire payment %s: %v", p.ID, err)
+			continue
+		}
+		expired++
+
+		if s.Holds != nil {
+			s.releaseHold(ctx, p)
+		}
+	}
+
+	return expired, nil
+}
+
+// releaseHold frees the seat hold of an expired payment's booking, unless another payment
+// for the booking is still at checkout or has been paid and needs the seats kept
+func (s *ExpirySweeper) releaseHold(ctx context.Context, p *Payment) {
+	open, err := s.Service.Repo.GetOpenPaymentsByBookingID(ctx, p.BookingID)
+	if err != nil {
+		log.Printf("⚠️ Failed to check payments of booking %s, keeping its seat hold: %v", p.BookingID, err)
+		return
+	}
+	if len(open) > 0 {
+		return
+	}
+	if err := s.Holds.ReleaseHold(ctx, p.BookingID, p.UserID); err != nil {
+		log.Printf("⚠️ Failed to release seat hold for booking %s: %v", p.BookingID, err)
+	}
+}
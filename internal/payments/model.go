@@ -2,18 +2,38 @@
 
 package payments
 
-import "time"
+import (
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
 
 type Payment struct {
-	ID        string    `json:"id"`
-	BookingID string    `json:"booking_id"`
-	UserID   string    `json:"user_id"`
-	Amount    int       `json:"amount"`
+	ID             string      `json:"id"`
+	BookingID      string      `json:"booking_id"`
+	UserID         string      `json:"user_id"`
+	Amount         money.Money `json:"amount"` // charged amount, after any discount
+	RefundedAmount money.Money `json:"refunded_amount"`
+	Discount       money.Money `json:"discount"`
+	PromoCode      string      `json:"promo_code,omitempty"`
+
+	// LineItems break Amount into fare, discount, fees and taxes
+	LineItems []money.LineItem `json:"line_items,omitempty"`
+	// Tenders split Amount across the card, wallet and gift cards
+	Tenders []Tender `json:"tenders,omitempty"`
 
-	Status        string    `json:"status"` // PENDING, SUCCESS, FAILED
-	TransactionID string    `json:"transaction_id"`
+	Status        string `json:"status"` // see state.go for the lifecycle
+	TransactionID string `json:"transaction_id"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// StatusHistoryEntry is one recorded status transition of a payment
+type StatusHistoryEntry struct {
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
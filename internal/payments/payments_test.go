@@ -1,9 +1,13 @@
 package payments
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
 )
 
 // TestPaymentModel tests the Payment struct
@@ -12,7 +16,7 @@ func TestPaymentModel(t *testing.T) {
 		ID:        "pay_123",
 		BookingID: "book_123",
 		UserID:    "user_123",
-		Amount:    100.00,
+		Amount:    money.Money{Amount: 10000, Currency: "INR"},
 		Status:    "PENDING",
 		CreatedAt: time.Now(),
 	}
@@ -26,8 +30,11 @@ func TestPaymentModel(t *testing.T) {
 	if payment.UserID != "user_123" {
 		t.Errorf("Expected UserID user_123, got %s", payment.UserID)
 	}
-	if payment.Amount != 100 {
-		t.Errorf("Expected Amount 100, got %d", payment.Amount)
+	if payment.Amount.Amount != 10000 {
+		t.Errorf("Expected Amount 10000, got %d", payment.Amount.Amount)
+	}
+	if payment.Amount.String() != "₹100.00" {
+		t.Errorf("Expected formatted amount ₹100.00, got %s", payment.Amount.String())
 	}
 	if payment.Status != "PENDING" {
 		t.Errorf("Expected Status PENDING, got %s", payment.Status)
@@ -255,34 +262,77 @@ func validateRefundPayment(paymentID string) error {
 
 // TestPaymentStatus tests valid payment statuses
 func TestPaymentStatus(t *testing.T) {
-	validStatuses := []string{"PENDING", "SUCCESS", "FAILED", "REFUNDED"}
-
 	testCases := []struct {
 		status   string
 		expected bool
 	}{
-		{"PENDING", true},
-		{"SUCCESS", true},
-		{"FAILED", true},
-		{"REFUNDED", true},
+		{StatusPending, true},
+		{StatusAuthorized, true},
+		{StatusCaptured, true},
+		{StatusPartiallyRefunded, true},
+		{StatusRefunded, true},
+		{StatusFailed, true},
+		{StatusCancelled, true},
+		{StatusExpired, true},
+		{"SUCCESS", false},
 		{"INVALID", false},
 		{"", false},
 	}
 
 	for _, tc := range testCases {
-		isValid := false
-		for _, s := range validStatuses {
-			if tc.status == s {
-				isValid = true
-				break
-			}
-		}
-		if isValid != tc.expected {
+		if IsValidStatus(tc.status) != tc.expected {
 			t.Errorf("Expected status %s to be %v", tc.status, tc.expected)
 		}
 	}
 }
 
+// TestCanTransition tests the payment state machine
+func TestCanTransition(t *testing.T) {
+	testCases := []struct {
+		from     string
+		to       string
+		expected bool
+	}{
+		{StatusPending, StatusAuthorized, true},
+		{StatusPending, StatusExpired, true},
+		{StatusPending, StatusCaptured, false},
+		{StatusAuthorized, StatusCaptured, true},
+		{StatusCaptured, StatusPartiallyRefunded, true},
+		{StatusCaptured, StatusRefunded, true},
+		{StatusCaptured, StatusFailed, false},
+		{StatusPartiallyRefunded, StatusPartiallyRefunded, true},
+		{StatusPartiallyRefunded, StatusRefunded, true},
+		{StatusRefunded, StatusCaptured, false},
+		{StatusFailed, StatusAuthorized, false},
+		{StatusExpired, StatusAuthorized, false},
+	}
+
+	for _, tc := range testCases {
+		if CanTransition(tc.from, tc.to) != tc.expected {
+			t.Errorf("Expected %s -> %s to be %v", tc.from, tc.to, tc.expected)
+		}
+	}
+}
+
+// TestTransitionError tests that illegal transitions are recognisable
+func TestTransitionError(t *testing.T) {
+	p := &Payment{ID: "pay_123", Status: StatusRefunded}
+
+	err := checkTransition(p, StatusCaptured)
+	if !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("Expected ErrIllegalTransition, got %v", err)
+	}
+
+	var te *TransitionError
+	if !errors.As(err, &te) || te.From != StatusRefunded || te.To != StatusCaptured {
+		t.Errorf("Unexpected transition error: %v", err)
+	}
+
+	if err := checkTransition(&Payment{Status: StatusPending}, StatusAuthorized); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
 // TestNewCommandService tests constructor functions
 func TestNewCommandService(t *testing.T) {
 	svc := NewCommandService(nil)
@@ -291,13 +341,40 @@ func TestNewCommandService(t *testing.T) {
 	}
 }
 
+// recordingProvider records cancelled intents
+type recordingProvider struct {
+	cancelled []string
+}
+
+func (p *recordingProvider) CancelIntent(ctx context.Context, paymentID, transactionID string) error {
+	p.cancelled = append(p.cancelled, paymentID)
+	return nil
+}
+
+// TestExpirePayment_NotPending tests that only pending payments can expire
+func TestExpirePayment_NotPending(t *testing.T) {
+	provider := &recordingProvider{}
+	svc := &CommandService{Provider: provider}
+
+	for _, status := range []string{StatusCaptured, StatusFailed, StatusExpired} {
+		err := svc.ExpirePayment(context.Background(), &Payment{ID: "pay_123", Status: status})
+		if !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("Expected ErrIllegalTransition for %s, got %v", status, err)
+		}
+	}
+
+	if len(provider.cancelled) != 0 {
+		t.Errorf("Expected no intents cancelled, got %v", provider.cancelled)
+	}
+}
+
 // Benchmark tests
 func BenchmarkPaymentModel(b *testing.B) {
 	payment := Payment{
 		ID:        "pay_123",
 		BookingID: "book_123",
 		UserID:    "user_123",
-		Amount:    100.00,
+		Amount:    money.Money{Amount: 10000, Currency: "INR"},
 		Status:    "PENDING",
 		CreatedAt: time.Now(),
 	}
@@ -320,3 +397,160 @@ func BenchmarkInitiatePaymentValidation(b *testing.B) {
 		validateInitiatePaymentRequest(req)
 	}
 }
+
+// TestParseSettlementCSV tests parsing a provider settlement file
+func TestParseSettlementCSV(t *testing.T) {
+	data := "transaction_id,amount,currency,settled_at\n" +
+		"txn_1,500.00,INR,2026-01-02T10:00:00Z\n" +
+		"txn_2,12.5,USD,\n"
+
+	records, err := ParseSettlementCSV(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Amount != (money.Money{Amount: 50000, Currency: "INR"}) {
+		t.Errorf("Unexpected amount for txn_1: %+v", records[0].Amount)
+	}
+	if records[0].SettledAt.IsZero() {
+		t.Error("Expected settled_at for txn_1")
+	}
+	if records[1].Amount != (money.Money{Amount: 1250, Currency: "USD"}) {
+		t.Errorf("Unexpected amount for txn_2: %+v", records[1].Amount)
+	}
+
+	if _, err := ParseSettlementCSV(strings.NewReader("transaction_id,amount\ntxn_1,5\n")); err == nil {
+		t.Error("Expected error for missing currency column")
+	}
+}
+
+// TestReconcile tests classification of missing, extra and mismatched transactions
+func TestReconcile(t *testing.T) {
+	inr := func(amount int64) money.Money { return money.Money{Amount: amount, Currency: "INR"} }
+
+	ours := []Payment{
+		{ID: "p1", TransactionID: "txn_ok", Amount: inr(50000)},
+		{ID: "p2", TransactionID: "txn_missing", Amount: inr(30000)},
+		{ID: "p3", TransactionID: "txn_diff", Amount: inr(20000)},
+		// Only the card share of a split payment is settled by the provider
+		{ID: "p4", TransactionID: "txn_split", Amount: inr(40000), Tenders: []Tender{
+			{Method: MethodGiftCard, Reference: "GC-1", Amount: inr(15000)},
+			{Method: MethodCard, Amount: inr(25000)},
+		}},
+		{ID: "p5", TransactionID: "txn_split_diff", Amount: inr(40000), Tenders: []Tender{
+			{Method: MethodWallet, Amount: inr(15000)},
+			{Method: MethodCard, Amount: inr(25000)},
+		}},
+		// Paid from stored value alone, so never sent to the provider
+		{ID: "p6", TransactionID: "stored_value_p6", Amount: inr(12000), Tenders: []Tender{
+			{Method: MethodWallet, Amount: inr(12000)},
+		}},
+	}
+	settled := []SettlementRecord{
+		{TransactionID: "txn_ok", Amount: inr(50000)},
+		{TransactionID: "txn_diff", Amount: inr(19900)},
+		{TransactionID: "txn_extra", Amount: inr(10000)},
+		{TransactionID: "txn_split", Amount: inr(25000)},
+		{TransactionID: "txn_split_diff", Amount: inr(40000)},
+	}
+
+	report := Reconcile(ours, settled)
+
+	if report.Matched != 2 {
+		t.Errorf("Expected 2 matched, got %d", report.Matched)
+	}
+	kinds := map[string]string{}
+	for _, d := range report.Discrepancies {
+		kinds[d.TransactionID] = d.Kind
+	}
+	expected := map[string]string{
+		"txn_missing": DiscrepancyMissing,
+		"txn_diff":       DiscrepancyAmountMismatch,
+		"txn_extra":      DiscrepancyExtra,
+		"txn_split_diff": DiscrepancyAmountMismatch,
+	}
+	if len(kinds) != len(expected) {
+		t.Fatalf("Expected %d discrepancies, got %d", len(expected), len(kinds))
+	}
+	for txn, kind := range expected {
+		if kinds[txn] != kind {
+			t.Errorf("Expected %s for %s, got %s", kind, txn, kinds[txn])
+		}
+	}
+}
+
+// TestSplitTenders tests splitting a total across stored-value tenders and the card
+func TestSplitTenders(t *testing.T) {
+	inr := func(amount int64) money.Money { return money.Money{Amount: amount, Currency: "INR"} }
+	total := inr(50000)
+
+	tenders, err := splitTenders(total, []TenderRequest{
+		{Method: "gift_card", Code: " gc-abcd ", Amount: 20000},
+		{Method: MethodWallet, Amount: 10000},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tenders) != 3 {
+		t.Fatalf("Expected 3 tenders, got %d", len(tenders))
+	}
+	if tenders[0].Method != MethodGiftCard || tenders[0].Reference != "GC-ABCD" {
+		t.Errorf("Expected normalized gift card tender, got %+v", tenders[0])
+	}
+	if tenders[2].Method != MethodCard || tenders[2].Amount != inr(20000) {
+		t.Errorf("Expected card to cover 20000, got %+v", tenders[2])
+	}
+	if !hasCardTender(tenders) {
+		t.Error("Expected a card tender")
+	}
+
+	full, err := splitTenders(total, []TenderRequest{{Method: MethodWallet, Amount: 50000}})
+	if err != nil || len(full) != 1 || hasCardTender(full) {
+		t.Errorf("Expected a wallet-only split, got %+v (%v)", full, err)
+	}
+
+	invalid := [][]TenderRequest{
+		{{Method: MethodCard, Amount: 100}},
+		{{Method: MethodGiftCard, Amount: 100}},
+		{{Method: MethodWallet, Amount: 0}},
+		{{Method: MethodWallet, Amount: 100}, {Method: MethodWallet, Amount: 100}},
+		{{Method: MethodWallet, Amount: 50001}},
+	}
+	for _, reqs := range invalid {
+		if _, err := splitTenders(total, reqs); !errors.Is(err, ErrInvalidTender) {
+			t.Errorf("Expected ErrInvalidTender for %+v, got %v", reqs, err)
+		}
+	}
+}
+
+// TestAllocateRefund tests that refunds go to the card first, then stored value
+func TestAllocateRefund(t *testing.T) {
+	inr := func(amount int64) money.Money { return money.Money{Amount: amount, Currency: "INR"} }
+	tenders := []Tender{
+		{Method: MethodGiftCard, Amount: inr(20000), RefundedAmount: inr(0)},
+		{Method: MethodWallet, Amount: inr(10000), RefundedAmount: inr(0)},
+		{Method: MethodCard, Amount: inr(20000), RefundedAmount: inr(5000)},
+	}
+
+	tests := []struct {
+		amount   int64
+		expected []int64
+	}{
+		{10000, []int64{0, 0, 10000}},
+		{20000, []int64{0, 5000, 15000}},
+		{45000, []int64{20000, 10000, 15000}},
+		{60000, []int64{20000, 10000, 15000}},
+	}
+
+	for _, tt := range tests {
+		got := allocateRefund(tenders, tt.amount)
+		for i := range tt.expected {
+			if got[i] != tt.expected[i] {
+				t.Errorf("Refund %d: expected %v, got %v", tt.amount, tt.expected, got)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Payment provider abstraction
+
+package payments
+
+import (
+	"context"
+	"log"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// Provider is the payment gateway a payment intent lives with
+type Provider interface {
+	// CancelIntent voids an uncaptured payment intent so it can no longer be paid
+	CancelIntent(ctx context.Context, paymentID, transactionID string) error
+}
+
+// TenderProvider charges the stored-value tenders (wallets, gift cards) of a split payment.
+// Unlike the gateway, stored value is debited as soon as the payment is initiated.
+type TenderProvider interface {
+	// Charge takes amount from the gift card identified by reference, or the user's wallet
+	Charge(ctx context.Context, method, reference, userID, paymentID string, amount money.Money) error
+	// Reverse gives back amount of an earlier charge
+	Reverse(ctx context.Context, method, reference, userID, paymentID string, amount money.Money) error
+}
+
+// MockProvider stands in for a real gateway; verification is simulated in VerifyPayment
+type MockProvider struct{}
+
+func (MockProvider) CancelIntent(ctx context.Context, paymentID, transactionID string) error {
+	log.Printf("🧪 Mock provider cancelled intent for payment %s", paymentID)
+	return nil
+}
@@ -51,3 +51,25 @@ func (h *QueryHandler) GetPaymentsByUser(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, payments)
 }
+
+// GetReconciliationReports - Query handler for listing settlement reconciliation runs (admin)
+func (h *QueryHandler) GetReconciliationReports(c *gin.Context) {
+	reports, err := h.QueryService.ListReconciliationReports(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reconciliation reports"})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// GetReconciliationReport - Query handler for a single reconciliation report with discrepancies (admin)
+func (h *QueryHandler) GetReconciliationReport(c *gin.Context) {
+	id := c.Param("id")
+
+	report, err := h.QueryService.GetReconciliationReport(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reconciliation report not found"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -4,52 +4,90 @@ package payments
 
 import (
 	"context"
+	"errors"
 
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type QueryService struct {
-	DB *pgxpool.Pool
+	DB    *pgxpool.Pool
+	CmdDB *pgxpool.Pool // source of truth for line items, tenders and payments not yet projected
 }
 
 func NewQueryService(db *pgxpool.Pool) *QueryService {
 	return &QueryService{DB: db}
 }
 
+func NewQueryServiceWithCmdDB(queryDB, cmdDB *pgxpool.Pool) *QueryService {
+	return &QueryService{DB: queryDB, CmdDB: cmdDB}
+}
+
+// withBreakdown falls back to the CommandDB for unprojected payments and attaches line items and tenders
+func (s *QueryService) withBreakdown(ctx context.Context, p *Payment, err error, fromCmdDB func(*Repository) (*Payment, error)) (*Payment, error) {
+	if s.CmdDB == nil {
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		p, err = fromCmdDB(NewRepository(s.CmdDB))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.LineItems, err = getLineItems(ctx, s.CmdDB, p.ID); err != nil {
+		return nil, err
+	}
+	if p.Tenders, err = getTenders(ctx, s.CmdDB, p.ID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
 // GetPaymentByID - Query to get a payment by ID
 func (s *QueryService) GetPaymentByID(ctx context.Context, id string) (*Payment, error) {
 	var p Payment
 	err := s.DB.QueryRow(ctx, `
-		SELECT id, booking_id, user_id, amount, status, transaction_id, created_at
+		SELECT id, booking_id, user_id, amount, currency, refunded_amount, discount_amount, COALESCE(promo_code, ''), status, transaction_id, created_at, updated_at
 		FROM payments WHERE id = $1
-	`, id).Scan(&p.ID, &p.BookingID, &p.UserID, &p.Amount, &p.Status, &p.TransactionID, &p.CreatedAt)
+	`, id).Scan(&p.ID, &p.BookingID, &p.UserID, &p.Amount.Amount, &p.Amount.Currency, &p.RefundedAmount.Amount, &p.Discount.Amount, &p.PromoCode, &p.Status, &p.TransactionID, &p.CreatedAt, &p.UpdatedAt)
 
-	if err != nil {
-		return nil, err
+	if err == nil {
+		p.RefundedAmount.Currency = p.Amount.Currency
+		p.Discount.Currency = p.Amount.Currency
 	}
 
-	return &p, nil
+	return s.withBreakdown(ctx, &p, err, func(r *Repository) (*Payment, error) {
+		return r.GetPaymentByID(id)
+	})
 }
 
 // GetPaymentByBookingID - Query to get a payment by booking ID
 func (s *QueryService) GetPaymentByBookingID(ctx context.Context, bookingID string) (*Payment, error) {
 	var p Payment
 	err := s.DB.QueryRow(ctx, `
-		SELECT id, booking_id, user_id, amount, status, transaction_id, created_at
+		SELECT id, booking_id, user_id, amount, currency, refunded_amount, discount_amount, COALESCE(promo_code, ''), status, transaction_id, created_at, updated_at
 		FROM payments WHERE booking_id = $1
-	`, bookingID).Scan(&p.ID, &p.BookingID, &p.UserID, &p.Amount, &p.Status, &p.TransactionID, &p.CreatedAt)
+	`, bookingID).Scan(&p.ID, &p.BookingID, &p.UserID, &p.Amount.Amount, &p.Amount.Currency, &p.RefundedAmount.Amount, &p.Discount.Amount, &p.PromoCode, &p.Status, &p.TransactionID, &p.CreatedAt, &p.UpdatedAt)
 
-	if err != nil {
-		return nil, err
+	if err == nil {
+		p.RefundedAmount.Currency = p.Amount.Currency
+		p.Discount.Currency = p.Amount.Currency
 	}
 
-	return &p, nil
+	return s.withBreakdown(ctx, &p, err, func(r *Repository) (*Payment, error) {
+		return r.GetPaymentByBookingID(bookingID)
+	})
 }
 
 // GetPaymentsByUserID - Query to get all payments for a user
 func (s *QueryService) GetPaymentsByUserID(ctx context.Context, userID string) ([]Payment, error) {
 	rows, err := s.DB.Query(ctx, `
-		SELECT id, booking_id, user_id, amount, status, transaction_id, created_at
+		SELECT id, booking_id, user_id, amount, currency, refunded_amount, discount_amount, COALESCE(promo_code, ''), status, transaction_id, created_at, updated_at
 		FROM payments WHERE user_id = $1
 		ORDER BY created_at DESC
 	`, userID)
@@ -61,12 +99,80 @@ func (s *QueryService) GetPaymentsByUserID(ctx context.Context, userID string) (
 	var payments []Payment
 	for rows.Next() {
 		var p Payment
-		err := rows.Scan(&p.ID, &p.BookingID, &p.UserID, &p.Amount, &p.Status, &p.TransactionID, &p.CreatedAt)
+		err := rows.Scan(&p.ID, &p.BookingID, &p.UserID, &p.Amount.Amount, &p.Amount.Currency, &p.RefundedAmount.Amount, &p.Discount.Amount, &p.PromoCode, &p.Status, &p.TransactionID, &p.CreatedAt, &p.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
+		p.RefundedAmount.Currency = p.Amount.Currency
+		p.Discount.Currency = p.Amount.Currency
 		payments = append(payments, p)
 	}
 
 	return payments, nil
 }
+
+// ListReconciliationReports - Query to list reconciliation runs, newest first
+func (s *QueryService) ListReconciliationReports(ctx context.Context) ([]ReconciliationReport, error) {
+	rows, err := s.DB.Query(ctx, `
+		SELECT id, file_name, matched, created_at
+		FROM reconciliation_reports
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []ReconciliationReport{}
+	for rows.Next() {
+		var r ReconciliationReport
+		if err := rows.Scan(&r.ID, &r.FileName, &r.Matched, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+
+	return reports, nil
+}
+
+// GetReconciliationReport - Query to get a reconciliation report with its discrepancies
+func (s *QueryService) GetReconciliationReport(ctx context.Context, id string) (*ReconciliationReport, error) {
+	var r ReconciliationReport
+	err := s.DB.QueryRow(ctx, `
+		SELECT id, file_name, matched, created_at
+		FROM reconciliation_reports WHERE id = $1
+	`, id).Scan(&r.ID, &r.FileName, &r.Matched, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.Query(ctx, `
+		SELECT kind, transaction_id, COALESCE(payment_id::text, ''), expected_amount, settled_amount, currency
+		FROM reconciliation_items
+		WHERE report_id = $1
+		ORDER BY kind, transaction_id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	r.Discrepancies = []Discrepancy{}
+	for rows.Next() {
+		var d Discrepancy
+		var expected, settled *int64
+		var currency string
+		if err := rows.Scan(&d.Kind, &d.TransactionID, &d.PaymentID, &expected, &settled, &currency); err != nil {
+			return nil, err
+		}
+		if expected != nil {
+			d.Expected = &money.Money{Amount: *expected, Currency: currency}
+		}
+		if settled != nil {
+			d.Settled = &money.Money{Amount: *settled, Currency: currency}
+		}
+		r.Discrepancies = append(r.Discrepancies, d)
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,362 @@
+// Reconciliation of payments against provider settlement reports
+
+package payments
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Discrepancy kinds reported by a reconciliation run
+const (
+	DiscrepancyMissing        = "MISSING"         // we captured it, the provider didn't settle it
+	DiscrepancyExtra          = "EXTRA"           // the provider settled it, we have no successful payment
+	DiscrepancyAmountMismatch = "AMOUNT_MISMATCH" // both sides have it with different amounts
+)
+
+// settlementLookback widens the payment window before the first settled row,
+// since providers settle a day or two after capture
+const settlementLookback = 72 * time.Hour
+
+// SettlementRecord is one row of a provider settlement file
+type SettlementRecord struct {
+	TransactionID string      `json:"transaction_id"`
+	Amount        money.Money `json:"amount"`
+	SettledAt     time.Time   `json:"settled_at"`
+}
+
+// Discrepancy describes a single transaction that didn't reconcile
+type Discrepancy struct {
+	Kind          string       `json:"kind"`
+	TransactionID string       `json:"transaction_id"`
+	PaymentID     string       `json:"payment_id,omitempty"`
+	Expected      *money.Money `json:"expected,omitempty"`
+	Settled       *money.Money `json:"settled,omitempty"`
+}
+
+// ReconciliationReport is the result of reconciling one settlement file
+type ReconciliationReport struct {
+	ID            string        `json:"id"`
+	FileName      string        `json:"file_name"`
+	Matched       int           `json:"matched"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// ParseSettlementCSV reads a settlement report with a header row containing
+// transaction_id, amount and currency columns, and an optional settled_at (RFC 3339)
+func ParseSettlementCSV(r io.Reader) ([]SettlementRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settlement header: %w", err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"transaction_id", "amount", "currency"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("settlement file is missing %q column", required)
+		}
+	}
+	settledCol, hasSettled := cols["settled_at"]
+
+	var records []SettlementRecord
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		amount, err := money.ParseMajor(row[cols["amount"]], row[cols["currency"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rec := SettlementRecord{
+			TransactionID: strings.TrimSpace(row[cols["transaction_id"]]),
+			Amount:        amount,
+		}
+		if rec.TransactionID == "" {
+			return nil, fmt.Errorf("line %d: transaction_id is empty", line)
+		}
+		if hasSettled && strings.TrimSpace(row[settledCol]) != "" {
+			rec.SettledAt, err = time.Parse(time.RFC3339, strings.TrimSpace(row[settledCol]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid settled_at: %w", line, err)
+			}
+		}
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// cardShare returns the part of a payment the provider settles, which is what was charged
+// to its card tenders. Payments without tenders predate split payments and were all card.
+func cardShare(p Payment) money.Money {
+	if len(p.Tenders) == 0 {
+		return p.Amount
+	}
+	share := money.Zero(p.Amount.Currency)
+	for _, t := range p.Tenders {
+		if !t.IsStoredValue() {
+			share.Amount += t.Amount.Amount
+		}
+	}
+	return share
+}
+
+// Reconcile compares our successful payments with settled records, expecting the card share
+// of each; payments paid entirely from wallets and gift cards never reach the provider and
+// are left out. Only payments passed in are considered for MISSING, so callers choose the
+// time window.
+func Reconcile(ours []Payment, settled []SettlementRecord) ReconciliationReport {
+	report := ReconciliationReport{Discrepancies: []Discrepancy{}}
+
+	byTxn := make(map[string]Payment, len(ours))
+	for _, p := range ours {
+		if p.TransactionID != "" && cardShare(p).IsPositive() {
+			byTxn[p.TransactionID] = p
+		}
+	}
+
+	seen := make(map[string]bool, len(settled))
+	for _, rec := range settled {
+		rec := rec
+		seen[rec.TransactionID] = true
+
+		p, ok := byTxn[rec.TransactionID]
+		if !ok {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:          DiscrepancyExtra,
+				TransactionID: rec.TransactionID,
+				Settled:       &rec.Amount,
+			})
+			continue
+		}
+		if expected := cardShare(p); expected != rec.Amount {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:          DiscrepancyAmountMismatch,
+				TransactionID: rec.TransactionID,
+				PaymentID:     p.ID,
+				Expected:      &expected,
+				Settled:       &rec.Amount,
+			})
+			continue
+		}
+		report.Matched++
+	}
+
+	for txnID, p := range byTxn {
+		if seen[txnID] {
+			continue
+		}
+		expected := cardShare(p)
+		report.Discrepancies = append(report.Discrepancies, Discrepancy{
+			Kind:          DiscrepancyMissing,
+			TransactionID: txnID,
+			PaymentID:     p.ID,
+			Expected:      &expected,
+		})
+	}
+
+	sort.Slice(report.Discrepancies, func(i, j int) bool {
+		a, b := report.Discrepancies[i], report.Discrepancies[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.TransactionID < b.TransactionID
+	})
+
+	return report
+}
+
+// Reconciler ingests settlement files from a directory and stores reports in the Query DB
+type Reconciler struct {
+	Repo    *Repository
+	QueryDB *pgxpool.Pool
+	Dir     string
+}
+
+func NewReconciler(repo *Repository, queryDB *pgxpool.Pool, dir string) *Reconciler {
+	return &Reconciler{Repo: repo, QueryDB: queryDB, Dir: dir}
+}
+
+// Run processes settlement files every interval until the context is cancelled
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.RunOnce(ctx); err != nil {
+			log.Printf("❌ Reconciliation run failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce reconciles every *.csv in the directory and moves it to processed/
+func (r *Reconciler) RunOnce(ctx context.Context) error {
+	files, err := filepath.Glob(filepath.Join(r.Dir, "*.csv"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, path := range files {
+		report, err := r.reconcileFile(ctx, path)
+		if err != nil {
+			log.Printf("❌ Failed to reconcile %s: %v", path, err)
+			r.moveFile(path, "failed")
+			continue
+		}
+		log.Printf("✅ Reconciled %s: %d matched, %d discrepancies", report.FileName, report.Matched, len(report.Discrepancies))
+		r.moveFile(path, "processed")
+	}
+
+	return nil
+}
+
+func (r *Reconciler) reconcileFile(ctx context.Context, path string) (*ReconciliationReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := ParseSettlementCSV(f)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := settlementWindow(records)
+	ours, err := r.Repo.GetSuccessfulPaymentsBetween(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payments: %w", err)
+	}
+
+	// Settled rows outside our window still need their payment to avoid false EXTRAs
+	known := make(map[string]bool, len(ours))
+	for _, p := range ours {
+		known[p.TransactionID] = true
+	}
+	var unknown []string
+	for _, rec := range records {
+		if !known[rec.TransactionID] {
+			unknown = append(unknown, rec.TransactionID)
+		}
+	}
+	if len(unknown) > 0 {
+		more, err := r.Repo.GetSuccessfulPaymentsByTransactionIDs(ctx, unknown)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load payments: %w", err)
+		}
+		ours = append(ours, more...)
+	}
+	if err := r.Repo.LoadTenders(ctx, ours); err != nil {
+		return nil, fmt.Errorf("failed to load payment tenders: %w", err)
+	}
+
+	report := Reconcile(ours, records)
+	report.ID = uuid.New().String()
+	report.FileName = filepath.Base(path)
+	report.CreatedAt = time.Now()
+
+	if err := r.saveReport(ctx, &report); err != nil {
+		return nil, fmt.Errorf("failed to save report: %w", err)
+	}
+	return &report, nil
+}
+
+// settlementWindow returns the capture window covered by a settlement file
+func settlementWindow(records []SettlementRecord) (time.Time, time.Time) {
+	var from, to time.Time
+	for _, rec := range records {
+		if rec.SettledAt.IsZero() {
+			continue
+		}
+		if from.IsZero() || rec.SettledAt.Before(from) {
+			from = rec.SettledAt
+		}
+		if rec.SettledAt.After(to) {
+			to = rec.SettledAt
+		}
+	}
+	if from.IsZero() {
+		// No settlement dates: fall back to the last lookback period
+		to = time.Now()
+		from = to
+	}
+	return from.Add(-settlementLookback), to
+}
+
+func (r *Reconciler) saveReport(ctx context.Context, report *ReconciliationReport) error {
+	tx, err := r.QueryDB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO reconciliation_reports (id, file_name, matched, discrepancies, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, report.ID, report.FileName, report.Matched, len(report.Discrepancies), report.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range report.Discrepancies {
+		var expAmount, settledAmount *int64
+		var currency string
+		if d.Expected != nil {
+			expAmount, currency = &d.Expected.Amount, d.Expected.Currency
+		}
+		if d.Settled != nil {
+			settledAmount, currency = &d.Settled.Amount, d.Settled.Currency
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO reconciliation_items
+			(report_id, kind, transaction_id, payment_id, expected_amount, settled_amount, currency)
+			VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7)
+		`, report.ID, d.Kind, d.TransactionID, d.PaymentID, expAmount, settledAmount, currency)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *Reconciler) moveFile(path, subdir string) {
+	dest := filepath.Join(r.Dir, subdir)
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		log.Printf("⚠️ Failed to create %s: %v", dest, err)
+		return
+	}
+	if err := os.Rename(path, filepath.Join(dest, filepath.Base(path))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("⚠️ Failed to move %s: %v", path, err)
+	}
+}
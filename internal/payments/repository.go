@@ -3,11 +3,19 @@ package payments
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// uniqueViolation is the SQLSTATE of a second open payment for a booking
+const uniqueViolation = "23505"
+
 type Repository struct {
 	DB *pgxpool.Pool
 }
@@ -17,33 +25,176 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 }
 
 func (r *Repository) CreatePayment(payment *Payment) error {
-	payment.ID = uuid.New().String()
+	if payment.ID == "" {
+		payment.ID = uuid.New().String()
+	}
 
 	query := `
 		INSERT INTO payments
-		(id, booking_id, user_id, amount, status, transaction_id, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		(id, booking_id, user_id, amount, currency, discount_amount, promo_code, status, transaction_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10)
 	`
 
-	_, err := r.DB.Exec(
-		context.Background(),
+	ctx := context.Background()
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(
+		ctx,
 		query,
 		payment.ID,
 		payment.BookingID,
 		payment.UserID,
-		payment.Amount,
+		payment.Amount.Amount,
+		payment.Amount.Currency,
+		payment.Discount.Amount,
+		payment.PromoCode,
 		payment.Status,
 		payment.TransactionID,
 		payment.CreatedAt,
 	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return fmt.Errorf("%w: booking %s", ErrPaymentInProgress, payment.BookingID)
+		}
+		return err
+	}
+
+	// Store the breakdown with the payment so receipts never have to recompute it
+	for i, item := range payment.LineItems {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO payment_line_items (payment_id, booking_id, position, kind, label, amount, currency)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, payment.ID, payment.BookingID, i, item.Kind, item.Label, item.Amount.Amount, item.Amount.Currency)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, t := range payment.Tenders {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO payment_tenders (payment_id, position, method, reference, amount, currency)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		`, payment.ID, i, t.Method, t.Reference, t.Amount.Amount, t.Amount.Currency)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetTenders returns the tenders a payment was split across, in the order they were charged
+func (r *Repository) GetTenders(ctx context.Context, paymentID string) ([]Tender, error) {
+	return getTenders(ctx, r.DB, paymentID)
+}
+
+func getTenders(ctx context.Context, db *pgxpool.Pool, paymentID string) ([]Tender, error) {
+	rows, err := db.Query(ctx, `
+		SELECT method, COALESCE(reference, ''), amount, refunded_amount, currency
+		FROM payment_tenders
+		WHERE payment_id = $1
+		ORDER BY position
+	`, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenders := []Tender{}
+	for rows.Next() {
+		var t Tender
+		if err := rows.Scan(&t.Method, &t.Reference, &t.Amount.Amount, &t.RefundedAmount.Amount, &t.Amount.Currency); err != nil {
+			return nil, err
+		}
+		t.RefundedAmount.Currency = t.Amount.Currency
+		tenders = append(tenders, t)
+	}
+	return tenders, rows.Err()
+}
 
+// LoadTenders fills in the tenders of each of the payments with a single query
+func (r *Repository) LoadTenders(ctx context.Context, payments []Payment) error {
+	if len(payments) == 0 {
+		return nil
+	}
+	index := make(map[string]int, len(payments))
+	ids := make([]string, len(payments))
+	for i, p := range payments {
+		index[p.ID] = i
+		ids[i] = p.ID
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT payment_id::text, method, COALESCE(reference, ''), amount, refunded_amount, currency
+		FROM payment_tenders
+		WHERE payment_id = ANY($1::uuid[])
+		ORDER BY payment_id, position
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var paymentID string
+		var t Tender
+		if err := rows.Scan(&paymentID, &t.Method, &t.Reference, &t.Amount.Amount, &t.RefundedAmount.Amount, &t.Amount.Currency); err != nil {
+			return err
+		}
+		t.RefundedAmount.Currency = t.Amount.Currency
+		if i, ok := index[paymentID]; ok {
+			payments[i].Tenders = append(payments[i].Tenders, t)
+		}
+	}
+	return rows.Err()
+}
+
+// AddTenderRefund records amount returned to the tender at position
+func (r *Repository) AddTenderRefund(ctx context.Context, paymentID string, position int, amount int64) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE payment_tenders SET refunded_amount = refunded_amount + $3
+		WHERE payment_id = $1 AND position = $2
+	`, paymentID, position, amount)
 	return err
 }
 
+// GetLineItems returns the fare, discount, fee and tax lines of a payment in order
+func (r *Repository) GetLineItems(ctx context.Context, paymentID string) ([]money.LineItem, error) {
+	return getLineItems(ctx, r.DB, paymentID)
+}
+
+func getLineItems(ctx context.Context, db *pgxpool.Pool, paymentID string) ([]money.LineItem, error) {
+	rows, err := db.Query(ctx, `
+		SELECT kind, label, amount, currency
+		FROM payment_line_items
+		WHERE payment_id = $1
+		ORDER BY position
+	`, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []money.LineItem{}
+	for rows.Next() {
+		var item money.LineItem
+		if err := rows.Scan(&item.Kind, &item.Label, &item.Amount.Amount, &item.Amount.Currency); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
 func (r *Repository) GetPaymentByID(id string) (*Payment, error) {
 
 	query := `
-		SELECT id, booking_id, user_id, amount, status, transaction_id, created_at
+		SELECT id, booking_id, user_id, amount, currency, refunded_amount, discount_amount, COALESCE(promo_code, ''), status, transaction_id, created_at, updated_at
 		FROM payments
 		WHERE id = $1
 	`
@@ -60,12 +211,22 @@ func (r *Repository) GetPaymentByID(id string) (*Payment, error) {
 		&payment.ID,
 		&payment.BookingID,
 		&payment.UserID,
-		&payment.Amount,
+		&payment.Amount.Amount,
+		&payment.Amount.Currency,
+		&payment.RefundedAmount.Amount,
+		&payment.Discount.Amount,
+		&payment.PromoCode,
 		&payment.Status,
 		&payment.TransactionID,
 		&payment.CreatedAt,
+		&payment.UpdatedAt,
 	)
 
+	if err == nil {
+		payment.RefundedAmount.Currency = payment.Amount.Currency
+		payment.Discount.Currency = payment.Amount.Currency
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -73,30 +234,85 @@ func (r *Repository) GetPaymentByID(id string) (*Payment, error) {
 	return &payment, nil
 }
 
-// Extra UpdateStatus method (you can keep only one)
-func (r *Repository) UpdateStatus(paymentID, status, txnID string) error {
+// StatusChange describes a guarded status transition for a payment
+type StatusChange struct {
+	PaymentID     string
+	From          string
+	To            string
+	TransactionID string // left unchanged when empty
+	RefundDelta   int64  // minor units added to refunded_amount
+	Reason        string
+}
 
-	query := `
+// TransitionStatus moves a payment from change.From to change.To and records the
+// change in payment_status_history. The update only applies if the payment is still
+// in change.From, so concurrent transitions can't both win.
+func (r *Repository) TransitionStatus(ctx context.Context, change StatusChange) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx, `
 		UPDATE payments
-		SET status=$1, transaction_id=$2
-		WHERE id=$3
-	`
+		SET status = $1,
+		    transaction_id = COALESCE(NULLIF($2, ''), transaction_id),
+		    refunded_amount = refunded_amount + $3,
+		    updated_at = NOW()
+		WHERE id = $4 AND status = $5
+	`, change.To, change.TransactionID, change.RefundDelta, change.PaymentID, change.From)
+	if err != nil {
+		return err
+	}
 
-	_, err := r.DB.Exec(
-		context.Background(),
-		query,
-		status,
-		txnID,
-		paymentID,
-	)
+	if res.RowsAffected() == 0 {
+		// Someone else moved the payment first; report where it actually is
+		current := change.From
+		_ = tx.QueryRow(ctx, "SELECT status FROM payments WHERE id = $1", change.PaymentID).Scan(&current)
+		return &TransitionError{PaymentID: change.PaymentID, From: current, To: change.To}
+	}
 
-	return err
+	_, err = tx.Exec(ctx, `
+		INSERT INTO payment_status_history (payment_id, from_status, to_status, transaction_id, reason)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+	`, change.PaymentID, change.From, change.To, change.TransactionID, change.Reason)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetStatusHistory returns the status transitions of a payment, oldest first
+func (r *Repository) GetStatusHistory(ctx context.Context, paymentID string) ([]StatusHistoryEntry, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT from_status, to_status, COALESCE(transaction_id, ''), COALESCE(reason, ''), created_at
+		FROM payment_status_history
+		WHERE payment_id = $1
+		ORDER BY id
+	`, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []StatusHistoryEntry{}
+	for rows.Next() {
+		var h StatusHistoryEntry
+		if err := rows.Scan(&h.From, &h.To, &h.TransactionID, &h.Reason, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+
+	return history, nil
 }
 
 // GetPaymentByBookingID retrieves a payment by booking ID
 func (r *Repository) GetPaymentByBookingID(bookingID string) (*Payment, error) {
 	query := `
-		SELECT id, booking_id, user_id, amount, status, transaction_id, created_at
+		SELECT id, booking_id, user_id, amount, currency, refunded_amount, discount_amount, COALESCE(promo_code, ''), status, transaction_id, created_at, updated_at
 		FROM payments
 		WHERE booking_id = $1
 	`
@@ -113,15 +329,87 @@ func (r *Repository) GetPaymentByBookingID(bookingID string) (*Payment, error) {
 		&payment.ID,
 		&payment.BookingID,
 		&payment.UserID,
-		&payment.Amount,
+		&payment.Amount.Amount,
+		&payment.Amount.Currency,
+		&payment.RefundedAmount.Amount,
+		&payment.Discount.Amount,
+		&payment.PromoCode,
 		&payment.Status,
 		&payment.TransactionID,
 		&payment.CreatedAt,
+		&payment.UpdatedAt,
 	)
 
+	if err == nil {
+		payment.RefundedAmount.Currency = payment.Amount.Currency
+		payment.Discount.Currency = payment.Amount.Currency
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
 	return &payment, nil
 }
+
+// GetSuccessfulPaymentsBetween returns captured payments created in [from, to]
+func (r *Repository) GetSuccessfulPaymentsBetween(ctx context.Context, from, to time.Time) ([]Payment, error) {
+	return r.queryPayments(ctx, `
+		SELECT id, booking_id, user_id, amount, currency, refunded_amount, discount_amount, COALESCE(promo_code, ''), status, transaction_id, created_at, updated_at
+		FROM payments
+		WHERE status IN ('CAPTURED', 'PARTIALLY_REFUNDED', 'REFUNDED') AND created_at BETWEEN $1 AND $2
+	`, from, to)
+}
+
+// GetSuccessfulPaymentsByTransactionIDs returns captured payments with the given provider transaction IDs
+func (r *Repository) GetSuccessfulPaymentsByTransactionIDs(ctx context.Context, txnIDs []string) ([]Payment, error) {
+	return r.queryPayments(ctx, `
+		SELECT id, booking_id, user_id, amount, currency, refunded_amount, discount_amount, COALESCE(promo_code, ''), status, transaction_id, created_at, updated_at
+		FROM payments
+		WHERE status IN ('CAPTURED', 'PARTIALLY_REFUNDED', 'REFUNDED') AND transaction_id = ANY($1)
+	`, txnIDs)
+}
+
+// GetStalePendingPayments returns up to limit payments still PENDING that were created before cutoff
+func (r *Repository) GetStalePendingPayments(ctx context.Context, cutoff time.Time, limit int) ([]Payment, error) {
+	return r.queryPayments(ctx, `
+		SELECT id, booking_id, user_id, amount, currency, refunded_amount, discount_amount, COALESCE(promo_code, ''), status, transaction_id, created_at, updated_at
+		FROM payments
+		WHERE status = 'PENDING' AND created_at < $1
+		ORDER BY created_at
+		LIMIT $2
+	`, cutoff, limit)
+}
+
+// GetOpenPaymentsByBookingID returns a booking's payments that are still at checkout or
+// have money left to refund
+func (r *Repository) GetOpenPaymentsByBookingID(ctx context.Context, bookingID string) ([]Payment, error) {
+	return r.queryPayments(ctx, `
+		SELECT id, booking_id, user_id, amount, currency, refunded_amount, discount_amount, COALESCE(promo_code, ''), status, transaction_id, created_at, updated_at
+		FROM payments
+		WHERE booking_id = $1 AND status IN ('PENDING', 'AUTHORIZED', 'CAPTURED', 'PARTIALLY_REFUNDED')
+		ORDER BY created_at
+	`, bookingID)
+}
+
+func (r *Repository) queryPayments(ctx context.Context, query string, args ...interface{}) ([]Payment, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []Payment
+	for rows.Next() {
+		var p Payment
+		err := rows.Scan(&p.ID, &p.BookingID, &p.UserID, &p.Amount.Amount, &p.Amount.Currency, &p.RefundedAmount.Amount, &p.Discount.Amount, &p.PromoCode, &p.Status, &p.TransactionID, &p.CreatedAt, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		p.RefundedAmount.Currency = p.Amount.Currency
+		p.Discount.Currency = p.Amount.Currency
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
@@ -0,0 +1,83 @@
+// Payment status state machine
+
+package payments
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Payment statuses
+const (
+	StatusPending           = "PENDING"
+	StatusAuthorized        = "AUTHORIZED"
+	StatusCaptured          = "CAPTURED"
+	StatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+	StatusRefunded          = "REFUNDED"
+	StatusFailed            = "FAILED"
+	StatusCancelled         = "CANCELLED"
+	StatusExpired           = "EXPIRED"
+)
+
+// transitions lists the statuses reachable from each status.
+// REFUNDED, FAILED, CANCELLED and EXPIRED are terminal.
+var transitions = map[string][]string{
+	StatusPending:           {StatusAuthorized, StatusFailed, StatusCancelled, StatusExpired},
+	StatusAuthorized:        {StatusCaptured, StatusFailed, StatusCancelled, StatusExpired},
+	StatusCaptured:          {StatusPartiallyRefunded, StatusRefunded},
+	StatusPartiallyRefunded: {StatusPartiallyRefunded, StatusRefunded},
+	StatusRefunded:          {},
+	StatusFailed:            {},
+	StatusCancelled:         {},
+	StatusExpired:           {},
+}
+
+// ErrIllegalTransition is matched (via errors.Is) by every TransitionError
+var ErrIllegalTransition = errors.New("illegal payment status transition")
+
+// ErrRefundExceedsBalance is returned when a refund is larger than the captured remainder
+var ErrRefundExceedsBalance = errors.New("refund exceeds refundable amount")
+
+// TransitionError is returned when a payment can't move from its current status
+type TransitionError struct {
+	PaymentID string
+	From      string
+	To        string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("payment %s cannot move from %s to %s", e.PaymentID, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+// IsValidStatus reports whether status is a known payment status
+func IsValidStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// CanTransition reports whether a payment may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSettled reports whether money was actually captured for a payment in this status
+func IsSettled(status string) bool {
+	return status == StatusCaptured || status == StatusPartiallyRefunded || status == StatusRefunded
+}
+
+// checkTransition returns a TransitionError if the move is not allowed
+func checkTransition(p *Payment, to string) error {
+	if !CanTransition(p.Status, to) {
+		return &TransitionError{PaymentID: p.ID, From: p.Status, To: to}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Split payments across a card and stored-value tenders
+
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// Payment methods a booking can be split across. The card covers whatever the
+// stored-value tenders don't.
+const (
+	MethodCard     = "CARD"
+	MethodWallet   = "WALLET"
+	MethodGiftCard = "GIFT_CARD"
+)
+
+// ErrInvalidTender is returned when a split payment request is malformed
+var ErrInvalidTender = errors.New("invalid payment tender")
+
+// ErrTenderDeclined is returned when a stored-value tender can't be charged
+var ErrTenderDeclined = errors.New("payment tender declined")
+
+// Tender is the part of a payment charged to one method
+type Tender struct {
+	Method         string      `json:"method"`
+	Reference      string      `json:"reference,omitempty"` // gift card code
+	Amount         money.Money `json:"amount"`
+	RefundedAmount money.Money `json:"refunded_amount"`
+}
+
+// IsStoredValue reports whether the tender is charged to a wallet or gift card
+func (t Tender) IsStoredValue() bool {
+	return t.Method != MethodCard
+}
+
+// TenderRequest - Request model for one stored-value part of a split payment
+type TenderRequest struct {
+	Method string `json:"method"`         // WALLET or GIFT_CARD
+	Code   string `json:"code,omitempty"` // gift card code
+	Amount int64  `json:"amount"`         // minor units of the payment currency
+}
+
+// splitTenders turns the requested stored-value tenders into the payment's tenders,
+// adding a card tender for the remainder of total
+func splitTenders(total money.Money, reqs []TenderRequest) ([]Tender, error) {
+	tenders := make([]Tender, 0, len(reqs)+1)
+	seen := map[string]bool{}
+	remaining := total.Amount
+
+	for _, req := range reqs {
+		t := Tender{
+			Method:         strings.ToUpper(strings.TrimSpace(req.Method)),
+			Reference:      strings.ToUpper(strings.TrimSpace(req.Code)),
+			Amount:         money.Money{Amount: req.Amount, Currency: total.Currency},
+			RefundedAmount: money.Zero(total.Currency),
+		}
+		switch t.Method {
+		case MethodWallet:
+			t.Reference = ""
+		case MethodGiftCard:
+			if t.Reference == "" {
+				return nil, fmt.Errorf("%w: gift card code is required", ErrInvalidTender)
+			}
+		default:
+			return nil, fmt.Errorf("%w: method must be WALLET or GIFT_CARD, the card covers the rest", ErrInvalidTender)
+		}
+		if req.Amount <= 0 {
+			return nil, fmt.Errorf("%w: tender amount must be positive", ErrInvalidTender)
+		}
+
+		key := t.Method + ":" + t.Reference
+		if seen[key] {
+			return nil, fmt.Errorf("%w: %s used twice", ErrInvalidTender, strings.TrimSuffix(key, ":"))
+		}
+		seen[key] = true
+
+		remaining -= req.Amount
+		if remaining < 0 {
+			return nil, fmt.Errorf("%w: tenders exceed the total of %s", ErrInvalidTender, total)
+		}
+		tenders = append(tenders, t)
+	}
+
+	if remaining > 0 {
+		tenders = append(tenders, Tender{
+			Method:         MethodCard,
+			Amount:         money.Money{Amount: remaining, Currency: total.Currency},
+			RefundedAmount: money.Zero(total.Currency),
+		})
+	}
+	return tenders, nil
+}
+
+// hasCardTender reports whether part of the payment still has to go through the gateway
+func hasCardTender(tenders []Tender) bool {
+	for _, t := range tenders {
+		if !t.IsStoredValue() {
+			return true
+		}
+	}
+	return false
+}
+
+// allocateRefund splits a refund across tenders, last tender first, so the card is
+// refunded before balances are restored. It returns the share of each tender.
+func allocateRefund(tenders []Tender, amount int64) []int64 {
+	shares := make([]int64, len(tenders))
+	for i := len(tenders) - 1; i >= 0 && amount > 0; i-- {
+		refundable := tenders[i].Amount.Amount - tenders[i].RefundedAmount.Amount
+		if refundable <= 0 {
+			continue
+		}
+		share := refundable
+		if amount < share {
+			share = amount
+		}
+		shares[i] = share
+		amount -= share
+	}
+	return shares
+}
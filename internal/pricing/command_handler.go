@@ -0,0 +1,96 @@
+// Command handler for price list write operations (CQRS)
+
+package pricing
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommandHandler struct {
+	CommandService *CommandService
+}
+
+func NewCommandHandler(cs *CommandService) *CommandHandler {
+	return &CommandHandler{CommandService: cs}
+}
+
+// SetShowPrices - Command handler for replacing a show's price list
+func (h *CommandHandler) SetShowPrices(c *gin.Context) {
+	showID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		return
+	}
+
+	var req SetPricesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	prices, err := h.CommandService.SetShowPrices(c.Request.Context(), showID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPriceList) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set prices: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// AddSeats - Command handler for adding seats to a show
+func (h *CommandHandler) AddSeats(c *gin.Context) {
+	showID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		return
+	}
+
+	var req AddSeatsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	seats, err := h.CommandService.AddSeats(c.Request.Context(), showID, req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPriceList) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add seats: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, seats)
+}
+
+// SetPricesRequest - Request model for a show's price list
+type SetPricesRequest struct {
+	Prices []PriceRequest `json:"prices"`
+}
+
+type PriceRequest struct {
+	Tier     string `json:"tier"`
+	Amount   int64  `json:"amount"` // base price in minor units
+	Currency string `json:"currency"`
+	Floor    int64  `json:"floor"`   // optional lowest dynamic price
+	Ceiling  int64  `json:"ceiling"` // optional highest dynamic price
+}
+
+// AddSeatsRequest - Request model for adding seats to a show
+type AddSeatsRequest struct {
+	Seats []SeatRequest `json:"seats"`
+}
+
+type SeatRequest struct {
+	Label string `json:"label"` // e.g. "F12"
+	Tier  string `json:"tier"`  // defaults to STANDARD
+}
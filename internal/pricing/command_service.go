@@ -0,0 +1,198 @@
+// Command service for price list write operations (CQRS)
+
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// defaultQuoteTTL matches the default pending payment expiry, which bounds a seat hold
+const defaultQuoteTTL = 15 * time.Minute
+
+type CommandService struct {
+	Repo       *Repository
+	Engine     *Engine
+	Dispatcher *events.Dispatcher
+	QuoteTTL   time.Duration // how long a locked quote is honoured
+}
+
+// NewCommandService creates a command service with static pricing
+func NewCommandService(repo *Repository) *CommandService {
+	return &CommandService{Repo: repo, Engine: NewEngine(repo, nil, NewPricer(nil, 0, 0)), QuoteTTL: defaultQuoteTTL}
+}
+
+// NewCommandServiceWithEngine creates a command service that prices with the given engine
+func NewCommandServiceWithEngine(engine *Engine, dispatcher *events.Dispatcher, quoteTTL time.Duration) *CommandService {
+	if quoteTTL <= 0 {
+		quoteTTL = defaultQuoteTTL
+	}
+	return &CommandService{Repo: engine.Repo, Engine: engine, Dispatcher: dispatcher, QuoteTTL: quoteTTL}
+}
+
+// SetShowPrices - Command to replace the price list of a show
+func (s *CommandService) SetShowPrices(ctx context.Context, showID int, req SetPricesRequest) ([]Price, error) {
+	prices, err := buildPriceList(showID, req)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.Repo.GetPrices(ctx, showID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.ReplacePrices(ctx, showID, prices); err != nil {
+		return nil, err
+	}
+
+	before := make(map[string]Price, len(previous))
+	for _, p := range previous {
+		before[p.Tier] = p
+	}
+	for _, p := range prices {
+		old, ok := before[p.Tier]
+		if ok && old.Price == p.Price {
+			continue
+		}
+		var prev *Price
+		if ok {
+			prev = &old
+		}
+		s.publishPriceChanged(ctx, showID, p.Tier, prev, p.Price, "price list updated")
+	}
+	return prices, nil
+}
+
+// LockQuote - Command to price a held booking and guarantee that price until the hold
+// would expire. Locking an already locked booking returns the existing quote.
+func (s *CommandService) LockQuote(ctx context.Context, bookingID string) (*Quote, error) {
+	locked, err := s.Repo.GetLockedQuote(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if locked != nil {
+		return locked, nil
+	}
+
+	now := time.Now()
+	quote, err := s.Engine.Quote(ctx, bookingID, now)
+	if err != nil {
+		return nil, err
+	}
+	until := now.Add(s.QuoteTTL)
+	quote.LockedUntil = &until
+
+	if err := s.Repo.SaveLockedQuote(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	// Record dynamic price moves for audit
+	for _, item := range quote.Items {
+		previous, changed, err := s.Repo.RecordCurrentPrice(ctx, quote.ShowID, item.Tier, item.Price)
+		if err != nil {
+			log.Printf("⚠️ Failed to record price of %s for show %d: %v", item.Tier, quote.ShowID, err)
+			continue
+		}
+		if changed && previous != nil {
+			s.publishPriceChanged(ctx, quote.ShowID, item.Tier, &Price{Price: *previous}, item.Price, "dynamic:"+quote.Strategy)
+		}
+	}
+
+	return quote, nil
+}
+
+// publishPriceChanged emits a PriceChanged event for a tier of a show
+func (s *CommandService) publishPriceChanged(ctx context.Context, showID int, tier string, previous *Price, price money.Money, reason string) {
+	if s.Dispatcher == nil {
+		return
+	}
+	showIDStr := strconv.Itoa(showID)
+	payload := events.EventPayload{
+		ShowID: showIDStr,
+		Tier:   tier,
+		Price:  &price,
+		Reason: reason,
+	}
+	if previous != nil {
+		payload.PreviousPrice = &previous.Price
+	}
+	_ = s.Dispatcher.Publish(ctx, events.EventPriceChanged, showIDStr, payload)
+}
+
+// buildPriceList validates a price list: known tiers, each at most once, one currency
+func buildPriceList(showID int, req SetPricesRequest) ([]Price, error) {
+	if showID <= 0 {
+		return nil, fmt.Errorf("%w: valid show ID is required", ErrInvalidPriceList)
+	}
+	if len(req.Prices) == 0 {
+		return nil, fmt.Errorf("%w: at least one price is required", ErrInvalidPriceList)
+	}
+
+	seen := make(map[string]bool, len(req.Prices))
+	prices := make([]Price, 0, len(req.Prices))
+	for _, p := range req.Prices {
+		tier, err := NormalizeTier(p.Tier)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tier] {
+			return nil, fmt.Errorf("%w: tier %s listed twice", ErrInvalidPriceList, tier)
+		}
+		seen[tier] = true
+
+		if p.Amount <= 0 {
+			return nil, fmt.Errorf("%w: price for %s must be positive", ErrInvalidPriceList, tier)
+		}
+		price, err := money.New(p.Amount, p.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPriceList, err)
+		}
+		if len(prices) > 0 && !prices[0].Price.SameCurrency(price) {
+			return nil, fmt.Errorf("%w: all tiers of a show must use one currency", ErrInvalidPriceList)
+		}
+
+		if p.Floor < 0 || p.Ceiling < 0 ||
+			(p.Floor > 0 && p.Floor > p.Amount) || (p.Ceiling > 0 && p.Ceiling < p.Amount) {
+			return nil, fmt.Errorf("%w: %s must satisfy floor <= price <= ceiling", ErrInvalidPriceList, tier)
+		}
+
+		prices = append(prices, Price{ShowID: showID, Tier: tier, Price: price, Floor: p.Floor, Ceiling: p.Ceiling})
+	}
+	return prices, nil
+}
+
+// AddSeats - Command to add seats with their tiers to a show
+func (s *CommandService) AddSeats(ctx context.Context, showID int, req AddSeatsRequest) ([]Seat, error) {
+	if showID <= 0 {
+		return nil, fmt.Errorf("%w: valid show ID is required", ErrInvalidPriceList)
+	}
+	if len(req.Seats) == 0 {
+		return nil, fmt.Errorf("%w: at least one seat is required", ErrInvalidPriceList)
+	}
+
+	seats := make([]Seat, 0, len(req.Seats))
+	for _, sr := range req.Seats {
+		if sr.Label == "" {
+			return nil, fmt.Errorf("%w: seat label is required", ErrInvalidPriceList)
+		}
+		tier, err := NormalizeTier(sr.Tier)
+		if err != nil {
+			return nil, err
+		}
+		seats = append(seats, Seat{ShowID: showID, Label: sr.Label, Tier: tier})
+	}
+
+	if err := s.Repo.AddSeats(ctx, seats); err != nil {
+		return nil, err
+	}
+	if s.Dispatcher != nil {
+		showIDStr := strconv.Itoa(showID)
+		_ = s.Dispatcher.Publish(ctx, events.EventSeatsAdded, showIDStr, events.EventPayload{ShowID: showIDStr})
+	}
+	return seats, nil
+}
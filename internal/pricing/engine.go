@@ -0,0 +1,83 @@
+// Pricing engine: base price lists plus demand
+
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Engine prices bookings from their show's price list and current demand
+type Engine struct {
+	Repo         *Repository
+	ProjectionDB *pgxpool.Pool // Query DB with reservation_projection; demand is ignored when nil
+	Pricer       Pricer
+}
+
+func NewEngine(repo *Repository, projectionDB *pgxpool.Pool, pricer Pricer) *Engine {
+	return &Engine{Repo: repo, ProjectionDB: projectionDB, Pricer: pricer}
+}
+
+// Quote prices a booking at the current demand. The quote is not locked.
+func (e *Engine) Quote(ctx context.Context, bookingID string, now time.Time) (*Quote, error) {
+	b, err := e.Repo.GetBookingSeats(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	demand, err := e.Demand(ctx, b.ShowID, b.StartTime, now)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]QuoteItem, 0, len(b.Seats))
+	for _, seat := range b.Seats {
+		if seat.Base.Price.Currency == "" {
+			return nil, fmt.Errorf("%w: %s tier of show %d", ErrPriceNotFound, seat.Base.Tier, b.ShowID)
+		}
+		items = append(items, QuoteItem{
+			SeatID:    seat.SeatID,
+			Label:     seat.Label,
+			Tier:      seat.Base.Tier,
+			BasePrice: seat.Base.Price,
+			Price:     e.Pricer.Price(seat.Base, demand),
+		})
+	}
+
+	q, err := NewQuote(bookingID, b.UserID, b.ShowID, b.MovieID, items)
+	if err != nil {
+		return nil, err
+	}
+	q.Strategy = e.strategyName()
+	return q, nil
+}
+
+// Demand reads how a show is selling from the reservation projection
+func (e *Engine) Demand(ctx context.Context, showID int, startTime, now time.Time) (Demand, error) {
+	d := Demand{TimeToShow: startTime.Sub(now)}
+	if e.ProjectionDB == nil {
+		return d, nil
+	}
+
+	capacity, err := e.Repo.CountSeats(ctx, showID)
+	if err != nil {
+		return d, err
+	}
+	d.Capacity = capacity
+
+	err = e.ProjectionDB.QueryRow(ctx,
+		"SELECT COUNT(*) FROM reservation_projection WHERE show_id = $1 AND status IN ('HELD', 'BOOKED')",
+		showID,
+	).Scan(&d.Sold)
+	return d, err
+}
+
+func (e *Engine) strategyName() string {
+	if e.Pricer.Strategy == nil {
+		return StaticStrategy{}.Name()
+	}
+	return e.Pricer.Strategy.Name()
+}
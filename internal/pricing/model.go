@@ -0,0 +1,110 @@
+// Models for per-show price lists and seat tiers
+
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// Seat tiers
+const (
+	TierStandard   = "STANDARD"
+	TierPremium    = "PREMIUM"
+	TierRecliner   = "RECLINER"
+	TierWheelchair = "WHEELCHAIR"
+)
+
+var tiers = map[string]bool{
+	TierStandard:   true,
+	TierPremium:    true,
+	TierRecliner:   true,
+	TierWheelchair: true,
+}
+
+// ErrInvalidPriceList is returned when a price list or seat map is rejected
+var ErrInvalidPriceList = errors.New("invalid price list")
+
+// ErrPriceNotFound is returned when a booking's seats have no configured price
+var ErrPriceNotFound = errors.New("no price configured for seat")
+
+// ErrBookingNotFound is returned when quoting a booking that doesn't exist
+var ErrBookingNotFound = errors.New("booking not found")
+
+// NormalizeTier upper-cases a tier and checks that it is known; empty means standard
+func NormalizeTier(tier string) (string, error) {
+	tier = strings.ToUpper(strings.TrimSpace(tier))
+	if tier == "" {
+		return TierStandard, nil
+	}
+	if !tiers[tier] {
+		return "", fmt.Errorf("%w: unknown seat tier %q", ErrInvalidPriceList, tier)
+	}
+	return tier, nil
+}
+
+// Seat is a sellable seat of a show
+type Seat struct {
+	ID     string `json:"id"`
+	ShowID int    `json:"show_id"`
+	Label  string `json:"label"`
+	Tier   string `json:"tier"`
+}
+
+// Price is the base ticket price of a seat tier for a show. Dynamic pricing
+// moves the charged price between Floor and Ceiling when they are set.
+type Price struct {
+	ShowID  int         `json:"show_id"`
+	Tier    string      `json:"tier"`
+	Price   money.Money `json:"price"`
+	Floor   int64       `json:"floor,omitempty"`   // minor units, 0 for the pricer default
+	Ceiling int64       `json:"ceiling,omitempty"` // minor units, 0 for the pricer default
+}
+
+// QuoteItem is the price of one seat in a booking
+type QuoteItem struct {
+	SeatID    string      `json:"seat_id"`
+	Label     string      `json:"label"`
+	Tier      string      `json:"tier"`
+	BasePrice money.Money `json:"base_price"`
+	Price     money.Money `json:"price"`
+}
+
+// Quote is the server-side price of a booking
+type Quote struct {
+	BookingID   string      `json:"booking_id"`
+	UserID      string      `json:"user_id"`
+	ShowID      int         `json:"show_id"`
+	MovieID     int         `json:"movie_id"`
+	Items       []QuoteItem `json:"items"`
+	Total       money.Money `json:"total"`
+	Strategy    string      `json:"strategy"`
+	LockedUntil *time.Time  `json:"locked_until,omitempty"` // set while the seat hold guarantees the price
+}
+
+// NewQuote totals the items of a booking, which must share a currency
+func NewQuote(bookingID, userID string, showID, movieID int, items []QuoteItem) (*Quote, error) {
+	if len(items) == 0 {
+		return nil, ErrBookingNotFound
+	}
+	q := &Quote{
+		BookingID: bookingID,
+		UserID:    userID,
+		ShowID:    showID,
+		MovieID:   movieID,
+		Items:     items,
+		Total:     money.Zero(items[0].Price.Currency),
+	}
+	for _, item := range items {
+		total, err := q.Total.Add(item.Price)
+		if err != nil {
+			return nil, err
+		}
+		q.Total = total
+	}
+	return q, nil
+}
@@ -0,0 +1,154 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// TestNormalizeTier tests seat tier validation
+func TestNormalizeTier(t *testing.T) {
+	tests := []struct {
+		tier     string
+		expected string
+		wantErr  bool
+	}{
+		{"", TierStandard, false},
+		{"premium", TierPremium, false},
+		{" Recliner ", TierRecliner, false},
+		{"WHEELCHAIR", TierWheelchair, false},
+		{"balcony", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeTier(tt.tier)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidPriceList) {
+				t.Errorf("Expected ErrInvalidPriceList for %q, got %v", tt.tier, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.expected {
+			t.Errorf("Expected %s for %q, got %s (err %v)", tt.expected, tt.tier, got, err)
+		}
+	}
+}
+
+// TestBuildPriceList tests price list validation
+func TestBuildPriceList(t *testing.T) {
+	tests := []struct {
+		name    string
+		prices  []PriceRequest
+		wantErr bool
+	}{
+		{"valid", []PriceRequest{{Tier: "standard", Amount: 25000, Currency: "INR"}, {Tier: "recliner", Amount: 60000, Currency: "INR"}}, false},
+		{"empty", nil, true},
+		{"duplicate tier", []PriceRequest{{Tier: "PREMIUM", Amount: 30000, Currency: "INR"}, {Tier: "premium", Amount: 32000, Currency: "INR"}}, true},
+		{"zero price", []PriceRequest{{Tier: "STANDARD", Amount: 0, Currency: "INR"}}, true},
+		{"mixed currencies", []PriceRequest{{Tier: "STANDARD", Amount: 25000, Currency: "INR"}, {Tier: "PREMIUM", Amount: 500, Currency: "USD"}}, true},
+		{"unknown currency", []PriceRequest{{Tier: "STANDARD", Amount: 25000, Currency: "XYZ"}}, true},
+		{"floor above price", []PriceRequest{{Tier: "STANDARD", Amount: 25000, Currency: "INR", Floor: 30000}}, true},
+		{"ceiling below price", []PriceRequest{{Tier: "STANDARD", Amount: 25000, Currency: "INR", Ceiling: 20000}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices, err := buildPriceList(7, SetPricesRequest{Prices: tt.prices})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPriceList) {
+					t.Errorf("Expected ErrInvalidPriceList, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(prices) != len(tt.prices) || prices[0].ShowID != 7 || prices[1].Tier != TierRecliner {
+				t.Errorf("Unexpected price list: %+v", prices)
+			}
+		})
+	}
+}
+
+// TestNewQuote tests booking totals
+func TestNewQuote(t *testing.T) {
+	items := []QuoteItem{
+		{SeatID: "s1", Tier: TierStandard, Price: money.Money{Amount: 25000, Currency: "INR"}},
+		{SeatID: "s2", Tier: TierRecliner, Price: money.Money{Amount: 60000, Currency: "INR"}},
+	}
+
+	q, err := NewQuote("b1", "u1", 7, 3, items)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if q.Total != (money.Money{Amount: 85000, Currency: "INR"}) {
+		t.Errorf("Expected 85000 INR, got %v", q.Total)
+	}
+
+	if _, err := NewQuote("b1", "u1", 7, 3, nil); !errors.Is(err, ErrBookingNotFound) {
+		t.Errorf("Expected ErrBookingNotFound, got %v", err)
+	}
+
+	items[1].Price.Currency = "USD"
+	if _, err := NewQuote("b1", "u1", 7, 3, items); !errors.Is(err, money.ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+}
+
+// TestDemandStrategy tests surge and last-minute discount multipliers
+func TestDemandStrategy(t *testing.T) {
+	s := DefaultDemandStrategy()
+
+	tests := []struct {
+		name     string
+		demand   Demand
+		expected float64
+	}{
+		{"normal demand", Demand{Sold: 50, Capacity: 100, TimeToShow: 48 * time.Hour}, 1},
+		{"sold out", Demand{Sold: 100, Capacity: 100, TimeToShow: 48 * time.Hour}, 1.5},
+		{"filling up", Demand{Sold: 80, Capacity: 100, TimeToShow: 48 * time.Hour}, 1.25},
+		{"empty but far out", Demand{Sold: 0, Capacity: 100, TimeToShow: 48 * time.Hour}, 1},
+		{"empty matinee soon", Demand{Sold: 0, Capacity: 100, TimeToShow: time.Hour}, 0.7},
+		{"quiet matinee soon", Demand{Sold: 15, Capacity: 100, TimeToShow: time.Hour}, 0.85},
+		{"unknown capacity", Demand{Sold: 10, TimeToShow: 48 * time.Hour}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Multiplier(tt.demand); math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestPricerBounds tests floors and ceilings around the strategy price
+func TestPricerBounds(t *testing.T) {
+	base := Price{Tier: TierStandard, Price: money.Money{Amount: 20000, Currency: "INR"}}
+	soldOut := Demand{Sold: 100, Capacity: 100, TimeToShow: 48 * time.Hour}
+	emptySoon := Demand{Sold: 0, Capacity: 100, TimeToShow: time.Hour}
+
+	pricer := NewPricer(DefaultDemandStrategy(), 0.8, 1.2)
+	if got := pricer.Price(base, soldOut); got.Amount != 24000 {
+		t.Errorf("Expected default ceiling 24000, got %d", got.Amount)
+	}
+	if got := pricer.Price(base, emptySoon); got.Amount != 16000 {
+		t.Errorf("Expected default floor 16000, got %d", got.Amount)
+	}
+
+	base.Floor, base.Ceiling = 19000, 27000
+	if got := pricer.Price(base, soldOut); got.Amount != 27000 {
+		t.Errorf("Expected tier ceiling 27000, got %d", got.Amount)
+	}
+	if got := pricer.Price(base, emptySoon); got.Amount != 19000 {
+		t.Errorf("Expected tier floor 19000, got %d", got.Amount)
+	}
+
+	static := NewPricer(nil, 0.8, 1.2)
+	if got := static.Price(base, soldOut); got != base.Price {
+		t.Errorf("Expected base price from static pricing, got %v", got)
+	}
+}
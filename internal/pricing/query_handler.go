@@ -0,0 +1,51 @@
+// Query handler for price list read operations (CQRS)
+
+package pricing
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetShowPrices - Query handler for getting a show's price list
+func (h *QueryHandler) GetShowPrices(c *gin.Context) {
+	showID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		return
+	}
+
+	prices, err := h.QueryService.GetShowPrices(c.Request.Context(), showID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get prices: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+// QuoteBooking - Query handler for pricing a booking before checkout
+func (h *QueryHandler) QuoteBooking(c *gin.Context) {
+	quote, err := h.QueryService.QuoteBooking(c.Request.Context(), c.Param("bookingID"))
+	if err != nil {
+		if errors.Is(err, ErrBookingNotFound) || errors.Is(err, ErrPriceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to quote booking: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
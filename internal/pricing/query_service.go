@@ -0,0 +1,42 @@
+// Query service for price list read operations (CQRS)
+
+package pricing
+
+import (
+	"context"
+	"time"
+)
+
+// QueryService reads prices from the Command DB so quotes always use the current list
+type QueryService struct {
+	Repo   *Repository
+	Engine *Engine
+}
+
+// NewQueryService creates a query service with static pricing
+func NewQueryService(repo *Repository) *QueryService {
+	return &QueryService{Repo: repo, Engine: NewEngine(repo, nil, NewPricer(nil, 0, 0))}
+}
+
+// NewQueryServiceWithEngine creates a query service that prices with the given engine
+func NewQueryServiceWithEngine(engine *Engine) *QueryService {
+	return &QueryService{Repo: engine.Repo, Engine: engine}
+}
+
+// GetShowPrices - Query to get the price list of a show
+func (s *QueryService) GetShowPrices(ctx context.Context, showID int) ([]Price, error) {
+	return s.Repo.GetPrices(ctx, showID)
+}
+
+// QuoteBooking - Query to get the price of a booking: the locked quote while the
+// seat hold lasts, otherwise the current dynamic price
+func (s *QueryService) QuoteBooking(ctx context.Context, bookingID string) (*Quote, error) {
+	locked, err := s.Repo.GetLockedQuote(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if locked != nil {
+		return locked, nil
+	}
+	return s.Engine.Quote(ctx, bookingID, time.Now())
+}
@@ -0,0 +1,234 @@
+package pricing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+// ReplacePrices replaces the whole price list of a show
+func (r *Repository) ReplacePrices(ctx context.Context, showID int, prices []Price) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "DELETE FROM show_prices WHERE show_id = $1", showID); err != nil {
+		return err
+	}
+	for _, p := range prices {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO show_prices (show_id, tier, amount, currency, floor_amount, ceiling_amount)
+			VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, 0))
+		`, showID, p.Tier, p.Price.Amount, p.Price.Currency, p.Floor, p.Ceiling)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetPrices returns the price list of a show
+func (r *Repository) GetPrices(ctx context.Context, showID int) ([]Price, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT show_id, tier, amount, currency, COALESCE(floor_amount, 0), COALESCE(ceiling_amount, 0)
+		FROM show_prices
+		WHERE show_id = $1
+		ORDER BY amount
+	`, showID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []Price{}
+	for rows.Next() {
+		var p Price
+		if err := rows.Scan(&p.ShowID, &p.Tier, &p.Price.Amount, &p.Price.Currency, &p.Floor, &p.Ceiling); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+	return prices, rows.Err()
+}
+
+// AddSeats creates seats for a show and fills in their IDs
+func (r *Repository) AddSeats(ctx context.Context, seats []Seat) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for i := range seats {
+		seats[i].ID = uuid.New().String()
+		_, err := tx.Exec(ctx,
+			"INSERT INTO seats (id, show_id, label, tier) VALUES ($1, $2, $3, $4)",
+			seats[i].ID, seats[i].ShowID, seats[i].Label, seats[i].Tier,
+		)
+		if err != nil {
+			return fmt.Errorf("seat %s: %w", seats[i].Label, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CountSeats returns how many seats a show has on sale
+func (r *Repository) CountSeats(ctx context.Context, showID int) (int, error) {
+	var n int
+	err := r.DB.QueryRow(ctx, "SELECT COUNT(*) FROM seats WHERE show_id = $1", showID).Scan(&n)
+	return n, err
+}
+
+// bookingSeat is a seat of a booking with the base price of its tier
+type bookingSeat struct {
+	SeatID string
+	Label  string
+	Base   Price // Base.Price.Currency is empty when the tier has no price
+}
+
+// bookingSeats is everything needed to price a booking
+type bookingSeats struct {
+	UserID    string
+	ShowID    int
+	MovieID   int
+	StartTime time.Time
+	Seats     []bookingSeat
+}
+
+// GetBookingSeats loads the seats of a booking with their tiers' base prices
+func (r *Repository) GetBookingSeats(ctx context.Context, bookingID string) (*bookingSeats, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT r.user_id, s.show_id, COALESCE(sh.movie_id, 0), COALESCE(sh.start_time, 'epoch'::timestamptz),
+		       s.id, s.label, s.tier,
+		       COALESCE(p.amount, 0), COALESCE(p.currency, ''),
+		       COALESCE(p.floor_amount, 0), COALESCE(p.ceiling_amount, 0)
+		FROM reservations r
+		JOIN seats s ON s.id = r.seat_id
+		LEFT JOIN shows sh ON sh.id = s.show_id
+		LEFT JOIN show_prices p ON p.show_id = s.show_id AND p.tier = s.tier
+		WHERE r.id = $1
+	`, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	b := &bookingSeats{}
+	for rows.Next() {
+		var seat bookingSeat
+		err := rows.Scan(&b.UserID, &b.ShowID, &b.MovieID, &b.StartTime,
+			&seat.SeatID, &seat.Label, &seat.Base.Tier,
+			&seat.Base.Price.Amount, &seat.Base.Price.Currency, &seat.Base.Floor, &seat.Base.Ceiling)
+		if err != nil {
+			return nil, err
+		}
+		seat.Base.ShowID = b.ShowID
+		b.Seats = append(b.Seats, seat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(b.Seats) == 0 {
+		return nil, ErrBookingNotFound
+	}
+	return b, nil
+}
+
+// GetLockedQuote returns the quote locked for a booking, or nil if there is none or it expired
+func (r *Repository) GetLockedQuote(ctx context.Context, bookingID string) (*Quote, error) {
+	var data []byte
+	err := r.DB.QueryRow(ctx,
+		"SELECT quote FROM price_quotes WHERE booking_id = $1 AND expires_at > NOW()",
+		bookingID,
+	).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var q Quote
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// SaveLockedQuote stores a quote until q.LockedUntil, replacing an expired one
+func (r *Repository) SaveLockedQuote(ctx context.Context, q *Quote) error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	_, err = r.DB.Exec(ctx, `
+		INSERT INTO price_quotes (booking_id, show_id, total_amount, currency, quote, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (booking_id) DO UPDATE SET
+		show_id = EXCLUDED.show_id,
+		total_amount = EXCLUDED.total_amount,
+		currency = EXCLUDED.currency,
+		quote = EXCLUDED.quote,
+		expires_at = EXCLUDED.expires_at,
+		created_at = NOW()
+	`, q.BookingID, q.ShowID, q.Total.Amount, q.Total.Currency, data, q.LockedUntil)
+	return err
+}
+
+// RecordCurrentPrice stores the latest charged price of a tier and returns the previous one.
+// changed is false when the price is the same as last time.
+func (r *Repository) RecordCurrentPrice(ctx context.Context, showID int, tier string, price money.Money) (previous *money.Money, changed bool, err error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var prev money.Money
+	err = tx.QueryRow(ctx,
+		"SELECT amount, currency FROM current_prices WHERE show_id = $1 AND tier = $2 FOR UPDATE",
+		showID, tier,
+	).Scan(&prev.Amount, &prev.Currency)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return nil, false, err
+	case prev == price:
+		return &prev, false, nil
+	default:
+		previous = &prev
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO current_prices (show_id, tier, amount, currency, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (show_id, tier) DO UPDATE SET
+		amount = EXCLUDED.amount,
+		currency = EXCLUDED.currency,
+		updated_at = NOW()
+	`, showID, tier, price.Amount, price.Currency)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return previous, true, tx.Commit(ctx)
+}
@@ -0,0 +1,117 @@
+// Dynamic pricing strategies
+
+package pricing
+
+import (
+	"math"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// Demand describes how a show is selling when a price is evaluated
+type Demand struct {
+	Sold       int           // seats held or booked
+	Capacity   int           // seats on sale
+	TimeToShow time.Duration // until the show starts; negative once it has started
+}
+
+// Occupancy returns the sold fraction of the show, 0 when capacity is unknown
+func (d Demand) Occupancy() float64 {
+	if d.Capacity <= 0 {
+		return 0
+	}
+	occ := float64(d.Sold) / float64(d.Capacity)
+	if occ > 1 {
+		return 1
+	}
+	return occ
+}
+
+// Strategy turns demand into a multiplier applied to a tier's base price
+type Strategy interface {
+	Name() string
+	Multiplier(d Demand) float64
+}
+
+// StaticStrategy always charges the base price
+type StaticStrategy struct{}
+
+func (StaticStrategy) Name() string                { return "static" }
+func (StaticStrategy) Multiplier(d Demand) float64 { return 1 }
+
+// DemandStrategy raises prices as a show fills up and lowers them for shows
+// that are still empty close to showtime
+type DemandStrategy struct {
+	SurgeThreshold float64       // occupancy above which prices start rising, e.g. 0.6
+	MaxSurge       float64       // extra fraction charged at full occupancy, e.g. 0.5 for +50%
+	EmptyThreshold float64       // occupancy below which last-minute discounts apply, e.g. 0.3
+	MaxDiscount    float64       // fraction taken off a completely empty show, e.g. 0.3 for -30%
+	DiscountWindow time.Duration // how close to showtime discounts apply
+}
+
+// DefaultDemandStrategy returns the tuning used when none is configured
+func DefaultDemandStrategy() DemandStrategy {
+	return DemandStrategy{
+		SurgeThreshold: 0.6,
+		MaxSurge:       0.5,
+		EmptyThreshold: 0.3,
+		MaxDiscount:    0.3,
+		DiscountWindow: 6 * time.Hour,
+	}
+}
+
+func (DemandStrategy) Name() string { return "demand" }
+
+func (s DemandStrategy) Multiplier(d Demand) float64 {
+	occ := d.Occupancy()
+
+	if occ > s.SurgeThreshold && s.SurgeThreshold < 1 {
+		return 1 + s.MaxSurge*(occ-s.SurgeThreshold)/(1-s.SurgeThreshold)
+	}
+	if occ < s.EmptyThreshold && d.TimeToShow >= 0 && d.TimeToShow <= s.DiscountWindow {
+		return 1 - s.MaxDiscount*(s.EmptyThreshold-occ)/s.EmptyThreshold
+	}
+	return 1
+}
+
+// Pricer applies a strategy to base prices and keeps the result within bounds
+type Pricer struct {
+	Strategy      Strategy
+	MinMultiplier float64 // default floor as a fraction of the base price
+	MaxMultiplier float64 // default ceiling as a fraction of the base price
+}
+
+// NewPricer returns a pricer for the strategy; nil means static pricing
+func NewPricer(strategy Strategy, minMultiplier, maxMultiplier float64) Pricer {
+	if strategy == nil {
+		strategy = StaticStrategy{}
+	}
+	return Pricer{Strategy: strategy, MinMultiplier: minMultiplier, MaxMultiplier: maxMultiplier}
+}
+
+// Price returns the current price of a tier. The tier's own floor and ceiling
+// win over the pricer's default multipliers.
+func (p Pricer) Price(base Price, d Demand) money.Money {
+	strategy := p.Strategy
+	if strategy == nil {
+		strategy = StaticStrategy{}
+	}
+	amount := int64(math.Round(float64(base.Price.Amount) * strategy.Multiplier(d)))
+
+	floor, ceiling := base.Floor, base.Ceiling
+	if floor == 0 && p.MinMultiplier > 0 {
+		floor = int64(math.Round(float64(base.Price.Amount) * p.MinMultiplier))
+	}
+	if ceiling == 0 && p.MaxMultiplier > 0 {
+		ceiling = int64(math.Round(float64(base.Price.Amount) * p.MaxMultiplier))
+	}
+	if floor > 0 && amount < floor {
+		amount = floor
+	}
+	if ceiling > 0 && amount > ceiling {
+		amount = ceiling
+	}
+
+	return money.Money{Amount: amount, Currency: base.Price.Currency}
+}
@@ -0,0 +1,73 @@
+// Command handler for promotion write operations (CQRS)
+
+package promotions
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+type CommandHandler struct {
+	CommandService *CommandService
+}
+
+func NewCommandHandler(cs *CommandService) *CommandHandler {
+	return &CommandHandler{CommandService: cs}
+}
+
+// CreatePromotion - Command handler for creating a promo code
+func (h *CommandHandler) CreatePromotion(c *gin.Context) {
+	var req CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	promo, err := h.CommandService.CreatePromotion(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPromotion) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create promotion: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, promo)
+}
+
+// CreatePromotionRequest - Request model for creating a promo code
+type CreatePromotionRequest struct {
+	Code         string     `json:"code"`
+	Kind         string     `json:"kind"`         // PERCENTAGE or FIXED
+	Value        int64      `json:"value"`        // percent off, or minor units off for FIXED
+	Currency     string     `json:"currency"`     // required for FIXED
+	MaxDiscount  int64      `json:"max_discount"` // cap for PERCENTAGE in minor units
+	PerUserLimit int        `json:"per_user_limit"`
+	TotalLimit   int        `json:"total_limit"`
+	ShowID       string     `json:"show_id"`
+	MovieID      string     `json:"movie_id"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+}
+
+// RedeemRequest - Request model for applying a promo code to a booking
+type RedeemRequest struct {
+	Code      string
+	UserID    string
+	BookingID string
+	PaymentID string // the payment the discount is taken off
+	ShowID    string
+	MovieID   string
+	Total     money.Money // booking total before the discount
+}
@@ -0,0 +1,160 @@
+// Command service for promotion write operations (CQRS)
+
+package promotions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+type CommandService struct {
+	Repo       *Repository
+	Dispatcher *events.Dispatcher
+}
+
+func NewCommandService(repo *Repository) *CommandService {
+	return &CommandService{Repo: repo}
+}
+
+func NewCommandServiceWithDispatcher(repo *Repository, dispatcher *events.Dispatcher) *CommandService {
+	return &CommandService{Repo: repo, Dispatcher: dispatcher}
+}
+
+// NormalizeCode upper-cases and trims a promo code
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CreatePromotion - Command to create a promo code
+func (s *CommandService) CreatePromotion(ctx context.Context, req CreatePromotionRequest) (*Promotion, error) {
+	promo := &Promotion{
+		ID:           uuid.New().String(),
+		Code:         NormalizeCode(req.Code),
+		Kind:         strings.ToUpper(req.Kind),
+		Value:        req.Value,
+		MaxDiscount:  req.MaxDiscount,
+		PerUserLimit: req.PerUserLimit,
+		TotalLimit:   req.TotalLimit,
+		ShowID:       req.ShowID,
+		MovieID:      req.MovieID,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		CreatedAt:    time.Now(),
+	}
+	if err := validatePromotion(promo, req.Currency); err != nil {
+		return nil, err
+	}
+	if promo.Kind == KindFixed {
+		promo.Currency, _ = money.NormalizeCurrency(req.Currency)
+	}
+
+	if err := s.Repo.CreatePromotion(ctx, promo); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+// validatePromotion checks the promotion definition, not whether it can be redeemed
+func validatePromotion(p *Promotion, currency string) error {
+	if p.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidPromotion)
+	}
+	switch p.Kind {
+	case KindPercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return fmt.Errorf("%w: percentage must be between 1 and 100", ErrInvalidPromotion)
+		}
+	case KindFixed:
+		if p.Value <= 0 {
+			return fmt.Errorf("%w: fixed discount must be positive", ErrInvalidPromotion)
+		}
+		if _, err := money.NormalizeCurrency(currency); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPromotion, err)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be PERCENTAGE or FIXED", ErrInvalidPromotion)
+	}
+	if p.MaxDiscount < 0 || p.PerUserLimit < 0 || p.TotalLimit < 0 {
+		return fmt.Errorf("%w: limits must not be negative", ErrInvalidPromotion)
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidPromotion)
+	}
+	return nil
+}
+
+// Redeem - Command to apply a promo code to a payment's booking total. Redeeming the same
+// code for the same payment again is idempotent.
+func (s *CommandService) Redeem(ctx context.Context, req RedeemRequest) (*Redemption, error) {
+	if req.BookingID == "" || req.UserID == "" || req.PaymentID == "" {
+		return nil, errors.New("booking ID, payment ID and user ID are required")
+	}
+
+	now := time.Now()
+	redemption, replayed, err := s.Repo.Redeem(ctx, NormalizeCode(req.Code), req.UserID, req.BookingID, req.PaymentID,
+		func(p *Promotion) (*Redemption, error) {
+			if err := p.Check(now, req.ShowID, req.MovieID); err != nil {
+				return nil, err
+			}
+			discount, err := p.Discount(req.Total)
+			if err != nil {
+				return nil, err
+			}
+			return &Redemption{Discount: discount}, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		if !redemption.Discount.SameCurrency(req.Total) {
+			return nil, fmt.Errorf("%w: booking is priced in %s", money.ErrCurrencyMismatch, redemption.Discount.Currency)
+		}
+		return redemption, nil
+	}
+
+	// Emit event for event-driven flow
+	if s.Dispatcher != nil {
+		payload := events.EventPayload{
+			UserID:    req.UserID,
+			BookingID: req.BookingID,
+			PaymentID: req.PaymentID,
+			ShowID:    req.ShowID,
+			MovieID:   req.MovieID,
+			PromoCode: redemption.Code,
+			Amount:    &redemption.Discount,
+		}
+		_ = s.Dispatcher.Publish(ctx, events.EventPromoRedeemed, redemption.ID, payload)
+	}
+
+	return redemption, nil
+}
+
+// Release - Command to give back the promo redemptions of a payment that won't be captured
+func (s *CommandService) Release(ctx context.Context, paymentID string) error {
+	released, err := s.Repo.Release(ctx, paymentID)
+	if err != nil {
+		return err
+	}
+
+	// Emit event for event-driven flow, so redemption counts can be taken back
+	if s.Dispatcher != nil {
+		for _, red := range released {
+			payload := events.EventPayload{
+				UserID:    red.UserID,
+				BookingID: red.BookingID,
+				PaymentID: red.PaymentID,
+				PromoCode: red.Code,
+				Amount:    &red.Discount,
+			}
+			_ = s.Dispatcher.Publish(ctx, events.EventPromoReleased, red.ID, payload)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Models for promotions database
+
+package promotions
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// Promotion kinds
+const (
+	KindPercentage = "PERCENTAGE"
+	KindFixed      = "FIXED"
+)
+
+// ErrInvalidPromotion is returned when a promotion definition is rejected
+var ErrInvalidPromotion = errors.New("invalid promotion")
+
+// ErrInvalidPromo is matched (via errors.Is) by every reason a code can't be applied
+var ErrInvalidPromo = errors.New("invalid promo code")
+
+var (
+	ErrPromoNotFound      = fmt.Errorf("%w: promo code not found", ErrInvalidPromo)
+	ErrPromoNotActive     = fmt.Errorf("%w: promo code is not active", ErrInvalidPromo)
+	ErrPromoNotApplicable = fmt.Errorf("%w: promo code does not apply to this booking", ErrInvalidPromo)
+	ErrPromoLimitReached  = fmt.Errorf("%w: promo code redemption limit reached", ErrInvalidPromo)
+	ErrPromoInUse         = fmt.Errorf("%w: promo code is applied to another payment for this booking", ErrInvalidPromo)
+)
+
+type Promotion struct {
+	ID          string `json:"id"`
+	Code        string `json:"code"`
+	Kind        string `json:"kind"`                   // PERCENTAGE or FIXED
+	Value       int64  `json:"value"`                  // percent off, or minor units off for FIXED
+	Currency    string `json:"currency,omitempty"`     // currency of a FIXED discount
+	MaxDiscount int64  `json:"max_discount,omitempty"` // cap on a PERCENTAGE discount in minor units, 0 for none
+
+	PerUserLimit int `json:"per_user_limit"` // 0 for unlimited
+	TotalLimit   int `json:"total_limit"`    // 0 for unlimited
+	Redeemed     int `json:"redeemed"`
+
+	// Optional restrictions
+	ShowID   string     `json:"show_id,omitempty"`
+	MovieID  string     `json:"movie_id,omitempty"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Redemption records a promo code applied to a booking by one of its payments
+type Redemption struct {
+	ID          string      `json:"id"`
+	PromotionID string      `json:"promotion_id"`
+	Code        string      `json:"code"`
+	UserID      string      `json:"user_id"`
+	BookingID   string      `json:"booking_id"`
+	PaymentID   string      `json:"payment_id"`
+	Discount    money.Money `json:"discount"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Check returns an error if the promotion can't be used at now for the given show and movie
+func (p *Promotion) Check(now time.Time, showID, movieID string) error {
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return fmt.Errorf("%w: starts at %s", ErrPromoNotActive, p.StartsAt.Format(time.RFC3339))
+	}
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return fmt.Errorf("%w: ended at %s", ErrPromoNotActive, p.EndsAt.Format(time.RFC3339))
+	}
+	if p.ShowID != "" && p.ShowID != showID {
+		return fmt.Errorf("%w: only valid for show %s", ErrPromoNotApplicable, p.ShowID)
+	}
+	if p.MovieID != "" && p.MovieID != movieID {
+		return fmt.Errorf("%w: only valid for movie %s", ErrPromoNotApplicable, p.MovieID)
+	}
+	if p.TotalLimit > 0 && p.Redeemed >= p.TotalLimit {
+		return ErrPromoLimitReached
+	}
+	return nil
+}
+
+// Discount returns the amount taken off a booking total, never more than the total
+func (p *Promotion) Discount(total money.Money) (money.Money, error) {
+	var off int64
+	switch p.Kind {
+	case KindPercentage:
+		off = total.Amount * p.Value / 100
+		if p.MaxDiscount > 0 && off > p.MaxDiscount {
+			off = p.MaxDiscount
+		}
+	case KindFixed:
+		if p.Currency != total.Currency {
+			return money.Money{}, fmt.Errorf("%w: discount is in %s, booking in %s", ErrPromoNotApplicable, p.Currency, total.Currency)
+		}
+		off = p.Value
+	default:
+		return money.Money{}, fmt.Errorf("unknown promotion kind %q", p.Kind)
+	}
+
+	if off > total.Amount {
+		off = total.Amount
+	}
+	return money.Money{Amount: off, Currency: total.Currency}, nil
+}
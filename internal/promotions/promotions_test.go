@@ -0,0 +1,124 @@
+package promotions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// TestDiscount tests percentage and fixed discount calculation
+func TestDiscount(t *testing.T) {
+	total := money.Money{Amount: 50000, Currency: "INR"}
+
+	tests := []struct {
+		name     string
+		promo    Promotion
+		expected int64
+		wantErr  error
+	}{
+		{"percentage", Promotion{Kind: KindPercentage, Value: 10}, 5000, nil},
+		{"percentage capped", Promotion{Kind: KindPercentage, Value: 50, MaxDiscount: 10000}, 10000, nil},
+		{"fixed", Promotion{Kind: KindFixed, Value: 15000, Currency: "INR"}, 15000, nil},
+		{"fixed above total", Promotion{Kind: KindFixed, Value: 90000, Currency: "INR"}, 50000, nil},
+		{"fixed other currency", Promotion{Kind: KindFixed, Value: 500, Currency: "USD"}, 0, ErrPromoNotApplicable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.promo.Discount(total)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.Amount != tt.expected || got.Currency != "INR" {
+				t.Errorf("Expected %d INR, got %d %s", tt.expected, got.Amount, got.Currency)
+			}
+		})
+	}
+}
+
+// TestCheck tests validity windows, restrictions and the total cap
+func TestCheck(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		promo   Promotion
+		showID  string
+		movieID string
+		wantErr error
+	}{
+		{"unrestricted", Promotion{}, "", "", nil},
+		{"within window", Promotion{StartsAt: &past, EndsAt: &future}, "", "", nil},
+		{"not started", Promotion{StartsAt: &future}, "", "", ErrPromoNotActive},
+		{"ended", Promotion{EndsAt: &past}, "", "", ErrPromoNotActive},
+		{"right show", Promotion{ShowID: "7"}, "7", "", nil},
+		{"wrong show", Promotion{ShowID: "7"}, "8", "", ErrPromoNotApplicable},
+		{"wrong movie", Promotion{MovieID: "3"}, "7", "4", ErrPromoNotApplicable},
+		{"cap reached", Promotion{TotalLimit: 5, Redeemed: 5}, "", "", ErrPromoLimitReached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.promo.Check(now, tt.showID, tt.movieID)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) || !errors.Is(err, ErrInvalidPromo) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestValidatePromotion tests promotion definition validation
+func TestValidatePromotion(t *testing.T) {
+	start := time.Now()
+	end := start.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		promo    Promotion
+		currency string
+		wantErr  bool
+	}{
+		{"valid percentage", Promotion{Code: "SAVE10", Kind: KindPercentage, Value: 10}, "", false},
+		{"valid fixed", Promotion{Code: "FLAT100", Kind: KindFixed, Value: 10000}, "INR", false},
+		{"missing code", Promotion{Kind: KindPercentage, Value: 10}, "", true},
+		{"percentage over 100", Promotion{Code: "X", Kind: KindPercentage, Value: 120}, "", true},
+		{"fixed without currency", Promotion{Code: "X", Kind: KindFixed, Value: 100}, "", true},
+		{"unknown kind", Promotion{Code: "X", Kind: "BOGO", Value: 1}, "", true},
+		{"ends before start", Promotion{Code: "X", Kind: KindPercentage, Value: 5, StartsAt: &start, EndsAt: &end}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePromotion(&tt.promo, tt.currency)
+			if tt.wantErr && !errors.Is(err, ErrInvalidPromotion) {
+				t.Errorf("Expected ErrInvalidPromotion, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestNormalizeCode tests promo code normalization
+func TestNormalizeCode(t *testing.T) {
+	if got := NormalizeCode("  save10 "); got != "SAVE10" {
+		t.Errorf("Expected SAVE10, got %s", got)
+	}
+}
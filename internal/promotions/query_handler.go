@@ -0,0 +1,37 @@
+// Query handler for promotion read operations (CQRS)
+
+package promotions
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetPromotion - Query handler for getting a promotion by code
+func (h *QueryHandler) GetPromotion(c *gin.Context) {
+	promo, err := h.QueryService.GetPromotion(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		if errors.Is(err, ErrPromoNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Promotion not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get promotion: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, promo)
+}
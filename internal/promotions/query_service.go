@@ -0,0 +1,19 @@
+// Query service for promotion read operations (CQRS)
+
+package promotions
+
+import "context"
+
+// QueryService reads promotions from the Command DB, where redemption counts are kept
+type QueryService struct {
+	Repo *Repository
+}
+
+func NewQueryService(repo *Repository) *QueryService {
+	return &QueryService{Repo: repo}
+}
+
+// GetPromotion - Query to get a promotion and its redemption count by code
+func (s *QueryService) GetPromotion(ctx context.Context, code string) (*Promotion, error) {
+	return s.Repo.GetPromotionByCode(ctx, NormalizeCode(code))
+}
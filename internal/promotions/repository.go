@@ -0,0 +1,184 @@
+package promotions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+const promotionColumns = `
+	id, code, kind, value, COALESCE(currency, ''), max_discount, per_user_limit, total_limit,
+	redeemed_count, COALESCE(show_id, ''), COALESCE(movie_id, ''), starts_at, ends_at, created_at`
+
+// rowScanner is satisfied by pgx.Row and pgx.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPromotion(row rowScanner) (*Promotion, error) {
+	var p Promotion
+	err := row.Scan(&p.ID, &p.Code, &p.Kind, &p.Value, &p.Currency, &p.MaxDiscount, &p.PerUserLimit,
+		&p.TotalLimit, &p.Redeemed, &p.ShowID, &p.MovieID, &p.StartsAt, &p.EndsAt, &p.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPromoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *Repository) CreatePromotion(ctx context.Context, p *Promotion) error {
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO promotions
+		(id, code, kind, value, currency, max_discount, per_user_limit, total_limit,
+		 show_id, movie_id, starts_at, ends_at, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''), $11, $12, $13)
+	`, p.ID, p.Code, p.Kind, p.Value, p.Currency, p.MaxDiscount, p.PerUserLimit, p.TotalLimit,
+		p.ShowID, p.MovieID, p.StartsAt, p.EndsAt, p.CreatedAt)
+	return err
+}
+
+func (r *Repository) GetPromotionByCode(ctx context.Context, code string) (*Promotion, error) {
+	row := r.DB.QueryRow(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE code = $1", code)
+	return scanPromotion(row)
+}
+
+// Redeem applies a promo code to a booking's payment. The promotion row is locked for the
+// whole transaction, so concurrent checkouts can't exceed the total or per-user limits.
+// apply validates the promotion and computes the discount. Redeeming the same code for
+// the same payment again returns the existing redemption with replayed set; a code already
+// applied to another payment for the booking is ErrPromoInUse.
+func (r *Repository) Redeem(ctx context.Context, code, userID, bookingID, paymentID string, apply func(p *Promotion) (*Redemption, error)) (redemption *Redemption, replayed bool, err error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	promo, err := scanPromotion(tx.QueryRow(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE code = $1 FOR UPDATE", code))
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Retried checkout for the same payment
+	existing := Redemption{PromotionID: promo.ID, Code: promo.Code}
+	err = tx.QueryRow(ctx, `
+		SELECT id, user_id, booking_id, COALESCE(payment_id::text, ''), discount_amount, currency, created_at
+		FROM promotion_redemptions
+		WHERE promotion_id = $1 AND booking_id = $2
+	`, promo.ID, bookingID).Scan(&existing.ID, &existing.UserID, &existing.BookingID, &existing.PaymentID,
+		&existing.Discount.Amount, &existing.Discount.Currency, &existing.CreatedAt)
+	if err == nil {
+		if existing.PaymentID != paymentID {
+			return nil, false, ErrPromoInUse
+		}
+		return &existing, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, false, err
+	}
+
+	if promo.PerUserLimit > 0 {
+		var used int
+		err = tx.QueryRow(ctx,
+			"SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id = $1 AND user_id = $2",
+			promo.ID, userID,
+		).Scan(&used)
+		if err != nil {
+			return nil, false, err
+		}
+		if used >= promo.PerUserLimit {
+			return nil, false, ErrPromoLimitReached
+		}
+	}
+
+	redemption, err = apply(promo)
+	if err != nil {
+		return nil, false, err
+	}
+	redemption.ID = uuid.New().String()
+	redemption.PromotionID = promo.ID
+	redemption.Code = promo.Code
+	redemption.UserID = userID
+	redemption.BookingID = bookingID
+	redemption.PaymentID = paymentID
+	redemption.CreatedAt = time.Now()
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO promotion_redemptions
+		(id, promotion_id, user_id, booking_id, payment_id, discount_amount, currency, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, redemption.ID, redemption.PromotionID, redemption.UserID, redemption.BookingID, redemption.PaymentID,
+		redemption.Discount.Amount, redemption.Discount.Currency, redemption.CreatedAt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE promotions SET redeemed_count = redeemed_count + 1 WHERE id = $1", promo.ID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+	return redemption, false, nil
+}
+
+// Release removes the redemptions of a payment that will never be captured and frees
+// their slots. It returns the released redemptions.
+func (r *Repository) Release(ctx context.Context, paymentID string) ([]Redemption, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		DELETE FROM promotion_redemptions WHERE payment_id = $1
+		RETURNING id, promotion_id, user_id, booking_id, discount_amount, currency, created_at
+	`, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	var released []Redemption
+	for rows.Next() {
+		red := Redemption{PaymentID: paymentID}
+		err := rows.Scan(&red.ID, &red.PromotionID, &red.UserID, &red.BookingID,
+			&red.Discount.Amount, &red.Discount.Currency, &red.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		released = append(released, red)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range released {
+		err = tx.QueryRow(ctx, `
+			UPDATE promotions SET redeemed_count = GREATEST(redeemed_count - 1, 0)
+			WHERE id = $1 RETURNING code
+		`, released[i].PromotionID).Scan(&released[i].Code)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return released, tx.Commit(ctx)
+}
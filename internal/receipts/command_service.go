@@ -0,0 +1,90 @@
+// Command service for issuing receipts (CQRS)
+
+package receipts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/notification"
+	"github.com/hitorii/ticket-booking/internal/payments"
+)
+
+type CommandService struct {
+	Repo     *Repository
+	Payments *payments.Repository
+}
+
+func NewCommandService(repo *Repository, paymentRepo *payments.Repository) *CommandService {
+	return &CommandService{Repo: repo, Payments: paymentRepo}
+}
+
+// Issue - Command to generate, store and send the receipt of a captured payment.
+// Issuing again returns the stored receipt without sending it twice.
+func (s *CommandService) Issue(ctx context.Context, paymentID string) (*Receipt, error) {
+	if existing, err := s.Repo.GetByPaymentID(ctx, paymentID); err == nil {
+		return existing, nil
+	}
+
+	payment, err := s.Payments.GetPaymentByID(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	switch payment.Status {
+	case payments.StatusCaptured, payments.StatusPartiallyRefunded, payments.StatusRefunded:
+	default:
+		return nil, fmt.Errorf("%w: payment %s is %s", ErrNotPaid, paymentID, payment.Status)
+	}
+
+	items, err := s.Payments.GetLineItems(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	details, err := s.Repo.GetBookingDetails(ctx, payment.BookingID, payment.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	paidAt := payment.UpdatedAt
+	if paidAt.IsZero() {
+		paidAt = time.Now()
+	}
+	rec := &Receipt{
+		ID:            uuid.New().String(),
+		Number:        ReceiptNumber(payment.ID, paidAt),
+		PaymentID:     payment.ID,
+		BookingID:     payment.BookingID,
+		UserID:        payment.UserID,
+		Customer:      details.Customer,
+		Email:         details.Email,
+		Movie:         details.Movie,
+		Theater:       details.Theater,
+		ShowTime:      details.ShowTime,
+		Seats:         details.Seats,
+		LineItems:     items,
+		Total:         payment.Amount,
+		TransactionID: payment.TransactionID,
+		PaidAt:        paidAt,
+		CreatedAt:     time.Now(),
+	}
+	if rec.HTML, err = RenderHTML(rec); err != nil {
+		return nil, err
+	}
+	rec.PDF = RenderPDF(rec)
+
+	saved, err := s.Repo.Save(ctx, rec)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		// Issued concurrently; serve the one that was stored
+		return s.Repo.GetByPaymentID(ctx, paymentID)
+	}
+
+	if err := notification.EnqueueReceipt(rec.UserID, rec.PaymentID, rec.Number, rec.HTML); err != nil {
+		println("Warning: Failed to enqueue receipt:", err.Error())
+	}
+	return rec, nil
+}
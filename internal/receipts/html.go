@@ -0,0 +1,72 @@
+// HTML rendering of receipts
+
+package receipts
+
+import (
+	"bytes"
+	"html/template"
+)
+
+var receiptTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Receipt {{.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; max-width: 640px; margin: 2em auto; }
+h1 { font-size: 1.4em; margin-bottom: 0.2em; }
+table { width: 100%; border-collapse: collapse; margin-top: 1em; }
+th, td { padding: 6px 4px; text-align: left; }
+td.amount, th.amount { text-align: right; }
+tr.total td { border-top: 2px solid #222; font-weight: bold; }
+dl { display: grid; grid-template-columns: 9em 1fr; row-gap: 4px; }
+dt { color: #666; }
+dd { margin: 0; }
+</style>
+</head>
+<body>
+<h1>Tax Invoice</h1>
+<p>{{.Number}} &middot; {{.PaidAt.Format "02 Jan 2006 15:04 MST"}}</p>
+<dl>
+<dt>Billed to</dt><dd>{{.Customer}}{{if .Email}} &lt;{{.Email}}&gt;{{end}}</dd>
+<dt>Movie</dt><dd>{{.Movie}}</dd>
+<dt>Theater</dt><dd>{{.Theater}}</dd>
+<dt>Show time</dt><dd>{{.ShowTime}}</dd>
+<dt>Seats</dt><dd>{{.Seats}}</dd>
+<dt>Booking</dt><dd>{{.BookingID}}</dd>
+<dt>Payment</dt><dd>{{.PaymentID}}</dd>
+<dt>Transaction</dt><dd>{{.TransactionID}}</dd>
+</dl>
+<table>
+<thead><tr><th>Description</th><th class="amount">Amount</th></tr></thead>
+<tbody>
+{{range .LineItems}}<tr><td>{{.Label}}</td><td class="amount">{{.Amount}}</td></tr>
+{{end}}<tr class="total"><td>Total paid</td><td class="amount">{{.Total}}</td></tr>
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// RenderHTML renders a receipt as a standalone HTML document
+func RenderHTML(r *Receipt) (string, error) {
+	var buf bytes.Buffer
+	err := receiptTemplate.Execute(&buf, struct {
+		*Receipt
+		Customer      string
+		Movie         string
+		Theater       string
+		ShowTime      string
+		Seats         string
+		TransactionID string
+	}{
+		Receipt:       r,
+		Customer:      display(r.Customer),
+		Movie:         display(r.Movie),
+		Theater:       display(r.Theater),
+		ShowTime:      r.showTime(),
+		Seats:         r.seats(),
+		TransactionID: display(r.TransactionID),
+	})
+	return buf.String(), err
+}
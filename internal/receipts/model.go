@@ -0,0 +1,77 @@
+// Models for payment receipts
+
+package receipts
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// Receipt formats served by the query endpoint
+const (
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+	FormatJSON = "json"
+)
+
+var (
+	ErrReceiptNotFound = errors.New("receipt not found")
+	ErrNotPaid         = errors.New("payment has not been captured")
+)
+
+// Receipt is the invoice for a captured payment. The rendered documents are stored
+// alongside it so a receipt never changes after it is issued.
+type Receipt struct {
+	ID            string           `json:"id"`
+	Number        string           `json:"number"`
+	PaymentID     string           `json:"payment_id"`
+	BookingID     string           `json:"booking_id"`
+	UserID        string           `json:"user_id"`
+	Customer      string           `json:"customer,omitempty"`
+	Email         string           `json:"email,omitempty"`
+	Movie         string           `json:"movie,omitempty"`
+	Theater       string           `json:"theater,omitempty"`
+	ShowTime      *time.Time       `json:"show_time,omitempty"`
+	Seats         []string         `json:"seats"`
+	LineItems     []money.LineItem `json:"line_items"`
+	Total         money.Money      `json:"total"`
+	TransactionID string           `json:"transaction_id"`
+	PaidAt        time.Time        `json:"paid_at"`
+	CreatedAt     time.Time        `json:"created_at"`
+
+	HTML string `json:"-"`
+	PDF  []byte `json:"-"`
+}
+
+// ReceiptNumber derives the invoice number of a payment, e.g. INV-20261018-1A2B3C4D
+func ReceiptNumber(paymentID string, paidAt time.Time) string {
+	suffix := strings.ToUpper(strings.ReplaceAll(paymentID, "-", ""))
+	if len(suffix) > 8 {
+		suffix = suffix[:8]
+	}
+	return "INV-" + paidAt.UTC().Format("20060102") + "-" + suffix
+}
+
+// display returns value or a dash for receipt fields we couldn't resolve
+func display(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// showTime formats the show time of a receipt
+func (r *Receipt) showTime() string {
+	if r.ShowTime == nil {
+		return "-"
+	}
+	return r.ShowTime.Format("Mon 02 Jan 2006, 15:04")
+}
+
+// seats lists the booked seats of a receipt
+func (r *Receipt) seats() string {
+	return display(strings.Join(r.Seats, ", "))
+}
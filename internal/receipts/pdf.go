@@ -0,0 +1,147 @@
+// PDF rendering of receipts. The writer is deliberately minimal: A4 pages of monospaced
+// text in the standard Courier fonts, which every PDF reader ships, so no font embedding
+// or third-party library is needed.
+
+package receipts
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfPageWidth  = 595 // A4 in points
+	pdfPageHeight = 842
+	pdfMargin     = 50
+	pdfFontSize   = 10
+	pdfLeading    = 14
+	pdfColumns    = 80 // Courier glyphs are 0.6em wide: 80 * 6pt fits between the margins
+)
+
+// pdfLine is one line of receipt text
+type pdfLine struct {
+	Text string
+	Bold bool
+}
+
+// receiptLines lays a receipt out as fixed-width text
+func receiptLines(r *Receipt) []pdfLine {
+	field := func(label, value string) pdfLine {
+		return pdfLine{Text: fmt.Sprintf("%-14s%s", label+":", value)}
+	}
+	row := func(label, amount string, bold bool) pdfLine {
+		width := pdfColumns - len(amount) - 1
+		if len(label) > width {
+			label = label[:width]
+		}
+		return pdfLine{Text: fmt.Sprintf("%-*s %s", width, label, amount), Bold: bold}
+	}
+	rule := pdfLine{Text: strings.Repeat("-", pdfColumns)}
+
+	lines := []pdfLine{
+		{Text: "TAX INVOICE", Bold: true},
+		{},
+		field("Invoice", r.Number),
+		field("Date", r.PaidAt.Format("02 Jan 2006 15:04 MST")),
+		field("Billed to", display(r.Customer)),
+		field("Payment", r.PaymentID),
+		field("Transaction", display(r.TransactionID)),
+		{},
+		field("Movie", display(r.Movie)),
+		field("Theater", display(r.Theater)),
+		field("Show time", r.showTime()),
+		field("Seats", r.seats()),
+		field("Booking", r.BookingID),
+		{},
+		row("Description", "Amount", true),
+		rule,
+	}
+	for _, item := range r.LineItems {
+		lines = append(lines, row(item.Label, item.Amount.ISOString(), false))
+	}
+	return append(lines, rule, row("TOTAL PAID", r.Total.ISOString(), true))
+}
+
+// pdfEscape makes text safe inside a PDF string literal; anything outside ASCII is
+// replaced because the standard fonts can't show it
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case c == '\\' || c == '(' || c == ')':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case c < 32 || c > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// RenderPDF renders a receipt as a PDF document
+func RenderPDF(r *Receipt) []byte {
+	return renderPDF("Receipt "+r.Number, receiptLines(r))
+}
+
+func renderPDF(title string, lines []pdfLine) []byte {
+	perPage := (pdfPageHeight - 2*pdfMargin) / pdfLeading
+	var pages [][]pdfLine
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects: 1 catalog, 2 page tree, 3-4 fonts, 5 info, then a page and its content per page
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	objects = append(objects,
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Title (%s) /Producer (ticket-booking) >>", pdfEscape(title)),
+	)
+
+	for i, page := range pages {
+		var content strings.Builder
+		content.WriteString("BT\n")
+		fmt.Fprintf(&content, "%d TL\n%d %d Td\n", pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			font := "F1"
+			if line.Bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "/%s %d Tf (%s) Tj T*\n", font, pdfFontSize, pdfEscape(line.Text))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 7+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,47 @@
+// Query handler for receipt read operations (CQRS)
+
+package receipts
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetReceipt - Query handler for a payment's receipt as HTML (default), PDF or JSON
+func (h *QueryHandler) GetReceipt(c *gin.Context) {
+	format := c.DefaultQuery("format", FormatHTML)
+	if format != FormatHTML && format != FormatPDF && format != FormatJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be html, pdf or json"})
+		return
+	}
+
+	rec, err := h.QueryService.GetReceipt(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrReceiptNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get receipt: " + err.Error()})
+		return
+	}
+
+	switch format {
+	case FormatPDF:
+		c.Header("Content-Disposition", `inline; filename="`+rec.Number+`.pdf"`)
+		c.Data(http.StatusOK, "application/pdf", rec.PDF)
+	case FormatJSON:
+		c.JSON(http.StatusOK, rec)
+	default:
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rec.HTML))
+	}
+}
@@ -0,0 +1,20 @@
+// Query service for receipt read operations (CQRS)
+
+package receipts
+
+import (
+	"context"
+)
+
+type QueryService struct {
+	Repo *Repository
+}
+
+func NewQueryService(repo *Repository) *QueryService {
+	return &QueryService{Repo: repo}
+}
+
+// GetReceipt - Query to get the receipt issued for a payment
+func (s *QueryService) GetReceipt(ctx context.Context, paymentID string) (*Receipt, error) {
+	return s.Repo.GetByPaymentID(ctx, paymentID)
+}
@@ -0,0 +1,108 @@
+package receipts
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+func sampleReceipt() *Receipt {
+	inr := func(amount int64) money.Money { return money.Money{Amount: amount, Currency: "INR"} }
+	show := time.Date(2026, 10, 20, 18, 30, 0, 0, time.UTC)
+	return &Receipt{
+		Number:        "INV-20261018-1A2B3C4D",
+		PaymentID:     "1a2b3c4d-0000-0000-0000-000000000000",
+		BookingID:     "9f8e7d6c-0000-0000-0000-000000000000",
+		Customer:      "alice",
+		Movie:         "Inception <IMAX>",
+		Theater:       "Theater A",
+		ShowTime:      &show,
+		Seats:         []string{"F12", "F13"},
+		TransactionID: "mock_txn_1",
+		PaidAt:        time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC),
+		LineItems: []money.LineItem{
+			{Kind: money.LineBaseFare, Label: "Tickets", Amount: inr(50000)},
+			{Kind: money.LineDiscount, Label: "Promo SAVE10", Amount: inr(-5000)},
+			{Kind: money.LineTax, Label: "GST (18%)", Amount: inr(8100)},
+		},
+		Total: inr(53100),
+	}
+}
+
+// TestReceiptNumber tests invoice numbering
+func TestReceiptNumber(t *testing.T) {
+	got := ReceiptNumber("1a2b3c4d-5e6f-0000-0000-000000000000", time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC))
+	if got != "INV-20261018-1A2B3C4D" {
+		t.Errorf("Expected INV-20261018-1A2B3C4D, got %s", got)
+	}
+}
+
+// TestRenderHTML tests that the HTML receipt shows the booking and escapes its values
+func TestRenderHTML(t *testing.T) {
+	html, err := RenderHTML(sampleReceipt())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"INV-20261018-1A2B3C4D", "Inception &lt;IMAX&gt;", "F12, F13", "Promo SAVE10", "₹531.00", "mock_txn_1"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Expected HTML to contain %q", want)
+		}
+	}
+	if strings.Contains(html, "<IMAX>") {
+		t.Error("Expected movie name to be escaped")
+	}
+}
+
+// TestRenderPDF tests the PDF structure and cross-reference table
+func TestRenderPDF(t *testing.T) {
+	pdf := RenderPDF(sampleReceipt())
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("Expected a PDF header and trailer")
+	}
+	for _, want := range []string{"(TOTAL PAID", "INR 531.00)", "(Seats:        F12, F13)"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("Expected PDF to contain %q", want)
+		}
+	}
+
+	// Every xref entry must point at the start of its object
+	xrefAt := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	if xrefAt == nil {
+		t.Fatal("Expected startxref")
+	}
+	start, _ := strconv.Atoi(string(xrefAt[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdf[start:], -1)
+	if len(entries) != 7 {
+		t.Fatalf("Expected 7 objects, got %d", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if !bytes.HasPrefix(pdf[off:], []byte(fmt.Sprintf("%d 0 obj", i+1))) {
+			t.Errorf("xref entry %d does not point at its object", i+1)
+		}
+	}
+}
+
+// TestRenderPDFPages tests that long documents flow onto more pages
+func TestRenderPDFPages(t *testing.T) {
+	lines := make([]pdfLine, 120)
+	pdf := renderPDF("long", lines)
+	if !bytes.Contains(pdf, []byte("/Count 3")) {
+		t.Error("Expected 120 lines to take 3 pages")
+	}
+}
+
+// TestPDFEscape tests escaping of PDF string delimiters and non-ASCII text
+func TestPDFEscape(t *testing.T) {
+	if got := pdfEscape(`a(b)c\d ₹`); got != `a\(b\)c\\d ?` {
+		t.Errorf("Unexpected escape result %q", got)
+	}
+}
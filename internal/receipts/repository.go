@@ -0,0 +1,120 @@
+package receipts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/venue"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository stores receipts in the Command DB. The show time on a receipt is in the
+// venue's zone, from Zones.
+type Repository struct {
+	DB    *pgxpool.Pool
+	Zones *venue.QueryService
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+// bookingDetails is what a receipt shows about the booking behind a payment
+type bookingDetails struct {
+	Customer string
+	Email    string
+	Movie    string
+	Theater  string
+	ShowTime *time.Time
+	Seats    []string
+}
+
+// GetBookingDetails loads the customer, movie, show and seats of a booking.
+// Anything that can't be resolved is left empty rather than failing the receipt.
+func (r *Repository) GetBookingDetails(ctx context.Context, bookingID, userID string) (*bookingDetails, error) {
+	d := &bookingDetails{Seats: []string{}}
+
+	err := r.DB.QueryRow(ctx, "SELECT username, email FROM users WHERE id = $1", userID).Scan(&d.Customer, &d.Email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT COALESCE(m.name, ''), COALESCE(sh.theater, ''), sh.start_time, COALESCE(s.label, '')
+		FROM reservations res
+		LEFT JOIN seats s ON s.id = res.seat_id
+		LEFT JOIN shows sh ON sh.id = COALESCE(res.show_id, s.show_id)
+		LEFT JOIN movies m ON m.id = sh.movie_id
+		WHERE res.id = $1
+		ORDER BY s.label
+	`, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&d.Movie, &d.Theater, &d.ShowTime, &label); err != nil {
+			return nil, err
+		}
+		if label != "" {
+			d.Seats = append(d.Seats, label)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if d.ShowTime != nil {
+		loc, err := r.Zones.Location(ctx, d.Theater)
+		if err != nil {
+			return nil, err
+		}
+		local := d.ShowTime.In(loc)
+		d.ShowTime = &local
+	}
+	return d, nil
+}
+
+// Save stores an issued receipt once per payment. It reports false when the payment
+// already had one.
+func (r *Repository) Save(ctx context.Context, rec *Receipt) (bool, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.DB.Exec(ctx, `
+		INSERT INTO receipts (id, number, payment_id, booking_id, user_id, data, html, pdf, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (payment_id) DO NOTHING
+	`, rec.ID, rec.Number, rec.PaymentID, rec.BookingID, rec.UserID, data, rec.HTML, rec.PDF, rec.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// GetByPaymentID returns the receipt issued for a payment with its documents
+func (r *Repository) GetByPaymentID(ctx context.Context, paymentID string) (*Receipt, error) {
+	var data []byte
+	var html string
+	var pdf []byte
+	err := r.DB.QueryRow(ctx, "SELECT data, html, pdf FROM receipts WHERE payment_id = $1", paymentID).Scan(&data, &html, &pdf)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rec Receipt
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	rec.HTML, rec.PDF = html, pdf
+	return &rec, nil
+}
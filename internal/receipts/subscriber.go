@@ -0,0 +1,34 @@
+// Event subscriber that issues receipts for captured payments
+
+package receipts
+
+import (
+	"context"
+	"log"
+
+	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/hitorii/ticket-booking/internal/payments"
+)
+
+// Subscribe registers the receipt handler on the dispatcher
+func (s *CommandService) Subscribe(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(events.EventPaymentVerified, s.onPaymentVerified)
+}
+
+// onPaymentVerified issues a receipt once a payment is captured
+func (s *CommandService) onPaymentVerified(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	if payload.Status != payments.StatusCaptured {
+		return nil
+	}
+
+	rec, err := s.Issue(context.Background(), payload.PaymentID)
+	if err != nil {
+		return err
+	}
+	log.Printf("🧾 Issued receipt %s for payment %s", rec.Number, rec.PaymentID)
+	return nil
+}
@@ -3,20 +3,85 @@
 package show
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/venue"
 )
 
 type CommandHandler struct {
 	CommandService *CommandService
+	Managers       *venue.CommandService // admins and a venue's managers may change its shows
 }
 
 func NewCommandHandler(cs *CommandService) *CommandHandler {
 	return &CommandHandler{CommandService: cs}
 }
 
+// RequireShowManager - Middleware letting through admins and the managers of the venue of
+// the show with the :id in the path. It follows middleware.Authenticate.
+func (h *CommandHandler) RequireShowManager(c *gin.Context) {
+	venueName, err := h.CommandService.showVenue(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, "Failed to check access: ", err)
+		c.Abort()
+		return
+	}
+	if h.allowed(c, venueName) {
+		c.Next()
+	}
+}
+
+// RequireScheduleManager - Middleware letting through admins and the managers of the venue
+// of the schedule with the :id in the path. It follows middleware.Authenticate.
+func (h *CommandHandler) RequireScheduleManager(c *gin.Context) {
+	venueName, err := h.CommandService.scheduleVenue(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeError(c, "Failed to check access: ", err)
+		c.Abort()
+		return
+	}
+	if h.allowed(c, venueName) {
+		c.Next()
+	}
+}
+
+// allowed checks the signed-in user may manage every one of the venues, aborting with the
+// error when they may not. Empty venues are left for the commands to reject.
+func (h *CommandHandler) allowed(c *gin.Context, venues ...string) bool {
+	var err error
+	if h.Managers == nil {
+		err = errors.New("venue access is not configured")
+	}
+	for _, v := range venues {
+		if err != nil {
+			break
+		}
+		if v != "" {
+			err = h.Managers.Authorize(c.Request.Context(), c.GetString("user_id"), v)
+		}
+	}
+	if err != nil {
+		writeError(c, "Failed to check access: ", err)
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+// allowedAt checks the signed-in user may manage the venue a show is being put in
+func (h *CommandHandler) allowedAt(c *gin.Context, theater string, screenID *int) bool {
+	venueName, err := h.CommandService.venueOf(c.Request.Context(), theater, screenID)
+	if err != nil {
+		writeError(c, "Failed to check access: ", err)
+		c.Abort()
+		return false
+	}
+	return h.allowed(c, venueName)
+}
+
 // CreateShow - Command handler for creating a new show
 func (h *CommandHandler) CreateShow(c *gin.Context) {
 	var req CreateShowRequest
@@ -25,16 +90,103 @@ func (h *CommandHandler) CreateShow(c *gin.Context) {
 		return
 	}
 
+	if !h.allowedAt(c, req.Theater, req.ScreenID) {
+		return
+	}
+
 	show, err := h.CommandService.CreateShow(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create show: " + err.Error()})
+		writeError(c, "Failed to create show: ", err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, show)
 }
 
-// UpdateShow - Command handler for updating an existing show
+// ScheduleShows - Command handler for scheduling a batch of shows atomically
+func (h *CommandHandler) ScheduleShows(c *gin.Context) {
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	// Rows whose venue can't be told are reported by the command itself
+	venues := make([]string, 0, len(req.Shows))
+	for _, r := range req.Shows {
+		if v, err := h.CommandService.venueOf(c.Request.Context(), r.Theater, r.ScreenID); err == nil {
+			venues = append(venues, v)
+		}
+	}
+	if !h.allowed(c, venues...) {
+		return
+	}
+
+	shows, err := h.CommandService.ScheduleShows(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, "Failed to schedule shows: ", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"shows": shows, "count": len(shows)})
+}
+
+// CreateSchedule - Command handler for creating a recurring schedule
+func (h *CommandHandler) CreateSchedule(c *gin.Context) {
+	var req CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if !h.allowedAt(c, req.Theater, req.ScreenID) {
+		return
+	}
+
+	schedule, err := h.CommandService.CreateSchedule(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, "Failed to create schedule: ", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// UpdateFollowing - Command handler for editing a show and the rest of its schedule. It
+// follows RequireScheduleManager; moving the shows needs access to their new venue too.
+func (h *CommandHandler) UpdateFollowing(c *gin.Context) {
+	var req UpdateFollowingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if !h.allowedAt(c, req.Theater, req.ScreenID) {
+		return
+	}
+
+	schedule, err := h.CommandService.UpdateFollowing(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeError(c, "Failed to update schedule: ", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// CancelFollowing - Command handler for cancelling a show and the rest of its schedule
+func (h *CommandHandler) CancelFollowing(c *gin.Context) {
+	ids, err := h.CommandService.CancelFollowing(c.Request.Context(), c.Param("id"), c.Query("from_show_id"))
+	if err != nil {
+		writeError(c, "Failed to cancel schedule: ", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Shows cancelled successfully", "cancelled": ids})
+}
+
+// UpdateShow - Command handler for updating an existing show. It follows RequireShowManager;
+// moving the show needs access to its new venue too.
 func (h *CommandHandler) UpdateShow(c *gin.Context) {
 	id := c.Param("id")
 	
@@ -44,13 +196,17 @@ func (h *CommandHandler) UpdateShow(c *gin.Context) {
 		return
 	}
 
-	err := h.CommandService.UpdateShow(c.Request.Context(), id, req)
+	if !h.allowedAt(c, req.Theater, req.ScreenID) {
+		return
+	}
+
+	show, err := h.CommandService.UpdateShow(c.Request.Context(), id, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update show: " + err.Error()})
+		writeError(c, "Failed to update show: ", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Show updated successfully"})
+	c.JSON(http.StatusOK, show)
 }
 
 // DeleteShow - Command handler for deleting a show
@@ -59,7 +215,7 @@ func (h *CommandHandler) DeleteShow(c *gin.Context) {
 
 	err := h.CommandService.DeleteShow(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete show: " + err.Error()})
+		writeError(c, "Failed to delete show: ", err)
 		return
 	}
 
@@ -70,13 +226,73 @@ func (h *CommandHandler) DeleteShow(c *gin.Context) {
 type CreateShowRequest struct {
 	MovieID   int       `json:"movie_id"`
 	Theater   string    `json:"theater"`
+	ScreenID  *int      `json:"screen_id"`
 	StartTime time.Time `json:"start_time"`
 }
 
-// UpdateShowRequest - Request model for updating a show
+// ScheduleRequest - Request model for scheduling several shows at once
+type ScheduleRequest struct {
+	Shows []CreateShowRequest `json:"shows"`
+}
+
+// CreateScheduleRequest - Request model for a recurring schedule. Rule is an RRULE such as
+// "FREQ=WEEKLY;BYDAY=FR,SA;UNTIL=20240331"; exceptions are YYYY-MM-DD dates to skip.
+type CreateScheduleRequest struct {
+	MovieID    int       `json:"movie_id"`
+	Theater    string    `json:"theater"`
+	ScreenID   *int      `json:"screen_id"`
+	StartTime  time.Time `json:"start_time"`
+	Rule       string    `json:"rule"`
+	Exceptions []string  `json:"exceptions"`
+}
+
+// UpdateFollowingRequest - Request model for editing a schedule from one show onwards
+type UpdateFollowingRequest struct {
+	FromShowID int       `json:"from_show_id"`
+	MovieID    int       `json:"movie_id"`
+	Theater    string    `json:"theater"`
+	ScreenID   *int      `json:"screen_id"`
+	StartTime  time.Time `json:"start_time"`
+}
+
+// UpdateShowRequest - Request model for updating a show; the end time is derived
 type UpdateShowRequest struct {
 	MovieID   int       `json:"movie_id"`
 	Theater   string    `json:"theater"`
+	ScreenID  *int      `json:"screen_id"`
 	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
+}
+
+// writeError maps show errors to status codes
+func writeError(c *gin.Context, prefix string, err error) {
+	var conflict *ConflictError
+	var schedule *ScheduleError
+	switch {
+	case errors.As(err, &schedule):
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrScheduleConflict) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": ErrInvalidSchedule.Error(), "rows": schedule.Rows})
+	case errors.As(err, &conflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflict": conflict.Show})
+	case errors.Is(err, ErrInvalidSchedule), errors.Is(err, ErrInvalidRule), errors.Is(err, ErrNotInSchedule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, venue.ErrUnauthenticated):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, venue.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+	case errors.Is(err, ErrTheaterRequired), errors.Is(err, ErrMovieRequired),
+		errors.Is(err, ErrStartTimeMissing), errors.Is(err, ErrUnknownMovie),
+		errors.Is(err, ErrUnknownScreen), errors.Is(err, ErrScreenRequired), errors.Is(err, ErrScreenElsewhere):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrShowNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
+	case errors.Is(err, ErrShowHasBookings), errors.Is(err, ErrShowCancelled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": prefix + err.Error()})
+	}
 }
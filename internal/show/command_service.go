@@ -4,82 +4,389 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/hitorii/ticket-booking/internal/venue"
 )
 
 type CommandService struct {
+	Repo       *Repository
 	Dispatcher *events.Dispatcher
+	Buffers    Buffers
+	Venues     *venue.QueryService // screens shows are put on; nil when venues have none
 }
 
 func NewCommandService() *CommandService {
 	return &CommandService{}
 }
 
-func NewCommandServiceWithDispatcher(dispatcher *events.Dispatcher) *CommandService {
-	return &CommandService{Dispatcher: dispatcher}
+func NewCommandServiceWithDispatcher(repo *Repository, dispatcher *events.Dispatcher, buffers Buffers) *CommandService {
+	return &CommandService{Repo: repo, Dispatcher: dispatcher, Buffers: buffers}
 }
 
-// CreateShow - Command to create a new show
-func (s *CommandService) CreateShow(ctx context.Context, req CreateShowRequest) (*Show, error) {
-	// Validate input
-	if req.Theater == "" {
-		return nil, errors.New("theater is required")
+// parseShowID turns a path ID into a show ID; anything else can't name a show
+func parseShowID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, ErrShowNotFound
+	}
+	return n, nil
+}
+
+// newShow validates a create request; the end time is left for the repository to derive.
+// A screen is enough to name the theater, which is filled in by placeOnScreen.
+func newShow(req CreateShowRequest) (*Show, error) {
+	theater := strings.TrimSpace(req.Theater)
+	if theater == "" && req.ScreenID == nil {
+		return nil, ErrTheaterRequired
 	}
 	if req.MovieID <= 0 {
-		return nil, errors.New("valid movie ID is required")
+		return nil, ErrMovieRequired
 	}
-
-	// Calculate end time based on movie duration (this would typically be done with a query to movies table)
-	// For now, we'll just set a placeholder end time
-	endTime := req.StartTime.Add(2 * time.Hour) // Default 2 hours
-
-	show := &Show{
+	if req.StartTime.IsZero() {
+		return nil, ErrStartTimeMissing
+	}
+	return &Show{
 		MovieID:   req.MovieID,
-		Theater:   req.Theater,
+		Theater:   theater,
+		ScreenID:  req.ScreenID,
 		StartTime: req.StartTime,
-		EndTime:   endTime,
-	}
+	}, nil
+}
 
-	// Emit event for event-driven flow
-	if s.Dispatcher != nil {
-		showIDStr := fmt.Sprintf("%d", show.ID)
-		movieIDStr := fmt.Sprintf("%d", req.MovieID)
-		payload := events.EventPayload{
-			ShowID:    showIDStr,
-			MovieID:   movieIDStr,
-			StartTime: req.StartTime.Format(time.RFC3339),
-			EndTime:   endTime.Format(time.RFC3339),
+// placeOnScreen returns the theater of a show on the given screen, which is the screen's
+// venue. A theater that is a venue with screens needs one of them to be named.
+func (s *CommandService) placeOnScreen(ctx context.Context, theater string, screenID *int) (string, error) {
+	if screenID == nil {
+		has, err := s.Venues.HasScreens(ctx, theater)
+		if err != nil {
+			return "", err
+		}
+		if has {
+			return "", ErrScreenRequired
 		}
-		_ = s.Dispatcher.Publish(ctx, events.EventShowCreated, showIDStr, payload)
+		return theater, nil
+	}
+	if s.Venues == nil {
+		return "", ErrUnknownScreen
+	}
+	_, venueName, err := s.Venues.Screen(ctx, *screenID)
+	if errors.Is(err, venue.ErrScreenNotFound) {
+		return "", ErrUnknownScreen
+	}
+	if err != nil {
+		return "", err
 	}
+	if theater != "" && theater != venueName {
+		return "", fmt.Errorf("%w: screen %d is in %s", ErrScreenElsewhere, *screenID, venueName)
+	}
+	return venueName, nil
+}
+
+// venueOf returns the venue a show given by theater and screen is put in, so its managers
+// can be checked before it is. A screen names its own venue.
+func (s *CommandService) venueOf(ctx context.Context, theater string, screenID *int) (string, error) {
+	if screenID == nil || s.Venues == nil {
+		return strings.TrimSpace(theater), nil
+	}
+	_, venueName, err := s.Venues.Screen(ctx, *screenID)
+	if errors.Is(err, venue.ErrScreenNotFound) {
+		return "", ErrUnknownScreen
+	}
+	return venueName, err
+}
+
+// showVenue returns the venue of a stored show
+func (s *CommandService) showVenue(ctx context.Context, id string) (string, error) {
+	showID, err := parseShowID(id)
+	if err != nil {
+		return "", err
+	}
+	if s.Repo == nil {
+		return "", errors.New("show repository is not configured")
+	}
+	show, err := s.Repo.GetByID(ctx, showID)
+	if err != nil {
+		return "", err
+	}
+	return show.Theater, nil
+}
 
+// scheduleVenue returns the venue of a stored schedule
+func (s *CommandService) scheduleVenue(ctx context.Context, id string) (string, error) {
+	scheduleID, err := parseScheduleID(id)
+	if err != nil {
+		return "", err
+	}
+	if s.Repo == nil {
+		return "", errors.New("show repository is not configured")
+	}
+	sc, err := s.Repo.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return "", err
+	}
+	return sc.Theater, nil
+}
+
+// parseScheduleID turns a path ID into a schedule ID
+func parseScheduleID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, ErrScheduleNotFound
+	}
+	return n, nil
+}
+
+// CreateShow - Command to create a new show. Anything attached is saved with it.
+func (s *CommandService) CreateShow(ctx context.Context, req CreateShowRequest, attach ...Attach) (*Show, error) {
+	show, err := newShow(req)
+	if err != nil {
+		return nil, err
+	}
+	if show.Theater, err = s.placeOnScreen(ctx, show.Theater, show.ScreenID); err != nil {
+		return nil, err
+	}
+	if s.Repo == nil {
+		return nil, errors.New("show repository is not configured")
+	}
+
+	// End time comes from the movie's duration plus the buffers around it
+	if err := s.Repo.Create(ctx, show, s.Buffers, attach...); err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, events.EventShowCreated, show)
 	return show, nil
 }
 
-// UpdateShow - Command to update an existing show
-func (s *CommandService) UpdateShow(ctx context.Context, id string, req UpdateShowRequest) error {
-	// Validate input
-	if req.Theater == "" {
-		return errors.New("theater is required")
+// ScheduleShows - Command to schedule a programme, such as a week of shows, in one go.
+// Either every show is created or none is; a ScheduleError says what's wrong with each row.
+func (s *CommandService) ScheduleShows(ctx context.Context, req ScheduleRequest) ([]Show, error) {
+	if len(req.Shows) == 0 {
+		return nil, fmt.Errorf("%w: no shows given", ErrInvalidSchedule)
+	}
+	if len(req.Shows) > MaxScheduleShows {
+		return nil, fmt.Errorf("%w: at most %d shows per schedule", ErrInvalidSchedule, MaxScheduleShows)
+	}
+
+	shows := make([]*Show, len(req.Shows))
+	var rows []RowError
+	for i, r := range req.Shows {
+		show, err := newShow(r)
+		if err == nil {
+			show.Theater, err = s.placeOnScreen(ctx, show.Theater, show.ScreenID)
+		}
+		if err != nil {
+			rows = append(rows, RowError{Row: i, Error: err.Error()})
+			continue
+		}
+		shows[i] = show
+	}
+	if len(rows) > 0 {
+		return nil, &ScheduleError{Rows: rows}
+	}
+	if s.Repo == nil {
+		return nil, errors.New("show repository is not configured")
+	}
+
+	if err := s.Repo.CreateMany(ctx, shows, s.Buffers); err != nil {
+		return nil, err
+	}
+
+	created := make([]Show, len(shows))
+	for i, show := range shows {
+		s.publish(ctx, events.EventShowCreated, show)
+		created[i] = *show
+	}
+	return created, nil
+}
+
+// CreateSchedule - Command to create a recurring schedule. The rule is expanded into shows
+// up front, and the schedule is only saved if every one of them fits.
+func (s *CommandService) CreateSchedule(ctx context.Context, req CreateScheduleRequest) (*Schedule, error) {
+	first, err := newShow(CreateShowRequest{MovieID: req.MovieID, Theater: req.Theater, ScreenID: req.ScreenID, StartTime: req.StartTime})
+	if err != nil {
+		return nil, err
+	}
+	if first.Theater, err = s.placeOnScreen(ctx, first.Theater, first.ScreenID); err != nil {
+		return nil, err
+	}
+	rule, err := ParseRule(req.Rule)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range req.Exceptions {
+		if _, err := time.Parse(dateLayout, d); err != nil {
+			return nil, fmt.Errorf("%w: exception %q is not a YYYY-MM-DD date", ErrInvalidRule, d)
+		}
+	}
+	// Occurrences are stepped through in the venue's zone so they keep its wall-clock time
+	loc, err := s.Repo.Location(ctx, first.Theater)
+	if err != nil {
+		return nil, err
+	}
+	rule = rule.In(loc)
+	starts, err := rule.Expand(first.StartTime.In(loc), req.Exceptions)
+	if err != nil {
+		return nil, err
+	}
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("%w: the rule has no occurrences", ErrInvalidRule)
+	}
+	if s.Repo == nil {
+		return nil, errors.New("show repository is not configured")
+	}
+
+	shows := make([]*Show, len(starts))
+	for i, start := range starts {
+		shows[i] = &Show{MovieID: first.MovieID, Theater: first.Theater, ScreenID: first.ScreenID, StartTime: start}
+	}
+	sc := &Schedule{
+		MovieID:    first.MovieID,
+		Theater:    first.Theater,
+		ScreenID:   first.ScreenID,
+		StartTime:  first.StartTime,
+		Rule:       rule.String(),
+		Exceptions: append([]string{}, req.Exceptions...),
+	}
+	if err := s.Repo.CreateSchedule(ctx, sc, shows, s.Buffers); err != nil {
+		return nil, err
+	}
+
+	sc.Shows = make([]Show, len(shows))
+	for i, show := range shows {
+		s.publish(ctx, events.EventShowCreated, show)
+		sc.Shows[i] = *show
+	}
+	return sc, nil
+}
+
+// UpdateFollowing - Command to edit a show and every later one in its schedule. The edited
+// shows are split off into a new schedule, which is returned.
+func (s *CommandService) UpdateFollowing(ctx context.Context, id string, req UpdateFollowingRequest) (*Schedule, error) {
+	scheduleID, err := parseScheduleID(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.FromShowID <= 0 {
+		return nil, ErrNotInSchedule
+	}
+	edit := SeriesEdit{MovieID: req.MovieID, Theater: strings.TrimSpace(req.Theater), ScreenID: req.ScreenID, StartTime: req.StartTime}
+	if edit == (SeriesEdit{}) {
+		return nil, fmt.Errorf("%w: nothing to change", ErrInvalidSchedule)
+	}
+	if edit.Theater != "" || edit.ScreenID != nil {
+		if edit.Theater, err = s.placeOnScreen(ctx, edit.Theater, edit.ScreenID); err != nil {
+			return nil, err
+		}
+	}
+	if s.Repo == nil {
+		return nil, errors.New("show repository is not configured")
+	}
+
+	sc, shows, err := s.Repo.UpdateFollowing(ctx, scheduleID, req.FromShowID, edit, s.Buffers)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.Shows = make([]Show, len(shows))
+	for i, show := range shows {
+		s.publish(ctx, events.EventShowUpdated, show)
+		sc.Shows[i] = *show
+	}
+	return sc, nil
+}
+
+// CancelFollowing - Command to cancel a show and every later one in its schedule. It returns
+// the IDs of the deleted shows.
+func (s *CommandService) CancelFollowing(ctx context.Context, id string, fromShowID string) ([]int, error) {
+	scheduleID, err := parseScheduleID(id)
+	if err != nil {
+		return nil, err
+	}
+	showID, err := strconv.Atoi(fromShowID)
+	if err != nil || showID <= 0 {
+		return nil, ErrNotInSchedule
+	}
+	if s.Repo == nil {
+		return nil, errors.New("show repository is not configured")
+	}
+
+	ids, err := s.Repo.CancelFollowing(ctx, scheduleID, showID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Emit event for event-driven flow
 	if s.Dispatcher != nil {
-		movieIDStr := fmt.Sprintf("%d", req.MovieID)
-		payload := events.EventPayload{
-			ShowID:  id,
-			MovieID: movieIDStr,
+		for _, deleted := range ids {
+			showIDStr := strconv.Itoa(deleted)
+			payload := events.EventPayload{
+				ShowID:     showIDStr,
+				ScheduleID: id,
+			}
+			_ = s.Dispatcher.Publish(ctx, events.EventShowDeleted, showIDStr, payload)
 		}
-		_ = s.Dispatcher.Publish(ctx, events.EventShowUpdated, id, payload)
 	}
+	return ids, nil
+}
 
-	return nil
+// UpdateShow - Command to update an existing show. A missing movie or start time keeps the
+// current one; the end time is always recomputed. Cancelled shows can't be changed.
+func (s *CommandService) UpdateShow(ctx context.Context, id string, req UpdateShowRequest) (*Show, error) {
+	showID, err := parseShowID(id)
+	if err != nil {
+		return nil, err
+	}
+	theater := strings.TrimSpace(req.Theater)
+	if theater == "" && req.ScreenID == nil {
+		return nil, ErrTheaterRequired
+	}
+	if theater, err = s.placeOnScreen(ctx, theater, req.ScreenID); err != nil {
+		return nil, err
+	}
+	if s.Repo == nil {
+		return nil, errors.New("show repository is not configured")
+	}
+
+	show, err := s.Repo.GetByID(ctx, showID)
+	if err != nil {
+		return nil, err
+	}
+	if show.Status == StatusCancelled {
+		return nil, ErrShowCancelled
+	}
+	show.Theater, show.ScreenID = theater, req.ScreenID
+	if req.MovieID > 0 {
+		show.MovieID = req.MovieID
+	}
+	if !req.StartTime.IsZero() {
+		show.StartTime = req.StartTime
+	}
+	if err := s.Repo.Update(ctx, show, s.Buffers); err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, events.EventShowUpdated, show)
+	return show, nil
 }
 
-// DeleteShow - Command to delete a show
+// DeleteShow - Command to delete a show nobody has booked
 func (s *CommandService) DeleteShow(ctx context.Context, id string) error {
+	showID, err := parseShowID(id)
+	if err != nil {
+		return err
+	}
+	if s.Repo == nil {
+		return errors.New("show repository is not configured")
+	}
+
+	if err := s.Repo.Delete(ctx, showID); err != nil {
+		return err
+	}
+
 	// Emit event for event-driven flow
 	if s.Dispatcher != nil {
 		payload := events.EventPayload{
@@ -87,6 +394,24 @@ func (s *CommandService) DeleteShow(ctx context.Context, id string) error {
 		}
 		_ = s.Dispatcher.Publish(ctx, events.EventShowDeleted, id, payload)
 	}
-
 	return nil
 }
+
+// publish emits a show event carrying its schedule
+func (s *CommandService) publish(ctx context.Context, eventType string, show *Show) {
+	if s.Dispatcher == nil {
+		return
+	}
+	showIDStr := strconv.Itoa(show.ID)
+	payload := events.EventPayload{
+		ShowID:    showIDStr,
+		MovieID:   strconv.Itoa(show.MovieID),
+		StartTime: show.StartTime.Format(time.RFC3339),
+		EndTime:   show.EndTime.Format(time.RFC3339),
+		TimeZone:  show.TimeZone,
+	}
+	if show.ScheduleID != nil {
+		payload.ScheduleID = strconv.Itoa(*show.ScheduleID)
+	}
+	_ = s.Dispatcher.Publish(ctx, eventType, showIDStr, payload)
+}
@@ -1,11 +1,218 @@
 package show
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/hitorii/ticket-booking/internal/venue"
+)
+
+var (
+	ErrShowNotFound     = errors.New("show not found")
+	ErrUnknownMovie     = errors.New("movie not found")
+	ErrShowHasBookings  = errors.New("show has booked seats")
+	ErrShowCancelled    = errors.New("show is cancelled")
+	ErrTheaterRequired  = errors.New("theater is required")
+	ErrMovieRequired    = errors.New("valid movie ID is required")
+	ErrStartTimeMissing = errors.New("start time is required")
+	ErrScheduleConflict = errors.New("theater is already in use")
+	ErrInvalidSchedule  = errors.New("invalid schedule")
+
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrNotInSchedule    = errors.New("show is not part of the schedule")
+	ErrInvalidRule      = errors.New("invalid recurrence rule")
+
+	ErrUnknownScreen   = errors.New("screen not found")
+	ErrScreenRequired  = errors.New("theater has screens; screen_id is required")
+	ErrScreenElsewhere = errors.New("screen is not in that theater")
+)
+
+var ErrInvalidSearch = errors.New("invalid search")
+
+// Sort keys for show search; a leading "-" sorts descending
+const (
+	SortStartTime     = "start_time"
+	SortStartTimeDesc = "-start_time"
+	SortTitle         = "title"
+	SortRelevance     = "relevance" // best title match first; needs a search text
+)
+
+// Show statuses. A cancelled show is kept, with its reservations, for the refunds and
+// rebooking offers of its cancellation; it no longer takes up its screen.
+const (
+	StatusScheduled = "SCHEDULED"
+	StatusCancelled = "CANCELLED"
+)
+
+// MaxScheduleShows caps how many shows one bulk schedule may carry
+const MaxScheduleShows = 500
+
+// Show times are instants. They are given in TimeZone, the zone of the show's venue.
+// Theater is the venue's name; a show of a venue with screens runs on one of them.
+// VenueID, City and Screen are filled in on the read side.
 type Show struct {
-	ID        int       `json:"id"`
-	MovieID   int       `json:"movie_id"`
-	Theater   string    `json:"theater"`
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
+	ID         int       `json:"id"`
+	MovieID    int       `json:"movie_id"`
+	Theater    string    `json:"theater"`
+	ScreenID   *int      `json:"screen_id,omitempty"`
+	Screen     string    `json:"screen,omitempty"`
+	VenueID    *int      `json:"venue_id,omitempty"`
+	City       string    `json:"city,omitempty"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	TimeZone   string    `json:"time_zone,omitempty"`
+	ScheduleID *int      `json:"schedule_id,omitempty"`
+	Status     string    `json:"status"`
+}
+
+// inZone expresses the show's times in its TimeZone
+func (sh *Show) inZone() {
+	sh.StartTime = venue.In(sh.StartTime, sh.TimeZone)
+	sh.EndTime = venue.In(sh.EndTime, sh.TimeZone)
+}
+
+// Schedule is a recurring slot that was expanded into shows. Editing a series from one
+// occurrence onwards splits it: the later shows move to a new schedule whose parent is this one.
+// Occurrences keep their wall-clock time in the venue's zone, across DST changes too.
+type Schedule struct {
+	ID         int       `json:"id"`
+	ParentID   *int      `json:"parent_id,omitempty"`
+	MovieID    int       `json:"movie_id"`
+	Theater    string    `json:"theater"`
+	ScreenID   *int      `json:"screen_id,omitempty"`
+	StartTime  time.Time `json:"start_time"`
+	TimeZone   string    `json:"time_zone,omitempty"`
+	Rule       string    `json:"rule"`
+	Exceptions []string  `json:"exceptions"`
+	CreatedAt  time.Time `json:"created_at"`
+	Shows      []Show    `json:"shows"`
+}
+
+// SeriesEdit is a change to a run of shows in a schedule. Zero fields are left as they are;
+// StartTime is the new start of the first show, and the rest move by as much. A new
+// Theater comes with its ScreenID, which is nil for a theater without screens.
+type SeriesEdit struct {
+	MovieID   int
+	Theater   string
+	ScreenID  *int
+	StartTime time.Time
+}
+
+// Buffers is the screen time a show takes besides the movie itself
+type Buffers struct {
+	Ads      time.Duration // trailers and ads before the movie
+	Cleaning time.Duration // turning the auditorium round afterwards
+}
+
+// EndTime is when the auditorium is free again after a movie of the given length (minutes)
+func (b Buffers) EndTime(start time.Time, duration int) time.Time {
+	return start.Add(b.Ads + time.Duration(duration)*time.Minute + b.Cleaning)
+}
+
+// sameScreen reports whether two shows need the same auditorium. Shows without a screen
+// take the whole theater, so they share it with every show there.
+func (sh *Show) sameScreen(other *Show) bool {
+	if sh.Theater != other.Theater {
+		return false
+	}
+	if sh.ScreenID == nil || other.ScreenID == nil {
+		return true
+	}
+	return *sh.ScreenID == *other.ScreenID
+}
+
+// overlaps reports whether two shows on the same screen would be on at once
+func (sh *Show) overlaps(other *Show) bool {
+	return sh.sameScreen(other) && sh.StartTime.Before(other.EndTime) && other.StartTime.Before(sh.EndTime)
+}
+
+// ConflictError names the show already occupying the theater. Show is nil when the
+// clash was caught by the database constraint and the other show couldn't be read back.
+type ConflictError struct {
+	Show *Show
+}
+
+func (e *ConflictError) Error() string {
+	if e.Show == nil {
+		return ErrScheduleConflict.Error()
+	}
+	return fmt.Sprintf("%s: show %d (movie %d) runs in %s from %s to %s",
+		ErrScheduleConflict, e.Show.ID, e.Show.MovieID, e.Show.Theater,
+		e.Show.StartTime.Format(time.RFC3339), e.Show.EndTime.Format(time.RFC3339))
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrScheduleConflict
+}
+
+// RowError is what's wrong with one show of a bulk schedule
+type RowError struct {
+	Row      int    `json:"row"`
+	ShowID   int    `json:"show_id,omitempty"`
+	Error    string `json:"error"`
+	Conflict *Show  `json:"conflict,omitempty"`
+}
+
+// ScheduleError lists every row that kept a bulk schedule from being saved
+type ScheduleError struct {
+	Rows []RowError
+}
+
+func (e *ScheduleError) Error() string {
+	msgs := make([]string, len(e.Rows))
+	for i, row := range e.Rows {
+		msgs[i] = fmt.Sprintf("row %d: %s", row.Row, row.Error)
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidSchedule, strings.Join(msgs, "; "))
+}
+
+func (e *ScheduleError) Is(target error) bool {
+	if target == ErrInvalidSchedule {
+		return true
+	}
+	if target == ErrScheduleConflict {
+		for _, row := range e.Rows {
+			if row.Conflict != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ShowSearch filters and orders shows. Empty fields don't filter; the genre, language,
+// certification and format filters apply to the show's movie. Shows starting at or after
+// From and before To are included, as are shows on FromDate through ToDate, days that are
+// read in the zone of each show's venue.
+type ShowSearch struct {
+	Query         string
+	MovieID       int
+	Theater       string
+	VenueID       int
+	City          string
+	Genre         string
+	Language      string
+	Certification string
+	Format        string
+	From          time.Time
+	To            time.Time
+	FromDate      string
+	ToDate        string
+	Sort          string
+	Limit         int
+	Cursor        string
+}
+
+// ShowResult is a show found by a search, with its movie's title
+type ShowResult struct {
+	Show
+	MovieName string `json:"movie_name"`
+}
+
+// ShowPage is one page of search results. NextCursor is empty on the last page.
+type ShowPage struct {
+	Shows      []ShowResult `json:"shows"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
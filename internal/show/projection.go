@@ -0,0 +1,121 @@
+// Show read model updater (CQRS projection)
+
+package show
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Projection copies shows from the Command DB into the Query DB shows table
+type Projection struct {
+	QueryDB *pgxpool.Pool
+	Repo    *Repository
+}
+
+func NewProjection(queryDB *pgxpool.Pool, repo *Repository) *Projection {
+	return &Projection{QueryDB: queryDB, Repo: repo}
+}
+
+// Subscribe keeps the read model in step with show events
+func (p *Projection) Subscribe(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(events.EventShowCreated, p.onShowEvent)
+	dispatcher.Subscribe(events.EventShowUpdated, p.onShowEvent)
+	dispatcher.Subscribe(events.EventShowDeleted, p.onShowEvent)
+	dispatcher.Subscribe(events.EventShowCancelled, p.onShowEvent)
+	dispatcher.Subscribe(events.EventVenueUpdated, p.onVenueEvent)
+}
+
+func (p *Projection) onShowEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(payload.ShowID)
+	if err != nil {
+		return nil
+	}
+	return p.Project(context.Background(), id)
+}
+
+// onVenueEvent re-projects a venue's shows, whose times are given in its zone and which
+// carry its city and screen names
+func (p *Projection) onVenueEvent(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	shows, err := p.Repo.listShows(ctx, "SELECT "+showColumns+" FROM shows WHERE theater = $1 ORDER BY id", payload.Venue)
+	if err != nil {
+		return err
+	}
+	for i := range shows {
+		if err := p.upsert(ctx, &shows[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Project copies the show to the Query DB, or removes it there once deleted
+func (p *Projection) Project(ctx context.Context, id int) error {
+	sh, err := p.Repo.GetByID(ctx, id)
+	if errors.Is(err, ErrShowNotFound) {
+		_, err = p.QueryDB.Exec(ctx, "DELETE FROM shows WHERE id = $1", id)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return p.upsert(ctx, sh)
+}
+
+// Rebuild projects every show and drops read rows whose show no longer exists
+func (p *Projection) Rebuild(ctx context.Context) error {
+	shows, err := p.Repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(shows))
+	for i := range shows {
+		if err := p.upsert(ctx, &shows[i]); err != nil {
+			return err
+		}
+		ids = append(ids, shows[i].ID)
+	}
+	if _, err := p.QueryDB.Exec(ctx, "DELETE FROM shows WHERE NOT (id = ANY($1))", ids); err != nil {
+		return err
+	}
+	log.Printf("🎞️ Projected %d shows into the query database", len(shows))
+	return nil
+}
+
+// upsert writes a show with where it is on, which venue events can change
+func (p *Projection) upsert(ctx context.Context, sh *Show) error {
+	if err := p.Repo.place(ctx, sh); err != nil {
+		return err
+	}
+	_, err := p.QueryDB.Exec(ctx, `
+		INSERT INTO shows (id, movie_id, theater, venue_id, screen_id, screen, city, start_time, end_time, time_zone, schedule_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (id) DO UPDATE SET
+			movie_id = EXCLUDED.movie_id,
+			theater = EXCLUDED.theater,
+			venue_id = EXCLUDED.venue_id,
+			screen_id = EXCLUDED.screen_id,
+			screen = EXCLUDED.screen,
+			city = EXCLUDED.city,
+			start_time = EXCLUDED.start_time,
+			end_time = EXCLUDED.end_time,
+			time_zone = EXCLUDED.time_zone,
+			schedule_id = EXCLUDED.schedule_id,
+			status = EXCLUDED.status
+	`, sh.ID, sh.MovieID, sh.Theater, sh.VenueID, sh.ScreenID, sh.Screen, sh.City, sh.StartTime, sh.EndTime, sh.TimeZone, sh.ScheduleID, sh.Status)
+	return err
+}
@@ -3,9 +3,13 @@
 package show
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/utils"
 )
 
 type QueryHandler struct {
@@ -49,3 +53,95 @@ func (h *QueryHandler) GetShowsByMovie(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, shows)
 }
+
+// GetSchedule - Query handler for getting a recurring schedule with its shows
+func (h *QueryHandler) GetSchedule(c *gin.Context) {
+	schedule, err := h.QueryService.GetSchedule(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, ErrScheduleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule"})
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// SearchShows - Query handler for searching shows with filters, sorting and pagination.
+// from and to take an RFC 3339 time or a date; a date for to includes that whole day.
+func (h *QueryHandler) SearchShows(c *gin.Context) {
+	limit, err := utils.ParseLimit(c.Query("limit"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	movieID := 0
+	if v := c.Query("movie_id"); v != "" {
+		if movieID, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie_id"})
+			return
+		}
+	}
+	venueID := 0
+	if v := c.Query("venue_id"); v != "" {
+		if venueID, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid venue_id"})
+			return
+		}
+	}
+	from, fromDate, err := parseTimeBound(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from: " + err.Error()})
+		return
+	}
+	to, toDate, err := parseTimeBound(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to: " + err.Error()})
+		return
+	}
+
+	page, err := h.QueryService.SearchShows(c.Request.Context(), ShowSearch{
+		Query:         c.Query("q"),
+		MovieID:       movieID,
+		Theater:       c.Query("theater"),
+		VenueID:       venueID,
+		City:          c.Query("city"),
+		Genre:         c.Query("genre"),
+		Language:      c.Query("language"),
+		Certification: c.Query("certification"),
+		Format:        c.Query("format"),
+		From:          from,
+		To:            to,
+		FromDate:      fromDate,
+		ToDate:        toDate,
+		Sort:          c.Query("sort"),
+		Limit:         limit,
+		Cursor:        c.Query("cursor"),
+	})
+	if errors.Is(err, ErrInvalidSearch) || errors.Is(err, utils.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search shows"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// parseTimeBound reads an RFC 3339 time, which is an instant, or a YYYY-MM-DD date, which
+// is a day in each venue's own zone
+func parseTimeBound(s string) (time.Time, string, error) {
+	if s == "" {
+		return time.Time{}, "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, "", nil
+	}
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Time{}, "", errors.New("expected an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	return time.Time{}, d.Format(dateLayout), nil
+}
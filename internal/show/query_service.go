@@ -4,21 +4,43 @@ package show
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/hitorii/ticket-booking/internal/utils"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type QueryService struct {
-	DB *pgxpool.Pool
+	DB   *pgxpool.Pool
+	Repo *Repository // schedules aren't projected, so they're read from the Command DB
 }
 
 func NewQueryService(db *pgxpool.Pool) *QueryService {
 	return &QueryService{DB: db}
 }
 
-// GetAllShows - Query to get all shows
-func (s *QueryService) GetAllShows(ctx context.Context) ([]Show, error) {
-	rows, err := s.DB.Query(ctx, "SELECT id, movie_id, theater, start_time, end_time FROM shows")
+// readColumns are a read-model show's columns, for scanRead
+const readColumns = "s.id, s.movie_id, s.theater, s.screen_id, s.screen, s.venue_id, s.city, s.start_time, s.end_time, s.time_zone, s.schedule_id, s.status"
+
+// scanRead reads a show of the read model, given in its venue's zone. Any further
+// columns are scanned into extra.
+func scanRead(row pgx.Row, extra ...any) (Show, error) {
+	var sh Show
+	dest := append([]any{&sh.ID, &sh.MovieID, &sh.Theater, &sh.ScreenID, &sh.Screen, &sh.VenueID, &sh.City,
+		&sh.StartTime, &sh.EndTime, &sh.TimeZone, &sh.ScheduleID, &sh.Status}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return Show{}, err
+	}
+	sh.inZone()
+	return sh, nil
+}
+
+func (s *QueryService) listShows(ctx context.Context, query string, args ...any) ([]Show, error) {
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -26,8 +48,8 @@ func (s *QueryService) GetAllShows(ctx context.Context) ([]Show, error) {
 
 	var shows []Show
 	for rows.Next() {
-		var sh Show
-		if err := rows.Scan(&sh.ID, &sh.MovieID, &sh.Theater, &sh.StartTime, &sh.EndTime); err != nil {
+		sh, err := scanRead(rows)
+		if err != nil {
 			return nil, err
 		}
 		shows = append(shows, sh)
@@ -36,10 +58,14 @@ func (s *QueryService) GetAllShows(ctx context.Context) ([]Show, error) {
 	return shows, nil
 }
 
+// GetAllShows - Query to get all shows
+func (s *QueryService) GetAllShows(ctx context.Context) ([]Show, error) {
+	return s.listShows(ctx, "SELECT "+readColumns+" FROM shows s")
+}
+
 // GetShowByID - Query to get a single show by ID
 func (s *QueryService) GetShowByID(ctx context.Context, id string) (*Show, error) {
-	var sh Show
-	err := s.DB.QueryRow(ctx, "SELECT id, movie_id, theater, start_time, end_time FROM shows WHERE id=$1", id).Scan(&sh.ID, &sh.MovieID, &sh.Theater, &sh.StartTime, &sh.EndTime)
+	sh, err := scanRead(s.DB.QueryRow(ctx, "SELECT "+readColumns+" FROM shows s WHERE s.id=$1", id))
 	if err != nil {
 		return nil, err
 	}
@@ -48,20 +74,165 @@ func (s *QueryService) GetShowByID(ctx context.Context, id string) (*Show, error
 
 // GetShowsByMovie - Query to get shows by movie ID
 func (s *QueryService) GetShowsByMovie(ctx context.Context, movieID string) ([]Show, error) {
-	rows, err := s.DB.Query(ctx, "SELECT id, movie_id, theater, start_time, end_time FROM shows WHERE movie_id=$1", movieID)
+	return s.listShows(ctx, "SELECT "+readColumns+" FROM shows s WHERE s.movie_id=$1", movieID)
+}
+
+// GetSchedule - Query to get a recurring schedule and its shows
+func (s *QueryService) GetSchedule(ctx context.Context, id string) (*Schedule, error) {
+	scheduleID, err := strconv.Atoi(id)
+	if err != nil || s.Repo == nil {
+		return nil, ErrScheduleNotFound
+	}
+	return s.Repo.GetSchedule(ctx, scheduleID)
+}
+
+// sortKey is an ORDER BY expression, the type its text form is cast back to when it comes
+// round in a cursor, and its direction. Shows tied on it are ordered by ID the same way.
+type sortKey struct {
+	expr string
+	cast string
+	desc bool
+}
+
+var showSorts = map[string]sortKey{
+	SortStartTime:     {"s.start_time", "timestamptz", false},
+	SortStartTimeDesc: {"s.start_time", "timestamptz", true},
+	SortTitle:         {"lower(m.name)", "text", false},
+}
+
+// SearchShows - Query to search shows by movie title with filters, a sort order and cursor
+// pagination. Title matching uses the full-text index on the movies table.
+func (s *QueryService) SearchShows(ctx context.Context, q ShowSearch) (*ShowPage, error) {
+	query, args, err := q.sql()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var shows []Show
+	page := &ShowPage{Shows: []ShowResult{}}
+	var last string
 	for rows.Next() {
-		var sh Show
-		if err := rows.Scan(&sh.ID, &sh.MovieID, &sh.Theater, &sh.StartTime, &sh.EndTime); err != nil {
+		var r ShowResult
+		var sortValue string
+		sh, err := scanRead(rows, &r.MovieName, &sortValue)
+		if err != nil {
 			return nil, err
 		}
-		shows = append(shows, sh)
+		r.Show = sh
+		if len(page.Shows) == q.Limit {
+			page.NextCursor = utils.Cursor{Sort: q.Sort, Value: last, ID: page.Shows[q.Limit-1].ID}.Encode()
+			break
+		}
+		page.Shows = append(page.Shows, r)
+		last = sortValue
 	}
+	return page, rows.Err()
+}
 
-	return shows, nil
+// sql builds the search query, filling in the default sort and page size. One row more
+// than the page is fetched to tell whether there is another page, and each row ends with
+// its sort key as text for the cursor.
+func (q *ShowSearch) sql() (string, []any, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	sorts := showSorts
+	if tsq := utils.PrefixQuery(q.Query); tsq != "" {
+		p := arg(tsq)
+		conds = append(conds, "m.search_vector @@ to_tsquery('simple', "+p+")")
+		sorts = map[string]sortKey{SortRelevance: {"ts_rank(m.search_vector, to_tsquery('simple', " + p + "))", "real", true}}
+		for name, key := range showSorts {
+			sorts[name] = key
+		}
+	}
+	if q.Sort == "" {
+		q.Sort = SortStartTime
+	}
+	key, ok := sorts[q.Sort]
+	if !ok && q.Sort == SortRelevance {
+		return "", nil, fmt.Errorf("%w: sorting by relevance needs a search text", ErrInvalidSearch)
+	}
+	if !ok {
+		return "", nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidSearch, q.Sort)
+	}
+
+	// Cancelled shows can't be booked, so they are never found
+	conds = append(conds, "s.status <> '"+StatusCancelled+"'")
+	if q.MovieID > 0 {
+		conds = append(conds, "s.movie_id = "+arg(q.MovieID))
+	}
+	if q.Theater != "" {
+		conds = append(conds, "s.theater = "+arg(q.Theater))
+	}
+	if q.VenueID > 0 {
+		conds = append(conds, "s.venue_id = "+arg(q.VenueID))
+	}
+	if q.City != "" {
+		conds = append(conds, "lower(s.city) = lower("+arg(q.City)+")")
+	}
+	if q.Genre != "" {
+		conds = append(conds, "m.genres @> ARRAY["+arg(q.Genre)+"]::text[]")
+	}
+	if q.Language != "" {
+		conds = append(conds, "m.languages @> ARRAY["+arg(q.Language)+"]::text[]")
+	}
+	if q.Format != "" {
+		conds = append(conds, "m.formats @> ARRAY["+arg(strings.ToUpper(q.Format))+"]::text[]")
+	}
+	if q.Certification != "" {
+		conds = append(conds, "m.certification = "+arg(q.Certification))
+	}
+	if !q.From.IsZero() {
+		conds = append(conds, "s.start_time >= "+arg(q.From))
+	}
+	if !q.To.IsZero() {
+		if !q.From.IsZero() && !q.To.After(q.From) {
+			return "", nil, fmt.Errorf("%w: the end of the date range must be after its start", ErrInvalidSearch)
+		}
+		conds = append(conds, "s.start_time < "+arg(q.To))
+	}
+	// Days are matched against the wall-clock start at the venue
+	if q.FromDate != "" {
+		conds = append(conds, "(s.start_time AT TIME ZONE s.time_zone) >= "+arg(q.FromDate)+"::date")
+	}
+	if q.ToDate != "" {
+		if q.FromDate != "" && q.ToDate < q.FromDate {
+			return "", nil, fmt.Errorf("%w: the end of the date range must not be before its start", ErrInvalidSearch)
+		}
+		conds = append(conds, "(s.start_time AT TIME ZONE s.time_zone) < "+arg(q.ToDate)+"::date + 1")
+	}
+
+	dir, cmp := "ASC", ">"
+	if key.desc {
+		dir, cmp = "DESC", "<"
+	}
+	if q.Cursor != "" {
+		cursor, err := utils.DecodeCursor(q.Cursor, q.Sort)
+		if err != nil {
+			return "", nil, err
+		}
+		conds = append(conds, fmt.Sprintf("(%s, s.id) %s (%s::%s, %s)", key.expr, cmp, arg(cursor.Value), key.cast, arg(cursor.ID)))
+	}
+	if q.Limit <= 0 {
+		q.Limit = utils.DefaultPageSize
+	}
+	q.Limit = min(q.Limit, utils.MaxPageSize)
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	query := fmt.Sprintf(`SELECT %s, m.name, (%s)::text
+		FROM shows s JOIN movies m ON m.id = s.movie_id
+		%s ORDER BY %s %s, s.id %s LIMIT %d`,
+		readColumns, key.expr, where, key.expr, dir, dir, q.Limit+1)
+	return query, args, nil
 }
@@ -0,0 +1,235 @@
+// Recurrence rules that expand a schedule into individual shows
+
+package show
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies a rule can repeat at
+const (
+	FreqDaily  = "DAILY"
+	FreqWeekly = "WEEKLY"
+)
+
+// dateLayout is how exception dates are written
+const dateLayout = "2006-01-02"
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Rule is the subset of an iCalendar RRULE schedules support: FREQ (DAILY or WEEKLY),
+// INTERVAL, BYDAY, and an end given by UNTIL or COUNT
+type Rule struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday
+	Until    time.Time
+	Count    int
+
+	untilDay bool // UNTIL was a date, which ends in UTC until the rule is anchored with In
+}
+
+// ParseRule reads a rule such as "FREQ=WEEKLY;BYDAY=MO,WE,FR;UNTIL=20240331". A leading
+// "RRULE:" is ignored. UNTIL may be a date, which includes that whole day, or a UTC date-time.
+func ParseRule(s string) (Rule, error) {
+	r := Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: %q is not KEY=VALUE", ErrInvalidRule, part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+			if r.Freq != FreqDaily && r.Freq != FreqWeekly {
+				return Rule{}, fmt.Errorf("%w: FREQ must be DAILY or WEEKLY", ErrInvalidRule)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return Rule{}, fmt.Errorf("%w: INTERVAL must be a positive number", ErrInvalidRule)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return Rule{}, fmt.Errorf("%w: COUNT must be a positive number", ErrInvalidRule)
+			}
+			r.Count = n
+		case "UNTIL":
+			r.untilDay = !strings.Contains(value, "T")
+			until, err := parseUntil(value)
+			if err != nil {
+				return Rule{}, err
+			}
+			r.Until = until
+		case "BYDAY":
+			seen := map[time.Weekday]bool{}
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekdayCodes[strings.ToUpper(strings.TrimSpace(code))]
+				if !ok {
+					return Rule{}, fmt.Errorf("%w: unknown weekday %q", ErrInvalidRule, code)
+				}
+				if !seen[day] {
+					seen[day] = true
+					r.ByDay = append(r.ByDay, day)
+				}
+			}
+			sortWeekdays(r.ByDay)
+		default:
+			return Rule{}, fmt.Errorf("%w: %s is not supported", ErrInvalidRule, key)
+		}
+	}
+
+	if r.Freq == "" {
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+	if r.Until.IsZero() == (r.Count == 0) {
+		return Rule{}, fmt.Errorf("%w: exactly one of UNTIL or COUNT is required", ErrInvalidRule)
+	}
+	return r, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"20060102", dateLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Add(24*time.Hour - time.Second), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSSZ", ErrInvalidRule)
+}
+
+// In anchors a date UNTIL to the end of that day in loc, the venue's zone, instead of UTC
+func (r Rule) In(loc *time.Location) Rule {
+	if r.untilDay {
+		y, m, d := r.Until.Date()
+		r.Until = time.Date(y, m, d+1, 0, 0, 0, 0, loc).Add(-time.Second)
+		r.untilDay = false
+	}
+	return r
+}
+
+// String writes the rule back in RRULE form
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = strings.ToUpper(day.String()[:2])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	} else {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Expand lists the start of every occurrence from start onwards, leaving out the dates in
+// exceptions. COUNT counts the excepted occurrences too, as in iCalendar.
+func (r Rule) Expand(start time.Time, exceptions []string) ([]time.Time, error) {
+	skip := map[string]bool{}
+	for _, d := range exceptions {
+		skip[d] = true
+	}
+	days := r.ByDay
+	if len(days) == 0 {
+		days = []time.Weekday{start.Weekday()}
+	}
+	matches := map[time.Weekday]bool{}
+	for _, day := range days {
+		matches[day] = true
+	}
+
+	// Step through candidate days: every Interval days for DAILY, every day of every
+	// Interval-th week (weeks start on Monday) for WEEKLY
+	var starts []time.Time
+	generated := 0
+	weekStart := start.AddDate(0, 0, -mondayOffset(start.Weekday()))
+	for day := start; ; day = day.AddDate(0, 0, 1) {
+		if !r.Until.IsZero() && day.After(r.Until) || r.Count > 0 && generated == r.Count {
+			return starts, nil
+		}
+		switch r.Freq {
+		case FreqDaily:
+			if daysBetween(start, day)%r.Interval != 0 || len(r.ByDay) > 0 && !matches[day.Weekday()] {
+				continue
+			}
+		case FreqWeekly:
+			if (daysBetween(weekStart, day)/7)%r.Interval != 0 || !matches[day.Weekday()] {
+				continue
+			}
+		}
+		generated++
+		if skip[day.Format(dateLayout)] {
+			continue
+		}
+		if len(starts) == MaxScheduleShows {
+			return nil, fmt.Errorf("%w: more than %d occurrences", ErrInvalidRule, MaxScheduleShows)
+		}
+		starts = append(starts, day)
+	}
+}
+
+// Shift moves the rule's weekdays along by the given number of days, for a series whose
+// occurrences have all been moved by that much
+func (r Rule) Shift(days int) Rule {
+	if len(r.ByDay) == 0 || days%7 == 0 {
+		return r
+	}
+	shifted := make([]time.Weekday, len(r.ByDay))
+	for i, day := range r.ByDay {
+		shifted[i] = time.Weekday(((int(day)+days)%7 + 7) % 7)
+	}
+	sortWeekdays(shifted)
+	r.ByDay = shifted
+	return r
+}
+
+// mondayOffset is how many days after Monday the weekday falls
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+func sortWeekdays(days []time.Weekday) {
+	sort.Slice(days, func(i, j int) bool { return mondayOffset(days[i]) < mondayOffset(days[j]) })
+}
+
+// daysBetween counts calendar days from a to b, ignoring the clock
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return int(time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
+
+// clockOf is the wall-clock time of day of t in its own zone
+func clockOf(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// shiftWall moves t by whole days and then by a change of wall-clock time, both in t's
+// zone, so a show moved across a DST change keeps its local start time
+func shiftWall(t time.Time, days int, clock time.Duration) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+days, 0, 0, 0, int(clockOf(t)+clock), t.Location())
+}
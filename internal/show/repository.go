@@ -0,0 +1,658 @@
+package show
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/venue"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository stores shows in the Command DB. Shows it returns are given in their
+// venue's zone, looked up in Zones along with where the venue is; without it every venue
+// is in UTC.
+type Repository struct {
+	DB    *pgxpool.Pool
+	Zones *venue.QueryService
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+const showColumns = "id, movie_id, theater, screen_id, start_time, end_time, schedule_id, status"
+
+func scanShow(row pgx.Row) (*Show, error) {
+	var sh Show
+	err := row.Scan(&sh.ID, &sh.MovieID, &sh.Theater, &sh.ScreenID, &sh.StartTime, &sh.EndTime, &sh.ScheduleID, &sh.Status)
+	if err != nil {
+		return nil, err
+	}
+	return &sh, nil
+}
+
+// Location returns the zone of a theater's shows
+func (r *Repository) Location(ctx context.Context, theater string) (*time.Location, error) {
+	if r == nil {
+		return time.UTC, nil
+	}
+	return r.Zones.Location(ctx, theater)
+}
+
+// localize gives shows their venue's zone and their times in it
+func (r *Repository) localize(ctx context.Context, shows ...*Show) error {
+	zones := map[string]*time.Location{}
+	for _, sh := range shows {
+		loc, ok := zones[sh.Theater]
+		if !ok {
+			var err error
+			if loc, err = r.Location(ctx, sh.Theater); err != nil {
+				return err
+			}
+			zones[sh.Theater] = loc
+		}
+		sh.TimeZone = loc.String()
+		sh.StartTime, sh.EndTime = sh.StartTime.In(loc), sh.EndTime.In(loc)
+	}
+	return nil
+}
+
+// place fills in a show's venue, city and screen name
+func (r *Repository) place(ctx context.Context, sh *Show) error {
+	p, err := r.Zones.Place(ctx, sh.Theater, sh.ScreenID)
+	if err != nil {
+		return err
+	}
+	sh.VenueID, sh.City, sh.Screen = p.VenueID, p.City, p.Screen
+	return nil
+}
+
+// localizeConflicts gives the clashing shows of a scheduling error in their venue's zone
+func (r *Repository) localizeConflicts(ctx context.Context, err error) error {
+	var conflict *ConflictError
+	var sched *ScheduleError
+	switch {
+	case errors.As(err, &conflict) && conflict.Show != nil:
+		_ = r.localize(ctx, conflict.Show)
+	case errors.As(err, &sched):
+		for _, row := range sched.Rows {
+			if row.Conflict != nil {
+				_ = r.localize(ctx, row.Conflict)
+			}
+		}
+	}
+	return err
+}
+
+// movieDuration returns a movie's length in minutes, locking the movie so it can't be
+// deleted while a show is being scheduled for it
+func movieDuration(ctx context.Context, tx pgx.Tx, movieID int) (int, error) {
+	var duration int
+	err := tx.QueryRow(ctx, "SELECT duration FROM movies WHERE id = $1 FOR SHARE", movieID).Scan(&duration)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrUnknownMovie
+	}
+	return duration, err
+}
+
+// exclusionViolation is the SQLSTATE for a row rejected by shows_no_overlap
+const exclusionViolation = "23P01"
+
+// rowQuerier is satisfied by both the pool and a transaction
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// findConflict returns the earliest show needing sh's screen while sh runs, where a show
+// without a screen needs them all. It ignores cancelled shows and the shows in exclude
+// (sh itself, or a series that is being moved as a whole).
+func findConflict(ctx context.Context, db rowQuerier, sh *Show, exclude []int) (*Show, error) {
+	other, err := scanShow(db.QueryRow(ctx, `
+		SELECT `+showColumns+` FROM shows
+		WHERE theater = $1 AND (screen_id IS NULL OR $5::int IS NULL OR screen_id = $5) AND NOT (id = ANY($4))
+		  AND status <> '`+StatusCancelled+`'
+		  AND tstzrange(start_time, end_time, '[)') && tstzrange($2, $3, '[)')
+		ORDER BY start_time
+		LIMIT 1
+	`, sh.Theater, sh.StartTime, sh.EndTime, exclude, sh.ScreenID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return other, err
+}
+
+// conflictFromDB turns a show that lost a race to the exclusion constraint into a ConflictError.
+// The transaction is aborted by then, so the clashing show is looked up on the pool.
+func (r *Repository) conflictFromDB(ctx context.Context, sh *Show, err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != exclusionViolation {
+		return err
+	}
+	other, _ := findConflict(ctx, r.DB, sh, []int{sh.ID})
+	return r.localizeConflicts(ctx, &ConflictError{Show: other})
+}
+
+// schedule derives a show's end time and makes sure the theater is free for it
+func schedule(ctx context.Context, tx pgx.Tx, sh *Show, buffers Buffers, exclude []int) error {
+	duration, err := movieDuration(ctx, tx, sh.MovieID)
+	if err != nil {
+		return err
+	}
+	sh.EndTime = buffers.EndTime(sh.StartTime, duration)
+
+	other, err := findConflict(ctx, tx, sh, exclude)
+	if err != nil {
+		return err
+	}
+	if other != nil {
+		return &ConflictError{Show: other}
+	}
+	return nil
+}
+
+// scheduleAll runs schedule for a batch of shows and also checks them against each other.
+// Every failure is collected into one ScheduleError.
+func scheduleAll(ctx context.Context, tx pgx.Tx, shows []*Show, buffers Buffers, exclude []int) error {
+	var rows []RowError
+	for i, sh := range shows {
+		err := schedule(ctx, tx, sh, buffers, exclude)
+		if err == nil {
+			for j := range i {
+				if shows[j].overlaps(sh) {
+					err = fmt.Errorf("%w: overlaps row %d", ErrScheduleConflict, j)
+					break
+				}
+			}
+		}
+		if err == nil {
+			continue
+		}
+
+		row := RowError{Row: i, ShowID: sh.ID, Error: err.Error()}
+		var conflict *ConflictError
+		if errors.As(err, &conflict) {
+			row.Conflict = conflict.Show
+		} else if !errors.Is(err, ErrUnknownMovie) && !errors.Is(err, ErrScheduleConflict) {
+			return err
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) > 0 {
+		return &ScheduleError{Rows: rows}
+	}
+	return nil
+}
+
+func insertShow(ctx context.Context, tx pgx.Tx, sh *Show) error {
+	return tx.QueryRow(ctx,
+		"INSERT INTO shows (movie_id, theater, screen_id, start_time, end_time, schedule_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, status",
+		sh.MovieID, sh.Theater, sh.ScreenID, sh.StartTime, sh.EndTime, sh.ScheduleID,
+	).Scan(&sh.ID, &sh.Status)
+}
+
+// Attach records something about a show being created in the transaction that creates
+// it, so the show is never saved without it
+type Attach func(ctx context.Context, tx pgx.Tx, id int) error
+
+// Create inserts a show, deriving its end time from the movie's duration, and runs attach
+// in the same transaction. It fails with a ConflictError if the theater is taken for any
+// of that time.
+func (r *Repository) Create(ctx context.Context, sh *Show, buffers Buffers, attach ...Attach) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := schedule(ctx, tx, sh, buffers, []int{sh.ID}); err != nil {
+		return r.localizeConflicts(ctx, err)
+	}
+	if err := insertShow(ctx, tx, sh); err != nil {
+		return r.conflictFromDB(ctx, sh, err)
+	}
+	for _, fn := range attach {
+		if err := fn(ctx, tx, sh.ID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return r.localize(ctx, sh)
+}
+
+// CreateMany inserts a whole programme or none of it. Every show is checked, against the
+// schedule and against the others in the batch, and all failures come back in a ScheduleError.
+func (r *Repository) CreateMany(ctx context.Context, shows []*Show, buffers Buffers) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := scheduleAll(ctx, tx, shows, buffers, []int{0}); err != nil {
+		return r.localizeConflicts(ctx, err)
+	}
+	for _, sh := range shows {
+		if err := insertShow(ctx, tx, sh); err != nil {
+			return r.conflictFromDB(ctx, sh, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return r.localize(ctx, shows...)
+}
+
+// Update rewrites a show and recomputes its end time, refusing to move it onto another show
+func (r *Repository) Update(ctx context.Context, sh *Show, buffers Buffers) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := schedule(ctx, tx, sh, buffers, []int{sh.ID}); err != nil {
+		return r.localizeConflicts(ctx, err)
+	}
+
+	res, err := tx.Exec(ctx,
+		"UPDATE shows SET movie_id = $2, theater = $3, screen_id = $4, start_time = $5, end_time = $6 WHERE id = $1",
+		sh.ID, sh.MovieID, sh.Theater, sh.ScreenID, sh.StartTime, sh.EndTime,
+	)
+	if err != nil {
+		return r.conflictFromDB(ctx, sh, err)
+	}
+	if res.RowsAffected() == 0 {
+		return ErrShowNotFound
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	return r.localize(ctx, sh)
+}
+
+// bookedShows returns which of the shows have seats held or booked
+func bookedShows(ctx context.Context, tx pgx.Tx, ids []int) ([]int, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT s.show_id FROM reservations r
+		JOIN seats s ON s.id = r.seat_id
+		WHERE s.show_id = ANY($1) AND r.status IN ('HELD', 'BOOKED')
+		ORDER BY s.show_id
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// Delete removes a show nobody holds or has booked a seat for. Its seats and prices go with it.
+// A show from a recurring schedule becomes an exception to it.
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	sh, err := scanShow(tx.QueryRow(ctx, "SELECT "+showColumns+" FROM shows WHERE id = $1 FOR UPDATE", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrShowNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	booked, err := bookedShows(ctx, tx, []int{id})
+	if err != nil {
+		return err
+	}
+	if len(booked) > 0 {
+		return ErrShowHasBookings
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM shows WHERE id = $1", id); err != nil {
+		return err
+	}
+	if sh.ScheduleID != nil {
+		// Exceptions are days in the venue's zone, like the occurrences they skip
+		if err := r.localize(ctx, sh); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx,
+			"UPDATE show_schedules SET exceptions = array_append(exceptions, $2::date) WHERE id = $1",
+			*sh.ScheduleID, sh.StartTime.Format(dateLayout))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// GetByID returns a show from the Command DB
+func (r *Repository) GetByID(ctx context.Context, id int) (*Show, error) {
+	sh, err := scanShow(r.DB.QueryRow(ctx, "SELECT "+showColumns+" FROM shows WHERE id = $1", id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrShowNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sh, r.localize(ctx, sh)
+}
+
+// List returns every show in the Command DB
+func (r *Repository) List(ctx context.Context) ([]Show, error) {
+	return r.listShows(ctx, "SELECT "+showColumns+" FROM shows ORDER BY id")
+}
+
+func (r *Repository) listShows(ctx context.Context, query string, args ...any) ([]Show, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []*Show
+	for rows.Next() {
+		sh, err := scanShow(rows)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, sh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.localize(ctx, found...); err != nil {
+		return nil, err
+	}
+
+	shows := make([]Show, len(found))
+	for i, sh := range found {
+		shows[i] = *sh
+	}
+	return shows, nil
+}
+
+const scheduleColumns = "id, parent_id, movie_id, theater, screen_id, start_time, rule, exceptions, created_at"
+
+func scanSchedule(row pgx.Row) (*Schedule, error) {
+	var sc Schedule
+	var exceptions []time.Time
+	err := row.Scan(&sc.ID, &sc.ParentID, &sc.MovieID, &sc.Theater, &sc.ScreenID, &sc.StartTime, &sc.Rule, &exceptions, &sc.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	sc.Exceptions = make([]string, len(exceptions))
+	for i, d := range exceptions {
+		sc.Exceptions[i] = d.Format(dateLayout)
+	}
+	return &sc, nil
+}
+
+func insertSchedule(ctx context.Context, tx pgx.Tx, sc *Schedule) error {
+	return tx.QueryRow(ctx, `
+		INSERT INTO show_schedules (parent_id, movie_id, theater, screen_id, start_time, rule, exceptions)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::date[])
+		RETURNING id, created_at
+	`, sc.ParentID, sc.MovieID, sc.Theater, sc.ScreenID, sc.StartTime, sc.Rule, sc.Exceptions).Scan(&sc.ID, &sc.CreatedAt)
+}
+
+// CreateSchedule stores a schedule together with the shows it expands to, all or nothing
+func (r *Repository) CreateSchedule(ctx context.Context, sc *Schedule, shows []*Show, buffers Buffers) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertSchedule(ctx, tx, sc); err != nil {
+		return err
+	}
+	if err := scheduleAll(ctx, tx, shows, buffers, []int{0}); err != nil {
+		return r.localizeConflicts(ctx, err)
+	}
+	for _, sh := range shows {
+		sh.ScheduleID = &sc.ID
+		if err := insertShow(ctx, tx, sh); err != nil {
+			return r.conflictFromDB(ctx, sh, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if err := r.localize(ctx, shows...); err != nil {
+		return err
+	}
+	return r.localizeSchedule(ctx, sc)
+}
+
+// localizeSchedule gives a schedule its venue's zone and its first start in it
+func (r *Repository) localizeSchedule(ctx context.Context, sc *Schedule) error {
+	loc, err := r.Location(ctx, sc.Theater)
+	if err != nil {
+		return err
+	}
+	sc.TimeZone = loc.String()
+	sc.StartTime = sc.StartTime.In(loc)
+	return nil
+}
+
+// following locks a schedule and the shows in it from the given one onwards
+func following(ctx context.Context, tx pgx.Tx, scheduleID, fromShowID int) (*Schedule, []*Show, error) {
+	sc, err := scanSchedule(tx.QueryRow(ctx, "SELECT "+scheduleColumns+" FROM show_schedules WHERE id = $1 FOR UPDATE", scheduleID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var from time.Time
+	err = tx.QueryRow(ctx, "SELECT start_time FROM shows WHERE id = $1 AND schedule_id = $2", fromShowID, scheduleID).Scan(&from)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, ErrNotInSchedule
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT `+showColumns+` FROM shows
+		WHERE schedule_id = $1 AND start_time >= $2
+		ORDER BY start_time
+		FOR UPDATE
+	`, scheduleID, from)
+	if err != nil {
+		return nil, nil, err
+	}
+	shows, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*Show, error) { return scanShow(row) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return sc, shows, nil
+}
+
+// truncate ends a schedule just before the given occurrence, dropping its later exceptions.
+// before must be in the venue's zone for its date to match theirs.
+func truncate(ctx context.Context, tx pgx.Tx, sc *Schedule, before time.Time) error {
+	rule, err := ParseRule(sc.Rule)
+	if err != nil {
+		return err
+	}
+	rule.Count = 0
+	rule.Until = before.Add(-time.Second)
+
+	kept := []string{}
+	for _, d := range sc.Exceptions {
+		if d < before.Format(dateLayout) {
+			kept = append(kept, d)
+		}
+	}
+	sc.Rule, sc.Exceptions = rule.String(), kept
+	_, err = tx.Exec(ctx, "UPDATE show_schedules SET rule = $2, exceptions = $3::date[] WHERE id = $1", sc.ID, sc.Rule, sc.Exceptions)
+	return err
+}
+
+// UpdateFollowing applies an edit to a show and every later show in its schedule. The
+// schedule is split there: it now ends before that show, and the edited shows move to a new
+// schedule that keeps the rule, shifted by however far the shows were moved.
+func (r *Repository) UpdateFollowing(ctx context.Context, scheduleID, fromShowID int, edit SeriesEdit, buffers Buffers) (*Schedule, []*Show, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	parent, shows, err := following(ctx, tx, scheduleID, fromShowID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rule, err := ParseRule(parent.Rule)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := r.Location(ctx, parent.Theater)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The move is made in days and wall-clock time at the venue rather than as a duration,
+	// so shows on the other side of a DST change keep the same local time as the first
+	pivot := shows[0].StartTime.In(loc)
+	shiftDays, shiftClock := 0, time.Duration(0)
+	if !edit.StartTime.IsZero() {
+		target := edit.StartTime.In(loc)
+		shiftDays, shiftClock = daysBetween(pivot, target), clockOf(target)-clockOf(pivot)
+	}
+
+	ids := make([]int, len(shows))
+	for i, sh := range shows {
+		ids[i] = sh.ID
+		if edit.MovieID > 0 {
+			sh.MovieID = edit.MovieID
+		}
+		if edit.Theater != "" {
+			sh.Theater, sh.ScreenID = edit.Theater, edit.ScreenID
+		}
+		sh.StartTime = shiftWall(sh.StartTime.In(loc), shiftDays, shiftClock)
+	}
+	// The series is checked against everything but itself, so a show may move into a slot
+	// one of its siblings is leaving; the constraint is checked again at commit
+	if _, err := tx.Exec(ctx, "SET CONSTRAINTS shows_no_overlap DEFERRED"); err != nil {
+		return nil, nil, err
+	}
+	if err := scheduleAll(ctx, tx, shows, buffers, ids); err != nil {
+		return nil, nil, r.localizeConflicts(ctx, err)
+	}
+
+	child := rule.Shift(shiftDays)
+	if rule.Count > 0 {
+		child.Count, child.Until = 0, shows[len(shows)-1].StartTime
+	} else if !rule.Until.IsZero() {
+		child.Until = shiftWall(rule.Until.In(loc), shiftDays, shiftClock)
+	}
+	exceptions := []string{}
+	for _, d := range parent.Exceptions {
+		if day, err := time.Parse(dateLayout, d); err == nil && d >= pivot.Format(dateLayout) {
+			exceptions = append(exceptions, day.AddDate(0, 0, shiftDays).Format(dateLayout))
+		}
+	}
+	sc := &Schedule{
+		ParentID:   &parent.ID,
+		MovieID:    shows[0].MovieID,
+		Theater:    shows[0].Theater,
+		ScreenID:   shows[0].ScreenID,
+		StartTime:  shows[0].StartTime,
+		Rule:       child.String(),
+		Exceptions: exceptions,
+	}
+	if err := insertSchedule(ctx, tx, sc); err != nil {
+		return nil, nil, err
+	}
+
+	for _, sh := range shows {
+		sh.ScheduleID = &sc.ID
+		_, err := tx.Exec(ctx,
+			"UPDATE shows SET movie_id = $2, theater = $3, screen_id = $4, start_time = $5, end_time = $6, schedule_id = $7 WHERE id = $1",
+			sh.ID, sh.MovieID, sh.Theater, sh.ScreenID, sh.StartTime, sh.EndTime, sc.ID,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := truncate(ctx, tx, parent, pivot); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+			return nil, nil, &ConflictError{}
+		}
+		return nil, nil, err
+	}
+	if err := r.localize(ctx, shows...); err != nil {
+		return nil, nil, err
+	}
+	return sc, shows, r.localizeSchedule(ctx, sc)
+}
+
+// CancelFollowing deletes a show and every later show in its schedule, and ends the schedule
+// before it. Nothing is deleted if any of those shows has seats held or booked.
+func (r *Repository) CancelFollowing(ctx context.Context, scheduleID, fromShowID int) ([]int, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	sc, shows, err := following(ctx, tx, scheduleID, fromShowID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(shows))
+	for i, sh := range shows {
+		ids[i] = sh.ID
+	}
+
+	booked, err := bookedShows(ctx, tx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(booked) > 0 {
+		return nil, fmt.Errorf("%w: shows %v", ErrShowHasBookings, booked)
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM shows WHERE id = ANY($1)", ids); err != nil {
+		return nil, err
+	}
+	loc, err := r.Location(ctx, sc.Theater)
+	if err != nil {
+		return nil, err
+	}
+	if err := truncate(ctx, tx, sc, shows[0].StartTime.In(loc)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetSchedule returns a schedule with the shows that are still in it
+func (r *Repository) GetSchedule(ctx context.Context, id int) (*Schedule, error) {
+	sc, err := scanSchedule(r.DB.QueryRow(ctx, "SELECT "+scheduleColumns+" FROM show_schedules WHERE id = $1", id))
+	if err != nil {
+		return nil, err
+	}
+	sc.Shows, err = r.listShows(ctx, "SELECT "+showColumns+" FROM shows WHERE schedule_id = $1 ORDER BY start_time", id)
+	if err != nil {
+		return nil, err
+	}
+	return sc, r.localizeSchedule(ctx, sc)
+}
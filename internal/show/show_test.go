@@ -1,9 +1,17 @@
 package show
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/utils"
 )
 
 // TestShowModel tests the Show struct
@@ -54,6 +62,16 @@ func TestCreateShowRequest_Validation(t *testing.T) {
 			wantErr:   true,
 			errString: "theater is required",
 		},
+		{
+			name: "blank theater",
+			req: CreateShowRequest{
+				Theater:   "  ",
+				MovieID:   1,
+				StartTime: time.Now(),
+			},
+			wantErr:   true,
+			errString: "theater is required",
+		},
 		{
 			name: "zero movie ID",
 			req: CreateShowRequest{
@@ -74,6 +92,15 @@ func TestCreateShowRequest_Validation(t *testing.T) {
 			wantErr:   true,
 			errString: "valid movie ID is required",
 		},
+		{
+			name: "missing start time",
+			req: CreateShowRequest{
+				Theater: "Theater A",
+				MovieID: 1,
+			},
+			wantErr:   true,
+			errString: "start time is required",
+		},
 		{
 			name: "valid request",
 			req: CreateShowRequest{
@@ -83,6 +110,15 @@ func TestCreateShowRequest_Validation(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "screen names the theater",
+			req: CreateShowRequest{
+				ScreenID:  new(int),
+				MovieID:   1,
+				StartTime: time.Now(),
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -103,16 +139,23 @@ func TestCreateShowRequest_Validation(t *testing.T) {
 	}
 }
 
-// TestUpdateShowRequest_Validation tests validation for UpdateShowRequest
+// TestUpdateShowRequest_Validation tests that updates and deletes reject a bad show ID or
+// missing theater before reaching storage
 func TestUpdateShowRequest_Validation(t *testing.T) {
+	svc := NewCommandService()
+	ctx := context.Background()
+
 	tests := []struct {
 		name      string
+		id        string
 		req       UpdateShowRequest
+		delete    bool
 		wantErr   bool
 		errString string
 	}{
 		{
 			name: "empty theater",
+			id:   "1",
 			req: UpdateShowRequest{
 				Theater: "",
 				MovieID: 1,
@@ -120,8 +163,26 @@ func TestUpdateShowRequest_Validation(t *testing.T) {
 			wantErr:   true,
 			errString: "theater is required",
 		},
+		{
+			name: "bad id",
+			id:   "abc",
+			req: UpdateShowRequest{
+				Theater: "Theater B",
+				MovieID: 1,
+			},
+			wantErr:   true,
+			errString: "show not found",
+		},
+		{
+			name:      "delete bad id",
+			id:        "0",
+			delete:    true,
+			wantErr:   true,
+			errString: "show not found",
+		},
 		{
 			name: "valid request",
+			id:   "1",
 			req: UpdateShowRequest{
 				Theater: "Theater B",
 				MovieID: 1,
@@ -132,7 +193,16 @@ func TestUpdateShowRequest_Validation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateUpdateShowRequest(tt.req)
+			var err error
+			if tt.delete {
+				err = svc.DeleteShow(ctx, tt.id)
+			} else {
+				_, err = svc.UpdateShow(ctx, tt.id, tt.req)
+			}
+			// Without a repository, a request that passes validation stops at storage
+			if err != nil && err.Error() == "show repository is not configured" {
+				err = nil
+			}
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("Expected error for %s", tt.name)
@@ -148,22 +218,10 @@ func TestUpdateShowRequest_Validation(t *testing.T) {
 	}
 }
 
-// Validation functions that match the service implementation
+// validateCreateShowRequest runs the checks CreateShow makes before touching storage
 func validateCreateShowRequest(req CreateShowRequest) error {
-	if req.Theater == "" {
-		return errors.New("theater is required")
-	}
-	if req.MovieID <= 0 {
-		return errors.New("valid movie ID is required")
-	}
-	return nil
-}
-
-func validateUpdateShowRequest(req UpdateShowRequest) error {
-	if req.Theater == "" {
-		return errors.New("theater is required")
-	}
-	return nil
+	_, err := newShow(req)
+	return err
 }
 
 // TestNewCommandService tests constructor functions
@@ -177,22 +235,565 @@ func TestNewCommandService(t *testing.T) {
 	}
 }
 
-// TestEndTimeCalculation tests that end time is calculated correctly
+// TestEndTimeCalculation tests that end time comes from the movie duration plus buffers
 func TestEndTimeCalculation(t *testing.T) {
 	startTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
-	expectedEndTime := startTime.Add(2 * time.Hour)
 
-	req := CreateShowRequest{
-		Theater:   "Theater A",
-		MovieID:   1,
-		StartTime: startTime,
+	tests := []struct {
+		name     string
+		buffers  Buffers
+		duration int
+		want     time.Time
+	}{
+		{"no buffers", Buffers{}, 148, startTime.Add(148 * time.Minute)},
+		{"ads and cleaning", Buffers{Ads: 15 * time.Minute, Cleaning: 10 * time.Minute}, 148, startTime.Add(173 * time.Minute)},
+		{"short film", Buffers{Cleaning: 5 * time.Minute}, 12, startTime.Add(17 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.buffers.EndTime(startTime, tt.duration); !got.Equal(tt.want) {
+				t.Errorf("Expected end time %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestShowOverlaps tests which shows would clash in a theater
+func TestShowOverlaps(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2024, 1, 1, h, m, 0, 0, time.UTC) }
+	base := &Show{Theater: "Theater A", StartTime: at(10, 0), EndTime: at(12, 30)}
+
+	tests := []struct {
+		name  string
+		other *Show
+		want  bool
+	}{
+		{"same slot", &Show{Theater: "Theater A", StartTime: at(10, 0), EndTime: at(12, 30)}, true},
+		{"starts during", &Show{Theater: "Theater A", StartTime: at(12, 0), EndTime: at(14, 0)}, true},
+		{"ends during", &Show{Theater: "Theater A", StartTime: at(8, 0), EndTime: at(10, 1)}, true},
+		{"inside", &Show{Theater: "Theater A", StartTime: at(11, 0), EndTime: at(11, 30)}, true},
+		{"back to back after", &Show{Theater: "Theater A", StartTime: at(12, 30), EndTime: at(15, 0)}, false},
+		{"back to back before", &Show{Theater: "Theater A", StartTime: at(8, 0), EndTime: at(10, 0)}, false},
+		{"other theater", &Show{Theater: "Theater B", StartTime: at(10, 0), EndTime: at(12, 30)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := base.overlaps(tt.other); got != tt.want {
+				t.Errorf("Expected overlaps %v, got %v", tt.want, got)
+			}
+			if got := tt.other.overlaps(base); got != tt.want {
+				t.Errorf("Expected overlaps to be symmetric, got %v", got)
+			}
+		})
+	}
+}
+
+// TestShowOverlaps_Screens tests that screens of one theater are scheduled independently
+func TestShowOverlaps_Screens(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2024, 1, 1, h, 0, 0, 0, time.UTC) }
+	one, two := 1, 2
+	show := func(theater string, screen *int) *Show {
+		return &Show{Theater: theater, ScreenID: screen, StartTime: at(10), EndTime: at(12)}
+	}
+
+	tests := []struct {
+		name string
+		a, b *Show
+		want bool
+	}{
+		{"same screen", show("Plaza", &one), show("Plaza", &one), true},
+		{"other screen", show("Plaza", &one), show("Plaza", &two), false},
+		{"screen and whole theater", show("Plaza", &one), show("Plaza", nil), true},
+		{"whole theater and screen", show("Plaza", nil), show("Plaza", &two), true},
+		{"whole theater", show("Plaza", nil), show("Plaza", nil), true},
+		{"same screen ID elsewhere", show("Plaza", &one), show("Regal", &one), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.overlaps(tt.b); got != tt.want {
+				t.Errorf("Expected overlaps %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestScheduleErrors tests that conflict errors name the clashing show and match the sentinels
+func TestScheduleErrors(t *testing.T) {
+	clash := &Show{ID: 7, MovieID: 3, Theater: "Theater A",
+		StartTime: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), EndTime: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name         string
+		err          error
+		wantConflict bool
+		wantSchedule bool
+		wantText     string
+	}{
+		{"conflict", &ConflictError{Show: clash}, true, false, "show 7 (movie 3) runs in Theater A from 2024-01-01T10:00:00Z to 2024-01-01T12:00:00Z"},
+		{"conflict without show", &ConflictError{}, true, false, ErrScheduleConflict.Error()},
+		{"schedule with conflict", &ScheduleError{Rows: []RowError{{Row: 0, Error: "x"}, {Row: 2, Error: "y", Conflict: clash}}}, true, true, "row 0: x; row 2: y"},
+		{"schedule without conflict", &ScheduleError{Rows: []RowError{{Row: 1, Error: ErrTheaterRequired.Error()}}}, false, true, "row 1: theater is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, ErrScheduleConflict); got != tt.wantConflict {
+				t.Errorf("Expected conflict %v, got %v", tt.wantConflict, got)
+			}
+			if got := errors.Is(tt.err, ErrInvalidSchedule); got != tt.wantSchedule {
+				t.Errorf("Expected invalid schedule %v, got %v", tt.wantSchedule, got)
+			}
+			if !strings.Contains(tt.err.Error(), tt.wantText) {
+				t.Errorf("Expected %q in %q", tt.wantText, tt.err.Error())
+			}
+		})
+	}
+}
+
+// TestScheduleShows_Validation tests that a bulk schedule is checked row by row before storage
+func TestScheduleShows_Validation(t *testing.T) {
+	svc := NewCommandService()
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	valid := CreateShowRequest{MovieID: 1, Theater: "Theater A", StartTime: start}
+
+	tests := []struct {
+		name     string
+		shows    []CreateShowRequest
+		wantRows []int
+	}{
+		{"empty", nil, nil},
+		{"too many", make([]CreateShowRequest, MaxScheduleShows+1), nil},
+		{"bad rows", []CreateShowRequest{valid, {Theater: "Theater A", StartTime: start}, valid, {MovieID: 1, Theater: "Theater A"}}, []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ScheduleShows(context.Background(), ScheduleRequest{Shows: tt.shows})
+			if !errors.Is(err, ErrInvalidSchedule) {
+				t.Fatalf("Expected %v, got %v", ErrInvalidSchedule, err)
+			}
+			var schedErr *ScheduleError
+			errors.As(err, &schedErr)
+			var rows []int
+			if schedErr != nil {
+				for _, row := range schedErr.Rows {
+					rows = append(rows, row.Row)
+				}
+			}
+			if fmt.Sprint(rows) != fmt.Sprint(tt.wantRows) {
+				t.Errorf("Expected rows %v, got %v", tt.wantRows, rows)
+			}
+		})
 	}
+}
 
-	// Simulate the service logic
-	endTime := req.StartTime.Add(2 * time.Hour)
+// TestParseRule tests reading RRULE-style recurrence rules
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		want    string
+		wantErr bool
+	}{
+		{"daily until date", "FREQ=DAILY;UNTIL=20240107", "FREQ=DAILY;UNTIL=20240107T235959Z", false},
+		{"weekly with days", "RRULE:FREQ=WEEKLY;BYDAY=FR,MO,WE,MO;COUNT=6", "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=6", false},
+		{"interval and iso until", "freq=weekly;interval=2;until=2024-03-31", "FREQ=WEEKLY;INTERVAL=2;UNTIL=20240331T235959Z", false},
+		{"until date-time", "FREQ=DAILY;UNTIL=20240105T120000Z", "FREQ=DAILY;UNTIL=20240105T120000Z", false},
+		{"missing freq", "COUNT=3", "", true},
+		{"monthly", "FREQ=MONTHLY;COUNT=3", "", true},
+		{"unbounded", "FREQ=DAILY", "", true},
+		{"until and count", "FREQ=DAILY;COUNT=3;UNTIL=20240107", "", true},
+		{"bad weekday", "FREQ=WEEKLY;BYDAY=XX;COUNT=3", "", true},
+		{"bad interval", "FREQ=DAILY;INTERVAL=0;COUNT=3", "", true},
+		{"unsupported part", "FREQ=DAILY;COUNT=3;BYMONTH=1", "", true},
+	}
 
-	if !endTime.Equal(expectedEndTime) {
-		t.Errorf("Expected end time %v, got %v", expectedEndTime, endTime)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRule) {
+					t.Errorf("Expected %v, got %v", ErrInvalidRule, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestRuleExpand tests expanding a rule into show start times
+func TestRuleExpand(t *testing.T) {
+	// Monday 1 January 2024, 18:30
+	start := time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		rule       string
+		exceptions []string
+		want       []string
+	}{
+		{"daily", "FREQ=DAILY;UNTIL=20240104", nil,
+			[]string{"2024-01-01", "2024-01-02", "2024-01-03", "2024-01-04"}},
+		{"daily with exception", "FREQ=DAILY;COUNT=4", []string{"2024-01-02"},
+			[]string{"2024-01-01", "2024-01-03", "2024-01-04"}},
+		{"every other day", "FREQ=DAILY;INTERVAL=2;UNTIL=20240107", nil,
+			[]string{"2024-01-01", "2024-01-03", "2024-01-05", "2024-01-07"}},
+		{"weekdays only", "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;UNTIL=20240109", nil,
+			[]string{"2024-01-01", "2024-01-02", "2024-01-03", "2024-01-04", "2024-01-05", "2024-01-08", "2024-01-09"}},
+		{"weekly on start day", "FREQ=WEEKLY;COUNT=3", nil,
+			[]string{"2024-01-01", "2024-01-08", "2024-01-15"}},
+		{"weekends", "FREQ=WEEKLY;BYDAY=SA,SU;UNTIL=20240114", []string{"2024-01-13"},
+			[]string{"2024-01-06", "2024-01-07", "2024-01-14"}},
+		{"fortnightly", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;UNTIL=20240131", nil,
+			[]string{"2024-01-01", "2024-01-05", "2024-01-15", "2024-01-19", "2024-01-29"}},
+		{"until before the show time", "FREQ=DAILY;UNTIL=20240103T180000Z", nil,
+			[]string{"2024-01-01", "2024-01-02"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			starts, err := rule.Expand(start, tt.exceptions)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := make([]string, len(starts))
+			for i, s := range starts {
+				if s.Hour() != 18 || s.Minute() != 30 {
+					t.Errorf("Expected every show at 18:30, got %v", s)
+				}
+				got[i] = s.Format("2006-01-02")
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("too many occurrences", func(t *testing.T) {
+		rule, _ := ParseRule("FREQ=DAILY;UNTIL=20300101")
+		if _, err := rule.Expand(start, nil); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Expected %v, got %v", ErrInvalidRule, err)
+		}
+	})
+}
+
+// TestRuleShift tests moving a weekly rule's days along with its shows
+func TestRuleShift(t *testing.T) {
+	rule, _ := ParseRule("FREQ=WEEKLY;BYDAY=MO,SA;COUNT=4")
+
+	tests := []struct {
+		days int
+		want string
+	}{
+		{0, "FREQ=WEEKLY;BYDAY=MO,SA;COUNT=4"},
+		{1, "FREQ=WEEKLY;BYDAY=TU,SU;COUNT=4"},
+		{-1, "FREQ=WEEKLY;BYDAY=FR,SU;COUNT=4"},
+		{7, "FREQ=WEEKLY;BYDAY=MO,SA;COUNT=4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.days), func(t *testing.T) {
+			if got := rule.Shift(tt.days).String(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestRuleExpandAcrossDST tests that occurrences keep the venue's wall-clock time when its
+// clocks change, so the instants between them aren't a whole number of days apart
+func TestRuleExpandAcrossDST(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	// Clocks go forward on Sunday 31 March 2024
+	rule, _ := ParseRule("FREQ=DAILY;COUNT=3")
+	starts, err := rule.Expand(time.Date(2024, 3, 30, 19, 0, 0, 0, london), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{"2024-03-30T19:00:00Z", "2024-03-31T18:00:00Z", "2024-04-01T18:00:00Z"}
+	for i, s := range starts {
+		if s.Hour() != 19 {
+			t.Errorf("Expected show %d at 19:00 local, got %v", i, s)
+		}
+		if got := s.UTC().Format(time.RFC3339); got != want[i] {
+			t.Errorf("Expected show %d at %s, got %s", i, want[i], got)
+		}
+	}
+}
+
+// TestRuleIn tests that a date UNTIL takes in the whole of that day at the venue
+func TestRuleIn(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	// 19:30 in New York is already the next day in UTC
+	start := time.Date(2024, 1, 1, 19, 30, 0, 0, newYork)
+
+	tests := []struct {
+		rule string
+		want int
+	}{
+		{"FREQ=DAILY;UNTIL=20240104", 4},
+		{"FREQ=DAILY;UNTIL=20240104T235959Z", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule, _ := ParseRule(tt.rule)
+			starts, err := rule.In(newYork).Expand(start, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(starts) != tt.want {
+				t.Errorf("Expected %d shows, got %v", tt.want, starts)
+			}
+		})
+	}
+}
+
+// TestShiftWall tests moving shows by days and wall-clock time in their own zone
+func TestShiftWall(t *testing.T) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		from  time.Time
+		days  int
+		clock time.Duration
+		want  time.Time
+	}{
+		{"same day later", time.Date(2024, 3, 29, 19, 0, 0, 0, london), 0, 90 * time.Minute,
+			time.Date(2024, 3, 29, 20, 30, 0, 0, london)},
+		{"across the clocks going forward", time.Date(2024, 3, 29, 19, 0, 0, 0, london), 3, 0,
+			time.Date(2024, 4, 1, 19, 0, 0, 0, london)},
+		{"across the clocks going back", time.Date(2024, 10, 26, 19, 0, 0, 0, london), 1, -time.Hour,
+			time.Date(2024, 10, 27, 18, 0, 0, 0, london)},
+		{"past midnight", time.Date(2024, 3, 29, 23, 0, 0, 0, london), 0, 2 * time.Hour,
+			time.Date(2024, 3, 30, 1, 0, 0, 0, london)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shiftWall(tt.from, tt.days, tt.clock)
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestShowInZone tests that read rows are given in their venue's zone
+func TestShowInZone(t *testing.T) {
+	start := time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)
+	sh := Show{StartTime: start, EndTime: start.Add(3 * time.Hour), TimeZone: "Asia/Kolkata"}
+	sh.inZone()
+
+	if got := sh.StartTime.Format(time.RFC3339); got != "2024-03-01T18:30:00+05:30" {
+		t.Errorf("Expected the start at the venue, got %s", got)
+	}
+	if !sh.StartTime.Equal(start) {
+		t.Errorf("Expected the same instant, got %v", sh.StartTime)
+	}
+}
+
+// TestScheduleCommands_Validation tests that schedule commands reject bad input before storage
+func TestScheduleCommands_Validation(t *testing.T) {
+	svc := NewCommandService()
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{"create without theater", func() error {
+			_, err := svc.CreateSchedule(ctx, CreateScheduleRequest{MovieID: 1, StartTime: start, Rule: "FREQ=DAILY;COUNT=3"})
+			return err
+		}, ErrTheaterRequired},
+		{"create with bad rule", func() error {
+			_, err := svc.CreateSchedule(ctx, CreateScheduleRequest{MovieID: 1, Theater: "Theater A", StartTime: start, Rule: "FREQ=DAILY"})
+			return err
+		}, ErrInvalidRule},
+		{"create with bad exception", func() error {
+			_, err := svc.CreateSchedule(ctx, CreateScheduleRequest{MovieID: 1, Theater: "Theater A", StartTime: start,
+				Rule: "FREQ=DAILY;COUNT=3", Exceptions: []string{"01/02/2024"}})
+			return err
+		}, ErrInvalidRule},
+		{"create with no occurrences", func() error {
+			_, err := svc.CreateSchedule(ctx, CreateScheduleRequest{MovieID: 1, Theater: "Theater A", StartTime: start,
+				Rule: "FREQ=DAILY;COUNT=1", Exceptions: []string{"2024-01-01"}})
+			return err
+		}, ErrInvalidRule},
+		{"create on a screen no venue has", func() error {
+			screen := 4
+			_, err := svc.CreateSchedule(ctx, CreateScheduleRequest{MovieID: 1, ScreenID: &screen, StartTime: start, Rule: "FREQ=DAILY;COUNT=3"})
+			return err
+		}, ErrUnknownScreen},
+		{"update bad schedule", func() error {
+			_, err := svc.UpdateFollowing(ctx, "x", UpdateFollowingRequest{FromShowID: 1, Theater: "Theater B"})
+			return err
+		}, ErrScheduleNotFound},
+		{"update without show", func() error {
+			_, err := svc.UpdateFollowing(ctx, "1", UpdateFollowingRequest{Theater: "Theater B"})
+			return err
+		}, ErrNotInSchedule},
+		{"update without changes", func() error {
+			_, err := svc.UpdateFollowing(ctx, "1", UpdateFollowingRequest{FromShowID: 1, Theater: " "})
+			return err
+		}, ErrInvalidSchedule},
+		{"cancel without show", func() error {
+			_, err := svc.CancelFollowing(ctx, "1", "")
+			return err
+		}, ErrNotInSchedule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestShowSearch_SQL tests how search parameters become a query
+func TestShowSearch_SQL(t *testing.T) {
+	day := time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		search   ShowSearch
+		wantSort string
+		wantSQL  []string
+		wantArgs int
+		wantErr  error
+	}{
+		{
+			name:     "defaults",
+			wantSort: SortStartTime,
+			wantSQL:  []string{"JOIN movies m ON m.id = s.movie_id", "s.status <> 'CANCELLED'", "ORDER BY s.start_time ASC, s.id ASC LIMIT 21"},
+		},
+		{
+			name:     "text search keeps time order",
+			search:   ShowSearch{Query: "inception"},
+			wantSort: SortStartTime,
+			wantSQL:  []string{"m.search_vector @@ to_tsquery('simple', $1)", "ORDER BY s.start_time ASC"},
+			wantArgs: 1,
+		},
+		{
+			name:     "relevance",
+			search:   ShowSearch{Query: "inception", Sort: SortRelevance},
+			wantSort: SortRelevance,
+			wantSQL:  []string{"ORDER BY ts_rank(m.search_vector, to_tsquery('simple', $1)) DESC"},
+			wantArgs: 1,
+		},
+		{
+			name:     "filters",
+			search:   ShowSearch{MovieID: 2, Theater: "Theater A", Genre: "Drama", Language: "Tamil", Format: "3d", Certification: "U", From: day, To: day.AddDate(0, 0, 7)},
+			wantSort: SortStartTime,
+			wantSQL:  []string{"s.movie_id = $1", "s.theater = $2", "m.genres @> ARRAY[$3]", "m.languages @> ARRAY[$4]", "m.formats @> ARRAY[$5]", "m.certification = $6", "s.start_time >= $7", "s.start_time < $8"},
+			wantArgs: 8,
+		},
+		{
+			name:     "cursor",
+			search:   ShowSearch{Sort: SortStartTimeDesc, Cursor: utils.Cursor{Sort: SortStartTimeDesc, Value: "2024-01-05 18:30:00", ID: 9}.Encode()},
+			wantSort: SortStartTimeDesc,
+			wantSQL:  []string{"(s.start_time, s.id) < ($1::timestamptz, $2)", "DESC, s.id DESC"},
+			wantArgs: 2,
+		},
+		{
+			name:     "venue and city",
+			search:   ShowSearch{VenueID: 3, City: "Chennai"},
+			wantSort: SortStartTime,
+			wantSQL:  []string{"s.venue_id = $1", "lower(s.city) = lower($2)"},
+			wantArgs: 2,
+		},
+		{name: "unknown sort", search: ShowSearch{Sort: "price"}, wantErr: ErrInvalidSearch},
+		{name: "relevance without text", search: ShowSearch{Sort: SortRelevance}, wantErr: ErrInvalidSearch},
+		{
+			name:     "local days",
+			search:   ShowSearch{FromDate: "2024-01-05", ToDate: "2024-01-05"},
+			wantSort: SortStartTime,
+			wantSQL:  []string{"(s.start_time AT TIME ZONE s.time_zone) >= $1::date", "(s.start_time AT TIME ZONE s.time_zone) < $2::date + 1"},
+			wantArgs: 2,
+		},
+		{name: "empty range", search: ShowSearch{From: day, To: day}, wantErr: ErrInvalidSearch},
+		{name: "backwards days", search: ShowSearch{FromDate: "2024-01-06", ToDate: "2024-01-05"}, wantErr: ErrInvalidSearch},
+		{name: "bad cursor", search: ShowSearch{Cursor: "%%"}, wantErr: utils.ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.search
+			query, args, err := q.sql()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if q.Sort != tt.wantSort {
+				t.Errorf("Expected sort %q, got %q", tt.wantSort, q.Sort)
+			}
+			for _, want := range tt.wantSQL {
+				if !strings.Contains(query, want) {
+					t.Errorf("Expected %q in %s", want, query)
+				}
+			}
+			if len(args) != tt.wantArgs {
+				t.Errorf("Expected %d args, got %d", tt.wantArgs, len(args))
+			}
+		})
+	}
+}
+
+// TestParseTimeBound tests reading the ends of a show search's date range
+func TestParseTimeBound(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     time.Time
+		wantDate string
+		wantErr  bool
+	}{
+		{"", time.Time{}, "", false},
+		{"2024-01-05", time.Time{}, "2024-01-05", false},
+		{"2024-01-05T18:30:00Z", time.Date(2024, 1, 5, 18, 30, 0, 0, time.UTC), "", false},
+		{"2024-01-05T18:30:00+05:30", time.Date(2024, 1, 5, 13, 0, 0, 0, time.UTC), "", false},
+		{"05/01/2024", time.Time{}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, date, err := parseTimeBound(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !got.Equal(tt.want) || date != tt.wantDate {
+				t.Errorf("Expected %v %q, got %v %q", tt.want, tt.wantDate, got, date)
+			}
+		})
 	}
 }
 
@@ -227,3 +828,42 @@ func BenchmarkCreateShowValidation(b *testing.B) {
 		validateCreateShowRequest(req)
 	}
 }
+
+// TestCommandHandler_VenueAccess tests that show changes stop before the commands when the
+// venue they touch can't be checked
+func TestCommandHandler_VenueAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewCommandHandler(NewCommandService())
+	reached := func(c *gin.Context) {
+		t.Error("Expected the request to stop before the command")
+		c.Status(http.StatusOK)
+	}
+
+	tests := []struct {
+		name    string
+		route   string
+		path    string
+		body    string
+		handler gin.HandlerFunc
+		want    int
+	}{
+		{"bad show id", "/shows/:id", "/shows/abc", "", h.RequireShowManager, http.StatusNotFound},
+		{"bad schedule id", "/schedules/:id", "/schedules/0", "", h.RequireScheduleManager, http.StatusNotFound},
+		{"show not loaded", "/shows/:id", "/shows/1", "", h.RequireShowManager, http.StatusInternalServerError},
+		{"schedule not loaded", "/schedules/:id", "/schedules/1", "", h.RequireScheduleManager, http.StatusInternalServerError},
+		{"create without managers", "/shows", "/shows", `{"movie_id":1,"theater":"Plaza","start_time":"2024-01-01T18:00:00Z"}`, h.CreateShow, http.StatusInternalServerError},
+		{"bulk without managers", "/shows", "/shows", `{"shows":[{"movie_id":1,"theater":"Plaza","start_time":"2024-01-01T18:00:00Z"}]}`, h.ScheduleShows, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST(tt.route, tt.handler, reached)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
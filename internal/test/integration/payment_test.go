@@ -35,7 +35,8 @@ func TestPaymentIntegration_InitiatePayment(t *testing.T) {
 		assert.NotEmpty(t, payment.ID)
 		assert.Equal(t, req.BookingID, payment.BookingID)
 		assert.Equal(t, req.UserID, payment.UserID)
-		assert.Equal(t, req.Amount, payment.Amount)
+		assert.Equal(t, req.Amount, payment.Amount.Amount)
+		assert.Equal(t, "INR", payment.Amount.Currency)
 		assert.Equal(t, "PENDING", payment.Status)
 	})
 
@@ -121,7 +122,7 @@ func TestPaymentIntegration_VerifyPayment(t *testing.T) {
 		// Verify the status was updated
 		updatedPayment, err := svc.PaymentCmdSvc.Repo.GetPaymentByID(payment.ID)
 		require.NoError(t, err)
-		assert.Equal(t, "SUCCESS", updatedPayment.Status)
+		assert.Equal(t, payments.StatusCaptured, updatedPayment.Status)
 		assert.NotEmpty(t, updatedPayment.TransactionID)
 	})
 
@@ -158,7 +159,7 @@ func TestPaymentIntegration_VerifyPayment(t *testing.T) {
 
 		err := svc.PaymentCmdSvc.VerifyPayment(ctx, verifyReq)
 		require.Error(t, err)
-		assert.Contains(t, err.Error(), "already processed")
+		assert.ErrorIs(t, err, payments.ErrIllegalTransition)
 	})
 
 	t.Run("VerifyPayment_InvalidPaymentID", func(t *testing.T) {
@@ -208,7 +209,13 @@ func TestPaymentIntegration_RefundPayment(t *testing.T) {
 		// Verify the status was updated
 		updatedPayment, err := svc.PaymentCmdSvc.Repo.GetPaymentByID(payment.ID)
 		require.NoError(t, err)
-		assert.Equal(t, "REFUNDED", updatedPayment.Status)
+		assert.Equal(t, payments.StatusRefunded, updatedPayment.Status)
+		assert.Equal(t, updatedPayment.Amount.Amount, updatedPayment.RefundedAmount.Amount)
+
+		history, err := svc.PaymentCmdSvc.Repo.GetStatusHistory(ctx, payment.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Equal(t, payments.StatusRefunded, history[2].To)
 	})
 
 	t.Run("RefundPayment_NotSuccessful", func(t *testing.T) {
@@ -224,7 +231,7 @@ func TestPaymentIntegration_RefundPayment(t *testing.T) {
 		// Try to refund a non-successful payment
 		err = svc.PaymentCmdSvc.RefundPayment(ctx, payment2.ID)
 		require.Error(t, err)
-		assert.Contains(t, err.Error(), "can only refund successful payments")
+		assert.ErrorIs(t, err, payments.ErrIllegalTransition)
 	})
 }
 
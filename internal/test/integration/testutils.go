@@ -226,11 +226,11 @@ func SetupTestServices(t *testing.T) *TestServices {
 	notificationQuerySvc := notification.NewQueryService(queryDB.Pool, cmdDB.Pool)
 
 	// Initialize Movie services
-	movieCmdSvc := movie.NewCommandServiceWithDispatcher(dispatcher)
+	movieCmdSvc := movie.NewCommandServiceWithDispatcher(movie.NewRepository(cmdDB.Pool), dispatcher)
 	movieQuerySvc := movie.NewQueryService(cmdDB.Pool)
 
 	// Initialize Show services
-	showCmdSvc := show.NewCommandServiceWithDispatcher(dispatcher)
+	showCmdSvc := show.NewCommandServiceWithDispatcher(show.NewRepository(cmdDB.Pool), dispatcher, show.Buffers{})
 	showQuerySvc := show.NewQueryService(cmdDB.Pool)
 
 	return &TestServices{
@@ -300,6 +300,7 @@ func runMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 		CREATE TABLE IF NOT EXISTS reservations (
 			seat_id TEXT PRIMARY KEY,
 			user_id TEXT NOT NULL,
+			show_id INT,
 			status TEXT NOT NULL,
 			reserved_at TIMESTAMP DEFAULT NOW()
 		)
@@ -308,6 +309,18 @@ func runMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 		return fmt.Errorf("failed to create reservations table: %w", err)
 	}
 
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS seats (
+			id TEXT PRIMARY KEY,
+			show_id INT NOT NULL,
+			label TEXT NOT NULL,
+			tier TEXT NOT NULL DEFAULT 'STANDARD'
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create seats table: %w", err)
+	}
+
 	_, err = pool.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS events (
 			id SERIAL PRIMARY KEY,
@@ -341,16 +354,68 @@ func runMigrations(ctx context.Context, pool *pgxpool.Pool) error {
 			id TEXT PRIMARY KEY,
 			booking_id TEXT NOT NULL,
 			user_id TEXT NOT NULL,
-			amount INTEGER NOT NULL,
+			amount BIGINT NOT NULL,
+			currency TEXT NOT NULL DEFAULT 'INR',
+			refunded_amount BIGINT NOT NULL DEFAULT 0,
+			discount_amount BIGINT NOT NULL DEFAULT 0,
+			promo_code TEXT,
 			status TEXT NOT NULL,
 			transaction_id TEXT,
-			created_at TIMESTAMP DEFAULT NOW()
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
 		)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to create payments table: %w", err)
 	}
 
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS payment_status_history (
+			id BIGSERIAL PRIMARY KEY,
+			payment_id TEXT NOT NULL,
+			from_status TEXT NOT NULL,
+			to_status TEXT NOT NULL,
+			transaction_id TEXT,
+			reason TEXT,
+			created_at TIMESTAMP DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create payment_status_history table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS payment_line_items (
+			payment_id TEXT NOT NULL,
+			booking_id TEXT NOT NULL,
+			position INT NOT NULL,
+			kind TEXT NOT NULL,
+			label TEXT NOT NULL,
+			amount BIGINT NOT NULL,
+			currency TEXT NOT NULL,
+			PRIMARY KEY (payment_id, position)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create payment_line_items table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS payment_tenders (
+			payment_id TEXT NOT NULL,
+			position INT NOT NULL,
+			method TEXT NOT NULL,
+			reference TEXT,
+			amount BIGINT NOT NULL,
+			refunded_amount BIGINT NOT NULL DEFAULT 0,
+			currency TEXT NOT NULL,
+			PRIMARY KEY (payment_id, position)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create payment_tenders table: %w", err)
+	}
+
 	// Notifications table
 	_, err = pool.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS notifications (
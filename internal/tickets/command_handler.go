@@ -0,0 +1,114 @@
+// Command handler for gate check-in (CQRS)
+
+package tickets
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/utils"
+	"github.com/hitorii/ticket-booking/internal/venue"
+)
+
+type CommandHandler struct {
+	CommandService *CommandService
+	Managers       *venue.CommandService // gate staff sign in as managers of the show's venue
+}
+
+func NewCommandHandler(cs *CommandService) *CommandHandler {
+	return &CommandHandler{CommandService: cs}
+}
+
+// allowed checks the signed-in user is an admin or manages the venue of the show being
+// scanned for, aborting with the error when they are not. Shows left out are for the
+// command to reject. It follows middleware.Authenticate.
+func (h *CommandHandler) allowed(c *gin.Context, showID int) bool {
+	if showID <= 0 {
+		return true
+	}
+	err := errors.New("venue access is not configured")
+	if h.Managers != nil && h.CommandService.Repo != nil {
+		var venueName string
+		venueName, err = h.CommandService.Repo.ShowVenue(c.Request.Context(), showID)
+		if err == nil {
+			err = h.Managers.Authorize(c.Request.Context(), c.GetString("user_id"), venueName)
+		}
+	}
+	if err != nil {
+		writeAccessError(c, err)
+		return false
+	}
+	return true
+}
+
+// writeAccessError aborts a request that failed an access check
+func writeAccessError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, venue.ErrUnauthenticated):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, venue.ErrForbidden):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrShowNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Show not found"})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check access: " + err.Error()})
+	}
+}
+
+// CheckIn - Command handler for a ticket scanned at a gate
+func (h *CommandHandler) CheckIn(c *gin.Context) {
+	var req CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if !h.allowed(c, req.ShowID) {
+		return
+	}
+
+	checkIn, err := h.CommandService.CheckIn(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAlreadyCheckedIn):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "check_in": checkIn})
+		case errors.Is(err, utils.ErrLockNotAcquired):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrInvalidCheckIn):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrWrongShow), errors.Is(err, ErrNotBooked):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrTicketNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, checkIn)
+}
+
+// SyncOfflineScans - Command handler for uploading scans made without connectivity
+func (h *CommandHandler) SyncOfflineScans(c *gin.Context) {
+	var req SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if !h.allowed(c, req.ShowID) {
+		return
+	}
+
+	result, err := h.CommandService.SyncOfflineScans(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCheckIn) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sync scans: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,241 @@
+// Command service for issuing e-tickets (CQRS)
+
+package tickets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/events"
+	"github.com/hitorii/ticket-booking/internal/utils"
+)
+
+// clockSkew is how far in the future an offline scanner's clock may be
+const clockSkew = 2 * time.Minute
+
+type CommandService struct {
+	Repo       *Repository
+	Keys       *Keyring
+	Grace      time.Duration // how long after the show ends a ticket still scans
+	TTL        time.Duration // validity of tickets whose show isn't known
+	Dispatcher *events.Dispatcher
+	Lock       *utils.DistributedLock // serialises scans of the same ticket across gates
+}
+
+func NewCommandService(repo *Repository, keys *Keyring, grace, ttl time.Duration) *CommandService {
+	return &CommandService{Repo: repo, Keys: keys, Grace: grace, TTL: ttl}
+}
+
+// Issue - Command to issue the e-ticket of a confirmed booking.
+// Issuing again returns the ticket already issued.
+func (s *CommandService) Issue(ctx context.Context, bookingID string) (*Ticket, error) {
+	if existing, err := s.Repo.GetByBookingID(ctx, bookingID); err == nil {
+		return existing, nil
+	}
+
+	b, err := s.Repo.GetBooking(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != "BOOKED" {
+		return nil, fmt.Errorf("%w: booking %s is %s", ErrNotBooked, bookingID, b.Status)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	t := &Ticket{
+		ID:        uuid.New().String(),
+		BookingID: b.ID,
+		UserID:    b.UserID,
+		SeatID:    b.SeatID,
+		SeatLabel: b.SeatLabel,
+		ShowTime:  b.StartTime,
+		KeyID:     s.Keys.ActiveKeyID(),
+		ExpiresAt: b.expiry(now, s.Grace, s.TTL).UTC(),
+		CreatedAt: now,
+	}
+	if b.ShowID != nil {
+		t.ShowID = *b.ShowID
+	}
+
+	t.Token, err = s.Keys.Sign(Claims{
+		BookingID: t.BookingID,
+		ShowID:    t.ShowID,
+		SeatID:    t.SeatID,
+		SeatLabel: t.SeatLabel,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        t.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(t.ExpiresAt),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign ticket: %w", err)
+	}
+
+	saved, err := s.Repo.Save(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		// Issued concurrently; serve the one that was stored
+		return s.Repo.GetByBookingID(ctx, bookingID)
+	}
+	return t, nil
+}
+
+// CheckIn - Command to admit a ticket scanned at a gate
+func (s *CommandService) CheckIn(ctx context.Context, req CheckInRequest) (*CheckIn, error) {
+	if req.ShowID <= 0 {
+		return nil, fmt.Errorf("%w: show_id is required", ErrInvalidCheckIn)
+	}
+	return s.checkIn(ctx, req, time.Now(), false)
+}
+
+// SyncOfflineScans - Command to record the scans a scanner made while offline. Each scan is
+// checked as of when it happened and gets its own outcome; a failing scan doesn't stop the rest.
+func (s *CommandService) SyncOfflineScans(ctx context.Context, req SyncRequest) (*SyncResult, error) {
+	if req.ShowID <= 0 {
+		return nil, fmt.Errorf("%w: show_id is required", ErrInvalidCheckIn)
+	}
+	if len(req.Scans) == 0 || len(req.Scans) > MaxSyncScans {
+		return nil, fmt.Errorf("%w: a sync carries between 1 and %d scans", ErrInvalidCheckIn, MaxSyncScans)
+	}
+
+	// Replay in the order the gate saw them so the first admission wins
+	scans := append([]OfflineScan{}, req.Scans...)
+	sort.SliceStable(scans, func(i, j int) bool { return scans[i].ScannedAt.Before(scans[j].ScannedAt) })
+
+	result := &SyncResult{Results: make([]ScanResult, 0, len(scans))}
+	now := time.Now()
+	for _, scan := range scans {
+		res := ScanResult{Token: scan.Token}
+		var checkIn *CheckIn
+		var err error
+		switch {
+		case scan.ScannedAt.IsZero():
+			err = fmt.Errorf("%w: scanned_at is required", ErrInvalidCheckIn)
+		case scan.ScannedAt.After(now.Add(clockSkew)):
+			err = fmt.Errorf("%w: scanned_at is in the future", ErrInvalidCheckIn)
+		default:
+			checkIn, err = s.checkIn(ctx, CheckInRequest{
+				Token:     scan.Token,
+				ShowID:    req.ShowID,
+				Gate:      req.Gate,
+				ScannerID: req.ScannerID,
+			}, scan.ScannedAt, true)
+		}
+
+		res.Status, res.Reason = scanOutcome(err)
+		res.CheckIn = checkIn
+		if checkIn != nil {
+			res.TicketID = checkIn.TicketID
+		}
+		switch res.Status {
+		case ScanAdmitted:
+			result.Admitted++
+		case ScanDuplicate:
+			result.Duplicates++
+			log.Printf("⚠️  Offline scan at gate %s duplicates check-in %s of ticket %s", req.Gate, checkIn.ID, checkIn.TicketID)
+		case ScanRejected:
+			result.Rejected++
+		default:
+			result.Retry++
+		}
+		result.Results = append(result.Results, res)
+	}
+	return result, nil
+}
+
+// scanOutcome maps a check-in error to what the scanner is told
+func scanOutcome(err error) (status, reason string) {
+	switch {
+	case err == nil:
+		return ScanAdmitted, ""
+	case errors.Is(err, ErrAlreadyCheckedIn):
+		return ScanDuplicate, err.Error()
+	case errors.Is(err, ErrInvalidToken), errors.Is(err, ErrInvalidCheckIn), errors.Is(err, ErrWrongShow),
+		errors.Is(err, ErrNotBooked), errors.Is(err, ErrTicketNotFound):
+		return ScanRejected, err.Error()
+	default:
+		return ScanRetry, err.Error()
+	}
+}
+
+// checkIn verifies the token as of scannedAt and records the admission under the ticket's lock
+func (s *CommandService) checkIn(ctx context.Context, req CheckInRequest, scannedAt time.Time, offline bool) (*CheckIn, error) {
+	token := strings.TrimSpace(req.Token)
+	if token == "" {
+		return nil, fmt.Errorf("%w: token is required", ErrInvalidCheckIn)
+	}
+
+	verifier := s.Keys.Verifier()
+	verifier.Now = func() time.Time { return scannedAt }
+	claims, err := verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ShowID != req.ShowID {
+		return nil, fmt.Errorf("%w: ticket is for show %d", ErrWrongShow, claims.ShowID)
+	}
+
+	c := &CheckIn{
+		ID:        uuid.New().String(),
+		TicketID:  claims.ID,
+		BookingID: claims.BookingID,
+		ShowID:    claims.ShowID,
+		SeatID:    claims.SeatID,
+		SeatLabel: claims.SeatLabel,
+		Gate:      strings.TrimSpace(req.Gate),
+		ScannerID: strings.TrimSpace(req.ScannerID),
+		ScannedAt: scannedAt.UTC(),
+		Offline:   offline,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	record := func() error {
+		var err error
+		c, err = s.Repo.CheckIn(ctx, c)
+		return err
+	}
+	if s.Lock != nil {
+		err = s.Lock.WithTicketLock(ctx, claims.ID, record)
+	} else {
+		err = record()
+	}
+	if errors.Is(err, ErrAlreadyCheckedIn) {
+		return c, err // c is the earlier admission
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Emit event for event-driven flow
+	if s.Dispatcher != nil {
+		payload := events.EventPayload{
+			BookingID: c.BookingID,
+			SeatID:    c.SeatID,
+			ShowID:    strconv.Itoa(c.ShowID),
+			TicketID:  c.TicketID,
+			Gate:      c.Gate,
+			Status:    "CHECKED_IN",
+			Mode:      checkInMode(offline),
+		}
+		_ = s.Dispatcher.Publish(ctx, events.EventTicketCheckedIn, c.TicketID, payload)
+	}
+	return c, nil
+}
+
+func checkInMode(offline bool) string {
+	if offline {
+		return "OFFLINE"
+	}
+	return "ONLINE"
+}
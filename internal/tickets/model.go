@@ -0,0 +1,141 @@
+// Models for scannable e-tickets
+
+package tickets
+
+import (
+	"errors"
+	"time"
+)
+
+// Ticket formats served by the query endpoint
+const (
+	FormatPNG  = "png"
+	FormatJSON = "json"
+)
+
+var (
+	ErrTicketNotFound = errors.New("ticket not found")
+	ErrNotBooked      = errors.New("booking is not confirmed")
+	ErrInvalidKey     = errors.New("invalid ticket signing key")
+	ErrInvalidToken   = errors.New("invalid ticket token")
+	ErrUnknownKey     = errors.New("ticket signed with an unknown key")
+	ErrTokenExpired   = errors.New("ticket has expired")
+
+	ErrInvalidCheckIn   = errors.New("invalid check-in")
+	ErrWrongShow        = errors.New("ticket is for a different show")
+	ErrAlreadyCheckedIn = errors.New("ticket already checked in")
+	ErrShowNotFound     = errors.New("show not found")
+)
+
+// Outcomes of a scan reported back to the scanner
+const (
+	ScanAdmitted  = "ADMITTED"
+	ScanDuplicate = "DUPLICATE"
+	ScanRejected  = "REJECTED"
+	ScanRetry     = "RETRY" // nothing was recorded; sync the scan again later
+)
+
+// MaxSyncScans caps how many offline scans one sync request may carry
+const MaxSyncScans = 500
+
+// Ticket is the e-ticket for one booked seat. The token is what the QR code carries.
+type Ticket struct {
+	ID        string     `json:"id"`
+	BookingID string     `json:"booking_id"`
+	UserID    string     `json:"user_id"`
+	ShowID    int        `json:"show_id,omitempty"`
+	SeatID    string     `json:"seat_id"`
+	SeatLabel string     `json:"seat_label,omitempty"`
+	ShowTime  *time.Time `json:"show_time,omitempty"`
+	KeyID     string     `json:"key_id"`
+	Token     string     `json:"token"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CheckIn records a ticket being let in at a gate
+type CheckIn struct {
+	ID        string    `json:"id"`
+	TicketID  string    `json:"ticket_id"`
+	BookingID string    `json:"booking_id"`
+	ShowID    int       `json:"show_id,omitempty"`
+	SeatID    string    `json:"seat_id"`
+	SeatLabel string    `json:"seat_label,omitempty"`
+	Gate      string    `json:"gate,omitempty"`
+	ScannerID string    `json:"scanner_id,omitempty"`
+	ScannedAt time.Time `json:"scanned_at"`
+	Offline   bool      `json:"offline"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CheckInRequest is a ticket scanned at a gate with connectivity
+type CheckInRequest struct {
+	Token     string `json:"token"`
+	ShowID    int    `json:"show_id"`
+	Gate      string `json:"gate"`
+	ScannerID string `json:"scanner_id"`
+}
+
+// OfflineScan is a ticket a scanner admitted while it had no connectivity
+type OfflineScan struct {
+	Token     string    `json:"token"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// SyncRequest uploads the scans a scanner collected offline
+type SyncRequest struct {
+	ShowID    int           `json:"show_id"`
+	Gate      string        `json:"gate"`
+	ScannerID string        `json:"scanner_id"`
+	Scans     []OfflineScan `json:"scans"`
+}
+
+// ScanResult is the outcome of one synced scan. For duplicates, CheckIn is the earlier admission.
+type ScanResult struct {
+	Token    string   `json:"token"`
+	TicketID string   `json:"ticket_id,omitempty"`
+	Status   string   `json:"status"`
+	Reason   string   `json:"reason,omitempty"`
+	CheckIn  *CheckIn `json:"check_in,omitempty"`
+}
+
+// SyncResult summarises an offline sync
+type SyncResult struct {
+	Admitted   int          `json:"admitted"`
+	Duplicates int          `json:"duplicates"`
+	Rejected   int          `json:"rejected"`
+	Retry      int          `json:"retry"`
+	Results    []ScanResult `json:"results"`
+}
+
+// ShowCheckIns lists who has been let in to a show
+type ShowCheckIns struct {
+	ShowID    int       `json:"show_id"`
+	CheckedIn int       `json:"checked_in"`
+	CheckIns  []CheckIn `json:"check_ins"`
+}
+
+// booking is the confirmed reservation a ticket is issued for
+type booking struct {
+	ID        string
+	UserID    string
+	SeatID    string
+	Status    string
+	ShowID    *int
+	SeatLabel string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// expiry is when a ticket for the booking stops scanning: the end of the show plus a grace
+// period, or ttl after issue when the show isn't known
+func (b *booking) expiry(issuedAt time.Time, grace, ttl time.Duration) time.Time {
+	switch {
+	case b.EndTime != nil:
+		return b.EndTime.Add(grace)
+	case b.StartTime != nil:
+		return b.StartTime.Add(grace)
+	default:
+		return issuedAt.Add(ttl)
+	}
+}
@@ -0,0 +1,507 @@
+// QR code encoder for e-ticket tokens (byte mode, ISO/IEC 18004)
+
+package tickets
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ErrTooLong is returned when the data doesn't fit in the largest QR version
+var ErrTooLong = errors.New("data too long for a QR code")
+
+// qrLevel is an error correction level. Tickets use medium so a scratched
+// phone screen or a crumpled printout still scans.
+type qrLevel int
+
+const (
+	qrLow qrLevel = iota
+	qrMedium
+)
+
+// formatBits are the two bits the format information stores for each level
+var formatBits = [...]int{qrLow: 1, qrMedium: 0}
+
+// Error correction codewords per block and number of blocks, indexed by level then version
+var (
+	eccCodewordsPerBlock = [...][41]int{
+		qrLow:    {-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		qrMedium: {-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	}
+	eccBlocks = [...][41]int{
+		qrLow:    {-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		qrMedium: {-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	}
+)
+
+// QRCode is an encoded symbol; Modules[y][x] is true for dark modules
+type QRCode struct {
+	Version int
+	Size    int
+	Modules [][]bool
+
+	isFunction [][]bool
+}
+
+// EncodeQR encodes data in byte mode at medium error correction, choosing the smallest version that fits
+func EncodeQR(data []byte) (*QRCode, error) {
+	return encodeQR(data, qrMedium)
+}
+
+func encodeQR(data []byte, level qrLevel) (*QRCode, error) {
+	version := 1
+	for ; ; version++ {
+		if version > 40 {
+			return nil, ErrTooLong
+		}
+		if 4+countBits(version)+8*len(data) <= numDataCodewords(version, level)*8 {
+			break
+		}
+	}
+
+	var bb bitBuffer
+	bb.append(0x4, 4) // byte mode
+	bb.append(len(data), countBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	capacity := numDataCodewords(version, level) * 8
+	bb.append(0, min(4, capacity-len(bb)))
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+
+	qr := newQRCode(version)
+	qr.drawFunctionPatterns(level)
+	qr.drawCodewords(addECCAndInterleave(codewords, version, level))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qr.applyMask(mask)
+		qr.drawFormatBits(level, mask)
+		if p := qr.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		qr.applyMask(mask) // masking is an XOR, so this undoes it
+	}
+	qr.applyMask(best)
+	qr.drawFormatBits(level, best)
+	qr.isFunction = nil
+	return qr, nil
+}
+
+// PNG renders the symbol with the given pixels per module and the standard four-module quiet zone
+func (qr *QRCode) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	const border = 4
+	dim := (qr.Size + 2*border) * scale
+	img := image.NewPaletted(image.Rect(0, 0, dim, dim), color.Palette{color.White, color.Black})
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if !qr.Modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				row := ((y+border)*scale + dy) * img.Stride
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[row+(x+border)*scale+dx] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newQRCode(version int) *QRCode {
+	size := version*4 + 17
+	qr := &QRCode{Version: version, Size: size, Modules: make([][]bool, size), isFunction: make([][]bool, size)}
+	for i := range qr.Modules {
+		qr.Modules[i] = make([]bool, size)
+		qr.isFunction[i] = make([]bool, size)
+	}
+	return qr
+}
+
+func (qr *QRCode) setFunction(x, y int, dark bool) {
+	qr.Modules[y][x] = dark
+	qr.isFunction[y][x] = true
+}
+
+func (qr *QRCode) drawFunctionPatterns(level qrLevel) {
+	for i := 0; i < qr.Size; i++ {
+		qr.setFunction(6, i, i%2 == 0)
+		qr.setFunction(i, 6, i%2 == 0)
+	}
+
+	qr.drawFinderPattern(3, 3)
+	qr.drawFinderPattern(qr.Size-4, 3)
+	qr.drawFinderPattern(3, qr.Size-4)
+
+	pos := alignmentPositions(qr.Version)
+	n := len(pos)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			// Skip the three corners taken by finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				continue
+			}
+			qr.drawAlignmentPattern(pos[i], pos[j])
+		}
+	}
+
+	// Reserve the format areas; the real bits are drawn once the mask is chosen
+	qr.drawFormatBits(level, 0)
+	qr.drawVersion()
+}
+
+func (qr *QRCode) drawFinderPattern(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= qr.Size || y < 0 || y >= qr.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			qr.setFunction(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (qr *QRCode) drawAlignmentPattern(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			qr.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the level and mask, protected by a BCH code
+func (qr *QRCode) drawFormatBits(level qrLevel, mask int) {
+	bits := formatInfo(level, mask)
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		qr.setFunction(8, i, bit(i))
+	}
+	qr.setFunction(8, 7, bit(6))
+	qr.setFunction(8, 8, bit(7))
+	qr.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		qr.setFunction(qr.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunction(8, qr.Size-15+i, bit(i))
+	}
+	qr.setFunction(8, qr.Size-8, true) // always dark
+}
+
+// drawVersion draws both copies of the version information (version 7 and up)
+func (qr *QRCode) drawVersion() {
+	if qr.Version < 7 {
+		return
+	}
+	bits := versionInfo(qr.Version)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 != 0
+		a, b := qr.Size-11+i%3, i/3
+		qr.setFunction(a, b, dark)
+		qr.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the data in the zigzag column pairs, skipping function modules
+func (qr *QRCode) drawCodewords(data []byte) {
+	i := 0
+	for right := qr.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < qr.Size; vert++ {
+			y := vert
+			if upward {
+				y = qr.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if qr.isFunction[y][x] || i >= len(data)*8 {
+					continue
+				}
+				qr.Modules[y][x] = (data[i>>3]>>(7-i&7))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+func (qr *QRCode) applyMask(mask int) {
+	for y := 0; y < qr.Size; y++ {
+		for x := 0; x < qr.Size; x++ {
+			if qr.isFunction[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				qr.Modules[y][x] = !qr.Modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to scan, following the four rules of the standard
+func (qr *QRCode) penalty() int {
+	n := qr.Size
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return qr.Modules[x][y]
+		}
+		return qr.Modules[y][x]
+	}
+
+	score := 0
+	finderLike := [2][11]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			// Runs of five or more modules of the same colour
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// Patterns that look like a finder
+			for x := 0; x+11 <= n; x++ {
+				for _, pattern := range finderLike {
+					match := true
+					for k, dark := range pattern {
+						if at(x+k, y, vertical) != dark {
+							match = false
+							break
+						}
+					}
+					if match {
+						score += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			c := qr.Modules[y][x]
+			if c {
+				dark++
+			}
+			// 2x2 blocks of the same colour
+			if x+1 < n && y+1 < n && c == qr.Modules[y][x+1] && c == qr.Modules[y+1][x] && c == qr.Modules[y+1][x+1] {
+				score += 3
+			}
+		}
+	}
+
+	// Imbalance between dark and light modules
+	percent := dark * 100 / (n * n)
+	score += abs(percent-50) / 5 * 10
+	return score
+}
+
+// formatInfo returns the 15 format bits for a level and mask
+func formatInfo(level qrLevel, mask int) int {
+	data := formatBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionInfo returns the 18 version bits for versions 7 and up
+func versionInfo(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// alignmentPositions returns the centre coordinates of the alignment patterns
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, version*4+17-7; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// countBits is the width of the byte mode character count
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// numRawDataModules counts the modules left for data and error correction
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		n := version/7 + 2
+		result -= (25*n-10)*n - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func numDataCodewords(version int, level qrLevel) int {
+	return numRawDataModules(version)/8 - eccCodewordsPerBlock[level][version]*eccBlocks[level][version]
+}
+
+// addECCAndInterleave splits the data into blocks, appends each block's
+// Reed-Solomon codewords and interleaves the result
+func addECCAndInterleave(data []byte, version int, level qrLevel) []byte {
+	numBlocks := eccBlocks[level][version]
+	eccLen := eccCodewordsPerBlock[level][version]
+	raw := numRawDataModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := reedSolomonDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte{}, data[k:k+n]...)
+		k += n
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShort {
+			block = append(block, 0) // padding, skipped when interleaving
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, raw)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// reedSolomonDivisor returns the generator polynomial of the given degree, highest term dropped
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*bb = append(*bb, (value>>i)&1 != 0)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
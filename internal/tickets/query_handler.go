@@ -0,0 +1,141 @@
+// Query handler for e-ticket read operations (CQRS)
+
+package tickets
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hitorii/ticket-booking/internal/utils"
+	"github.com/hitorii/ticket-booking/internal/venue"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+	Admins       *venue.CommandService // admins may read anyone's tickets
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// owns checks the signed-in user is the ticket holder or an admin, aborting with the error
+// when they are not. A ticket's token admits whoever shows it, so it is never handed to
+// anyone else. It follows middleware.Authenticate.
+func (h *QueryHandler) owns(c *gin.Context, ownerID string) bool {
+	userID := c.GetString("user_id")
+	if userID != "" && userID == ownerID {
+		return true
+	}
+	err := errors.New("ticket access is not configured")
+	if h.Admins != nil {
+		err = h.Admins.Authorize(c.Request.Context(), userID, "")
+	}
+	if errors.Is(err, venue.ErrForbidden) {
+		err = fmt.Errorf("%w: tickets are only shown to their holder", err)
+	}
+	if err != nil {
+		writeAccessError(c, err)
+		return false
+	}
+	return true
+}
+
+// GetTicket - Query handler for an e-ticket as a QR code PNG (default) or JSON
+func (h *QueryHandler) GetTicket(c *gin.Context) {
+	format := c.DefaultQuery("format", FormatPNG)
+	if format != FormatPNG && format != FormatJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be png or json"})
+		return
+	}
+	id := c.Param("id")
+	if err := utils.ValidateUUID("ticket_id", id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t, err := h.QueryService.GetTicket(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, ErrTicketNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get ticket: " + err.Error()})
+		return
+	}
+	if !h.owns(c, t.UserID) {
+		return
+	}
+
+	if format == FormatJSON {
+		c.JSON(http.StatusOK, t)
+		return
+	}
+	img, err := h.QueryService.RenderQR(t)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render ticket: " + err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="ticket-`+t.ID+`.png"`)
+	c.Data(http.StatusOK, "image/png", img)
+}
+
+// GetBookingTicket - Query handler for the e-ticket of a booking
+func (h *QueryHandler) GetBookingTicket(c *gin.Context) {
+	t, err := h.QueryService.GetBookingTicket(c.Request.Context(), c.Param("bookingID"))
+	if err != nil {
+		if errors.Is(err, ErrTicketNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get ticket: " + err.Error()})
+		return
+	}
+	if !h.owns(c, t.UserID) {
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// GetUserTickets - Query handler for a user's e-tickets
+func (h *QueryHandler) GetUserTickets(c *gin.Context) {
+	userID := c.Param("id")
+	if err := utils.ValidateUUID("user_id", userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.owns(c, userID) {
+		return
+	}
+
+	tickets, err := h.QueryService.GetUserTickets(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tickets: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tickets)
+}
+
+// GetShowCheckIns - Query handler for a show's admissions
+func (h *QueryHandler) GetShowCheckIns(c *gin.Context) {
+	showID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || showID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show id"})
+		return
+	}
+
+	checkIns, err := h.QueryService.GetShowCheckIns(c.Request.Context(), showID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get check-ins: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, checkIns)
+}
+
+// GetPublicKeys - Query handler for the public keys gate scanners cache to verify tickets offline
+func (h *QueryHandler) GetPublicKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"keys": h.QueryService.GetPublicKeys()})
+}
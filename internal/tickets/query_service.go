@@ -0,0 +1,58 @@
+// Query service for e-ticket read operations (CQRS)
+
+package tickets
+
+import (
+	"context"
+)
+
+// qrScale is the pixels per module of the rendered QR code
+const qrScale = 6
+
+type QueryService struct {
+	Repo *Repository
+	Keys *Keyring
+}
+
+func NewQueryService(repo *Repository, keys *Keyring) *QueryService {
+	return &QueryService{Repo: repo, Keys: keys}
+}
+
+// GetTicket - Query to get an e-ticket by ID
+func (s *QueryService) GetTicket(ctx context.Context, id string) (*Ticket, error) {
+	return s.Repo.GetByID(ctx, id)
+}
+
+// GetBookingTicket - Query to get the e-ticket issued for a booking
+func (s *QueryService) GetBookingTicket(ctx context.Context, bookingID string) (*Ticket, error) {
+	return s.Repo.GetByBookingID(ctx, bookingID)
+}
+
+// GetUserTickets - Query to list a user's e-tickets
+func (s *QueryService) GetUserTickets(ctx context.Context, userID string) ([]Ticket, error) {
+	return s.Repo.GetByUser(ctx, userID)
+}
+
+// RenderQR renders a ticket's token as a QR code PNG
+func (s *QueryService) RenderQR(t *Ticket) ([]byte, error) {
+	qr, err := EncodeQR([]byte(t.Token))
+	if err != nil {
+		return nil, err
+	}
+	return qr.PNG(qrScale)
+}
+
+// GetPublicKeys - Query to get the keys gate scanners verify tokens with
+func (s *QueryService) GetPublicKeys() []PublicKey {
+	return s.Keys.PublicKeys()
+}
+
+// GetShowCheckIns - Query to list who has been let in to a show. Scanners load it before
+// going offline so they can refuse tickets already used at other gates.
+func (s *QueryService) GetShowCheckIns(ctx context.Context, showID int) (*ShowCheckIns, error) {
+	checkIns, err := s.Repo.GetCheckInsForShow(ctx, showID)
+	if err != nil {
+		return nil, err
+	}
+	return &ShowCheckIns{ShowID: showID, CheckedIn: len(checkIns), CheckIns: checkIns}, nil
+}
@@ -0,0 +1,251 @@
+package tickets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/venue"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository stores tickets in the Command DB. Show times are given in the venue's zone,
+// from Zones.
+type Repository struct {
+	DB    *pgxpool.Pool
+	Zones *venue.QueryService
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+const ticketColumns = `
+	t.id, t.reservation_id, t.user_id, t.show_id, t.seat_id, COALESCE(t.seat_label, ''),
+	sh.start_time, t.key_id, t.token, t.expires_at, t.created_at, COALESCE(sh.theater, '')`
+
+func (r *Repository) scanTicket(ctx context.Context, row pgx.Row) (*Ticket, error) {
+	var t Ticket
+	var showID *int
+	var theater string
+	err := row.Scan(&t.ID, &t.BookingID, &t.UserID, &showID, &t.SeatID, &t.SeatLabel,
+		&t.ShowTime, &t.KeyID, &t.Token, &t.ExpiresAt, &t.CreatedAt, &theater)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTicketNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if showID != nil {
+		t.ShowID = *showID
+	}
+	t.ShowTime, err = r.showTime(ctx, theater, t.ShowTime)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// showTime gives a show's start in its venue's zone
+func (r *Repository) showTime(ctx context.Context, theater string, start *time.Time) (*time.Time, error) {
+	if start == nil {
+		return nil, nil
+	}
+	loc, err := r.Zones.Location(ctx, theater)
+	if err != nil {
+		return nil, err
+	}
+	local := start.In(loc)
+	return &local, nil
+}
+
+// GetBooking loads a reservation with its seat and show
+func (r *Repository) GetBooking(ctx context.Context, bookingID string) (*booking, error) {
+	var b booking
+	var theater string
+	err := r.DB.QueryRow(ctx, `
+		SELECT res.id, res.user_id, res.seat_id, res.status, s.show_id, COALESCE(s.label, ''),
+		       sh.start_time, sh.end_time, COALESCE(sh.theater, '')
+		FROM reservations res
+		LEFT JOIN seats s ON s.id = res.seat_id
+		LEFT JOIN shows sh ON sh.id = s.show_id
+		WHERE res.id = $1
+	`, bookingID).Scan(&b.ID, &b.UserID, &b.SeatID, &b.Status, &b.ShowID, &b.SeatLabel, &b.StartTime, &b.EndTime, &theater)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTicketNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if b.StartTime, err = r.showTime(ctx, theater, b.StartTime); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Save stores a ticket unless the booking already has one; it reports whether it was stored
+func (r *Repository) Save(ctx context.Context, t *Ticket) (bool, error) {
+	var showID *int
+	if t.ShowID != 0 {
+		showID = &t.ShowID
+	}
+	res, err := r.DB.Exec(ctx, `
+		INSERT INTO tickets (id, reservation_id, user_id, show_id, seat_id, seat_label, key_id, token, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10)
+		ON CONFLICT (reservation_id) DO NOTHING
+	`, t.ID, t.BookingID, t.UserID, showID, t.SeatID, t.SeatLabel, t.KeyID, t.Token, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+// ShowVenue returns the venue a show is in
+func (r *Repository) ShowVenue(ctx context.Context, showID int) (string, error) {
+	var theater string
+	err := r.DB.QueryRow(ctx, `SELECT theater FROM shows WHERE id = $1`, showID).Scan(&theater)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrShowNotFound
+	}
+	return theater, err
+}
+
+// GetByID returns a ticket by its ID
+func (r *Repository) GetByID(ctx context.Context, id string) (*Ticket, error) {
+	return r.scanTicket(ctx, r.DB.QueryRow(ctx, `
+		SELECT `+ticketColumns+`
+		FROM tickets t LEFT JOIN shows sh ON sh.id = t.show_id
+		WHERE t.id = $1
+	`, id))
+}
+
+// GetByBookingID returns the ticket issued for a booking
+func (r *Repository) GetByBookingID(ctx context.Context, bookingID string) (*Ticket, error) {
+	return r.scanTicket(ctx, r.DB.QueryRow(ctx, `
+		SELECT `+ticketColumns+`
+		FROM tickets t LEFT JOIN shows sh ON sh.id = t.show_id
+		WHERE t.reservation_id = $1
+	`, bookingID))
+}
+
+// GetByUser lists a user's tickets, soonest show first
+func (r *Repository) GetByUser(ctx context.Context, userID string) ([]Ticket, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+ticketColumns+`
+		FROM tickets t LEFT JOIN shows sh ON sh.id = t.show_id
+		WHERE t.user_id = $1
+		ORDER BY t.expires_at, t.seat_label
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []Ticket{}
+	for rows.Next() {
+		t, err := r.scanTicket(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, *t)
+	}
+	return tickets, rows.Err()
+}
+
+const checkInColumns = `
+	id, ticket_id, reservation_id, show_id, seat_id, COALESCE(seat_label, ''),
+	COALESCE(gate, ''), COALESCE(scanner_id, ''), scanned_at, offline, created_at`
+
+func scanCheckIn(row pgx.Row) (*CheckIn, error) {
+	var c CheckIn
+	var showID *int
+	err := row.Scan(&c.ID, &c.TicketID, &c.BookingID, &showID, &c.SeatID, &c.SeatLabel,
+		&c.Gate, &c.ScannerID, &c.ScannedAt, &c.Offline, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if showID != nil {
+		c.ShowID = *showID
+	}
+	return &c, nil
+}
+
+// CheckIn records an admission. The reservation is locked so a concurrent cancellation
+// can't slip in between the checks and the insert; if the ticket was already let in, the
+// earlier check-in is returned with ErrAlreadyCheckedIn.
+func (r *Repository) CheckIn(ctx context.Context, c *CheckIn) (*CheckIn, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var status, seatID string
+	err = tx.QueryRow(ctx, "SELECT status, seat_id FROM reservations WHERE id = $1 FOR UPDATE", c.BookingID).Scan(&status, &seatID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: booking %s was cancelled", ErrNotBooked, c.BookingID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != "BOOKED" {
+		return nil, fmt.Errorf("%w: booking %s is %s", ErrNotBooked, c.BookingID, status)
+	}
+	if seatID != c.SeatID {
+		return nil, fmt.Errorf("%w: seat does not match the booking", ErrInvalidToken)
+	}
+
+	var issued bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM tickets WHERE id = $1 AND reservation_id = $2)", c.TicketID, c.BookingID).Scan(&issued)
+	if err != nil {
+		return nil, err
+	}
+	if !issued {
+		return nil, ErrTicketNotFound
+	}
+
+	existing, err := scanCheckIn(tx.QueryRow(ctx, "SELECT "+checkInColumns+" FROM ticket_checkins WHERE ticket_id = $1", c.TicketID))
+	if err == nil {
+		return existing, ErrAlreadyCheckedIn
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	var showID *int
+	if c.ShowID != 0 {
+		showID = &c.ShowID
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO ticket_checkins (id, ticket_id, reservation_id, show_id, seat_id, seat_label, gate, scanner_id, scanned_at, offline, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11)
+	`, c.ID, c.TicketID, c.BookingID, showID, c.SeatID, c.SeatLabel, c.Gate, c.ScannerID, c.ScannedAt, c.Offline, c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCheckInsForShow lists a show's admissions in the order they happened
+func (r *Repository) GetCheckInsForShow(ctx context.Context, showID int) ([]CheckIn, error) {
+	rows, err := r.DB.Query(ctx, "SELECT "+checkInColumns+" FROM ticket_checkins WHERE show_id = $1 ORDER BY scanned_at", showID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkIns := []CheckIn{}
+	for rows.Next() {
+		c, err := scanCheckIn(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkIns = append(checkIns, *c)
+	}
+	return checkIns, rows.Err()
+}
@@ -0,0 +1,33 @@
+// Event subscriber that issues e-tickets for confirmed bookings
+
+package tickets
+
+import (
+	"context"
+	"log"
+
+	"github.com/hitorii/ticket-booking/internal/events"
+)
+
+// Subscribe registers the ticket handler on the dispatcher
+func (s *CommandService) Subscribe(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(events.EventTicketConfirmed, s.onTicketConfirmed)
+}
+
+// onTicketConfirmed issues the e-ticket once a seat is booked
+func (s *CommandService) onTicketConfirmed(event events.BaseEvent) error {
+	payload, err := events.DecodePayload(event)
+	if err != nil {
+		return err
+	}
+	if payload.BookingID == "" {
+		return nil
+	}
+
+	t, err := s.Issue(context.Background(), payload.BookingID)
+	if err != nil {
+		return err
+	}
+	log.Printf("🎟️ Issued ticket %s for booking %s (key %s)", t.ID, t.BookingID, t.KeyID)
+	return nil
+}
@@ -0,0 +1,556 @@
+package tickets
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hitorii/ticket-booking/internal/utils"
+	"github.com/hitorii/ticket-booking/internal/venue"
+)
+
+func testSeed(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, ed25519.SeedSize))
+}
+
+func sampleClaims(expires time.Time) Claims {
+	return Claims{
+		BookingID: "9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a",
+		ShowID:    42,
+		SeatID:    "0a1b2c3d-4e5f-4a6b-8c7d-8e9f0a1b2c3d",
+		SeatLabel: "F12",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d",
+			IssuedAt:  jwt.NewNumericDate(expires.Add(-24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	}
+}
+
+// TestParseKeyring tests loading signing keys from configuration
+func TestParseKeyring(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		active     string
+		wantActive string
+		wantErr    bool
+	}{
+		{"single key", "k1:" + testSeed(1), "", "k1", false},
+		{"last key is active by default", "k1:" + testSeed(1) + ", k2:" + testSeed(2), "", "k2", false},
+		{"explicit active key", "k1:" + testSeed(1) + ",k2:" + testSeed(2), "k1", "k1", false},
+		{"active key not on ring", "k1:" + testSeed(1), "k9", "", true},
+		{"no keys", "", "", "", true},
+		{"missing kid", testSeed(1), "", "", true},
+		{"short seed", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), "", "", true},
+		{"duplicate kid", "k1:" + testSeed(1) + ",k1:" + testSeed(2), "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kr, err := ParseKeyring(tt.spec, tt.active)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKey) {
+					t.Fatalf("expected ErrInvalidKey, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if kr.ActiveKeyID() != tt.wantActive {
+				t.Errorf("active key = %s, want %s", kr.ActiveKeyID(), tt.wantActive)
+			}
+		})
+	}
+}
+
+// TestTokenVerification tests signing and offline verification, including key rotation
+func TestTokenVerification(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(6 * time.Hour)
+
+	oldRing, _ := ParseKeyring("k1:"+testSeed(1), "")
+	rotated, _ := ParseKeyring("k1:"+testSeed(1)+",k2:"+testSeed(2), "k2")
+	retired, _ := ParseKeyring("k2:"+testSeed(2), "")
+
+	oldToken, err := oldRing.Sign(sampleClaims(expires))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	newToken, _ := rotated.Sign(sampleClaims(expires))
+	expired, _ := rotated.Sign(sampleClaims(now.Add(-time.Minute)))
+
+	// The scanner only has the published public keys
+	verifier := func(kr *Keyring) *Verifier {
+		v, err := NewVerifier(kr.PublicKeys())
+		if err != nil {
+			t.Fatalf("verifier: %v", err)
+		}
+		v.Now = func() time.Time { return now }
+		return v
+	}
+
+	parts := strings.Split(newToken, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	forged := parts[0] + "." + base64.RawURLEncoding.EncodeToString(bytes.Replace(payload, []byte("F12"), []byte("A01"), 1)) + "." + parts[2]
+
+	tests := []struct {
+		name    string
+		ring    *Keyring
+		token   string
+		wantErr error
+	}{
+		{"signed with active key", rotated, newToken, nil},
+		{"signed before rotation", rotated, oldToken, nil},
+		{"key retired", retired, oldToken, ErrUnknownKey},
+		{"expired", rotated, expired, ErrTokenExpired},
+		{"tampered seat", rotated, forged, ErrInvalidToken},
+		{"garbage", rotated, "not-a-token", ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier(tt.ring).Verify(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if claims.BookingID != "9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a" || claims.ShowID != 42 || claims.SeatLabel != "F12" {
+				t.Errorf("unexpected claims: %+v", claims)
+			}
+		})
+	}
+}
+
+// TestTicketExpiry tests when tickets stop scanning
+func TestTicketExpiry(t *testing.T) {
+	issued := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2026, 10, 20, 18, 0, 0, 0, time.UTC)
+	end := start.Add(3 * time.Hour)
+
+	tests := []struct {
+		name string
+		b    booking
+		want time.Time
+	}{
+		{"show end plus grace", booking{StartTime: &start, EndTime: &end}, end.Add(time.Hour)},
+		{"show start plus grace", booking{StartTime: &start}, start.Add(time.Hour)},
+		{"unknown show", booking{}, issued.Add(48 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.b.expiry(issued, time.Hour, 48*time.Hour); !got.Equal(tt.want) {
+				t.Errorf("expiry = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReedSolomon tests error correction against the worked 1-M "HELLO WORLD" example
+func TestReedSolomon(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := reedSolomonRemainder(data, reedSolomonDivisor(len(want)))
+	if !bytes.Equal(got, want) {
+		t.Errorf("ecc = %v, want %v", got, want)
+	}
+}
+
+// TestQRTables tests format and version information and symbol layout
+func TestQRTables(t *testing.T) {
+	tests := []struct {
+		name string
+		got  int
+		want int
+	}{
+		{"format M mask 0", formatInfo(qrMedium, 0), 0b101010000010010},
+		{"format L mask 0", formatInfo(qrLow, 0), 0b111011111000100},
+		{"format M mask 5", formatInfo(qrMedium, 5), 0b100000011001110},
+		{"version 7", versionInfo(7), 0x07C94},
+		{"version 40", versionInfo(40), 0x28C69},
+		{"1-M data codewords", numDataCodewords(1, qrMedium), 16},
+		{"10-M data codewords", numDataCodewords(10, qrMedium), 216},
+		{"40-L data codewords", numDataCodewords(40, qrLow), 2956},
+		{"40-M data codewords", numDataCodewords(40, qrMedium), 2334},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %b, want %b", tt.got, tt.want)
+			}
+		})
+	}
+
+	for version, want := range map[int][]int{2: {6, 18}, 7: {6, 22, 38}, 32: {6, 34, 60, 86, 112, 138}} {
+		got := alignmentPositions(version)
+		if len(got) != len(want) {
+			t.Errorf("version %d alignment = %v, want %v", version, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("version %d alignment = %v, want %v", version, got, want)
+				break
+			}
+		}
+	}
+}
+
+// TestEncodeQR tests version selection and reads the data back out of the symbol
+func TestEncodeQR(t *testing.T) {
+	kr, _ := ParseKeyring("k1:"+testSeed(1), "")
+	token, _ := kr.Sign(sampleClaims(time.Date(2026, 10, 20, 23, 0, 0, 0, time.UTC)))
+
+	tests := []struct {
+		name        string
+		data        string
+		wantVersion int
+	}{
+		{"fits version 1", "ABCDEFGHIJKLMN", 1},
+		{"spills to version 2", "ABCDEFGHIJKLMNO", 2},
+		{"version 10 limit", strings.Repeat("x", 213), 10},
+		{"ticket token", token, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qr, err := EncodeQR([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if tt.wantVersion != 0 && qr.Version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", qr.Version, tt.wantVersion)
+			}
+			if qr.Size != qr.Version*4+17 {
+				t.Errorf("size = %d for version %d", qr.Size, qr.Version)
+			}
+			if got := readQR(t, qr); got != tt.data {
+				t.Errorf("decoded %q, want %q", got, tt.data)
+			}
+		})
+	}
+
+	if _, err := EncodeQR(make([]byte, 3000)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected ErrTooLong, got %v", err)
+	}
+}
+
+// TestQRPNG tests the rendered image
+func TestQRPNG(t *testing.T) {
+	qr, _ := EncodeQR([]byte("ticket"))
+	data, err := qr.PNG(4)
+	if err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if dim := (qr.Size + 8) * 4; img.Bounds().Dx() != dim || img.Bounds().Dy() != dim {
+		t.Errorf("bounds = %v, want %dx%d", img.Bounds(), dim, dim)
+	}
+
+	// Quiet zone is light, the finder's corner is dark
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("expected light quiet zone")
+	}
+	if r, _, _, _ := img.At(16, 16).RGBA(); r != 0 {
+		t.Error("expected dark finder pattern")
+	}
+}
+
+// readQR undoes the encoding: it reads the format bits, unmasks, collects the codewords,
+// checks every block's error correction and returns the byte mode payload
+func readQR(t *testing.T, qr *QRCode) string {
+	t.Helper()
+	level := qrMedium
+
+	format := 0
+	for i := 14; i >= 9; i-- {
+		format = format<<1 | bit(qr.Modules[8][14-i])
+	}
+	format = format<<1 | bit(qr.Modules[8][7])
+	format = format<<1 | bit(qr.Modules[8][8])
+	format = format<<1 | bit(qr.Modules[7][8])
+	for i := 5; i >= 0; i-- {
+		format = format<<1 | bit(qr.Modules[i][8])
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatInfo(level, m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("unreadable format bits %015b", format)
+	}
+
+	// Rebuild the function pattern map and unmask a copy of the symbol
+	ref := newQRCode(qr.Version)
+	ref.drawFunctionPatterns(level)
+	for y := range qr.Modules {
+		copy(ref.Modules[y], qr.Modules[y])
+	}
+	ref.applyMask(mask)
+
+	var raw []byte
+	var cur byte
+	n := 0
+	for right := ref.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < ref.Size; vert++ {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = ref.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if ref.isFunction[y][right-j] {
+					continue
+				}
+				cur = cur<<1 | byte(bit(ref.Modules[y][right-j]))
+				if n++; n%8 == 0 {
+					raw = append(raw, cur)
+				}
+			}
+		}
+	}
+	raw = raw[:numRawDataModules(qr.Version)/8]
+
+	// De-interleave and check each block
+	numBlocks := eccBlocks[level][qr.Version]
+	eccLen := eccCodewordsPerBlock[level][qr.Version]
+	numShort := numBlocks - len(raw)%numBlocks
+	shortLen := len(raw) / numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := 0; i <= shortLen; i++ {
+		for j := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				blocks[j] = append(blocks[j], raw[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	divisor := reedSolomonDivisor(eccLen)
+	for i, block := range blocks {
+		split := len(block) - eccLen
+		if !bytes.Equal(reedSolomonRemainder(block[:split], divisor), block[split:]) {
+			t.Fatalf("block %d fails error correction", i)
+		}
+		data = append(data, block[:split]...)
+	}
+
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("mode = %x, want byte mode", data[0]>>4)
+	}
+	var bits bitBuffer
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	read := func(from, length int) int {
+		v := 0
+		for _, b := range bits[from : from+length] {
+			v = v<<1 | bit(b)
+		}
+		return v
+	}
+	count := read(4, countBits(qr.Version))
+	out := make([]byte, count)
+	for i := range out {
+		out[i] = byte(read(4+countBits(qr.Version)+8*i, 8))
+	}
+	return string(out)
+}
+
+func bit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// TestScanOutcome tests how check-in errors are reported to scanners
+func TestScanOutcome(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"admitted", nil, ScanAdmitted},
+		{"already used", ErrAlreadyCheckedIn, ScanDuplicate},
+		{"bad signature", fmt.Errorf("%w: signature is invalid", ErrInvalidToken), ScanRejected},
+		{"wrong show", fmt.Errorf("%w: ticket is for show 7", ErrWrongShow), ScanRejected},
+		{"cancelled booking", fmt.Errorf("%w: booking was cancelled", ErrNotBooked), ScanRejected},
+		{"unknown ticket", ErrTicketNotFound, ScanRejected},
+		{"busy at another gate", fmt.Errorf("%w: ticket is being scanned", utils.ErrLockNotAcquired), ScanRetry},
+		{"database down", errors.New("connection refused"), ScanRetry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := scanOutcome(tt.err); got != tt.want {
+				t.Errorf("status = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckInValidation tests the checks made before the database is consulted
+func TestCheckInValidation(t *testing.T) {
+	kr, _ := ParseKeyring("k1:"+testSeed(1), "")
+	other, _ := ParseKeyring("k9:"+testSeed(9), "")
+	s := NewCommandService(nil, kr, time.Hour, time.Hour)
+	now := time.Now()
+
+	valid, _ := kr.Sign(sampleClaims(now.Add(time.Hour)))
+	foreign, _ := other.Sign(sampleClaims(now.Add(time.Hour)))
+	expired, _ := kr.Sign(sampleClaims(now.Add(-time.Hour)))
+
+	tests := []struct {
+		name    string
+		req     CheckInRequest
+		wantErr error
+	}{
+		{"missing show", CheckInRequest{Token: valid}, ErrInvalidCheckIn},
+		{"missing token", CheckInRequest{ShowID: 42}, ErrInvalidCheckIn},
+		{"wrong show", CheckInRequest{Token: valid, ShowID: 7}, ErrWrongShow},
+		{"unknown key", CheckInRequest{Token: foreign, ShowID: 42}, ErrUnknownKey},
+		{"expired", CheckInRequest{Token: expired, ShowID: 42}, ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.CheckIn(context.Background(), tt.req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestSyncOfflineScans tests that offline scans are judged as of when they were made
+func TestSyncOfflineScans(t *testing.T) {
+	kr, _ := ParseKeyring("k1:"+testSeed(1), "")
+	s := NewCommandService(nil, kr, time.Hour, time.Hour)
+	now := time.Now()
+
+	// Expired by now, but still valid when it was scanned; it fails only on the show check
+	lapsed, _ := kr.Sign(sampleClaims(now.Add(-time.Hour)))
+	stale, _ := kr.Sign(sampleClaims(now.Add(-3 * time.Hour)))
+
+	if _, err := s.SyncOfflineScans(context.Background(), SyncRequest{ShowID: 42}); !errors.Is(err, ErrInvalidCheckIn) {
+		t.Errorf("expected ErrInvalidCheckIn for an empty sync, got %v", err)
+	}
+	if _, err := s.SyncOfflineScans(context.Background(), SyncRequest{ShowID: 42, Scans: make([]OfflineScan, MaxSyncScans+1)}); !errors.Is(err, ErrInvalidCheckIn) {
+		t.Errorf("expected ErrInvalidCheckIn for an oversized sync, got %v", err)
+	}
+
+	result, err := s.SyncOfflineScans(context.Background(), SyncRequest{
+		ShowID: 7,
+		Gate:   "North",
+		Scans: []OfflineScan{
+			{Token: lapsed, ScannedAt: now.Add(-2 * time.Hour)},
+			{Token: stale, ScannedAt: now.Add(-2 * time.Hour)},
+			{Token: lapsed, ScannedAt: now.Add(time.Hour)},
+			{Token: lapsed},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Rejected != 4 || len(result.Results) != 4 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	// Results come back in scan order; scans without a time sort first
+	wantReasons := []string{"scanned_at is required", "different show", "expired", "in the future"}
+	for i, want := range wantReasons {
+		if !strings.Contains(result.Results[i].Reason, want) {
+			t.Errorf("result %d reason = %q, want it to mention %q", i, result.Results[i].Reason, want)
+		}
+	}
+}
+
+// TestQueryHandler_HolderAccess tests that tickets are refused to anyone but their holder
+func TestQueryHandler_HolderAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewQueryHandler(NewQueryService(nil, nil))
+	h.Admins = venue.NewCommandService(nil, nil)
+	holder := "3c1d2e4f-5a6b-4c7d-8e9f-0a1b2c3d4e5f"
+
+	tests := []struct {
+		name   string
+		userID string
+		want   int
+	}{
+		{"signed out", "", http.StatusUnauthorized},
+		{"someone else", "not-an-admin", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/users/:id/tickets", func(c *gin.Context) {
+				if tt.userID != "" {
+					c.Set("user_id", tt.userID)
+				}
+			}, h.GetUserTickets)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/"+holder+"/tickets", nil))
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestCommandHandler_ScannerAccess tests that scans are only taken from staff of the show's venue
+func TestCommandHandler_ScannerAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	kr, _ := ParseKeyring("k1:"+testSeed(1), "")
+	h := NewCommandHandler(NewCommandService(nil, kr, time.Hour, time.Hour))
+
+	tests := []struct {
+		name    string
+		body    string
+		handler gin.HandlerFunc
+		want    int
+	}{
+		{"check-in without managers", `{"token":"t","show_id":42}`, h.CheckIn, http.StatusInternalServerError},
+		{"sync without managers", `{"show_id":42,"scans":[{"token":"t"}]}`, h.SyncOfflineScans, http.StatusInternalServerError},
+		{"check-in without show", `{"token":"t"}`, h.CheckIn, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/checkin", tt.handler)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/checkin", strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
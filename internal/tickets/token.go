@@ -0,0 +1,181 @@
+// Signed e-ticket tokens and the rotatable keys that sign them
+
+package tickets
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims is what a ticket token proves: this seat of this booking gets into this show until ExpiresAt.
+// The ticket ID is the token's jti.
+type Claims struct {
+	BookingID string `json:"bid"`
+	ShowID    int    `json:"show,omitempty"`
+	SeatID    string `json:"seat"`
+	SeatLabel string `json:"lbl,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// PublicKey is a verification key published to gate scanners
+type PublicKey struct {
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Key       string `json:"key"` // base64url, unpadded
+	Active    bool   `json:"active"`
+}
+
+// Keyring holds the Ed25519 keys tickets are signed with. New tickets are signed with the
+// active key; tickets signed with any other key on the ring keep verifying, so a key is rotated
+// by adding a new one, making it active and dropping the old one once its tickets have expired.
+type Keyring struct {
+	active  string
+	private map[string]ed25519.PrivateKey
+}
+
+// ParseKeyring reads keys in the form "kid:seed,kid:seed" where each seed is a base64
+// 32-byte Ed25519 seed. An empty active key selects the last key listed.
+func ParseKeyring(spec, active string) (*Keyring, error) {
+	kr := &Keyring{private: map[string]ed25519.PrivateKey{}}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, encoded, ok := strings.Cut(entry, ":")
+		if !ok || kid == "" {
+			return nil, fmt.Errorf("%w: expected kid:seed", ErrInvalidKey)
+		}
+		seed, err := decodeKey(encoded)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("%w: key %s must be a base64 %d-byte seed", ErrInvalidKey, kid, ed25519.SeedSize)
+		}
+		if _, dup := kr.private[kid]; dup {
+			return nil, fmt.Errorf("%w: duplicate key %s", ErrInvalidKey, kid)
+		}
+		kr.private[kid] = ed25519.NewKeyFromSeed(seed)
+		if active == "" {
+			kr.active = kid
+		}
+	}
+	if len(kr.private) == 0 {
+		return nil, fmt.Errorf("%w: no signing keys", ErrInvalidKey)
+	}
+	if active != "" {
+		if _, ok := kr.private[active]; !ok {
+			return nil, fmt.Errorf("%w: active key %s is not on the ring", ErrInvalidKey, active)
+		}
+		kr.active = active
+	}
+	return kr, nil
+}
+
+// NewEphemeralKeyring creates a ring with one random key. Tickets it signs stop
+// verifying when the process restarts, so it is only meant for development.
+func NewEphemeralKeyring() (*Keyring, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kid := "dev-" + time.Now().UTC().Format("20060102150405")
+	return &Keyring{active: kid, private: map[string]ed25519.PrivateKey{kid: priv}}, nil
+}
+
+// ActiveKeyID is the key new tickets are signed with
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// Sign issues a token for the claims with the active key
+func (k *Keyring) Sign(claims Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = k.active
+	return token.SignedString(k.private[k.active])
+}
+
+// PublicKeys lists every key on the ring for scanners to verify against
+func (k *Keyring) PublicKeys() []PublicKey {
+	keys := make([]PublicKey, 0, len(k.private))
+	for kid, priv := range k.private {
+		keys = append(keys, PublicKey{
+			KeyID:     kid,
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			Key:       base64.RawURLEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)),
+			Active:    kid == k.active,
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+	return keys
+}
+
+// Verifier checks ticket tokens against published public keys. It needs no
+// database or network, so a gate scanner can run it offline.
+type Verifier struct {
+	Keys map[string]ed25519.PublicKey
+	Now  func() time.Time
+}
+
+// NewVerifier builds a verifier from the keys served by the ticket keys endpoint
+func NewVerifier(keys []PublicKey) (*Verifier, error) {
+	v := &Verifier{Keys: map[string]ed25519.PublicKey{}}
+	for _, key := range keys {
+		raw, err := decodeKey(key.Key)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: public key %s", ErrInvalidKey, key.KeyID)
+		}
+		v.Keys[key.KeyID] = ed25519.PublicKey(raw)
+	}
+	return v, nil
+}
+
+// Verifier returns a verifier for the ring's own keys
+func (k *Keyring) Verifier() *Verifier {
+	v := &Verifier{Keys: map[string]ed25519.PublicKey{}}
+	for kid, priv := range k.private {
+		v.Keys[kid] = priv.Public().(ed25519.PublicKey)
+	}
+	return v
+}
+
+// Verify checks the token's signature and expiry and returns its claims
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}), jwt.WithExpirationRequired()}
+	if v.Now != nil {
+		opts = append(opts, jwt.WithTimeFunc(v.Now))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := v.Keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+		}
+		return key, nil
+	}, opts...)
+	switch {
+	case err == nil:
+		return claims, nil
+	case errors.Is(err, ErrUnknownKey):
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, ErrUnknownKey)
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, ErrTokenExpired)
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+}
+
+// decodeKey accepts standard or URL-safe base64, padded or not
+func decodeKey(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+	return base64.RawURLEncoding.DecodeString(s)
+}
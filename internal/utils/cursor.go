@@ -0,0 +1,61 @@
+// Opaque cursors for keyset pagination
+
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// Page sizes for paginated queries
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidLimit  = errors.New("limit must be a positive number")
+)
+
+// Cursor records the last row of a page: the sort it was fetched with, that row's sort
+// key rendered as text, and its ID to break ties. The next page starts after it.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Encode renders the cursor as an opaque URL-safe token
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a token made by Encode. The cursor must come from a query with the
+// same sort, since the key it holds means nothing under another one.
+func DecodeCursor(token, sort string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, &c) != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.Sort != sort {
+		return Cursor{}, errors.Join(ErrInvalidCursor, errors.New("cursor was made for a different sort"))
+	}
+	return c, nil
+}
+
+// ParseLimit reads a page size, defaulting when empty and capping at MaxPageSize
+func ParseLimit(s string) (int, error) {
+	if s == "" {
+		return DefaultPageSize, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, ErrInvalidLimit
+	}
+	return min(n, MaxPageSize), nil
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	c := Cursor{Sort: "-release_date", Value: "2010-07-16", ID: 42}
+
+	got, err := DecodeCursor(c.Encode(), "-release_date")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got != c {
+		t.Errorf("Expected %+v, got %+v", c, got)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		sort  string
+	}{
+		{"not base64", "!!!", "title"},
+		{"not json", "bm90IGpzb24", "title"},
+		{"other sort", Cursor{Sort: "title", Value: "a", ID: 1}.Encode(), "-title"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.token, tt.sort); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("Expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"", DefaultPageSize, false},
+		{"5", 5, false},
+		{"1000", MaxPageSize, false},
+		{"0", 0, true},
+		{"-3", 0, true},
+		{"ten", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseLimit(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
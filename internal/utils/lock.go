@@ -2,12 +2,16 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrLockNotAcquired is returned when the lock is held by someone else
+var ErrLockNotAcquired = errors.New("could not acquire lock")
+
 // DistributedLock provides Redis-based distributed locking
 type DistributedLock struct {
 	redisClient *redis.Client
@@ -106,3 +110,34 @@ func (dl *DistributedLock) WithSeatLockAndCommit(ctx context.Context, seatID str
 	return commitErr
 }
 
+// AcquireTicketLock acquires a lock for checking in a specific ticket
+func (dl *DistributedLock) AcquireTicketLock(ctx context.Context, ticketID string) (bool, error) {
+	key := fmt.Sprintf("lock:ticket:%s", ticketID)
+	return dl.AcquireLock(ctx, key, 10) // 10 seconds TTL
+}
+
+// ReleaseTicketLock releases a lock for a specific ticket
+func (dl *DistributedLock) ReleaseTicketLock(ctx context.Context, ticketID string) error {
+	key := fmt.Sprintf("lock:ticket:%s", ticketID)
+	return dl.ReleaseLock(ctx, key)
+}
+
+// WithTicketLock runs fn while holding the ticket's lock, so the same ticket scanned at
+// two gates at once is only admitted by one of them
+func (dl *DistributedLock) WithTicketLock(ctx context.Context, ticketID string, fn func() error) error {
+	locked, err := dl.AcquireTicketLock(ctx, ticketID)
+	if err != nil {
+		return fmt.Errorf("failed to acquire ticket lock: %w", err)
+	}
+	if !locked {
+		return fmt.Errorf("%w: ticket %s is being scanned at another gate", ErrLockNotAcquired, ticketID)
+	}
+
+	defer func() {
+		if err := dl.ReleaseTicketLock(ctx, ticketID); err != nil {
+			fmt.Printf("Warning: failed to release ticket lock: %v\n", err)
+		}
+	}()
+
+	return fn()
+}
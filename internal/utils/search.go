@@ -0,0 +1,21 @@
+// Helpers for Postgres full-text search
+
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PrefixQuery turns free text into a to_tsquery expression matching every word as a
+// prefix, so "dark kni" finds "The Dark Knight". Punctuation is dropped, which also keeps
+// tsquery operators in the input from being interpreted. It returns "" when no words remain.
+func PrefixQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
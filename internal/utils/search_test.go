@@ -0,0 +1,26 @@
+package utils
+
+import "testing"
+
+func TestPrefixQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Dark Kni", "dark:* & kni:*"},
+		{"  spider-man: no way home ", "spider:* & man:* & no:* & way:* & home:*"},
+		{"amélie", "amélie:*"},
+		{"a & b | !c", "a:* & b:* & c:*"},
+		{"'); DROP TABLE movies; --", "drop:* & table:* & movies:*"},
+		{"?!", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := PrefixQuery(tt.in); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Command handler for cities, venues, screens and venue settings (CQRS)
+
+package venue
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommandHandler struct {
+	CommandService *CommandService
+}
+
+func NewCommandHandler(cs *CommandService) *CommandHandler {
+	return &CommandHandler{CommandService: cs}
+}
+
+// RequireAdmin - Middleware letting only admins through. It follows middleware.Authenticate,
+// which sets the signed-in user's ID.
+func (h *CommandHandler) RequireAdmin(c *gin.Context) {
+	h.authorize(c, "")
+}
+
+// RequireManager - Middleware letting through admins and the managers of the :venue in the path
+func (h *CommandHandler) RequireManager(c *gin.Context) {
+	h.authorize(c, c.Param("venue"))
+}
+
+func (h *CommandHandler) authorize(c *gin.Context, venue string) {
+	err := h.CommandService.Authorize(c.Request.Context(), c.GetString("user_id"), venue)
+	switch {
+	case err == nil:
+		c.Next()
+	case errors.Is(err, ErrUnauthenticated):
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrForbidden):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check access: " + err.Error()})
+	}
+}
+
+// SetTimeZone - Command handler for setting a venue's time zone
+func (h *CommandHandler) SetTimeZone(c *gin.Context) {
+	var req SetTimeZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	tz, err := h.CommandService.SetTimeZone(c.Request.Context(), c.Param("venue"), req)
+	if err != nil {
+		if errors.Is(err, ErrVenueRequired) || errors.Is(err, ErrInvalidTimeZone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set time zone: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tz)
+}
+
+// CreateCity - Command handler for adding a city
+func (h *CommandHandler) CreateCity(c *gin.Context) {
+	var req CreateCityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	city, err := h.CommandService.CreateCity(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, "Failed to create city: ", err)
+		return
+	}
+	c.JSON(http.StatusCreated, city)
+}
+
+// CreateVenue - Command handler for adding a venue
+func (h *CommandHandler) CreateVenue(c *gin.Context) {
+	var req CreateVenueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	v, err := h.CommandService.CreateVenue(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, "Failed to create venue: ", err)
+		return
+	}
+	c.JSON(http.StatusCreated, v)
+}
+
+// UpdateVenue - Command handler for changing a venue's details
+func (h *CommandHandler) UpdateVenue(c *gin.Context) {
+	var req VenueDetails
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	v, err := h.CommandService.UpdateVenue(c.Request.Context(), c.Param("venue"), req)
+	if err != nil {
+		writeError(c, "Failed to update venue: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// AddScreen - Command handler for adding a screen to a venue
+func (h *CommandHandler) AddScreen(c *gin.Context) {
+	var req ScreenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	sc, err := h.CommandService.AddScreen(c.Request.Context(), c.Param("venue"), req)
+	if err != nil {
+		writeError(c, "Failed to add screen: ", err)
+		return
+	}
+	c.JSON(http.StatusCreated, sc)
+}
+
+// UpdateScreen - Command handler for changing one of a venue's screens
+func (h *CommandHandler) UpdateScreen(c *gin.Context) {
+	var req ScreenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	sc, err := h.CommandService.UpdateScreen(c.Request.Context(), c.Param("venue"), c.Param("id"), req)
+	if err != nil {
+		writeError(c, "Failed to update screen: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, sc)
+}
+
+// DeleteScreen - Command handler for removing one of a venue's screens
+func (h *CommandHandler) DeleteScreen(c *gin.Context) {
+	if err := h.CommandService.DeleteScreen(c.Request.Context(), c.Param("venue"), c.Param("id")); err != nil {
+		writeError(c, "Failed to delete screen: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Screen deleted"})
+}
+
+// AddManager - Command handler for letting a user manage a venue
+func (h *CommandHandler) AddManager(c *gin.Context) {
+	if err := h.CommandService.AddManager(c.Request.Context(), c.Param("venue"), c.Param("user_id")); err != nil {
+		writeError(c, "Failed to add manager: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Manager added"})
+}
+
+// RemoveManager - Command handler for stopping a user managing a venue
+func (h *CommandHandler) RemoveManager(c *gin.Context) {
+	if err := h.CommandService.RemoveManager(c.Request.Context(), c.Param("venue"), c.Param("user_id")); err != nil {
+		writeError(c, "Failed to remove manager: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Manager removed"})
+}
+
+// writeError maps venue errors to status codes
+func writeError(c *gin.Context, prefix string, err error) {
+	switch {
+	case errors.Is(err, ErrVenueRequired), errors.Is(err, ErrInvalidTimeZone), errors.Is(err, ErrInvalidVenue):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCityNotFound), errors.Is(err, ErrVenueNotFound),
+		errors.Is(err, ErrScreenNotFound), errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCityExists), errors.Is(err, ErrVenueExists),
+		errors.Is(err, ErrScreenExists), errors.Is(err, ErrScreenInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": prefix + err.Error()})
+	}
+}
+
+// SetTimeZoneRequest - Request model for a venue's time zone
+type SetTimeZoneRequest struct {
+	TimeZone string `json:"time_zone" binding:"required"`
+}
+
+// CreateCityRequest - Request model for a city; country is a two-letter code
+type CreateCityRequest struct {
+	Name    string `json:"name"`
+	Country string `json:"country"`
+}
+
+// VenueDetails - Request model for the parts of a venue its managers keep up to date.
+// An empty time zone leaves the venue's as it is.
+type VenueDetails struct {
+	Address      Address        `json:"address"`
+	Amenities    []string       `json:"amenities"`
+	OpeningHours []OpeningHours `json:"opening_hours"`
+	TimeZone     string         `json:"time_zone"`
+}
+
+// CreateVenueRequest - Request model for a venue
+type CreateVenueRequest struct {
+	CityID int    `json:"city_id"`
+	Name   string `json:"name"`
+	VenueDetails
+}
+
+// ScreenRequest - Request model for a screen
+type ScreenRequest struct {
+	Name      string   `json:"name"`
+	Capacity  int      `json:"capacity"`
+	Amenities []string `json:"amenities"`
+}
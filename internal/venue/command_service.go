@@ -0,0 +1,253 @@
+// Command service for cities, venues, screens and venue settings (CQRS)
+
+package venue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/events"
+)
+
+type CommandService struct {
+	Repo       *Repository
+	Dispatcher *events.Dispatcher
+}
+
+func NewCommandService(repo *Repository, dispatcher *events.Dispatcher) *CommandService {
+	return &CommandService{Repo: repo, Dispatcher: dispatcher}
+}
+
+// SetTimeZone - Command to set the IANA zone a venue's shows are local to. Shows keep
+// their instant in time; read models re-render them in the new zone.
+func (s *CommandService) SetTimeZone(ctx context.Context, venue string, req SetTimeZoneRequest) (*TimeZone, error) {
+	venue = strings.TrimSpace(venue)
+	if venue == "" {
+		return nil, ErrVenueRequired
+	}
+	zone := strings.TrimSpace(req.TimeZone)
+	loc, err := LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is not an IANA zone such as Asia/Kolkata", ErrInvalidTimeZone, zone)
+	}
+
+	tz := &TimeZone{Venue: venue, TimeZone: loc.String()}
+	if err := s.Repo.SetTimeZone(ctx, tz); err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, tz.Venue, tz.TimeZone)
+	return tz, nil
+}
+
+// publish tells the read models a venue changed, so its shows are projected again
+func (s *CommandService) publish(ctx context.Context, venue, zone string) {
+	if s.Dispatcher != nil {
+		payload := events.EventPayload{
+			Venue:    venue,
+			TimeZone: zone,
+		}
+		_ = s.Dispatcher.Publish(ctx, events.EventVenueUpdated, venue, payload)
+	}
+}
+
+// Authorize checks a user may manage a venue: admins manage every venue, and managers
+// the ones they are assigned to. An empty venue is something only admins may do.
+func (s *CommandService) Authorize(ctx context.Context, userID, venue string) error {
+	if userID == "" {
+		return ErrUnauthenticated
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrForbidden
+	}
+	admin, manager, err := s.Repo.Access(ctx, userID, strings.TrimSpace(venue))
+	if err != nil {
+		return err
+	}
+	if admin || manager && venue != "" {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// CreateCity - Command to add a city
+func (s *CommandService) CreateCity(ctx context.Context, req CreateCityRequest) (*City, error) {
+	c := &City{Name: strings.TrimSpace(req.Name), Country: strings.ToUpper(strings.TrimSpace(req.Country))}
+	if c.Name == "" {
+		return nil, fmt.Errorf("%w: a city needs a name", ErrInvalidVenue)
+	}
+	if len(c.Country) != 2 {
+		return nil, fmt.Errorf("%w: country must be a two-letter code such as IN", ErrInvalidVenue)
+	}
+	if err := s.Repo.CreateCity(ctx, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// details validates and normalizes the editable parts of a venue
+func (v *Venue) details(req VenueDetails) error {
+	if err := validHours(req.OpeningHours); err != nil {
+		return err
+	}
+	zone := strings.TrimSpace(req.TimeZone)
+	if zone != "" {
+		loc, err := LoadLocation(zone)
+		if err != nil {
+			return fmt.Errorf("%w: %q is not an IANA zone such as Asia/Kolkata", ErrInvalidTimeZone, zone)
+		}
+		v.TimeZone = loc.String()
+	}
+	v.Address = Address{
+		Line1:      strings.TrimSpace(req.Address.Line1),
+		Line2:      strings.TrimSpace(req.Address.Line2),
+		PostalCode: strings.TrimSpace(req.Address.PostalCode),
+	}
+	v.Amenities = cleanAmenities(req.Amenities)
+	v.OpeningHours = make([]OpeningHours, len(req.OpeningHours))
+	for i, h := range req.OpeningHours {
+		v.OpeningHours[i] = OpeningHours{Day: strings.ToLower(h.Day), Opens: h.Opens, Closes: h.Closes}
+	}
+	return nil
+}
+
+// CreateVenue - Command to add a venue to a city. Shows already given in a theater of
+// the same name become the venue's.
+func (s *CommandService) CreateVenue(ctx context.Context, req CreateVenueRequest) (*Venue, error) {
+	v := &Venue{CityID: req.CityID, Name: strings.TrimSpace(req.Name), Screens: []Screen{}}
+	if v.Name == "" {
+		return nil, ErrVenueRequired
+	}
+	if v.CityID <= 0 {
+		return nil, ErrCityNotFound
+	}
+	if err := v.details(req.VenueDetails); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateVenue(ctx, v); err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, v.Name, v.TimeZone)
+	return s.Repo.GetVenue(ctx, v.Name)
+}
+
+// UpdateVenue - Command to change a venue's address, amenities, opening hours or zone.
+// The venue keeps its zone when none is given.
+func (s *CommandService) UpdateVenue(ctx context.Context, name string, req VenueDetails) (*Venue, error) {
+	v, err := s.Repo.GetVenue(ctx, strings.TrimSpace(name))
+	if err != nil {
+		return nil, err
+	}
+	v.TimeZone = ""
+	if err := v.details(req); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.UpdateVenue(ctx, v); err != nil {
+		return nil, err
+	}
+
+	s.publish(ctx, v.Name, v.TimeZone)
+	return s.Repo.GetVenue(ctx, v.Name)
+}
+
+// newScreen validates a screen request for a venue
+func newScreen(venueID int, req ScreenRequest) (*Screen, error) {
+	sc := &Screen{VenueID: venueID, Name: strings.TrimSpace(req.Name), Capacity: req.Capacity, Amenities: cleanAmenities(req.Amenities)}
+	if sc.Name == "" {
+		return nil, fmt.Errorf("%w: a screen needs a name", ErrInvalidVenue)
+	}
+	if sc.Capacity < 0 {
+		return nil, fmt.Errorf("%w: capacity can't be negative", ErrInvalidVenue)
+	}
+	return sc, nil
+}
+
+// parseScreenID turns a path ID into a screen ID
+func parseScreenID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n <= 0 {
+		return 0, ErrScreenNotFound
+	}
+	return n, nil
+}
+
+// AddScreen - Command to add a screen to a venue
+func (s *CommandService) AddScreen(ctx context.Context, venue string, req ScreenRequest) (*Screen, error) {
+	v, err := s.Repo.GetVenue(ctx, strings.TrimSpace(venue))
+	if err != nil {
+		return nil, err
+	}
+	sc, err := newScreen(v.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateScreen(ctx, sc); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// UpdateScreen - Command to rename or refit one of a venue's screens
+func (s *CommandService) UpdateScreen(ctx context.Context, venue, id string, req ScreenRequest) (*Screen, error) {
+	screenID, err := parseScreenID(id)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.Repo.GetVenue(ctx, strings.TrimSpace(venue))
+	if err != nil {
+		return nil, err
+	}
+	sc, err := newScreen(v.ID, req)
+	if err != nil {
+		return nil, err
+	}
+	sc.ID = screenID
+	if err := s.Repo.UpdateScreen(ctx, sc); err != nil {
+		return nil, err
+	}
+
+	// Listings show the screen's name
+	s.publish(ctx, v.Name, v.TimeZone)
+	return sc, nil
+}
+
+// DeleteScreen - Command to remove a screen that never had a show
+func (s *CommandService) DeleteScreen(ctx context.Context, venue, id string) error {
+	screenID, err := parseScreenID(id)
+	if err != nil {
+		return err
+	}
+	v, err := s.Repo.GetVenue(ctx, strings.TrimSpace(venue))
+	if err != nil {
+		return err
+	}
+	return s.Repo.DeleteScreen(ctx, v.ID, screenID)
+}
+
+// AddManager - Command to let a user manage a venue
+func (s *CommandService) AddManager(ctx context.Context, venue, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrUserNotFound
+	}
+	v, err := s.Repo.GetVenue(ctx, strings.TrimSpace(venue))
+	if err != nil {
+		return err
+	}
+	return s.Repo.AddManager(ctx, v.ID, userID)
+}
+
+// RemoveManager - Command to stop a user managing a venue
+func (s *CommandService) RemoveManager(ctx context.Context, venue, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrUserNotFound
+	}
+	v, err := s.Repo.GetVenue(ctx, strings.TrimSpace(venue))
+	if err != nil {
+		return err
+	}
+	return s.Repo.RemoveManager(ctx, v.ID, userID)
+}
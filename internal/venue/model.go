@@ -0,0 +1,160 @@
+// Models for cities, venues, screens and venue settings
+
+package venue
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrVenueRequired   = errors.New("venue is required")
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+
+	ErrCityNotFound   = errors.New("city not found")
+	ErrVenueNotFound  = errors.New("venue not found")
+	ErrScreenNotFound = errors.New("screen not found")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrCityExists     = errors.New("city already exists")
+	ErrVenueExists    = errors.New("venue already exists")
+	ErrScreenExists   = errors.New("venue already has a screen of that name")
+	ErrScreenInUse    = errors.New("screen has shows")
+	ErrInvalidVenue   = errors.New("invalid venue")
+
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("not allowed to manage this venue")
+)
+
+// City is where venues are. Country is an ISO 3166-1 alpha-2 code.
+type City struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Country   string    `json:"country"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Venue is a cinema. Its name is how shows, fees and time zones refer to it, so it is
+// unique and can't be changed once the venue is created.
+type Venue struct {
+	ID           int            `json:"id"`
+	CityID       int            `json:"city_id"`
+	City         string         `json:"city"`
+	Name         string         `json:"name"`
+	Address      Address        `json:"address"`
+	Amenities    []string       `json:"amenities"`
+	OpeningHours []OpeningHours `json:"opening_hours"`
+	TimeZone     string         `json:"time_zone,omitempty"`
+	Screens      []Screen       `json:"screens"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+type Address struct {
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+}
+
+// Screen is one auditorium of a venue; shows on different screens don't clash
+type Screen struct {
+	ID        int      `json:"id"`
+	VenueID   int      `json:"venue_id"`
+	Name      string   `json:"name"`
+	Capacity  int      `json:"capacity"`
+	Amenities []string `json:"amenities"`
+}
+
+// OpeningHours is when a venue is open on a day of the week, in its own zone. Closes
+// before Opens means it closes after midnight.
+type OpeningHours struct {
+	Day    string `json:"day"`    // mon … sun
+	Opens  string `json:"opens"`  // HH:MM
+	Closes string `json:"closes"` // HH:MM
+}
+
+// Place is where a show is on, as far as the hierarchy knows it. VenueID is nil for a
+// theater that isn't a managed venue.
+type Place struct {
+	VenueID  *int
+	City     string
+	ScreenID *int
+	Screen   string
+}
+
+var weekdays = map[string]bool{"mon": true, "tue": true, "wed": true, "thu": true, "fri": true, "sat": true, "sun": true}
+
+// validHours checks opening hours name each day at most once with HH:MM times
+func validHours(hours []OpeningHours) error {
+	seen := map[string]bool{}
+	for _, h := range hours {
+		day := strings.ToLower(h.Day)
+		if !weekdays[day] {
+			return fmt.Errorf("%w: %q is not a day such as mon", ErrInvalidVenue, h.Day)
+		}
+		if seen[day] {
+			return fmt.Errorf("%w: %s is given twice", ErrInvalidVenue, day)
+		}
+		seen[day] = true
+		for _, t := range []string{h.Opens, h.Closes} {
+			if _, err := time.Parse("15:04", t); err != nil || len(t) != 5 {
+				return fmt.Errorf("%w: %q is not an HH:MM time", ErrInvalidVenue, t)
+			}
+		}
+	}
+	return nil
+}
+
+// cleanAmenities trims, lower-cases and de-duplicates amenities such as "dolby-atmos"
+func cleanAmenities(amenities []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, a := range amenities {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a != "" && !seen[a] {
+			seen[a] = true
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// TimeZone is the IANA zone a venue's shows are local to. Default is set when the venue
+// has no zone of its own and uses the configured one.
+type TimeZone struct {
+	Venue     string     `json:"venue"`
+	TimeZone  string     `json:"time_zone"`
+	Default   bool       `json:"default,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// locations caches loaded zones by name; parsing tzdata on every row adds up
+var locations sync.Map
+
+// LoadLocation is time.LoadLocation for IANA names, keeping the zones it has loaded.
+// "Local" and names that aren't zones are rejected.
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	if name == "" || name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// In expresses t in the named zone. Times of an unknown zone are given in UTC.
+func In(t time.Time, zone string) time.Time {
+	loc, err := LoadLocation(zone)
+	if err != nil {
+		return t.UTC()
+	}
+	return t.In(loc)
+}
@@ -0,0 +1,86 @@
+// Query handler for venue settings (CQRS)
+
+package venue
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetTimeZone - Query handler for the zone a venue's shows are local to
+func (h *QueryHandler) GetTimeZone(c *gin.Context) {
+	tz, err := h.QueryService.GetTimeZone(c.Request.Context(), c.Param("venue"))
+	if err != nil {
+		if errors.Is(err, ErrVenueRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get time zone: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tz)
+}
+
+// ListCities - Query handler for listing cities
+func (h *QueryHandler) ListCities(c *gin.Context) {
+	cities, err := h.QueryService.ListCities(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cities"})
+		return
+	}
+	if cities == nil {
+		cities = []City{}
+	}
+	c.JSON(http.StatusOK, cities)
+}
+
+// ListVenues - Query handler for listing venues, optionally of one city (?city_id=)
+func (h *QueryHandler) ListVenues(c *gin.Context) {
+	venues, err := h.QueryService.ListVenues(c.Request.Context(), c.Query("city_id"))
+	if errors.Is(err, ErrCityNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "city_id must be a city's ID"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venues"})
+		return
+	}
+	if venues == nil {
+		venues = []Venue{}
+	}
+	c.JSON(http.StatusOK, venues)
+}
+
+// GetVenue - Query handler for a venue with its screens
+func (h *QueryHandler) GetVenue(c *gin.Context) {
+	v, err := h.QueryService.GetVenue(c.Request.Context(), c.Param("venue"))
+	if err != nil {
+		writeError(c, "Failed to fetch venue: ", err)
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+// ListManagers - Query handler for the IDs of a venue's managers
+func (h *QueryHandler) ListManagers(c *gin.Context) {
+	ids, err := h.QueryService.ListManagers(c.Request.Context(), c.Param("venue"))
+	if err != nil {
+		writeError(c, "Failed to fetch managers: ", err)
+		return
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"venue": c.Param("venue"), "managers": ids})
+}
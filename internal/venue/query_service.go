@@ -0,0 +1,145 @@
+// Query service for cities, venues and venue settings (CQRS)
+
+package venue
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryService reads venues and their settings from the Command DB; show times and places
+// are worked out with them as shows are written, so they must always be current
+type QueryService struct {
+	Repo    *Repository
+	Default *time.Location // the zone of venues without their own
+}
+
+func NewQueryService(repo *Repository, def *time.Location) *QueryService {
+	return &QueryService{Repo: repo, Default: def}
+}
+
+func (s *QueryService) defaultLocation() *time.Location {
+	if s.Default == nil {
+		return time.UTC
+	}
+	return s.Default
+}
+
+// GetTimeZone - Query to get the zone a venue's shows are local to
+func (s *QueryService) GetTimeZone(ctx context.Context, venue string) (*TimeZone, error) {
+	venue = strings.TrimSpace(venue)
+	if venue == "" {
+		return nil, ErrVenueRequired
+	}
+	tz, err := s.Repo.GetTimeZone(ctx, venue)
+	if err != nil {
+		return nil, err
+	}
+	if tz == nil {
+		tz = &TimeZone{Venue: venue, TimeZone: s.defaultLocation().String(), Default: true}
+	}
+	return tz, nil
+}
+
+// Location returns the zone of a venue's shows. A nil service means every venue is in UTC.
+func (s *QueryService) Location(ctx context.Context, venue string) (*time.Location, error) {
+	if s == nil {
+		return time.UTC, nil
+	}
+	if s.Repo == nil || strings.TrimSpace(venue) == "" {
+		return s.defaultLocation(), nil
+	}
+	tz, err := s.Repo.GetTimeZone(ctx, strings.TrimSpace(venue))
+	if err != nil {
+		return nil, err
+	}
+	if tz == nil {
+		return s.defaultLocation(), nil
+	}
+	loc, err := LoadLocation(tz.TimeZone)
+	if err != nil {
+		// Only valid zones are stored, so this is tzdata missing one it used to have
+		return s.defaultLocation(), nil
+	}
+	return loc, nil
+}
+
+// ListCities - Query to list every city
+func (s *QueryService) ListCities(ctx context.Context) ([]City, error) {
+	return s.Repo.ListCities(ctx)
+}
+
+// ListVenues - Query to list the venues of a city, or of every city when cityID is empty
+func (s *QueryService) ListVenues(ctx context.Context, cityID string) ([]Venue, error) {
+	id := 0
+	if cityID != "" {
+		n, err := strconv.Atoi(cityID)
+		if err != nil || n <= 0 {
+			return nil, ErrCityNotFound
+		}
+		id = n
+	}
+	venues, err := s.Repo.ListVenues(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range venues {
+		s.withZone(&venues[i])
+	}
+	return venues, nil
+}
+
+// GetVenue - Query to get a venue with its screens
+func (s *QueryService) GetVenue(ctx context.Context, name string) (*Venue, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrVenueRequired
+	}
+	v, err := s.Repo.GetVenue(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	s.withZone(v)
+	return v, nil
+}
+
+// withZone gives a venue without a zone of its own the default one
+func (s *QueryService) withZone(v *Venue) {
+	if v.TimeZone == "" {
+		v.TimeZone = s.defaultLocation().String()
+	}
+}
+
+// Screen returns a screen and the name of its venue
+func (s *QueryService) Screen(ctx context.Context, id int) (*Screen, string, error) {
+	return s.Repo.GetScreen(ctx, id)
+}
+
+// HasScreens reports whether a theater is a venue with screens set up. A nil service
+// knows of no screens.
+func (s *QueryService) HasScreens(ctx context.Context, theater string) (bool, error) {
+	if s == nil || s.Repo == nil {
+		return false, nil
+	}
+	return s.Repo.HasScreens(ctx, theater)
+}
+
+// Place returns the venue, city and screen of a show. A nil service places every show
+// in an unmanaged theater.
+func (s *QueryService) Place(ctx context.Context, theater string, screenID *int) (*Place, error) {
+	if s == nil || s.Repo == nil {
+		return &Place{ScreenID: screenID}, nil
+	}
+	return s.Repo.Place(ctx, theater, screenID)
+}
+
+// ListManagers - Query to list the IDs of the users who manage a venue
+func (s *QueryService) ListManagers(ctx context.Context, venue string) ([]string, error) {
+	v, err := s.Repo.GetVenue(ctx, strings.TrimSpace(venue))
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.ListManagers(ctx, v.ID)
+}
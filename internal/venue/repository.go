@@ -0,0 +1,355 @@
+package venue
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+// GetTimeZone returns the zone set for exactly this venue, or nil if it has none
+func (r *Repository) GetTimeZone(ctx context.Context, venue string) (*TimeZone, error) {
+	tz := TimeZone{Venue: venue}
+	err := r.DB.QueryRow(ctx,
+		"SELECT time_zone, updated_at FROM venue_time_zones WHERE venue = $1", venue,
+	).Scan(&tz.TimeZone, &tz.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tz, nil
+}
+
+// SetTimeZone sets a venue's zone
+func (r *Repository) SetTimeZone(ctx context.Context, tz *TimeZone) error {
+	return r.DB.QueryRow(ctx, `
+		INSERT INTO venue_time_zones (venue, time_zone) VALUES ($1, $2)
+		ON CONFLICT (venue) DO UPDATE SET time_zone = EXCLUDED.time_zone, updated_at = NOW()
+		RETURNING updated_at
+	`, tz.Venue, tz.TimeZone).Scan(&tz.UpdatedAt)
+}
+
+// SQLSTATEs the hierarchy's constraints fail with
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+func pgCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// CreateCity adds a city
+func (r *Repository) CreateCity(ctx context.Context, c *City) error {
+	err := r.DB.QueryRow(ctx,
+		"INSERT INTO cities (name, country) VALUES ($1, $2) RETURNING id, created_at",
+		c.Name, c.Country,
+	).Scan(&c.ID, &c.CreatedAt)
+	if pgCode(err) == uniqueViolation {
+		return ErrCityExists
+	}
+	return err
+}
+
+// ListCities returns every city by name
+func (r *Repository) ListCities(ctx context.Context) ([]City, error) {
+	rows, err := r.DB.Query(ctx, "SELECT id, name, country, created_at FROM cities ORDER BY name, country")
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (City, error) {
+		var c City
+		err := row.Scan(&c.ID, &c.Name, &c.Country, &c.CreatedAt)
+		return c, err
+	})
+}
+
+// venueColumns reads a venue with its city and the zone set for it, if any
+const venueColumns = `v.id, v.city_id, c.name, v.name, v.address_line1, v.address_line2, v.postal_code,
+	v.amenities, v.opening_hours, COALESCE(tz.time_zone, ''), v.created_at, v.updated_at`
+
+const venueFrom = `FROM venues v
+	JOIN cities c ON c.id = v.city_id
+	LEFT JOIN venue_time_zones tz ON tz.venue = v.name`
+
+func scanVenue(row pgx.Row) (*Venue, error) {
+	var v Venue
+	err := row.Scan(&v.ID, &v.CityID, &v.City, &v.Name, &v.Address.Line1, &v.Address.Line2, &v.Address.PostalCode,
+		&v.Amenities, &v.OpeningHours, &v.TimeZone, &v.CreatedAt, &v.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// setTimeZone stores a venue's zone inside a venue write; an empty zone is left alone
+func setTimeZone(ctx context.Context, tx pgx.Tx, venue, zone string) error {
+	if zone == "" {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO venue_time_zones (venue, time_zone) VALUES ($1, $2)
+		ON CONFLICT (venue) DO UPDATE SET time_zone = EXCLUDED.time_zone, updated_at = NOW()
+	`, venue, zone)
+	return err
+}
+
+// CreateVenue adds a venue, with its zone when one is given
+func (r *Repository) CreateVenue(ctx context.Context, v *Venue) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO venues (city_id, name, address_line1, address_line2, postal_code, amenities, opening_hours)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`, v.CityID, v.Name, v.Address.Line1, v.Address.Line2, v.Address.PostalCode, v.Amenities, v.OpeningHours,
+	).Scan(&v.ID, &v.CreatedAt, &v.UpdatedAt)
+	switch pgCode(err) {
+	case uniqueViolation:
+		return ErrVenueExists
+	case foreignKeyViolation:
+		return ErrCityNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := setTimeZone(ctx, tx, v.Name, v.TimeZone); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// UpdateVenue rewrites a venue's address, amenities and opening hours, and its zone when
+// one is given
+func (r *Repository) UpdateVenue(ctx context.Context, v *Venue) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		UPDATE venues SET address_line1 = $2, address_line2 = $3, postal_code = $4,
+			amenities = $5, opening_hours = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, v.ID, v.Address.Line1, v.Address.Line2, v.Address.PostalCode, v.Amenities, v.OpeningHours,
+	).Scan(&v.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrVenueNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := setTimeZone(ctx, tx, v.Name, v.TimeZone); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetVenue returns a venue by name with its screens
+func (r *Repository) GetVenue(ctx context.Context, name string) (*Venue, error) {
+	v, err := scanVenue(r.DB.QueryRow(ctx, "SELECT "+venueColumns+" "+venueFrom+" WHERE v.name = $1", name))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrVenueNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if v.Screens, err = r.ListScreens(ctx, v.ID); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ListVenues returns the venues of a city by name, or of every city when cityID is 0.
+// Screens are left out.
+func (r *Repository) ListVenues(ctx context.Context, cityID int) ([]Venue, error) {
+	rows, err := r.DB.Query(ctx,
+		"SELECT "+venueColumns+" "+venueFrom+" WHERE ($1 = 0 OR v.city_id = $1) ORDER BY c.name, v.name", cityID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Venue, error) {
+		v, err := scanVenue(row)
+		if err != nil {
+			return Venue{}, err
+		}
+		return *v, nil
+	})
+}
+
+const screenColumns = "id, venue_id, name, capacity, amenities"
+
+func scanScreen(row pgx.Row) (*Screen, error) {
+	var sc Screen
+	if err := row.Scan(&sc.ID, &sc.VenueID, &sc.Name, &sc.Capacity, &sc.Amenities); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// ListScreens returns a venue's screens by name
+func (r *Repository) ListScreens(ctx context.Context, venueID int) ([]Screen, error) {
+	rows, err := r.DB.Query(ctx, "SELECT "+screenColumns+" FROM screens WHERE venue_id = $1 ORDER BY name", venueID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (Screen, error) {
+		sc, err := scanScreen(row)
+		if err != nil {
+			return Screen{}, err
+		}
+		return *sc, nil
+	})
+}
+
+// GetScreen returns a screen with the name of its venue
+func (r *Repository) GetScreen(ctx context.Context, id int) (*Screen, string, error) {
+	var venue string
+	var sc Screen
+	err := r.DB.QueryRow(ctx, `
+		SELECT s.id, s.venue_id, s.name, s.capacity, s.amenities, v.name
+		FROM screens s JOIN venues v ON v.id = s.venue_id
+		WHERE s.id = $1
+	`, id).Scan(&sc.ID, &sc.VenueID, &sc.Name, &sc.Capacity, &sc.Amenities, &venue)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", ErrScreenNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return &sc, venue, nil
+}
+
+// CreateScreen adds a screen to a venue
+func (r *Repository) CreateScreen(ctx context.Context, sc *Screen) error {
+	err := r.DB.QueryRow(ctx,
+		"INSERT INTO screens (venue_id, name, capacity, amenities) VALUES ($1, $2, $3, $4) RETURNING id",
+		sc.VenueID, sc.Name, sc.Capacity, sc.Amenities,
+	).Scan(&sc.ID)
+	if pgCode(err) == uniqueViolation {
+		return ErrScreenExists
+	}
+	return err
+}
+
+// UpdateScreen renames or refits a screen
+func (r *Repository) UpdateScreen(ctx context.Context, sc *Screen) error {
+	res, err := r.DB.Exec(ctx,
+		"UPDATE screens SET name = $3, capacity = $4, amenities = $5 WHERE id = $1 AND venue_id = $2",
+		sc.ID, sc.VenueID, sc.Name, sc.Capacity, sc.Amenities)
+	if pgCode(err) == uniqueViolation {
+		return ErrScreenExists
+	}
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrScreenNotFound
+	}
+	return nil
+}
+
+// DeleteScreen removes a screen of a venue that has no shows, past or future
+func (r *Repository) DeleteScreen(ctx context.Context, venueID, id int) error {
+	res, err := r.DB.Exec(ctx, "DELETE FROM screens WHERE id = $1 AND venue_id = $2", id, venueID)
+	if pgCode(err) == foreignKeyViolation {
+		return ErrScreenInUse
+	}
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrScreenNotFound
+	}
+	return nil
+}
+
+// HasScreens reports whether the venue of that name has screens set up
+func (r *Repository) HasScreens(ctx context.Context, venue string) (bool, error) {
+	var has bool
+	err := r.DB.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM screens s JOIN venues v ON v.id = s.venue_id WHERE v.name = $1)", venue,
+	).Scan(&has)
+	return has, err
+}
+
+// Place looks up the venue, city and screen of a show given in theater
+func (r *Repository) Place(ctx context.Context, theater string, screenID *int) (*Place, error) {
+	var p Place
+	err := r.DB.QueryRow(ctx, `
+		SELECT v.id, c.name FROM venues v JOIN cities c ON c.id = v.city_id WHERE v.name = $1
+	`, theater).Scan(&p.VenueID, &p.City)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if screenID != nil {
+		err := r.DB.QueryRow(ctx, "SELECT name FROM screens WHERE id = $1", *screenID).Scan(&p.Screen)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		p.ScreenID = screenID
+	}
+	return &p, nil
+}
+
+// AddManager lets a user manage a venue
+func (r *Repository) AddManager(ctx context.Context, venueID int, userID string) error {
+	_, err := r.DB.Exec(ctx,
+		"INSERT INTO venue_managers (venue_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", venueID, userID)
+	if pgCode(err) == foreignKeyViolation {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// RemoveManager stops a user managing a venue
+func (r *Repository) RemoveManager(ctx context.Context, venueID int, userID string) error {
+	_, err := r.DB.Exec(ctx, "DELETE FROM venue_managers WHERE venue_id = $1 AND user_id = $2", venueID, userID)
+	return err
+}
+
+// ListManagers returns the IDs of a venue's managers
+func (r *Repository) ListManagers(ctx context.Context, venueID int) ([]string, error) {
+	rows, err := r.DB.Query(ctx,
+		"SELECT user_id::text FROM venue_managers WHERE venue_id = $1 ORDER BY created_at", venueID)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// Access reports whether a user is an admin, and whether they manage the venue of that name
+func (r *Repository) Access(ctx context.Context, userID, venue string) (admin, manager bool, err error) {
+	err = r.DB.QueryRow(ctx, `
+		SELECT COALESCE(u.is_admin, false), EXISTS (
+			SELECT 1 FROM venue_managers m JOIN venues v ON v.id = m.venue_id
+			WHERE m.user_id = u.id AND v.name = $2)
+		FROM users u WHERE u.id = $1
+	`, userID, venue).Scan(&admin, &manager)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, false, nil
+	}
+	return admin, manager, err
+}
@@ -0,0 +1,235 @@
+package venue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestLoadLocation tests which zone names venues may use
+func TestLoadLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"Asia/Kolkata", false},
+		{"America/New_York", false},
+		{"UTC", false},
+		{"", true},
+		{"Local", true},
+		{"Mars/Olympus_Mons", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := LoadLocation(tt.name)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTimeZone) {
+					t.Errorf("Expected %v, got %v", ErrInvalidTimeZone, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if loc.String() != tt.name {
+				t.Errorf("Expected %s, got %s", tt.name, loc)
+			}
+		})
+	}
+}
+
+// TestIn tests expressing an instant in a venue's zone
+func TestIn(t *testing.T) {
+	instant := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		zone     string
+		expected string
+	}{
+		{"Asia/Kolkata", "2024-07-01T17:30:00+05:30"},
+		{"America/New_York", "2024-07-01T08:00:00-04:00"},
+		{"", "2024-07-01T12:00:00Z"},
+		{"Nowhere/Special", "2024-07-01T12:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		got := In(instant, tt.zone)
+		if got.Format(time.RFC3339) != tt.expected {
+			t.Errorf("In(%q): expected %s, got %s", tt.zone, tt.expected, got.Format(time.RFC3339))
+		}
+		if !got.Equal(instant) {
+			t.Errorf("In(%q) moved the instant to %v", tt.zone, got)
+		}
+	}
+}
+
+// TestSetTimeZone_Validation tests that bad venues and zones are rejected before storage
+func TestSetTimeZone_Validation(t *testing.T) {
+	svc := NewCommandService(nil, nil)
+
+	tests := []struct {
+		name  string
+		venue string
+		zone  string
+		err   error
+	}{
+		{"no venue", " ", "Asia/Kolkata", ErrVenueRequired},
+		{"no zone", "Screen 1", "", ErrInvalidTimeZone},
+		{"abbreviation", "Screen 1", "IST", ErrInvalidTimeZone},
+		{"offset", "Screen 1", "+05:30", ErrInvalidTimeZone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.SetTimeZone(context.Background(), tt.venue, SetTimeZoneRequest{TimeZone: tt.zone})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// TestLocation_Defaults tests the zone used when nothing is stored for a venue
+func TestLocation_Defaults(t *testing.T) {
+	ctx := context.Background()
+	kolkata, err := LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+
+	var none *QueryService
+	if loc, err := none.Location(ctx, "Screen 1"); err != nil || loc != time.UTC {
+		t.Errorf("Expected UTC without a service, got %v, %v", loc, err)
+	}
+	svc := NewQueryService(nil, kolkata)
+	if loc, err := svc.Location(ctx, "Screen 1"); err != nil || loc != kolkata {
+		t.Errorf("Expected the default zone, got %v, %v", loc, err)
+	}
+	if _, err := svc.GetTimeZone(ctx, ""); !errors.Is(err, ErrVenueRequired) {
+		t.Errorf("Expected %v, got %v", ErrVenueRequired, err)
+	}
+}
+
+// TestValidHours tests which opening hours a venue may have
+func TestValidHours(t *testing.T) {
+	tests := []struct {
+		name    string
+		hours   []OpeningHours
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"week", []OpeningHours{{"mon", "09:00", "23:00"}, {"SAT", "09:00", "02:00"}}, false},
+		{"unknown day", []OpeningHours{{"monday", "09:00", "23:00"}}, true},
+		{"day twice", []OpeningHours{{"fri", "09:00", "23:00"}, {"Fri", "10:00", "23:00"}}, true},
+		{"bad time", []OpeningHours{{"sun", "9am", "23:00"}}, true},
+		{"short time", []OpeningHours{{"sun", "9:00", "23:00"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validHours(tt.hours)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidVenue) {
+				t.Errorf("Expected %v, got %v", ErrInvalidVenue, err)
+			}
+		})
+	}
+}
+
+// TestCleanAmenities tests amenities are normalized and de-duplicated
+func TestCleanAmenities(t *testing.T) {
+	got := cleanAmenities([]string{" Dolby-Atmos", "parking", "", "PARKING", "recliners "})
+	want := []string{"dolby-atmos", "parking", "recliners"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	}
+	if cleanAmenities(nil) == nil {
+		t.Error("Expected an empty list rather than nil, which the column doesn't take")
+	}
+}
+
+// TestCommands_Validation tests that bad cities, venues and screens are rejected before storage
+func TestCommands_Validation(t *testing.T) {
+	svc := NewCommandService(nil, nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{"city without name", func() error {
+			_, err := svc.CreateCity(ctx, CreateCityRequest{Name: " ", Country: "IN"})
+			return err
+		}, ErrInvalidVenue},
+		{"city with long country", func() error {
+			_, err := svc.CreateCity(ctx, CreateCityRequest{Name: "Chennai", Country: "IND"})
+			return err
+		}, ErrInvalidVenue},
+		{"venue without name", func() error {
+			_, err := svc.CreateVenue(ctx, CreateVenueRequest{CityID: 1})
+			return err
+		}, ErrVenueRequired},
+		{"venue without city", func() error {
+			_, err := svc.CreateVenue(ctx, CreateVenueRequest{Name: "Plaza"})
+			return err
+		}, ErrCityNotFound},
+		{"venue with bad hours", func() error {
+			_, err := svc.CreateVenue(ctx, CreateVenueRequest{CityID: 1, Name: "Plaza",
+				VenueDetails: VenueDetails{OpeningHours: []OpeningHours{{"mon", "25:00", "23:00"}}}})
+			return err
+		}, ErrInvalidVenue},
+		{"venue with bad zone", func() error {
+			_, err := svc.CreateVenue(ctx, CreateVenueRequest{CityID: 1, Name: "Plaza", VenueDetails: VenueDetails{TimeZone: "IST"}})
+			return err
+		}, ErrInvalidTimeZone},
+		{"screen with bad ID", func() error {
+			_, err := svc.UpdateScreen(ctx, "Plaza", "x", ScreenRequest{Name: "Audi 1"})
+			return err
+		}, ErrScreenNotFound},
+		{"manager who isn't a user", func() error {
+			return svc.AddManager(ctx, "Plaza", "42")
+		}, ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := newScreen(1, ScreenRequest{Name: "Audi 1", Capacity: -1}); !errors.Is(err, ErrInvalidVenue) {
+		t.Errorf("Expected %v for a negative capacity, got %v", ErrInvalidVenue, err)
+	}
+}
+
+// TestAuthorize_WithoutUser tests that requests without a usable user are turned away
+// before anything is looked up
+func TestAuthorize_WithoutUser(t *testing.T) {
+	svc := NewCommandService(nil, nil)
+
+	tests := []struct {
+		userID  string
+		wantErr error
+	}{
+		{"", ErrUnauthenticated},
+		{"42", ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		if err := svc.Authorize(context.Background(), tt.userID, "Plaza"); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Authorize(%q): expected %v, got %v", tt.userID, tt.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Command handler for gift card and wallet write operations (CQRS)
+
+package wallet
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommandHandler struct {
+	CommandService *CommandService
+}
+
+func NewCommandHandler(cs *CommandService) *CommandHandler {
+	return &CommandHandler{CommandService: cs}
+}
+
+// IssueGiftCard - Command handler for issuing a gift card. No payment is taken, so only
+// admins may issue one.
+func (h *CommandHandler) IssueGiftCard(c *gin.Context) {
+	var req IssueGiftCardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	card, err := h.CommandService.IssueGiftCard(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAmount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue gift card: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, card)
+}
+
+// TopUpWallet - Command handler for adding funds to a user's wallet. No payment is taken,
+// so only admins may credit one.
+func (h *CommandHandler) TopUpWallet(c *gin.Context) {
+	var req TopUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	w, err := h.CommandService.TopUpWallet(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAmount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrStoredValue) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to top up wallet: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
+
+// IssueGiftCardRequest - Request model for issuing a gift card
+type IssueGiftCardRequest struct {
+	Amount    int64      `json:"amount" binding:"required"`
+	Currency  string     `json:"currency" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// TopUpRequest - Request model for topping up a wallet
+type TopUpRequest struct {
+	Amount   int64  `json:"amount" binding:"required"`
+	Currency string `json:"currency" binding:"required"`
+}
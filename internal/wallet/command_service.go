@@ -0,0 +1,162 @@
+// Command service for gift card and wallet write operations (CQRS)
+
+package wallet
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/hitorii/ticket-booking/internal/utils"
+)
+
+// giftCardAlphabet leaves out characters that are easy to misread
+const giftCardAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+type CommandService struct {
+	Repo *Repository
+}
+
+func NewCommandService(repo *Repository) *CommandService {
+	return &CommandService{Repo: repo}
+}
+
+// NormalizeCode upper-cases and trims a gift card code
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// newGiftCardCode returns a random code like GC-XXXX-XXXX-XXXX
+func newGiftCardCode() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("GC")
+	for i, c := range buf {
+		if i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(giftCardAlphabet[int(c)%len(giftCardAlphabet)])
+	}
+	return b.String(), nil
+}
+
+func positiveAmount(amount int64, currency string) (money.Money, error) {
+	if amount <= 0 {
+		return money.Money{}, fmt.Errorf("%w: amount must be positive", ErrInvalidAmount)
+	}
+	m, err := money.New(amount, currency)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	return m, nil
+}
+
+// IssueGiftCard - Command to issue a gift card loaded with a balance
+func (s *CommandService) IssueGiftCard(ctx context.Context, req IssueGiftCardRequest) (*Account, error) {
+	balance, err := positiveAmount(req.Amount, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAmount)
+	}
+
+	code, err := newGiftCardCode()
+	if err != nil {
+		return nil, err
+	}
+	card := &Account{
+		ID:        uuid.New().String(),
+		Kind:      KindGiftCard,
+		Code:      code,
+		Balance:   balance,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := s.Repo.CreateAccount(ctx, card, EntryIssue); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// TopUpWallet - Command to add funds to a user's wallet, opening it on first top-up
+func (s *CommandService) TopUpWallet(ctx context.Context, userID string, req TopUpRequest) (*Account, error) {
+	if err := utils.ValidateUUID("user_id", userID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	amount, err := positiveAmount(req.Amount, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := s.Repo.GetWallet(ctx, userID)
+	if errors.Is(err, ErrAccountNotFound) {
+		w = &Account{
+			ID:        uuid.New().String(),
+			Kind:      KindWallet,
+			UserID:    userID,
+			Balance:   amount,
+			CreatedAt: time.Now(),
+		}
+		if err := s.Repo.CreateAccount(ctx, w, EntryTopUp); err == nil {
+			return w, nil
+		}
+		// Lost a race to open the wallet; top up the one that won
+		if w, err = s.Repo.GetWallet(ctx, userID); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return s.Repo.Post(ctx, w.ID, EntryTopUp, amount, "", nil)
+}
+
+// account resolves the gift card or wallet a tender is charged to
+func (s *CommandService) account(ctx context.Context, method, reference, userID string) (*Account, error) {
+	switch method {
+	case KindGiftCard:
+		return s.Repo.GetGiftCard(ctx, NormalizeCode(reference))
+	case KindWallet:
+		return s.Repo.GetWallet(ctx, userID)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMethod, method)
+	}
+}
+
+// Charge takes amount from a gift card or the user's wallet for a payment
+func (s *CommandService) Charge(ctx context.Context, method, reference, userID, paymentID string, amount money.Money) error {
+	a, err := s.account(ctx, method, reference, userID)
+	if err != nil {
+		return err
+	}
+
+	delta := money.Money{Amount: -amount.Amount, Currency: amount.Currency}
+	_, err = s.Repo.Post(ctx, a.ID, EntryDebit, delta, paymentID, func(locked *Account) error {
+		if locked.Expired(time.Now()) {
+			return ErrCardExpired
+		}
+		return nil
+	})
+	return err
+}
+
+// Reverse returns amount of an earlier charge, e.g. on refund or a failed payment.
+// Expired gift cards are still credited so the balance can be reissued.
+func (s *CommandService) Reverse(ctx context.Context, method, reference, userID, paymentID string, amount money.Money) error {
+	a, err := s.account(ctx, method, reference, userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Repo.Post(ctx, a.ID, EntryReversal, amount, paymentID, nil)
+	return err
+}
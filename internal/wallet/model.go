@@ -0,0 +1,78 @@
+// Models for gift cards and stored-value wallets
+
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// Stored-value account kinds, also the payment methods they are charged as
+const (
+	KindWallet   = "WALLET"
+	KindGiftCard = "GIFT_CARD"
+)
+
+// Ledger entry kinds
+const (
+	EntryIssue    = "ISSUE"
+	EntryTopUp    = "TOP_UP"
+	EntryDebit    = "DEBIT"
+	EntryReversal = "REVERSAL"
+)
+
+// ErrStoredValue is the base error for tenders that can't be charged; wrap it with %w
+var ErrStoredValue = errors.New("stored value tender rejected")
+
+var (
+	ErrAccountNotFound     = fmt.Errorf("%w: gift card or wallet not found", ErrStoredValue)
+	ErrInsufficientBalance = fmt.Errorf("%w: insufficient balance", ErrStoredValue)
+	ErrCardExpired         = fmt.Errorf("%w: gift card has expired", ErrStoredValue)
+	ErrUnknownMethod       = fmt.Errorf("%w: unknown stored value method", ErrStoredValue)
+)
+
+// ErrInvalidAmount is returned when an issue or top-up amount is rejected
+var ErrInvalidAmount = errors.New("invalid stored value amount")
+
+// Account is a gift card or a user's wallet. Balance only changes through ledger entries.
+type Account struct {
+	ID        string      `json:"id"`
+	Kind      string      `json:"kind"`
+	Code      string      `json:"code,omitempty"`    // gift cards only
+	UserID    string      `json:"user_id,omitempty"` // wallets only
+	Balance   money.Money `json:"balance"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+
+	Entries []Entry `json:"entries,omitempty"`
+}
+
+// Entry is one movement on an account; debits are negative
+type Entry struct {
+	ID        string      `json:"id"`
+	Kind      string      `json:"kind"`
+	Amount    money.Money `json:"amount"`
+	PaymentID string      `json:"payment_id,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Expired reports whether a gift card can no longer be charged
+func (a *Account) Expired(now time.Time) bool {
+	return a.ExpiresAt != nil && !now.Before(*a.ExpiresAt)
+}
+
+// Apply returns the balance after posting delta, rejecting overdrafts and other currencies
+func (a *Account) Apply(delta money.Money) (money.Money, error) {
+	balance, err := a.Balance.Add(delta)
+	if err != nil {
+		return money.Money{}, err
+	}
+	if balance.Amount < 0 {
+		return money.Money{}, fmt.Errorf("%w: %s available, %s requested", ErrInsufficientBalance,
+			a.Balance, money.Money{Amount: -delta.Amount, Currency: delta.Currency})
+	}
+	return balance, nil
+}
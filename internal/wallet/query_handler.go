@@ -0,0 +1,48 @@
+// Query handler for gift card and wallet read operations (CQRS)
+
+package wallet
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QueryHandler struct {
+	QueryService *QueryService
+}
+
+func NewQueryHandler(qs *QueryService) *QueryHandler {
+	return &QueryHandler{QueryService: qs}
+}
+
+// GetGiftCard - Query handler for a gift card's balance
+func (h *QueryHandler) GetGiftCard(c *gin.Context) {
+	card, err := h.QueryService.GetGiftCard(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Gift card not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get gift card: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, card)
+}
+
+// GetWallet - Query handler for a user's wallet balance
+func (h *QueryHandler) GetWallet(c *gin.Context) {
+	w, err := h.QueryService.GetWallet(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallet: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
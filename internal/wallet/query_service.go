@@ -0,0 +1,43 @@
+// Query service for gift card and wallet read operations (CQRS)
+
+package wallet
+
+import (
+	"context"
+)
+
+// QueryService reads balances from the Command DB; they must never be stale at checkout
+type QueryService struct {
+	Repo *Repository
+}
+
+func NewQueryService(repo *Repository) *QueryService {
+	return &QueryService{Repo: repo}
+}
+
+// GetGiftCard - Query to get a gift card's balance and ledger
+func (s *QueryService) GetGiftCard(ctx context.Context, code string) (*Account, error) {
+	card, err := s.Repo.GetGiftCard(ctx, NormalizeCode(code))
+	if err != nil {
+		return nil, err
+	}
+	return s.withEntries(ctx, card)
+}
+
+// GetWallet - Query to get a user's wallet balance and ledger
+func (s *QueryService) GetWallet(ctx context.Context, userID string) (*Account, error) {
+	w, err := s.Repo.GetWallet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withEntries(ctx, w)
+}
+
+func (s *QueryService) withEntries(ctx context.Context, a *Account) (*Account, error) {
+	entries, err := s.Repo.GetEntries(ctx, a.ID)
+	if err != nil {
+		return nil, err
+	}
+	a.Entries = entries
+	return a, nil
+}
@@ -0,0 +1,139 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hitorii/ticket-booking/internal/money"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Repository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{DB: db}
+}
+
+const accountColumns = `id, kind, COALESCE(code, ''), COALESCE(user_id::text, ''), balance, currency, expires_at, created_at`
+
+// rowScanner is satisfied by pgx.Row and pgx.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccount(row rowScanner) (*Account, error) {
+	var a Account
+	err := row.Scan(&a.ID, &a.Kind, &a.Code, &a.UserID, &a.Balance.Amount, &a.Balance.Currency, &a.ExpiresAt, &a.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CreateAccount stores a new account and its opening ledger entry
+func (r *Repository) CreateAccount(ctx context.Context, a *Account, opening string) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO stored_value_accounts (id, kind, code, user_id, balance, currency, expires_at, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, '')::uuid, $5, $6, $7, $8)
+	`, a.ID, a.Kind, a.Code, a.UserID, a.Balance.Amount, a.Balance.Currency, a.ExpiresAt, a.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if err := insertEntry(ctx, tx, a.ID, opening, a.Balance, ""); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *Repository) GetGiftCard(ctx context.Context, code string) (*Account, error) {
+	row := r.DB.QueryRow(ctx, "SELECT "+accountColumns+" FROM stored_value_accounts WHERE kind = $1 AND code = $2", KindGiftCard, code)
+	return scanAccount(row)
+}
+
+func (r *Repository) GetWallet(ctx context.Context, userID string) (*Account, error) {
+	row := r.DB.QueryRow(ctx, "SELECT "+accountColumns+" FROM stored_value_accounts WHERE kind = $1 AND user_id = $2", KindWallet, userID)
+	return scanAccount(row)
+}
+
+// Post moves delta into (positive) or out of (negative) an account. The account row is
+// locked for the transaction so concurrent charges can't overdraw it. check validates the
+// locked account before the balance changes.
+func (r *Repository) Post(ctx context.Context, accountID, kind string, delta money.Money, paymentID string, check func(*Account) error) (*Account, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	a, err := scanAccount(tx.QueryRow(ctx, "SELECT "+accountColumns+" FROM stored_value_accounts WHERE id = $1 FOR UPDATE", accountID))
+	if err != nil {
+		return nil, err
+	}
+	if check != nil {
+		if err := check(a); err != nil {
+			return nil, err
+		}
+	}
+	if a.Balance, err = a.Apply(delta); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE stored_value_accounts SET balance = $2 WHERE id = $1", a.ID, a.Balance.Amount); err != nil {
+		return nil, err
+	}
+	if err := insertEntry(ctx, tx, a.ID, kind, delta, paymentID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func insertEntry(ctx context.Context, tx pgx.Tx, accountID, kind string, amount money.Money, paymentID string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO stored_value_entries (id, account_id, kind, amount, currency, payment_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7)
+	`, uuid.New().String(), accountID, kind, amount.Amount, amount.Currency, paymentID, time.Now())
+	return err
+}
+
+// GetEntries returns an account's ledger, newest first
+func (r *Repository) GetEntries(ctx context.Context, accountID string) ([]Entry, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, kind, amount, currency, COALESCE(payment_id::text, ''), created_at
+		FROM stored_value_entries
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Amount.Amount, &e.Amount.Currency, &e.PaymentID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
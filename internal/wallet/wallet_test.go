@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hitorii/ticket-booking/internal/money"
+)
+
+// TestApply tests balance changes, overdrafts and currency checks
+func TestApply(t *testing.T) {
+	a := &Account{Balance: money.Money{Amount: 10000, Currency: "INR"}}
+
+	tests := []struct {
+		name     string
+		delta    money.Money
+		expected int64
+		wantErr  error
+	}{
+		{"debit", money.Money{Amount: -4000, Currency: "INR"}, 6000, nil},
+		{"debit everything", money.Money{Amount: -10000, Currency: "INR"}, 0, nil},
+		{"credit", money.Money{Amount: 2500, Currency: "INR"}, 12500, nil},
+		{"overdraft", money.Money{Amount: -10001, Currency: "INR"}, 0, ErrInsufficientBalance},
+		{"other currency", money.Money{Amount: -100, Currency: "USD"}, 0, money.ErrCurrencyMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Apply(tt.delta)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.Amount != tt.expected {
+				t.Errorf("Expected balance %d, got %d", tt.expected, got.Amount)
+			}
+		})
+	}
+
+	if !errors.Is(ErrInsufficientBalance, ErrStoredValue) {
+		t.Error("Expected ErrInsufficientBalance to wrap ErrStoredValue")
+	}
+}
+
+// TestExpired tests gift card expiry
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	if (&Account{}).Expired(now) {
+		t.Error("Expected a card without expiry to be valid")
+	}
+	if !(&Account{ExpiresAt: &past}).Expired(now) {
+		t.Error("Expected an expired card")
+	}
+	if (&Account{ExpiresAt: &future}).Expired(now) {
+		t.Error("Expected a card expiring later to be valid")
+	}
+}
+
+// TestGiftCardCode tests the gift card code format
+func TestGiftCardCode(t *testing.T) {
+	code, err := newGiftCardCode()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	parts := strings.Split(code, "-")
+	if len(parts) != 4 || parts[0] != "GC" {
+		t.Fatalf("Expected GC-XXXX-XXXX-XXXX, got %s", code)
+	}
+	for _, p := range parts[1:] {
+		if len(p) != 4 || strings.Trim(p, giftCardAlphabet) != "" {
+			t.Errorf("Unexpected code group %q in %s", p, code)
+		}
+	}
+	if NormalizeCode(" "+strings.ToLower(code)+" ") != code {
+		t.Error("Expected NormalizeCode to undo case and spacing")
+	}
+}
@@ -0,0 +1,15 @@
+-- Store payment amounts as minor units with an explicit ISO 4217 currency. Amounts written
+-- before this were whole rupees, so they are converted to paise; the currency column
+-- doesn't exist until then, which keeps the conversion from running twice.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'payments' AND column_name = 'currency'
+    ) THEN
+        ALTER TABLE payments ALTER COLUMN amount TYPE BIGINT USING amount::BIGINT * 100;
+        ALTER TABLE payments ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'INR';
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_payments_booking_id ON payments(booking_id);
@@ -0,0 +1,3 @@
+-- Reconciliation looks payments up by provider transaction ID
+CREATE INDEX IF NOT EXISTS idx_payments_transaction_id ON payments(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_payments_status_created_at ON payments(status, created_at);
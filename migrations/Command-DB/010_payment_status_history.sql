@@ -0,0 +1,23 @@
+-- Payment lifecycle: PENDING -> AUTHORIZED -> CAPTURED -> PARTIALLY_REFUNDED/REFUNDED,
+-- with FAILED, CANCELLED and EXPIRED as terminal outcomes
+
+UPDATE payments SET status = 'CAPTURED' WHERE status = 'SUCCESS';
+
+ALTER TABLE payments ALTER COLUMN status SET NOT NULL;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD CONSTRAINT chk_payments_status CHECK (status IN (
+    'PENDING', 'AUTHORIZED', 'CAPTURED', 'PARTIALLY_REFUNDED', 'REFUNDED', 'FAILED', 'CANCELLED', 'EXPIRED'
+));
+ALTER TABLE payments ADD CONSTRAINT chk_payments_refunded_amount CHECK (refunded_amount BETWEEN 0 AND amount);
+
+CREATE TABLE IF NOT EXISTS payment_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    transaction_id VARCHAR(100),
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_status_history_payment_id ON payment_status_history(payment_id);
@@ -0,0 +1,35 @@
+-- Promo codes and their redemptions
+
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('PERCENTAGE', 'FIXED')),
+    value BIGINT NOT NULL CHECK (value > 0),
+    currency CHAR(3),
+    max_discount BIGINT NOT NULL DEFAULT 0,
+    per_user_limit INT NOT NULL DEFAULT 0,
+    total_limit INT NOT NULL DEFAULT 0,
+    redeemed_count INT NOT NULL DEFAULT 0 CHECK (redeemed_count >= 0),
+    show_id VARCHAR(50),
+    movie_id VARCHAR(50),
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id UUID PRIMARY KEY,
+    promotion_id UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    booking_id UUID NOT NULL,
+    discount_amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (promotion_id, booking_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_user ON promotion_redemptions(promotion_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_booking ON promotion_redemptions(booking_id);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS promo_code VARCHAR(50);
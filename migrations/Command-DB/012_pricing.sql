@@ -0,0 +1,24 @@
+-- Seat tiers and per-show price lists
+
+CREATE TABLE IF NOT EXISTS seats (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    show_id INT NOT NULL REFERENCES shows(id) ON DELETE CASCADE,
+    label VARCHAR(10) NOT NULL,
+    tier VARCHAR(20) NOT NULL DEFAULT 'STANDARD'
+        CHECK (tier IN ('STANDARD', 'PREMIUM', 'RECLINER', 'WHEELCHAIR')),
+    UNIQUE (show_id, label)
+);
+
+CREATE TABLE IF NOT EXISTS show_prices (
+    show_id INT NOT NULL REFERENCES shows(id) ON DELETE CASCADE,
+    tier VARCHAR(20) NOT NULL
+        CHECK (tier IN ('STANDARD', 'PREMIUM', 'RECLINER', 'WHEELCHAIR')),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'INR',
+    PRIMARY KEY (show_id, tier)
+);
+
+-- reservations.show_id was a UUID while shows use SERIAL ids; it was never populated
+ALTER TABLE reservations DROP COLUMN IF EXISTS show_id;
+ALTER TABLE reservations ADD COLUMN show_id INT REFERENCES shows(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_reservations_show_id ON reservations(show_id);
@@ -0,0 +1,26 @@
+-- Dynamic pricing: per-tier bounds, quotes locked for a seat hold, and the last
+-- charged price of each tier so changes can be audited
+
+ALTER TABLE show_prices ADD COLUMN IF NOT EXISTS floor_amount BIGINT CHECK (floor_amount > 0);
+ALTER TABLE show_prices ADD COLUMN IF NOT EXISTS ceiling_amount BIGINT CHECK (ceiling_amount > 0);
+
+CREATE TABLE IF NOT EXISTS price_quotes (
+    booking_id UUID PRIMARY KEY,
+    show_id INT NOT NULL,
+    total_amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    quote JSONB NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_quotes_expires_at ON price_quotes(expires_at);
+
+CREATE TABLE IF NOT EXISTS current_prices (
+    show_id INT NOT NULL REFERENCES shows(id) ON DELETE CASCADE,
+    tier VARCHAR(20) NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (show_id, tier)
+);
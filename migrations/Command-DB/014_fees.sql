@@ -0,0 +1,34 @@
+-- Venue booking fees, tax rates and the line items charged on each payment
+-- venue '*' holds the defaults for venues without their own rules
+
+CREATE TABLE IF NOT EXISTS venue_fee_rules (
+    venue VARCHAR(255) NOT NULL,
+    position INT NOT NULL,
+    label VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('PER_TICKET', 'PERCENTAGE')),
+    value BIGINT NOT NULL CHECK (value >= 0),
+    currency CHAR(3),
+    PRIMARY KEY (venue, position)
+);
+
+CREATE TABLE IF NOT EXISTS venue_tax_rates (
+    venue VARCHAR(255) NOT NULL,
+    position INT NOT NULL,
+    label VARCHAR(100) NOT NULL,
+    rate_bps BIGINT NOT NULL CHECK (rate_bps BETWEEN 0 AND 10000),
+    applies_to VARCHAR(10) NOT NULL CHECK (applies_to IN ('FARE', 'FEES', 'ALL')),
+    PRIMARY KEY (venue, position)
+);
+
+CREATE TABLE IF NOT EXISTS payment_line_items (
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    booking_id UUID NOT NULL,
+    position INT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    label VARCHAR(100) NOT NULL,
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    PRIMARY KEY (payment_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_line_items_booking ON payment_line_items(booking_id);
@@ -0,0 +1,40 @@
+-- Gift cards and wallets, their ledgers, and the tenders a payment is split across
+
+CREATE TABLE IF NOT EXISTS stored_value_accounts (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('WALLET', 'GIFT_CARD')),
+    code VARCHAR(50) UNIQUE,
+    user_id UUID,
+    balance BIGINT NOT NULL CHECK (balance >= 0),
+    currency CHAR(3) NOT NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    CHECK ((kind = 'GIFT_CARD' AND code IS NOT NULL) OR (kind = 'WALLET' AND user_id IS NOT NULL))
+);
+
+-- One wallet per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stored_value_wallet_user
+    ON stored_value_accounts(user_id) WHERE kind = 'WALLET';
+
+CREATE TABLE IF NOT EXISTS stored_value_entries (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES stored_value_accounts(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('ISSUE', 'TOP_UP', 'DEBIT', 'REVERSAL')),
+    amount BIGINT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    payment_id UUID,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stored_value_entries_account ON stored_value_entries(account_id, created_at);
+
+CREATE TABLE IF NOT EXISTS payment_tenders (
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('CARD', 'WALLET', 'GIFT_CARD')),
+    reference VARCHAR(50),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    refunded_amount BIGINT NOT NULL DEFAULT 0 CHECK (refunded_amount <= amount),
+    currency CHAR(3) NOT NULL,
+    PRIMARY KEY (payment_id, position)
+);
@@ -0,0 +1,25 @@
+-- Loyalty points: a running balance per user and the ledger behind it
+
+CREATE TABLE IF NOT EXISTS loyalty_accounts (
+    user_id UUID PRIMARY KEY,
+    balance BIGINT NOT NULL DEFAULT 0, -- may go negative when points earned on a refunded payment were already spent
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS loyalty_ledger (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES loyalty_accounts(user_id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('EARN', 'REVERSE', 'REDEEM', 'RESTORE')),
+    points BIGINT NOT NULL,
+    payment_id UUID,
+    booking_id UUID,
+    amount BIGINT,
+    currency CHAR(3),
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Points are earned once per payment, however often PaymentVerified is delivered
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_ledger_earn ON loyalty_ledger(payment_id) WHERE kind = 'EARN';
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_user ON loyalty_ledger(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_booking ON loyalty_ledger(booking_id);
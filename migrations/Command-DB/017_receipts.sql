@@ -0,0 +1,15 @@
+-- Receipts issued for captured payments, stored as rendered so they never change
+
+CREATE TABLE IF NOT EXISTS receipts (
+    id UUID PRIMARY KEY,
+    number VARCHAR(40) NOT NULL UNIQUE,
+    payment_id UUID NOT NULL UNIQUE REFERENCES payments(id) ON DELETE CASCADE,
+    booking_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    data JSONB NOT NULL,
+    html TEXT NOT NULL,
+    pdf BYTEA NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_receipts_user ON receipts(user_id);
@@ -0,0 +1,17 @@
+-- E-tickets: one signed token per booked seat, scanned at the gate
+
+CREATE TABLE IF NOT EXISTS tickets (
+    id UUID PRIMARY KEY,
+    reservation_id UUID NOT NULL UNIQUE REFERENCES reservations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    show_id INT,
+    seat_id UUID NOT NULL,
+    seat_label VARCHAR(10),
+    key_id VARCHAR(40) NOT NULL,
+    token TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tickets_user ON tickets(user_id);
+CREATE INDEX IF NOT EXISTS idx_tickets_show ON tickets(show_id);
@@ -0,0 +1,17 @@
+-- Gate check-ins: a ticket is admitted at most once
+
+CREATE TABLE IF NOT EXISTS ticket_checkins (
+    id UUID PRIMARY KEY,
+    ticket_id UUID NOT NULL UNIQUE REFERENCES tickets(id) ON DELETE CASCADE,
+    reservation_id UUID NOT NULL,
+    show_id INT,
+    seat_id UUID NOT NULL,
+    seat_label VARCHAR(10),
+    gate VARCHAR(50),
+    scanner_id VARCHAR(100),
+    scanned_at TIMESTAMP NOT NULL,
+    offline BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ticket_checkins_show ON ticket_checkins(show_id, scanned_at);
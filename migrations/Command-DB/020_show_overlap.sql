@@ -0,0 +1,13 @@
+-- No two shows may occupy the same auditorium at the same time
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'shows_no_overlap') THEN
+        ALTER TABLE shows
+        ADD CONSTRAINT shows_no_overlap
+        EXCLUDE USING gist (theater WITH =, tsrange(start_time, end_time, '[)') WITH &&);
+    END IF;
+END
+$$;
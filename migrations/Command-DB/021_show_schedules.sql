@@ -0,0 +1,23 @@
+-- Recurring schedules that shows are expanded from
+
+CREATE TABLE IF NOT EXISTS show_schedules (
+    id SERIAL PRIMARY KEY,
+    parent_id INT REFERENCES show_schedules(id) ON DELETE SET NULL,
+    movie_id INT NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    theater VARCHAR(100) NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    rule TEXT NOT NULL,
+    exceptions DATE[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS schedule_id INT REFERENCES show_schedules(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_shows_schedule ON shows(schedule_id, start_time);
+
+-- Moving a run of shows can put one into the slot another is just leaving, so the overlap
+-- check must be deferrable to the end of that transaction
+ALTER TABLE shows DROP CONSTRAINT IF EXISTS shows_no_overlap;
+ALTER TABLE shows
+ADD CONSTRAINT shows_no_overlap
+EXCLUDE USING gist (theater WITH =, tsrange(start_time, end_time, '[)') WITH &&)
+DEFERRABLE INITIALLY IMMEDIATE;
@@ -0,0 +1,33 @@
+-- Bulk catalogue imports, and the external references that make them idempotent
+
+CREATE TABLE IF NOT EXISTS catalogue_refs (
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('movies', 'shows')),
+    external_ref VARCHAR(100) NOT NULL,
+    entity_id INT NOT NULL,
+    PRIMARY KEY (kind, external_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_catalogue_refs_entity ON catalogue_refs (kind, entity_id);
+
+CREATE TABLE IF NOT EXISTS catalogue_imports (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('movies', 'shows')),
+    format VARCHAR(4) NOT NULL CHECK (format IN ('csv', 'json')),
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING'
+        CHECK (status IN ('PENDING', 'RUNNING', 'COMPLETED', 'FAILED')),
+    batch JSONB NOT NULL,
+    total_rows INT NOT NULL,
+    processed_rows INT NOT NULL DEFAULT 0,
+    created_count INT NOT NULL DEFAULT 0,
+    updated_count INT NOT NULL DEFAULT 0,
+    unchanged_count INT NOT NULL DEFAULT 0,
+    failed_count INT NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]',
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_catalogue_imports_status ON catalogue_imports (status, created_at);
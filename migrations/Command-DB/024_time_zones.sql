@@ -0,0 +1,54 @@
+-- Venue time zones, and show times and policy deadlines stored as instants
+
+CREATE TABLE IF NOT EXISTS venue_time_zones (
+    venue VARCHAR(100) PRIMARY KEY,
+    time_zone TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- The overlap check is rebuilt on the converted columns
+ALTER TABLE shows DROP CONSTRAINT IF EXISTS shows_no_overlap;
+
+-- Existing values are read as wall-clock times in app.time_zone, or UTC when it isn't set.
+-- Run `SET app.time_zone = 'Asia/Kolkata';` first if that's where they were written.
+-- Columns that are already TIMESTAMPTZ are left alone, so this can run twice.
+DO $$
+DECLARE
+    zone TEXT := COALESCE(NULLIF(current_setting('app.time_zone', true), ''), 'UTC');
+    col RECORD;
+BEGIN
+    FOR col IN
+        SELECT c.table_name, c.column_name
+        FROM information_schema.columns c
+        JOIN (VALUES
+            ('shows', 'start_time'),
+            ('shows', 'end_time'),
+            ('show_schedules', 'start_time'),
+            ('reservations', 'reserved_at'),
+            ('reservations', 'created_at'),
+            ('reservations', 'updated_at'),
+            ('payments', 'created_at'),
+            ('payments', 'updated_at'),
+            ('promotions', 'starts_at'),
+            ('promotions', 'ends_at'),
+            ('price_quotes', 'expires_at'),
+            ('stored_value_accounts', 'expires_at'),
+            ('tickets', 'expires_at'),
+            ('ticket_checkins', 'scanned_at'),
+            ('catalogue_imports', 'started_at'),
+            ('catalogue_imports', 'updated_at')
+        ) AS t (table_name, column_name)
+            ON t.table_name = c.table_name AND t.column_name = c.column_name
+        WHERE c.table_schema = current_schema()
+          AND c.data_type = 'timestamp without time zone'
+    LOOP
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN %I TYPE TIMESTAMPTZ USING %I AT TIME ZONE %L',
+            col.table_name, col.column_name, col.column_name, zone);
+    END LOOP;
+END
+$$;
+
+ALTER TABLE shows
+ADD CONSTRAINT shows_no_overlap
+EXCLUDE USING gist (theater WITH =, tstzrange(start_time, end_time, '[)') WITH &&)
+DEFERRABLE INITIALLY IMMEDIATE;
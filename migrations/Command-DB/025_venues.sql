@@ -0,0 +1,57 @@
+-- Cities, venues and their screens, and the users who manage each venue
+
+CREATE TABLE IF NOT EXISTS cities (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    country CHAR(2) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (name, country)
+);
+
+-- A venue's name is what shows.theater, venue_time_zones and the fee rules refer to it
+-- by, so it is unique and never changes. Shows already given in a theater of that name
+-- belong to the venue once it is created.
+CREATE TABLE IF NOT EXISTS venues (
+    id SERIAL PRIMARY KEY,
+    city_id INT NOT NULL REFERENCES cities(id),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    address_line1 VARCHAR(255) NOT NULL DEFAULT '',
+    address_line2 VARCHAR(255) NOT NULL DEFAULT '',
+    postal_code VARCHAR(20) NOT NULL DEFAULT '',
+    amenities TEXT[] NOT NULL DEFAULT '{}',
+    opening_hours JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_venues_city ON venues (city_id);
+
+CREATE TABLE IF NOT EXISTS screens (
+    id SERIAL PRIMARY KEY,
+    venue_id INT NOT NULL REFERENCES venues(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    capacity INT NOT NULL DEFAULT 0 CHECK (capacity >= 0),
+    amenities TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (venue_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS venue_managers (
+    venue_id INT NOT NULL REFERENCES venues(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (venue_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_venue_managers_user ON venue_managers (user_id);
+
+-- A show runs on one screen of its theater; shows without one take the whole theater
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS screen_id INT REFERENCES screens(id);
+ALTER TABLE show_schedules ADD COLUMN IF NOT EXISTS screen_id INT REFERENCES screens(id);
+
+-- Screens of one venue are booked independently of each other
+ALTER TABLE shows DROP CONSTRAINT IF EXISTS shows_no_overlap;
+ALTER TABLE shows
+ADD CONSTRAINT shows_no_overlap
+EXCLUDE USING gist (theater WITH =, (COALESCE(screen_id, 0)) WITH =, tstzrange(start_time, end_time, '[)') WITH &&)
+DEFERRABLE INITIALLY IMMEDIATE;
@@ -0,0 +1,61 @@
+-- Cancelling a show: the show and its reservations are kept, marked CANCELLED, and a
+-- cancellation job refunds, notifies and offers rebooking to everyone who had a seat
+
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS status VARCHAR(10) NOT NULL DEFAULT 'SCHEDULED'
+    CHECK (status IN ('SCHEDULED', 'CANCELLED'));
+
+-- A cancelled show no longer takes up its screen
+ALTER TABLE shows DROP CONSTRAINT IF EXISTS shows_no_overlap;
+ALTER TABLE shows
+ADD CONSTRAINT shows_no_overlap
+EXCLUDE USING gist (theater WITH =, (COALESCE(screen_id, 0)) WITH =, tstzrange(start_time, end_time, '[)') WITH &&)
+WHERE (status <> 'CANCELLED')
+DEFERRABLE INITIALLY IMMEDIATE;
+
+-- The show may be deleted later on; the record of its cancellation stays
+CREATE TABLE IF NOT EXISTS show_cancellations (
+    id UUID PRIMARY KEY,
+    show_id INT NOT NULL UNIQUE,
+    movie_id INT NOT NULL,
+    movie_name VARCHAR(255) NOT NULL DEFAULT '',
+    theater VARCHAR(100) NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    time_zone TEXT NOT NULL DEFAULT 'UTC',
+    reason TEXT NOT NULL DEFAULT '',
+    alternative_show_id INT,
+    cancelled_by UUID,
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING'
+        CHECK (status IN ('PENDING', 'RUNNING', 'COMPLETED', 'FAILED')),
+    total_bookings INT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_show_cancellations_status ON show_cancellations (status, created_at);
+
+-- One row per reservation the cancellation took away. The reservation's details are
+-- copied so the refund and the rebooking offer outlive it.
+CREATE TABLE IF NOT EXISTS show_cancellation_bookings (
+    cancellation_id UUID NOT NULL REFERENCES show_cancellations(id) ON DELETE CASCADE,
+    booking_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    seat_id UUID NOT NULL,
+    seat_label VARCHAR(10) NOT NULL DEFAULT '',
+    tier VARCHAR(20) NOT NULL DEFAULT 'STANDARD',
+    booking_status TEXT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DONE', 'FAILED')),
+    payment_id UUID,
+    payment_status VARCHAR(20) NOT NULL DEFAULT '',
+    error TEXT,
+    rebook_token UUID UNIQUE,
+    rebooked_seat_id UUID,
+    rebooked_at TIMESTAMPTZ,
+    processed_at TIMESTAMPTZ,
+    PRIMARY KEY (cancellation_id, booking_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_show_cancellation_bookings_pending
+    ON show_cancellation_bookings (cancellation_id) WHERE status = 'PENDING';
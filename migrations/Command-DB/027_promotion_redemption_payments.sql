@@ -0,0 +1,18 @@
+-- A redemption belongs to the payment that applied the code, so only that payment failing
+-- or expiring gives it back. Existing redemptions go to their booking's latest payment
+-- with the code.
+
+ALTER TABLE promotion_redemptions ADD COLUMN IF NOT EXISTS payment_id UUID;
+
+UPDATE promotion_redemptions r
+SET payment_id = (
+    SELECT p.id
+    FROM payments p
+    JOIN promotions pr ON pr.code = p.promo_code
+    WHERE p.booking_id = r.booking_id AND pr.id = r.promotion_id
+    ORDER BY p.created_at DESC
+    LIMIT 1
+)
+WHERE r.payment_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_promotion_redemptions_payment ON promotion_redemptions(payment_id);
@@ -0,0 +1,23 @@
+-- Redeemed points belong to the payment they were taken off, so only that payment failing,
+-- expiring or being refunded gives them back. Existing redemptions go to the first payment
+-- for their booking made after them, and restores to the last one made before them.
+
+UPDATE loyalty_ledger l
+SET payment_id = (
+    SELECT p.id FROM payments p
+    WHERE p.booking_id = l.booking_id AND p.created_at >= l.created_at
+    ORDER BY p.created_at
+    LIMIT 1
+)
+WHERE l.kind = 'REDEEM' AND l.payment_id IS NULL;
+
+UPDATE loyalty_ledger l
+SET payment_id = (
+    SELECT p.id FROM payments p
+    WHERE p.booking_id = l.booking_id AND p.created_at <= l.created_at
+    ORDER BY p.created_at DESC
+    LIMIT 1
+)
+WHERE l.kind = 'RESTORE' AND l.payment_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_ledger_payment ON loyalty_ledger(payment_id);
@@ -0,0 +1,17 @@
+-- A booking has at most one payment at checkout or paid. Checkout reads the open payments
+-- first for a friendly error; this index settles two requests racing past that read.
+-- Older duplicate PENDING payments were never charged, so they are expired first.
+
+UPDATE payments p
+SET status = 'EXPIRED', updated_at = NOW()
+WHERE p.status = 'PENDING'
+  AND EXISTS (
+      SELECT 1 FROM payments o
+      WHERE o.booking_id = p.booking_id
+        AND o.id <> p.id
+        AND o.status IN ('PENDING', 'AUTHORIZED', 'CAPTURED', 'PARTIALLY_REFUNDED')
+        AND (o.status <> 'PENDING' OR o.created_at > p.created_at)
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payments_open_booking ON payments(booking_id)
+    WHERE status IN ('PENDING', 'AUTHORIZED', 'CAPTURED', 'PARTIALLY_REFUNDED');
@@ -0,0 +1,14 @@
+-- A show without a screen takes the whole theater, so it clashes with a show on any of
+-- its screens as well as with other whole-theater shows. Its screen range is unbounded
+-- and overlaps every screen; a show on a screen covers just that one.
+
+ALTER TABLE shows DROP CONSTRAINT IF EXISTS shows_no_overlap;
+ALTER TABLE shows
+ADD CONSTRAINT shows_no_overlap
+EXCLUDE USING gist (
+    theater WITH =,
+    (CASE WHEN screen_id IS NULL THEN int4range(NULL, NULL) ELSE int4range(screen_id, screen_id, '[]') END) WITH &&,
+    tstzrange(start_time, end_time, '[)') WITH &&
+)
+WHERE (status <> 'CANCELLED')
+DEFERRABLE INITIALLY IMMEDIATE;
@@ -0,0 +1,22 @@
+-- Settlement reconciliation reports produced by the worker
+
+CREATE TABLE IF NOT EXISTS reconciliation_reports (
+    id UUID PRIMARY KEY,
+    file_name TEXT NOT NULL,
+    matched INT NOT NULL DEFAULT 0,
+    discrepancies INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_items (
+    id BIGSERIAL PRIMARY KEY,
+    report_id UUID NOT NULL REFERENCES reconciliation_reports(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL, -- MISSING, EXTRA, AMOUNT_MISMATCH
+    transaction_id VARCHAR(100) NOT NULL,
+    payment_id UUID,
+    expected_amount BIGINT,
+    settled_amount BIGINT,
+    currency CHAR(3)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_items_report_id ON reconciliation_items(report_id);
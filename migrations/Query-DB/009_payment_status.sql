@@ -0,0 +1,5 @@
+-- Mirror payment lifecycle columns from the Command DB
+UPDATE payments SET status = 'CAPTURED' WHERE status = 'SUCCESS';
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT NOW();
@@ -0,0 +1,4 @@
+-- Discount applied to a payment by a promo code
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS promo_code VARCHAR(50);
@@ -0,0 +1,4 @@
+-- Show of each projected reservation, used for occupancy-based pricing
+
+ALTER TABLE reservation_projection ADD COLUMN IF NOT EXISTS show_id INT;
+CREATE INDEX IF NOT EXISTS idx_reservation_projection_show_status ON reservation_projection(show_id, status);
@@ -0,0 +1,4 @@
+-- Recurring schedule each projected show was expanded from
+
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS schedule_id INT;
+CREATE INDEX IF NOT EXISTS idx_shows_schedule ON shows(schedule_id);
@@ -0,0 +1,18 @@
+-- Catalogue metadata projected from the Command DB
+
+ALTER TABLE movies
+    ADD COLUMN IF NOT EXISTS genres TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS release_date DATE,
+    ADD COLUMN IF NOT EXISTS languages TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS subtitles TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS certification VARCHAR(16),
+    ADD COLUMN IF NOT EXISTS synopsis TEXT,
+    ADD COLUMN IF NOT EXISTS cast_members JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS crew JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS poster_url TEXT,
+    ADD COLUMN IF NOT EXISTS formats TEXT[] NOT NULL DEFAULT '{}';
+
+-- Existing movies keep their one genre as the whole list
+UPDATE movies SET genres = ARRAY[genre] WHERE genres = '{}' AND genre <> '';
+
+CREATE INDEX IF NOT EXISTS idx_movies_genres ON movies USING gin (genres);
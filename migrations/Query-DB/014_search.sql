@@ -0,0 +1,13 @@
+-- Full-text search over movie titles, and the indexes search filters and sorts use
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple', coalesce(name, ''))) STORED;
+CREATE INDEX IF NOT EXISTS idx_movies_search ON movies USING gin (search_vector);
+CREATE INDEX IF NOT EXISTS idx_movies_languages ON movies USING gin (languages);
+CREATE INDEX IF NOT EXISTS idx_movies_formats ON movies USING gin (formats);
+CREATE INDEX IF NOT EXISTS idx_movies_title ON movies (lower(name), id);
+CREATE INDEX IF NOT EXISTS idx_movies_release_date ON movies (release_date, id);
+
+CREATE INDEX IF NOT EXISTS idx_shows_start_time ON shows (start_time, id);
+CREATE INDEX IF NOT EXISTS idx_shows_theater_start_time ON shows (theater, start_time);
+CREATE INDEX IF NOT EXISTS idx_shows_movie ON shows (movie_id);
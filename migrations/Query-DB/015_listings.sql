@@ -0,0 +1,25 @@
+-- "What's on" listings: one row per show with its movie, venue, seats left and lowest price
+
+CREATE TABLE IF NOT EXISTS listings (
+    show_id INT PRIMARY KEY,
+    movie_id INT NOT NULL,
+    movie_name VARCHAR(255) NOT NULL,
+    duration INT NOT NULL,
+    certification VARCHAR(16) NOT NULL DEFAULT '',
+    poster_url TEXT NOT NULL DEFAULT '',
+    languages TEXT[] NOT NULL DEFAULT '{}',
+    formats TEXT[] NOT NULL DEFAULT '{}',
+    venue VARCHAR(100) NOT NULL,
+    city VARCHAR(100) NOT NULL DEFAULT '',
+    show_date DATE NOT NULL,
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    total_seats INT NOT NULL DEFAULT 0,
+    remaining_seats INT NOT NULL DEFAULT 0,
+    lowest_price BIGINT,
+    currency CHAR(3),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_listings_date_city ON listings (show_date, lower(city));
+CREATE INDEX IF NOT EXISTS idx_listings_movie ON listings (movie_id);
@@ -0,0 +1,21 @@
+-- iCalendar feed entries: one per show for its venue's programme, and one per user who
+-- booked it. Cancelled shows and bookings are kept so subscribed calendars remove them.
+
+CREATE TABLE IF NOT EXISTS calendar_entries (
+    uid VARCHAR(200) PRIMARY KEY,
+    show_id INT NOT NULL,
+    user_id UUID,
+    venue VARCHAR(100) NOT NULL,
+    summary TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    start_time TIMESTAMP NOT NULL,
+    end_time TIMESTAMP NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('CONFIRMED', 'CANCELLED')),
+    sequence INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_entries_user ON calendar_entries (user_id, end_time);
+CREATE INDEX IF NOT EXISTS idx_calendar_entries_venue ON calendar_entries (venue, end_time) WHERE user_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_calendar_entries_show ON calendar_entries (show_id);
@@ -0,0 +1,16 @@
+-- Where each show is on: its venue, screen and city, for filtering by them
+
+ALTER TABLE shows
+    ADD COLUMN IF NOT EXISTS venue_id INT,
+    ADD COLUMN IF NOT EXISTS screen_id INT,
+    ADD COLUMN IF NOT EXISTS screen VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS city VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_shows_venue ON shows (venue_id, start_time);
+CREATE INDEX IF NOT EXISTS idx_shows_city ON shows (lower(city), start_time);
+
+ALTER TABLE listings
+    ADD COLUMN IF NOT EXISTS venue_id INT,
+    ADD COLUMN IF NOT EXISTS screen VARCHAR(100) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_listings_date_venue ON listings (show_date, venue_id);
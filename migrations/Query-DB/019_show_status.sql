@@ -0,0 +1,3 @@
+-- Whether a show is still on or was cancelled
+
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS status VARCHAR(10) NOT NULL DEFAULT 'SCHEDULED';